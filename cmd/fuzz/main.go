@@ -0,0 +1,62 @@
+// Command fuzz drives the testkit fuzz targets with random mutations of their
+// seeds, for a quick robustness pass without the native fuzzer:
+//
+//	go run ./cmd/fuzz -n 20000 -config configs/example.rules.yaml
+//
+// A failing input is written to the -out directory and the command exits 1.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"watcher/internal/rules"
+	"watcher/pkg/testkit"
+)
+
+func main() {
+	n := flag.Int("n", 5000, "Mutations per target")
+	var names []string
+	for _, target := range testkit.FuzzTargets(rules.RuleSet{}) {
+		names = append(names, target.Name)
+	}
+	only := flag.String("target", "", "Run only this target ("+strings.Join(names, ", ")+")")
+	config := flag.String("config", "configs/example.rules.yaml", "Rule file the match target uses")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "Random seed, to replay a run")
+	out := flag.String("out", "fuzz-crashers", "Directory failing inputs are written to")
+	flag.Parse()
+
+	ruleSet, err := rules.LoadFromFile(*config)
+	if err != nil {
+		log.Fatalf("load rules: %v", err)
+	}
+	fmt.Printf("seed %d\n", *seed)
+	rnd := rand.New(rand.NewSource(*seed))
+	failed := false
+	for _, target := range testkit.FuzzTargets(ruleSet) {
+		if *only != "" && target.Name != *only {
+			continue
+		}
+		start := time.Now()
+		input, err := testkit.Fuzz(target, *n, rnd)
+		if err == nil {
+			fmt.Printf("%-10s ok  %d inputs in %s\n", target.Name, *n, time.Since(start).Round(time.Millisecond))
+			continue
+		}
+		failed = true
+		path := filepath.Join(*out, fmt.Sprintf("%s-%d", target.Name, *seed))
+		if mkErr := os.MkdirAll(*out, 0o755); mkErr == nil {
+			os.WriteFile(path, input, 0o644)
+		}
+		fmt.Printf("%-10s FAIL (input in %s)\n%v\n", target.Name, path, err)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"watcher/internal/audit"
+	"watcher/internal/pipeline"
+	"watcher/internal/rules"
+	"watcher/internal/shutdown"
+	"watcher/internal/watch"
+)
+
+// newAgentCommand implements `agent --forward HOST:PORT [flags] [PATH...]`:
+// watch without a dashboard and forward every kept event over TLS to an
+// aggregator, a spectra instance listening with --agents, which shows the
+// whole fleet in one dashboard.
+func newAgentCommand() *cobra.Command {
+	return optionsCommand(&cobra.Command{
+		Use:   "agent --forward HOST:PORT [flags] [PATH...]",
+		Short: "Forward matched events to an aggregator over TLS",
+	}, flag.NewFlagSet("agent", flag.ExitOnError), func(fs *flag.FlagSet, args []string) error {
+		return runSession(fs, args, func(opts *options, shutdowns *shutdown.Coordinator, _ *audit.Log, _ string) {
+			if opts.forward == "" {
+				log.Fatalf("usage: %s agent --forward HOST:PORT [flags] [PATH...]", os.Args[0])
+			}
+			runHeadless(opts, shutdowns)
+		})
+	})
+}
+
+// runHeadless watches without a dashboard, in the headlessonly build and
+// for `agent`. Sources feed the configured sinks, and every event is also
+// written to stdout so container runtimes collect it. On a signal the sources
+// stop, the pipeline drains, and runHeadless returns once stdout has
+// everything.
+func runHeadless(opts *options, shutdowns *shutdown.Coordinator) {
+	files := opts.sourceFiles()
+	if len(files) == 0 {
+		log.Fatal("no files supplied via --files")
+	}
+
+	ctx, cancel := signalContext()
+	defer cancel()
+	shutdowns.Add(shutdown.Sources, "sources", stopSources(cancel))
+	// The pipeline and sinks outlive the sources so events in flight drain.
+	pctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	defer shutdowns.Shutdown()
+	ctx = watch.WithProgress(ctx, watch.NewProgress())
+	ctx = watch.WithRetryLimit(ctx, opts.sourceRetries)
+	ctx = watch.WithReplaySpeed(ctx, opts.replaySpeed)
+	ctx = watch.WithBackfillRotated(ctx, opts.backfill)
+	ctx, chaosNote, err := opts.withChaos(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if chaosNote != "" {
+		fmt.Fprintln(os.Stderr, chaosNote)
+	}
+	if ctx, err = opts.withAgentTLS(ctx); err != nil {
+		log.Fatal(err)
+	}
+
+	ruleSet, err := rules.LoadFromFile(opts.config)
+	if err != nil {
+		log.Fatalf("load rules: %v", err)
+	}
+	minSeverity, err := rules.ParseSeverity(opts.minSeverity)
+	if err != nil {
+		log.Fatalf("min severity: %v", err)
+	}
+	precision, err := pipeline.ParsePrecision(opts.timePrecision)
+	if err != nil {
+		log.Fatalf("time precision: %v", err)
+	}
+
+	lines, err := watch.TailFiles(ctx, files)
+	if err != nil {
+		log.Fatalf("start tailing: %v", err)
+	}
+	labeled, _, err := opts.matchLines(pctx, lines, ruleSet, minSeverity)
+	if err != nil {
+		log.Fatal(err)
+	}
+	matched, err := opts.stages(pctx, labeled)
+	if err != nil {
+		log.Fatal(err)
+	}
+	events := pipeline.NewBroadcaster(matched)
+	if _, _, err := opts.startSinks(pctx, events, ruleSet, shutdowns); err != nil {
+		log.Fatal(err)
+	}
+	out := events.Subscribe(sinkBuffer)
+	events.Start(pctx)
+	for evt := range out.Events() {
+		writeEvent(evt, precision)
+	}
+}
+
+// writeEvent prints one event in the `grep` subcommand's text layout.
+func writeEvent(evt pipeline.HighlightedEvent, precision pipeline.Precision) {
+	switch {
+	case evt.Err != nil:
+		log.Printf("%s: %v", evt.Path, evt.Err)
+	case evt.Gap > 0:
+		log.Printf("%s: ingestion paused %s", evt.Path, evt.Gap.Round(time.Second))
+	case evt.Conn != nil:
+		log.Printf("%s: %s", evt.Path, evt.Conn)
+	default:
+		rule := evt.RuleName
+		if rule == "" {
+			rule = "-"
+		}
+		if evt.Pipeline != "" {
+			rule = evt.Pipeline + "/" + rule
+		}
+		source := evt.Path
+		if len(evt.Sources) > 0 {
+			source = strings.Join(evt.Sources, ",")
+		}
+		fmt.Printf("%s  %-8s  %s  %s  %s\n",
+			evt.Timestamp.Format(precision.RFC3339()), strings.ToUpper(string(evt.Severity)), rule, source, evt.Line)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"watcher/internal/pipeline"
+	"watcher/internal/rules"
+	"watcher/internal/search"
+	"watcher/internal/watch"
+)
+
+// newBenchCommand implements `bench [flags] --corpus PATH...`: how fast the
+// ruleset matches, highlights, and redacts sample logs, read into memory
+// first so disk speed stays out of the numbers. Each of --repeat runs goes
+// through the same pipeline stage as the live view.
+func newBenchCommand() *cobra.Command {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	var corpus stringList
+	fs.Var(&corpus, "corpus", "File, directory, or glob of sample logs (`**` spans directories); repeatable, positional args also accepted")
+	repeat := fs.Int("repeat", 3, "Timed runs over the corpus; the fastest is reported as best")
+	maxLines := fs.Int("max-lines", 1000000, "Stop reading the corpus after this many lines")
+	return optionsCommand(&cobra.Command{
+		Use:   "bench [flags] --corpus PATH...",
+		Short: "Measure rule matching throughput on sample logs",
+	}, fs, func(fs *flag.FlagSet, args []string) error {
+		opts, err := parseOptions(fs, args)
+		if err != nil {
+			return err
+		}
+		corpus = append(corpus, opts.args...)
+		if len(corpus) == 0 || *repeat < 1 {
+			return fmt.Errorf("usage: %s bench --corpus DIR [--repeat 3] [--config rules.yaml]", os.Args[0])
+		}
+		ruleSet, err := rules.LoadFromFile(opts.config)
+		if err != nil {
+			return fmt.Errorf("load rules: %w", err)
+		}
+		minSeverity, err := rules.ParseSeverity(opts.minSeverity)
+		if err != nil {
+			return fmt.Errorf("min severity: %w", err)
+		}
+		files, err := search.Expand(corpus, false)
+		if err != nil {
+			return fmt.Errorf("expand corpus: %w", err)
+		}
+		lines, size, err := loadCorpus(files, *maxLines)
+		if err != nil {
+			return err
+		}
+		if len(lines) == 0 {
+			return fmt.Errorf("corpus has no lines")
+		}
+		fmt.Printf("corpus: %d files, %d lines, %.1f MB, %d rules\n", len(files), len(lines), float64(size)/1e6, len(ruleSet.Rules))
+
+		stream := pipeline.New(ruleSet, opts.showAll, minSeverity)
+		var best time.Duration
+		for run := 1; run <= *repeat; run++ {
+			events := 0
+			start := time.Now()
+			for _, evt := range lines {
+				if _, keep := stream.Process(evt); keep {
+					events++
+				}
+			}
+			elapsed := time.Since(start)
+			if best == 0 || elapsed < best {
+				best = elapsed
+			}
+			fmt.Printf("run %d  %s  %d events\n", run, throughput(elapsed, len(lines), size), events)
+		}
+		fmt.Printf("best   %s\n", throughput(best, len(lines), size))
+		return nil
+	})
+}
+
+// loadCorpus reads up to max lines of files into memory and returns them
+// with their total size in bytes.
+func loadCorpus(files []string, max int) ([]watch.LogEvent, int, error) {
+	var (
+		lines []watch.LogEvent
+		size  int
+	)
+	for _, path := range files {
+		err := search.EachLine(path, func(_ int, line string) bool {
+			lines = append(lines, watch.LogEvent{Path: path, Line: line})
+			size += len(line) + 1
+			return len(lines) < max
+		})
+		if err != nil {
+			return nil, 0, fmt.Errorf("read %s: %w", path, err)
+		}
+		if len(lines) >= max {
+			break
+		}
+	}
+	return lines, size, nil
+}
+
+// throughput formats one run's duration with its line and byte rates.
+func throughput(elapsed time.Duration, lines, size int) string {
+	secs := elapsed.Seconds()
+	if secs <= 0 {
+		secs = 1e-9
+	}
+	return fmt.Sprintf("%-10s  %9.0f lines/s  %7.1f MB/s", elapsed.Round(time.Microsecond), float64(lines)/secs, float64(size)/1e6/secs)
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"watcher/internal/rules"
+	"watcher/internal/sink"
+)
+
+// The command tree. Every command still parses its own arguments with the
+// flag package, because parseOptions layers the settings file and SPECTRA_*
+// environment under the flags it sees were given. Cobra dispatches, prints
+// help, and drives shell completion from a copy of each flag set.
+
+// themeNames are the dashboard themes, in the order `t` cycles them.
+var themeNames = []string{"vapor", "midnight", "dusk", "mono"}
+
+// flagChoices annotates a flag with the fixed values it completes to.
+const flagChoices = "spectra_choices"
+
+// sharedChoices are the completion values of flags every layered command has.
+var sharedChoices = map[string][]string{
+	"theme":          themeNames,
+	"time-precision": {"s", "ms", "us"},
+	"mode":           {"monitor", "triage"},
+	"ban-format":     {sink.BanFormatCIDR, sink.BanFormatNFT},
+}
+
+// newRootCommand is `spectra-watch [flags] [PATH...]`: without a subcommand
+// it watches, as `watch` does.
+func newRootCommand() *cobra.Command {
+	name := strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+	root := optionsCommand(&cobra.Command{
+		Use:           name + " [flags] [PATH...]",
+		Short:         "Tail logs and highlight what your rules match",
+		Args:          cobra.ArbitraryArgs,
+		SilenceErrors: true,
+	}, flag.NewFlagSet(name, flag.ExitOnError), runWatch)
+	root.AddCommand(
+		newWatchCommand(),
+		newAgentCommand(),
+		newTestCommand(),
+		newGrepCommand(),
+		newQueryCommand(),
+		newPacksCommand(),
+		newConfigCommand(),
+		newBenchCommand(),
+		newCoverageCommand(),
+		newDiffCommand(),
+		newExportConfigCommand(),
+		newClickHouseCommand(),
+		newUpdateCommand(),
+	)
+	return root
+}
+
+// newWatchCommand implements `watch [flags] [PATH...]`, the live dashboard
+// (or headless output).
+func newWatchCommand() *cobra.Command {
+	return optionsCommand(&cobra.Command{
+		Use:   "watch [flags] [PATH...]",
+		Short: "Tail sources live in the dashboard",
+	}, flag.NewFlagSet("watch", flag.ExitOnError), runWatch)
+}
+
+// optionsCommand is flagCommand for a command whose fs gets the shared
+// options through parseOptions.
+func optionsCommand(cmd *cobra.Command, fs *flag.FlagSet, run func(fs *flag.FlagSet, args []string) error) *cobra.Command {
+	described := flag.NewFlagSet(fs.Name(), flag.ContinueOnError)
+	fs.VisitAll(func(f *flag.Flag) { described.Var(f.Value, f.Name, f.Usage) })
+	defineFlags(described)
+	flagCommand(cmd, described, func(args []string) error { return run(fs, args) })
+	for name, values := range sharedChoices {
+		cmd.Flags().SetAnnotation(name, flagChoices, values)
+	}
+	for name := range hiddenFlags {
+		cmd.Flags().MarkHidden(name)
+	}
+	cmd.MarkFlagFilename("config", "yaml", "yml")
+	cmd.MarkFlagFilename("settings", "yaml", "yml")
+	cmd.MarkFlagFilename("notify", "yaml", "yml")
+	cmd.MarkFlagFilename("baseline", "json")
+	for _, name := range []string{"agents-cert", "agents-key", "agents-ca", "forward-ca", "forward-cert", "forward-key"} {
+		cmd.MarkFlagFilename(name, "pem", "crt", "key")
+	}
+	cmd.MarkFlagDirname("plugins")
+	return cmd
+}
+
+// flagCommand hands cmd's arguments to run unparsed and shows fs's flags in
+// help and completion. A ValidArgsFunction already set on cmd completes the
+// positional arguments.
+func flagCommand(cmd *cobra.Command, fs *flag.FlagSet, run func(args []string) error) *cobra.Command {
+	cmd.DisableFlagParsing = true
+	cmd.SilenceUsage = true
+	cmd.Flags().AddGoFlagSet(fs)
+	cmd.RunE = func(c *cobra.Command, args []string) error {
+		if wantsHelp(c, args) {
+			return c.Help()
+		}
+		return run(args)
+	}
+	positional := cmd.ValidArgsFunction
+	cmd.ValidArgsFunction = func(c *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		return completeArgs(c, args, toComplete, positional)
+	}
+	return cmd
+}
+
+// completeArgs completes flag values, which cobra leaves to commands that
+// parse their own flags, and otherwise positional arguments. Flag names are
+// completed by cobra itself.
+func completeArgs(cmd *cobra.Command, args []string, toComplete string, positional cobra.CompletionFunc) ([]cobra.Completion, cobra.ShellCompDirective) {
+	name := ""
+	switch {
+	case strings.HasPrefix(toComplete, "-") && strings.Contains(toComplete, "="):
+		name, toComplete, _ = strings.Cut(strings.TrimLeft(toComplete, "-"), "=")
+	case strings.HasPrefix(toComplete, "-"):
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	case len(args) > 0 && takesValue(cmd, args[len(args)-1]):
+		name = strings.TrimLeft(args[len(args)-1], "-")
+	case positional != nil:
+		return positional(cmd, args, toComplete)
+	default:
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	f := cmd.Flags().Lookup(name)
+	if f == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	if name == "min-severity" {
+		return matching(severityNames(args), toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+	if values, ok := f.Annotations[flagChoices]; ok {
+		return matching(values, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+	if exts, ok := f.Annotations[cobra.BashCompFilenameExt]; ok {
+		return exts, cobra.ShellCompDirectiveFilterFileExt
+	}
+	if _, ok := f.Annotations[cobra.BashCompSubdirsInDir]; ok {
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	}
+	return nil, cobra.ShellCompDirectiveDefault
+}
+
+// takesValue reports whether arg is a flag of cmd that reads the next argument.
+func takesValue(cmd *cobra.Command, arg string) bool {
+	if !strings.HasPrefix(arg, "-") || strings.Contains(arg, "=") {
+		return false
+	}
+	f := cmd.Flags().Lookup(strings.TrimLeft(arg, "-"))
+	return f != nil && f.NoOptDefVal == ""
+}
+
+// severityNames lists the severities of the rule file the command line names
+// with --config (or the default one), custom levels included.
+func severityNames(args []string) []string {
+	_, path := platformDefaults()
+	if value, ok := argValue(args, "config"); ok {
+		path = value
+	}
+	levels, err := rules.ReadSeverities(path)
+	if err != nil {
+		levels = rules.Severities()
+	}
+	var names []string
+	for _, level := range levels {
+		names = append(names, string(level.Name))
+	}
+	return names
+}
+
+// argValue finds the last value given to flag name in args, which cobra has
+// not parsed.
+func argValue(args []string, name string) (string, bool) {
+	value, found := "", false
+	for i, arg := range args {
+		flagName, v, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if flagName != name || !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		if !hasValue && i+1 < len(args) {
+			v = args[i+1]
+		}
+		value, found = v, true
+	}
+	return value, found
+}
+
+// matching keeps the values that start with prefix.
+func matching(values []string, prefix string) []cobra.Completion {
+	var out []cobra.Completion
+	for _, v := range values {
+		if strings.HasPrefix(v, prefix) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// wantsHelp reports whether args ask for help before the first positional
+// argument, where the flag package would print its own, shorter usage.
+func wantsHelp(cmd *cobra.Command, args []string) bool {
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "-h" || arg == "-help" || arg == "--help":
+			return true
+		case arg == "--" || !strings.HasPrefix(arg, "-"):
+			return false
+		case takesValue(cmd, arg):
+			i++
+		}
+	}
+	return false
+}
+
+// positionalArgs drops cmd's flags, and the values of those that take one,
+// from args.
+func positionalArgs(cmd *cobra.Command, args []string) []string {
+	var out []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case takesValue(cmd, args[i]):
+			i++
+		case strings.HasPrefix(args[i], "-"):
+		default:
+			out = append(out, args[i])
+		}
+	}
+	return out
+}
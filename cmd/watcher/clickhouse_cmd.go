@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// newClickHouseCommand implements `clickhouse schema|migrate [flags]` for the
+// --clickhouse-out table: print the migration statements, or apply them.
+func newClickHouseCommand() *cobra.Command {
+	clickhouse := &cobra.Command{
+		Use:   "clickhouse",
+		Short: "Manage the --clickhouse-out table schema",
+	}
+	for _, action := range []struct{ name, short string }{
+		{"schema", "Print the migration statements"},
+		{"migrate", "Apply the migrations the table is missing"},
+	} {
+		clickhouse.AddCommand(optionsCommand(&cobra.Command{
+			Use:   action.name + " --clickhouse-out=URL",
+			Short: action.short,
+		}, flag.NewFlagSet("clickhouse "+action.name, flag.ExitOnError), func(fs *flag.FlagSet, args []string) error {
+			return runClickHouse(action.name, fs, args)
+		}))
+	}
+	return clickhouse
+}
+
+func runClickHouse(action string, fs *flag.FlagSet, args []string) error {
+	opts, err := parseOptions(fs, args)
+	if err != nil {
+		return err
+	}
+	if opts.chOut == "" {
+		return fmt.Errorf("usage: %s clickhouse %s --clickhouse-out=URL", os.Args[0], action)
+	}
+	out, err := opts.clickhouse()
+	if err != nil {
+		return err
+	}
+	if action == "schema" {
+		for _, stmt := range out.Schema() {
+			fmt.Printf("%s;\n\n", stmt)
+		}
+		return nil
+	}
+	ctx, cancel := signalContext()
+	defer cancel()
+	if err := out.Migrate(ctx); err != nil {
+		return err
+	}
+	fmt.Println("schema is up to date")
+	return nil
+}
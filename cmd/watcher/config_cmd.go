@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"watcher/internal/settings"
+)
+
+// newConfigCommand implements `config show [--resolved] [flags...]`, printing
+// the effective settings and, with --resolved, which layer won and what it
+// overrode.
+func newConfigCommand() *cobra.Command {
+	config := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the layered settings",
+	}
+	fs := flag.NewFlagSet("config show", flag.ExitOnError)
+	resolvedFlag := fs.Bool("resolved", false, "Explain which layer (default, settings file, env, flag) supplied each value")
+	config.AddCommand(optionsCommand(&cobra.Command{
+		Use:   "show [--resolved] [flags]",
+		Short: "Print the effective settings",
+	}, fs, func(fs *flag.FlagSet, args []string) error {
+		opts, err := parseOptions(fs, args)
+		if err != nil {
+			return err
+		}
+		printSettings(os.Stdout, opts, *resolvedFlag)
+		return nil
+	}))
+	return config
+}
+
+func printSettings(w io.Writer, opts *options, explain bool) {
+	fileState := "not found"
+	if _, err := os.Stat(opts.settingsPath); err == nil {
+		fileState = "loaded"
+	}
+	fmt.Fprintf(w, "settings file: %s (%s)\n\n", coalesce(opts.settingsPath, "none"), fileState)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, s := range opts.resolved {
+		if hiddenFlags[s.Name] {
+			continue
+		}
+		if !explain {
+			fmt.Fprintf(tw, "%s\t%s\n", s.Name, s.Value)
+			continue
+		}
+		winner := s.Winner()
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Name, s.Value, describeLayer(winner), describeOverrides(s))
+	}
+	tw.Flush()
+}
+
+func describeLayer(l settings.Layer) string {
+	if l.Origin == "" {
+		return string(l.Source)
+	}
+	return fmt.Sprintf("%s %s", l.Source, l.Origin)
+}
+
+// describeOverrides lists the lower-precedence values the winner shadowed.
+func describeOverrides(s settings.Setting) string {
+	if len(s.Layers) < 2 {
+		return ""
+	}
+	shadowed := make([]string, 0, len(s.Layers)-1)
+	for i := len(s.Layers) - 2; i >= 0; i-- {
+		l := s.Layers[i]
+		shadowed = append(shadowed, fmt.Sprintf("%s=%q", l.Source, l.Value))
+	}
+	return "overrides " + strings.Join(shadowed, ", ")
+}
+
+func coalesce(val, fallback string) string {
+	if val == "" {
+		return fallback
+	}
+	return val
+}
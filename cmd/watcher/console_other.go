@@ -0,0 +1,8 @@
+//go:build !windows
+
+package main
+
+// prepareConsole is a no-op outside Windows; unix terminals already speak ANSI and UTF-8.
+func prepareConsole() func() {
+	return func() {}
+}
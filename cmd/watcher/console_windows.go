@@ -0,0 +1,32 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// utf8CodePage is the Windows code page identifier for UTF-8.
+const utf8CodePage = 65001
+
+// prepareConsole switches the console to UTF-8 output and ANSI escape processing so
+// Lip Gloss colors, borders, and the sentinel glyphs render in conhost and PowerShell.
+// The returned func restores the previous console state.
+func prepareConsole() func() {
+	out := windows.Handle(os.Stdout.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(out, &mode); err != nil {
+		return func() {}
+	}
+	prevCP, cpErr := windows.GetConsoleOutputCP()
+	windows.SetConsoleMode(out, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+	windows.SetConsoleOutputCP(utf8CodePage)
+	return func() {
+		windows.SetConsoleMode(out, mode)
+		if cpErr == nil {
+			windows.SetConsoleOutputCP(prevCP)
+		}
+	}
+}
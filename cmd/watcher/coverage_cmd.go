@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"watcher/internal/coverage"
+	"watcher/internal/rules"
+	"watcher/internal/search"
+)
+
+// newCoverageCommand implements `coverage --corpus DIR [--format text|json|junit]`:
+// which sample lines each rule fires on, rules that never fire, and rules that
+// fire on the same lines. It exits non-zero when the checks selected by --fail
+// do not pass, so it can gate CI.
+func newCoverageCommand() *cobra.Command {
+	fs := flag.NewFlagSet("coverage", flag.ExitOnError)
+	var corpus stringList
+	fs.Var(&corpus, "corpus", "File, directory, or glob of sample logs (`**` spans directories); repeatable, positional args also accepted")
+	format := fs.String("format", "text", "Report format: text, json, or junit")
+	output := fs.String("output", "", "Write the report to this file instead of stdout")
+	fail := fs.String("fail", "uncovered", "Exit non-zero on: uncovered, overlap, both, or none")
+	cmd := optionsCommand(&cobra.Command{
+		Use:   "coverage [flags] --corpus PATH...",
+		Short: "Report which rules fire on sample logs",
+	}, fs, func(fs *flag.FlagSet, args []string) error {
+		opts, err := parseOptions(fs, args)
+		if err != nil {
+			return err
+		}
+		corpus = append(corpus, opts.args...)
+		if len(corpus) == 0 {
+			return fmt.Errorf("usage: %s coverage --corpus DIR [--format text|json|junit] [--config rules.yaml]", os.Args[0])
+		}
+		failUncovered, failOverlaps, err := parseCoverageFail(*fail)
+		if err != nil {
+			return err
+		}
+		switch *format {
+		case "text", "json", "junit":
+		default:
+			return fmt.Errorf("unknown format %q (want text, json, or junit)", *format)
+		}
+
+		ruleSet, err := rules.LoadFromFile(opts.config)
+		if err != nil {
+			return fmt.Errorf("load rules: %w", err)
+		}
+		files, err := search.Expand(corpus, false)
+		if err != nil {
+			return fmt.Errorf("expand corpus: %w", err)
+		}
+		ctx, cancel := signalContext()
+		defer cancel()
+		report, err := coverage.Analyze(ctx, ruleSet, files)
+		if err != nil {
+			log.Printf("coverage: %v", err)
+		}
+
+		out := os.Stdout
+		if *output != "" {
+			f, err := os.Create(*output)
+			if err != nil {
+				return fmt.Errorf("create report: %w", err)
+			}
+			defer f.Close()
+			out = f
+		}
+		switch *format {
+		case "text":
+			err = coverage.WriteText(out, report)
+		case "json":
+			err = coverage.WriteJSON(out, report)
+		case "junit":
+			err = coverage.WriteJUnit(out, report, failOverlaps)
+		}
+		if err != nil {
+			return fmt.Errorf("write report: %w", err)
+		}
+
+		switch {
+		case failUncovered && len(report.Uncovered) > 0:
+			return fmt.Errorf("%d of %d rules matched no corpus lines", len(report.Uncovered), len(report.Rules))
+		case failOverlaps && len(report.Overlaps) > 0:
+			return fmt.Errorf("%d rule pairs match the same lines", len(report.Overlaps))
+		}
+		return nil
+	})
+	cmd.Flags().SetAnnotation("format", flagChoices, []string{"text", "json", "junit"})
+	cmd.Flags().SetAnnotation("fail", flagChoices, []string{"uncovered", "overlap", "both", "none"})
+	return cmd
+}
+
+func parseCoverageFail(value string) (uncovered, overlap bool, err error) {
+	switch value {
+	case "uncovered":
+		return true, false, nil
+	case "overlap":
+		return false, true, nil
+	case "both":
+		return true, true, nil
+	case "none":
+		return false, false, nil
+	default:
+		return false, false, fmt.Errorf("unknown --fail %q (want uncovered, overlap, both, or none)", value)
+	}
+}
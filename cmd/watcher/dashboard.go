@@ -0,0 +1,420 @@
+//go:build !headlessonly
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	goruntime "runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"watcher/internal/audit"
+	"watcher/internal/config"
+	"watcher/internal/pipeline"
+	"watcher/internal/rules"
+	"watcher/internal/runtime"
+	"watcher/internal/settings"
+	"watcher/internal/shutdown"
+	"watcher/internal/tui"
+	"watcher/internal/watch"
+)
+
+// uiBuffer is the TUI's share of the event broadcaster; bursts beyond it are dropped for the UI only
+// and counted in the header (dropped:N).
+const uiBuffer = 1024
+
+// run watches the configured sources in the dashboard. Builds tagged
+// headlessonly replace it with a TUI-free agent (see headless.go).
+func run(opts *options, shutdowns *shutdown.Coordinator, auditLog *audit.Log, notice string) {
+	if opts.macos {
+		if goruntime.GOOS != "darwin" {
+			log.Fatal("--macos flag is only supported on macOS")
+		}
+		runMacOSMode(opts, shutdowns, auditLog, notice)
+		return
+	}
+
+	files := opts.sourceFiles()
+	if len(files) == 0 {
+		log.Fatal("no files supplied via --files")
+	}
+
+	ctx, cancel := signalContext()
+	defer cancel()
+	shutdowns.Add(shutdown.Sources, "sources", stopSources(cancel))
+	// The pipeline and sinks outlive the sources so events in flight drain.
+	pctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	defer shutdowns.Shutdown()
+	ingestion := watch.NewGate()
+	ctx = watch.WithGate(ctx, ingestion)
+	progress := watch.NewProgress()
+	ctx = watch.WithProgress(ctx, progress)
+	ctx = watch.WithRetryLimit(ctx, opts.sourceRetries)
+	ctx = watch.WithReplaySpeed(ctx, opts.replaySpeed)
+	ctx = watch.WithBackfillRotated(ctx, opts.backfill)
+	ctx, chaosNote, err := opts.withChaos(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if chaosNote != "" {
+		fmt.Fprintln(os.Stderr, chaosNote)
+		notice = coalesce(notice, chaosNote)
+	}
+	if ctx, err = opts.withAgentTLS(ctx); err != nil {
+		log.Fatal(err)
+	}
+
+	ruleSet, err := rules.LoadFromFile(opts.config)
+	if err != nil {
+		log.Fatalf("load rules: %v", err)
+	}
+
+	minSeverity, err := rules.ParseSeverity(opts.minSeverity)
+	if err != nil {
+		log.Fatalf("min severity: %v", err)
+	}
+
+	swap := rules.NewSwap(ruleSet)
+	// Named pipelines fork the raw lines, which the controller keeps to
+	// itself, so with --pipelines the sources are tailed directly and the
+	// file selection is fixed for the run.
+	var ctrl *runtime.Controller
+	var labeled <-chan pipeline.HighlightedEvent
+	var pinned []string
+	if opts.pipelines != "" {
+		lines, err := watch.TailFiles(ctx, files)
+		if err != nil {
+			log.Fatalf("start tailing: %v", err)
+		}
+		if labeled, pinned, err = opts.matchLines(pctx, lines, swap.RuleSet(), minSeverity); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		ctrl = runtime.NewController(ctx, swap.RuleSet(), opts.showAll, minSeverity)
+		if err := ctrl.Apply(runtime.Selection{Files: files}); err != nil {
+			log.Fatalf("start tailing: %v", err)
+		}
+		labeled = ctrl.Events()
+	}
+
+	matched, err := opts.stages(pctx, labeled)
+	if err != nil {
+		log.Fatal(err)
+	}
+	events := pipeline.NewBroadcaster(matched)
+	notifier, health, err := opts.startSinks(pctx, events, ruleSet, shutdowns)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ui := events.Subscribe(uiBuffer)
+
+	baseline, err := opts.loadBaseline()
+	if err != nil {
+		log.Fatalf("load baseline: %v", err)
+	}
+	precision, err := pipeline.ParsePrecision(opts.timePrecision)
+	if err != nil {
+		log.Fatalf("time precision: %v", err)
+	}
+	profile, err := opts.loadProfile()
+	if err != nil {
+		log.Fatal(err)
+	}
+	mode, err := opts.interactionMode(profile)
+	if err != nil {
+		log.Fatalf("mode: %v", err)
+	}
+
+	presets := config.BuildLogPresets(files)
+	ruleGroups := runtime.BuildRuleGroups(ruleSet)
+
+	model := tui.NewModel(tui.ModelConfig{
+		Events:        ui.Events(),
+		UIDropped:     ui.Dropped,
+		ThemeName:     opts.theme,
+		Scrollback:    opts.scrollback,
+		RetainCap:     opts.retainSevere,
+		CompactWidth:  opts.compactWidth,
+		Precision:     precision,
+		FreshFor:      opts.fresh,
+		QuietGap:      opts.quietGap,
+		AgeAfter:      opts.ageAfter,
+		NoiseWindow:   opts.noiseWindow,
+		PauseRate:     opts.pauseRate,
+		PauseAfter:    opts.pauseAfter,
+		RateAlerts:    ruleSet.RateAlerts,
+		TagStyles:     ruleSet.TagStyles,
+		Entities:      ruleSet.Entities,
+		Actions:       ruleSet.Actions(),
+		Files:         files,
+		ShowAll:       opts.showAll,
+		MinSeverity:   minSeverity,
+		PinPipelines:  pinned,
+		Controller:    ctrl,
+		Presets:       presets,
+		RuleGroups:    ruleGroups,
+		ConfigPath:    opts.config,
+		RuleSwap:      swap,
+		RuleDirs:      []string{filepath.Dir(opts.config), "configs", settings.RulesDir()},
+		Ingestion:     ingestion,
+		Progress:      progress,
+		Baseline:      baseline,
+		BaselinePath:  opts.baseline,
+		BaselineLearn: opts.baselineLearn,
+		DeltaMode:     opts.delta,
+		Audit:         auditLog,
+		Notifier:      notifier,
+		SinkFailures:  health.Summary,
+		Notice:        notice,
+		Mode:          mode,
+		Profile:       opts.profile,
+		PulseTags:     profile.PulseTags,
+		ProfilePath:   settings.StatePath(),
+		SessionPath:   settings.SessionPath(opts.session),
+	})
+
+	events.Start(pctx)
+	runProgram(model, shutdowns)
+}
+
+// runProgram runs the dashboard until it quits, then registers saving the
+// --session checkpoint from its final state as a shutdown task.
+func runProgram(model tui.Model, shutdowns *shutdown.Coordinator) {
+	final, err := tea.NewProgram(model, tea.WithAltScreen(), tea.WithMouseCellMotion()).Run()
+	if err != nil && !errors.Is(err, tea.ErrInterrupted) {
+		log.Fatal(err)
+	}
+	if m, ok := final.(tui.Model); ok {
+		shutdowns.Add(shutdown.Persist, "session", func(context.Context) error { return m.SaveSession() })
+	}
+}
+
+// macOSSource names the unified log stream wherever a source path is shown.
+const macOSSource = "macOS Unified Log"
+
+// runMacOSMode feeds `log stream` straight from its stdout pipe into the
+// pipeline; nothing is written to disk.
+func runMacOSMode(opts *options, shutdowns *shutdown.Coordinator, auditLog *audit.Log, notice string) {
+	ctx, cancel := signalContext()
+	defer cancel()
+	shutdowns.Add(shutdown.Sources, "sources", stopSources(cancel))
+	// The pipeline and sinks outlive the sources so events in flight drain.
+	pctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	defer shutdowns.Shutdown()
+	ingestion := watch.NewGate()
+	ctx = watch.WithGate(ctx, ingestion)
+	progress := watch.NewProgress()
+	ctx = watch.WithProgress(ctx, progress)
+	ctx = watch.WithRetryLimit(ctx, opts.sourceRetries)
+	ctx = watch.WithReplaySpeed(ctx, opts.replaySpeed)
+	ctx = watch.WithBackfillRotated(ctx, opts.backfill)
+	ctx, chaosNote, err := opts.withChaos(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if chaosNote != "" {
+		fmt.Fprintln(os.Stderr, chaosNote)
+		notice = coalesce(notice, chaosNote)
+	}
+
+	streamArgs := []string{"stream", "--style", "syslog", "--level", "info"}
+	predicate := opts.macOSPredicate()
+	if predicate != "" {
+		streamArgs = append(streamArgs, "--predicate", predicate)
+	}
+	logCmd := exec.CommandContext(ctx, "log", streamArgs...)
+	logOut, err := logCmd.StdoutPipe()
+	if err != nil {
+		log.Fatalf("create log pipe: %v", err)
+	}
+	logCmd.Stderr = os.Stderr
+	if err := logCmd.Start(); err != nil {
+		log.Fatalf("start log stream: %v", err)
+	}
+
+	// The live stream starts first so nothing written during the replay is
+	// missed; ReplayThenStream drops what the two share.
+	var showCmd *exec.Cmd
+	var showOut io.Reader
+	if opts.macosSince != "" {
+		since, err := parseSince(opts.macosSince, time.Now())
+		if err != nil {
+			log.Fatalf("macos-since: %v", err)
+		}
+		showArgs := []string{"show", "--style", "syslog", "--info", "--start", since.Format("2006-01-02 15:04:05")}
+		if predicate != "" {
+			showArgs = append(showArgs, "--predicate", predicate)
+		}
+		showCmd = exec.CommandContext(ctx, "log", showArgs...)
+		if showOut, err = showCmd.StdoutPipe(); err != nil {
+			log.Fatalf("create log show pipe: %v", err)
+		}
+		showCmd.Stderr = os.Stderr
+		if err := showCmd.Start(); err != nil {
+			log.Fatalf("start log show: %v", err)
+		}
+		fmt.Printf("Replaying the unified log since %s...\n", since.Format("2006-01-02 15:04:05"))
+	}
+
+	fmt.Println("Starting macOS unified log stream...")
+	if predicate != "" {
+		fmt.Printf("Predicate: %s\n", predicate)
+	}
+	fmt.Println("Loading rules and starting TUI...")
+	fmt.Println()
+
+	ruleSet, err := rules.LoadFromFile(opts.config)
+	if err != nil {
+		log.Fatalf("load rules: %v", err)
+	}
+
+	minSeverity, err := rules.ParseSeverity(opts.minSeverity)
+	if err != nil {
+		log.Fatalf("min severity: %v", err)
+	}
+
+	swap := rules.NewSwap(ruleSet)
+	var lines <-chan watch.LogEvent
+	if showOut != nil {
+		lines = watch.Paced(ctx, watch.ReplayThenStream(ctx, macOSSource, showOut, logOut), opts.replaySpeed, nil)
+	} else {
+		lines = watch.StreamReader(ctx, macOSSource, logOut)
+	}
+	labeled, pinned, err := opts.matchLines(pctx, lines, swap.RuleSet(), minSeverity)
+	if err != nil {
+		log.Fatal(err)
+	}
+	matched, err := opts.stages(pctx, labeled)
+	if err != nil {
+		log.Fatal(err)
+	}
+	events := pipeline.NewBroadcaster(matched)
+	notifier, health, err := opts.startSinks(pctx, events, ruleSet, shutdowns)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ui := events.Subscribe(uiBuffer)
+
+	baseline, err := opts.loadBaseline()
+	if err != nil {
+		log.Fatalf("load baseline: %v", err)
+	}
+	precision, err := pipeline.ParsePrecision(opts.timePrecision)
+	if err != nil {
+		log.Fatalf("time precision: %v", err)
+	}
+	profile, err := opts.loadProfile()
+	if err != nil {
+		log.Fatal(err)
+	}
+	mode, err := opts.interactionMode(profile)
+	if err != nil {
+		log.Fatalf("mode: %v", err)
+	}
+
+	ruleGroups := runtime.BuildRuleGroups(ruleSet)
+
+	model := tui.NewModel(tui.ModelConfig{
+		Events:        ui.Events(),
+		UIDropped:     ui.Dropped,
+		ThemeName:     opts.theme,
+		Scrollback:    opts.scrollback,
+		RetainCap:     opts.retainSevere,
+		CompactWidth:  opts.compactWidth,
+		Precision:     precision,
+		FreshFor:      opts.fresh,
+		QuietGap:      opts.quietGap,
+		AgeAfter:      opts.ageAfter,
+		NoiseWindow:   opts.noiseWindow,
+		PauseRate:     opts.pauseRate,
+		PauseAfter:    opts.pauseAfter,
+		RateAlerts:    ruleSet.RateAlerts,
+		TagStyles:     ruleSet.TagStyles,
+		Entities:      ruleSet.Entities,
+		Actions:       ruleSet.Actions(),
+		Files:         []string{macOSSource},
+		ShowAll:       opts.showAll,
+		MinSeverity:   minSeverity,
+		PinPipelines:  pinned,
+		RuleGroups:    ruleGroups,
+		ConfigPath:    opts.config,
+		RuleSwap:      swap,
+		RuleDirs:      []string{filepath.Dir(opts.config), "configs", settings.RulesDir()},
+		Ingestion:     ingestion,
+		Progress:      progress,
+		Baseline:      baseline,
+		BaselinePath:  opts.baseline,
+		BaselineLearn: opts.baselineLearn,
+		DeltaMode:     opts.delta,
+		Audit:         auditLog,
+		Notifier:      notifier,
+		SinkFailures:  health.Summary,
+		Notice:        notice,
+		Mode:          mode,
+		Profile:       opts.profile,
+		PulseTags:     profile.PulseTags,
+		ProfilePath:   settings.StatePath(),
+		SessionPath:   settings.SessionPath(opts.session),
+	})
+
+	events.Start(pctx)
+	runProgram(model, shutdowns)
+
+	if logCmd.Process != nil {
+		logCmd.Process.Kill()
+	}
+	logCmd.Wait()
+	if showCmd != nil {
+		showCmd.Process.Kill()
+		showCmd.Wait()
+	}
+}
+
+// macOSPredicate joins --macos-subsystem and --macos-predicate into one
+// `log stream --predicate` (both must hold); "" streams everything.
+func (o *options) macOSPredicate() string {
+	var subsystems []string
+	for _, name := range strings.Split(o.macosSubsys, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			subsystems = append(subsystems, "subsystem == "+strconv.Quote(name))
+		}
+	}
+	var clauses []string
+	if len(subsystems) > 0 {
+		clauses = append(clauses, "("+strings.Join(subsystems, " OR ")+")")
+	}
+	if predicate := strings.TrimSpace(o.macosPred); predicate != "" {
+		clauses = append(clauses, "("+predicate+")")
+	}
+	return strings.Join(clauses, " AND ")
+}
+
+// interactionMode picks --mode when set anywhere, else the mode last saved for
+// the profile, else triage.
+func (o *options) interactionMode(profile settings.Profile) (string, error) {
+	if o.mode != "" {
+		return tui.ParseMode(o.mode)
+	}
+	return tui.ParseMode(profile.Mode)
+}
+
+// loadProfile reads the --profile state remembered from earlier sessions.
+func (o *options) loadProfile() (settings.Profile, error) {
+	profile, err := settings.LoadProfile(settings.StatePath(), o.profile)
+	if err != nil {
+		return profile, fmt.Errorf("load profile: %w", err)
+	}
+	return profile, nil
+}
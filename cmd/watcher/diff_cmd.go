@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"watcher/internal/rules"
+	"watcher/internal/search"
+	"watcher/internal/snapshot"
+)
+
+// newDiffCommand implements `diff [--format text|json] BEFORE AFTER`: the
+// ruleset run over two recordings (a file, directory, or glob each, e.g. the
+// logs from before and after a deployment), reporting rules and entities seen
+// on one side only, rule count changes, and message shapes new in AFTER or
+// gone from it.
+func newDiffCommand() *cobra.Command {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := fs.String("format", "text", "Report format: text or json")
+	output := fs.String("output", "", "Write the report to this file instead of stdout")
+	top := fs.Int("top", 20, "Message shapes listed per side, most frequent first (0 lists all)")
+	rotated := fs.Bool("rotated", false, "Include rotated siblings (.1, .2.gz, -20240101.gz) of plain file paths")
+	cmd := optionsCommand(&cobra.Command{
+		Use:   "diff [flags] BEFORE AFTER",
+		Short: "Compare rule hits and message shapes of two recordings",
+	}, fs, func(fs *flag.FlagSet, args []string) error {
+		opts, err := parseOptions(fs, args)
+		if err != nil {
+			return err
+		}
+		if len(opts.args) != 2 {
+			return fmt.Errorf("usage: %s diff [--format text|json] [--config rules.yaml] BEFORE AFTER", os.Args[0])
+		}
+		switch *format {
+		case "text", "json":
+		default:
+			return fmt.Errorf("unknown format %q (want text or json)", *format)
+		}
+
+		ruleSet, err := rules.LoadFromFile(opts.config)
+		if err != nil {
+			return fmt.Errorf("load rules: %w", err)
+		}
+		ctx, cancel := signalContext()
+		defer cancel()
+		var snaps [2]snapshot.Snapshot
+		for i, pattern := range opts.args {
+			files, err := search.Expand([]string{pattern}, *rotated)
+			if err != nil {
+				return fmt.Errorf("expand %s: %w", pattern, err)
+			}
+			snaps[i], err = snapshot.Take(ctx, pattern, ruleSet, files)
+			if err != nil {
+				log.Printf("diff: %v", err)
+			}
+		}
+		report := snapshot.Diff(snaps[0], snaps[1], *top)
+
+		out := os.Stdout
+		if *output != "" {
+			f, err := os.Create(*output)
+			if err != nil {
+				return fmt.Errorf("create report: %w", err)
+			}
+			defer f.Close()
+			out = f
+		}
+		if *format == "json" {
+			err = snapshot.WriteJSON(out, report)
+		} else {
+			err = snapshot.WriteText(out, report)
+		}
+		if err != nil {
+			return fmt.Errorf("write report: %w", err)
+		}
+		return nil
+	})
+	cmd.Flags().SetAnnotation("format", flagChoices, []string{"text", "json"})
+	return cmd
+}
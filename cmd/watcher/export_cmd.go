@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"watcher/internal/rules"
+	"watcher/internal/shipper"
+)
+
+// newExportConfigCommand implements `export-config --format vector|fluentbit`:
+// the configured sources and rules as shipper configuration, with rule matches
+// routed by severity and --min-severity choosing what reaches the output.
+func newExportConfigCommand() *cobra.Command {
+	fs := flag.NewFlagSet("export-config", flag.ExitOnError)
+	format := fs.String("format", "vector", "Shipper to export for: vector or fluentbit")
+	output := fs.String("output", "", "Write the configuration to this file instead of stdout")
+	cmd := optionsCommand(&cobra.Command{
+		Use:   "export-config [flags]",
+		Short: "Write the sources and rules as Vector or Fluent Bit configuration",
+	}, fs, func(fs *flag.FlagSet, args []string) error {
+		opts, err := parseOptions(fs, args)
+		if err != nil {
+			return err
+		}
+		switch *format {
+		case "vector", "fluentbit":
+		default:
+			return fmt.Errorf("unknown format %q (want vector or fluentbit)", *format)
+		}
+		ruleSet, err := rules.LoadFromFile(opts.config)
+		if err != nil {
+			return fmt.Errorf("load rules: %w", err)
+		}
+		min, err := rules.ParseSeverity(opts.minSeverity)
+		if err != nil {
+			return err
+		}
+		plan := shipper.NewPlan(opts.sourceFiles(), ruleSet, min)
+
+		out := os.Stdout
+		if *output != "" {
+			f, err := os.Create(*output)
+			if err != nil {
+				return fmt.Errorf("create config: %w", err)
+			}
+			defer f.Close()
+			out = f
+		}
+		if err := shipper.Write(out, *format, plan); err != nil {
+			return fmt.Errorf("export %s: %w", *format, err)
+		}
+		return nil
+	})
+	cmd.Flags().SetAnnotation("format", flagChoices, []string{"vector", "fluentbit"})
+	return cmd
+}
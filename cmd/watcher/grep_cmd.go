@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	goruntime "runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"watcher/internal/pipeline"
+	"watcher/internal/rules"
+	"watcher/internal/search"
+)
+
+// searchFlags are the flags grep and query share.
+type searchFlags struct {
+	paths   stringList
+	since   *string
+	asJSON  *bool
+	rotated *bool
+	workers *int
+}
+
+func defineSearchFlags(fs *flag.FlagSet) *searchFlags {
+	sf := &searchFlags{}
+	fs.Var(&sf.paths, "path", "File, directory, or glob to search (`**` spans directories); repeatable, positional args also accepted")
+	sf.since = fs.String("since", "", "Only lines newer than this: a duration (90m, 7d, 2w) or a date (2006-01-02, RFC 3339)")
+	sf.asJSON = fs.Bool("json", false, "Print one JSON object per match instead of text")
+	sf.rotated = fs.Bool("rotated", true, "Include rotated siblings (.1, .2.gz, -20240101.gz) of plain file paths")
+	sf.workers = fs.Int("workers", goruntime.NumCPU(), "Files scanned in parallel")
+	return sf
+}
+
+// newGrepCommand implements `grep --path PATTERN [--since 7d] [--json]`: the
+// live ruleset applied to historical (including gzipped) files, printed in
+// time order.
+func newGrepCommand() *cobra.Command {
+	fs := flag.NewFlagSet("grep", flag.ExitOnError)
+	sf := defineSearchFlags(fs)
+	return optionsCommand(&cobra.Command{
+		Use:   "grep [flags] [PATH...]",
+		Short: "Run the rules over files already on disk",
+	}, fs, func(fs *flag.FlagSet, args []string) error {
+		opts, err := parseOptions(fs, args)
+		if err != nil {
+			return err
+		}
+		paths := append(sf.paths, opts.args...)
+		if len(paths) == 0 {
+			return fmt.Errorf("usage: %s grep --path PATTERN [--since 7d] [--config rules.yaml]", os.Args[0])
+		}
+		return runSearch(opts, sf, paths, nil)
+	})
+}
+
+// newQueryCommand implements `query [flags] EXPR [PATH...]`: grep narrowed
+// by a filter expression, as the sinks' --*-filter flags take, so old logs
+// can be asked `severity>=high && user=root`.
+func newQueryCommand() *cobra.Command {
+	fs := flag.NewFlagSet("query", flag.ExitOnError)
+	sf := defineSearchFlags(fs)
+	return optionsCommand(&cobra.Command{
+		Use:   "query [flags] EXPR [PATH...]",
+		Short: "Search files already on disk with a filter expression",
+	}, fs, func(fs *flag.FlagSet, args []string) error {
+		opts, err := parseOptions(fs, args)
+		if err != nil {
+			return err
+		}
+		if len(opts.args) == 0 {
+			return fmt.Errorf("usage: %s query [--path PATTERN] [--since 7d] EXPR [PATH...]", os.Args[0])
+		}
+		filter, err := pipeline.ParseFilter(opts.args[0])
+		if err != nil {
+			return err
+		}
+		paths := append(sf.paths, opts.args[1:]...)
+		if len(paths) == 0 {
+			paths = splitFiles(opts.files)
+		}
+		return runSearch(opts, sf, paths, filter)
+	})
+}
+
+// runSearch prints the matches in paths that pass filter (nil passes all).
+func runSearch(opts *options, sf *searchFlags, paths []string, filter *pipeline.Filter) error {
+	cutoff, err := parseSince(*sf.since, time.Now())
+	if err != nil {
+		return fmt.Errorf("since: %w", err)
+	}
+	ruleSet, err := rules.LoadFromFile(opts.config)
+	if err != nil {
+		return fmt.Errorf("load rules: %w", err)
+	}
+	minSeverity, err := rules.ParseSeverity(opts.minSeverity)
+	if err != nil {
+		return fmt.Errorf("min severity: %w", err)
+	}
+	precision, err := pipeline.ParsePrecision(opts.timePrecision)
+	if err != nil {
+		return fmt.Errorf("time precision: %w", err)
+	}
+	files, err := search.Expand(paths, *sf.rotated)
+	if err != nil {
+		return fmt.Errorf("expand paths: %w", err)
+	}
+
+	ctx, cancel := signalContext()
+	defer cancel()
+	stream := pipeline.New(ruleSet, opts.showAll, minSeverity)
+	results, err := search.Run(ctx, files, stream, search.Options{Since: cutoff, Workers: *sf.workers})
+	if err != nil {
+		log.Printf("search: %v", err)
+	}
+	if filter != nil {
+		kept := results[:0]
+		for _, r := range results {
+			if filter.Match(r.HighlightedEvent) {
+				kept = append(kept, r)
+			}
+		}
+		results = kept
+	}
+	if *sf.asJSON {
+		return writeResultsJSON(os.Stdout, results)
+	}
+	writeResults(os.Stdout, results, precision)
+	fmt.Fprintf(os.Stderr, "%d matches in %d files\n", len(results), len(files))
+	return nil
+}
+
+func writeResults(w io.Writer, results []search.Result, precision pipeline.Precision) {
+	for _, r := range results {
+		rule := r.RuleName
+		if rule == "" {
+			rule = "-"
+		}
+		fmt.Fprintf(w, "%s  %-8s  %s  %s:%d  %s\n",
+			r.Timestamp.Format(precision.RFC3339()), strings.ToUpper(string(r.Severity)), rule, r.Path, r.LineNo, r.Line)
+	}
+}
+
+func writeResultsJSON(w io.Writer, results []search.Result) error {
+	enc := json.NewEncoder(w)
+	for _, r := range results {
+		err := enc.Encode(struct {
+			Time     time.Time         `json:"time"`
+			Severity rules.Severity    `json:"severity"`
+			Rule     string            `json:"rule,omitempty"`
+			Path     string            `json:"path"`
+			LineNo   int               `json:"line_no"`
+			Line     string            `json:"line"`
+			Captures map[string]string `json:"captures,omitempty"`
+			Chain    []string          `json:"chain,omitempty"`
+			Derived  string            `json:"derived,omitempty"`
+		}{r.Timestamp, r.Severity, r.RuleName, r.Path, r.LineNo, r.Line, r.Captures, r.Chain, r.Derived})
+		if err != nil {
+			return fmt.Errorf("encode match: %w", err)
+		}
+	}
+	return nil
+}
+
+// parseSince accepts Go durations plus d/w suffixes, or an absolute date.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if n := len(value); n > 1 && (value[n-1] == 'd' || value[n-1] == 'w') {
+		if count, err := strconv.Atoi(value[:n-1]); err == nil {
+			days := count
+			if value[n-1] == 'w' {
+				days *= 7
+			}
+			return now.AddDate(0, 0, -days), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	if ts, err := time.Parse(time.RFC3339, value); err == nil {
+		return ts, nil
+	}
+	if ts, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return ts, nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognized %q", value)
+}
+
+// stringList is a repeatable string flag that also splits commas.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, splitFiles(value)...)
+	return nil
+}
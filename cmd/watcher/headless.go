@@ -0,0 +1,19 @@
+//go:build headlessonly
+
+package main
+
+import (
+	"log"
+
+	"watcher/internal/audit"
+	"watcher/internal/shutdown"
+)
+
+// run is the fleet-agent entry point: no dashboard and none of its
+// dependencies (see runHeadless).
+func run(opts *options, shutdowns *shutdown.Coordinator, auditLog *audit.Log, notice string) {
+	if opts.macos {
+		log.Fatal("--macos needs the dashboard build")
+	}
+	runHeadless(opts, shutdowns)
+}
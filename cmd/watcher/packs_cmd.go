@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"watcher/internal/rules"
+)
+
+// packSuffix ends every rule pack's file name.
+const packSuffix = ".rules.yaml"
+
+// defaultPacksDir is where the bundled rule packs live.
+var defaultPacksDir = filepath.Join("configs", "packs")
+
+// newPacksCommand implements `packs list|show|path`: the bundled rule packs,
+// one rule pack's rules, and a pack's file for --config or include.
+func newPacksCommand() *cobra.Command {
+	packs := &cobra.Command{
+		Use:   "packs",
+		Short: "List and inspect the bundled rule packs",
+	}
+	packs.AddCommand(
+		packCommand("list", "List the rule packs", 0, func(dir string, _ []string) error {
+			return listPacks(dir)
+		}),
+		packCommand("show NAME", "Print a pack's rules", 1, func(dir string, args []string) error {
+			return showPack(dir, args[0])
+		}),
+		packCommand("path NAME", "Print a pack's file, e.g. for --config \"$(spectra-watch packs path sshd)\"", 1, func(dir string, args []string) error {
+			path, err := packPath(dir, args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Println(path)
+			return nil
+		}),
+	)
+	return packs
+}
+
+// packCommand builds one packs subcommand taking --dir and nargs pack names.
+func packCommand(use, short string, nargs int, run func(dir string, args []string) error) *cobra.Command {
+	name, _, _ := strings.Cut(use, " ")
+	fs := flag.NewFlagSet("packs "+name, flag.ExitOnError)
+	dir := fs.String("dir", defaultPacksDir, "Directory holding the *"+packSuffix+" packs")
+	cmd := &cobra.Command{Use: use, Short: short}
+	if nargs > 0 {
+		cmd.ValidArgsFunction = func(c *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+			if len(positionalArgs(c, args)) >= nargs {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			d, ok := argValue(args, "dir")
+			if !ok {
+				d = defaultPacksDir
+			}
+			names, _ := packNames(d)
+			return matching(names, toComplete), cobra.ShellCompDirectiveNoFileComp
+		}
+	}
+	flagCommand(cmd, fs, func(args []string) error {
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() != nargs {
+			return fmt.Errorf("usage: %s packs %s", os.Args[0], use)
+		}
+		return run(*dir, fs.Args())
+	})
+	cmd.MarkFlagDirname("dir")
+	return cmd
+}
+
+// packNames lists the packs in dir by name (file name without packSuffix).
+func packNames(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read packs: %w", err)
+	}
+	var names []string
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), packSuffix); ok && !e.IsDir() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// packPath resolves a pack name, or a pack's file name, to its path in dir.
+func packPath(dir, name string) (string, error) {
+	path := filepath.Join(dir, strings.TrimSuffix(name, packSuffix)+packSuffix)
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("pack %s: %w", name, err)
+	}
+	return path, nil
+}
+
+func listPacks(dir string) error {
+	names, err := packNames(dir)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tRULES\tDESCRIPTION")
+	for _, name := range names {
+		path := filepath.Join(dir, name+packSuffix)
+		count := "?"
+		if rs, err := rules.LoadFromFile(path); err == nil {
+			count = fmt.Sprint(len(rs.Rules))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", name, count, packSummary(path))
+	}
+	return tw.Flush()
+}
+
+func showPack(dir, name string) error {
+	path, err := packPath(dir, name)
+	if err != nil {
+		return err
+	}
+	rs, err := rules.LoadFromFile(path)
+	if err != nil {
+		return fmt.Errorf("load %s: %w", path, err)
+	}
+	fmt.Printf("%s: %s\n\n", path, packSummary(path))
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SEVERITY\tRULE\tTAGS\tDESCRIPTION")
+	for _, r := range rs.Rules {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Severity, r.Name, strings.Join(r.Tags, ","), r.Description)
+	}
+	return tw.Flush()
+}
+
+// packSummary is the first sentence of the comment heading a pack file.
+func packSummary(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	var text []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, ok := strings.CutPrefix(scanner.Text(), "#")
+		if !ok {
+			break
+		}
+		text = append(text, strings.TrimSpace(line))
+	}
+	summary := strings.Join(text, " ")
+	if end := strings.Index(summary, ". "); end >= 0 {
+		summary = summary[:end+1]
+	}
+	return summary
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"watcher/internal/pipeline"
+	"watcher/internal/rules"
+	"watcher/internal/watch"
+)
+
+// Panes a named pipeline can feed in the dashboard.
+const (
+	paneStream = "stream"
+	panePinned = "pinned"
+)
+
+// pipelineSpec is one --pipelines entry; unset keys fall back to --config,
+// --show-all, and --min-severity. min is the entry's own threshold, resolved
+// once its rule file (and any custom levels) is loaded.
+type pipelineSpec struct {
+	name        string
+	config      string
+	showAll     bool
+	min         string
+	minSeverity rules.Severity
+	pane        string
+}
+
+// parsePipelines reads `strict:min=high,pane=pinned;all:show-all`: entries
+// separated by `;`, each a name and comma separated keys.
+func parsePipelines(value, config string, showAll bool, min rules.Severity) ([]pipelineSpec, error) {
+	var specs []pipelineSpec
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, keys, _ := strings.Cut(entry, ":")
+		name = strings.TrimSpace(name)
+		if name == "" || strings.ContainsAny(name, " \t,=") {
+			return nil, fmt.Errorf("pipeline %q: want name:key=value,…", entry)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("pipeline %q defined twice", name)
+		}
+		seen[name] = true
+		spec := pipelineSpec{name: name, config: config, showAll: showAll, minSeverity: min, pane: paneStream}
+		for _, kv := range strings.Split(keys, ",") {
+			key, val, hasVal := strings.Cut(strings.TrimSpace(kv), "=")
+			var err error
+			switch key {
+			case "":
+			case "rules":
+				spec.config = val
+			case "show-all":
+				spec.showAll = true
+				if hasVal {
+					spec.showAll, err = strconv.ParseBool(val)
+				}
+			case "min":
+				spec.min = val
+			case "pane":
+				if val != paneStream && val != panePinned {
+					err = fmt.Errorf("unknown pane %q (stream, pinned)", val)
+				}
+				spec.pane = val
+			default:
+				err = fmt.Errorf("unknown key %q (rules, show-all, min, pane)", key)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("pipeline %s: %w", name, err)
+			}
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// matchLines runs lines through the --pipelines branches, or through the
+// one pipeline --show-all and --min-severity describe. Branches reading
+// --config share rs, so a rule file switched at runtime reaches them; the
+// others load their own file once. It also returns the pipelines whose
+// events go to the pinned strip.
+func (o *options) matchLines(ctx context.Context, lines <-chan watch.LogEvent, rs rules.RuleSet, min rules.Severity) (<-chan pipeline.HighlightedEvent, []string, error) {
+	specs, err := parsePipelines(o.pipelines, o.config, o.showAll, min)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(specs) == 0 {
+		return pipeline.New(rs, o.showAll, min).Connect(ctx, lines), nil, nil
+	}
+	var branches []pipeline.Branch
+	var pinned []string
+	for _, spec := range specs {
+		set := rs
+		if spec.config != o.config {
+			if set, err = rules.Load(spec.config); err != nil {
+				return nil, nil, fmt.Errorf("pipeline %s: load rules: %w", spec.name, err)
+			}
+		}
+		if spec.min != "" {
+			if spec.minSeverity, err = rules.ParseSeverity(spec.min); err != nil {
+				return nil, nil, fmt.Errorf("pipeline %s: %w", spec.name, err)
+			}
+		}
+		branches = append(branches, pipeline.Branch{Name: spec.name, Stream: pipeline.New(set, spec.showAll, spec.minSeverity)})
+		if spec.pane == panePinned {
+			pinned = append(pinned, spec.name)
+		}
+	}
+	return pipeline.Fork(ctx, lines, branches), pinned, nil
+}
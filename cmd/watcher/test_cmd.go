@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"watcher/internal/rules"
+)
+
+// newTestCommand implements `test [--expect RULE] [LINE...]`: the rule each
+// sample line (the arguments, else stdin) matches in the live view, with its
+// captures and the other rules it shadows, so a rule can be tried before it
+// is deployed. With --expect it exits non-zero unless every line's winner is
+// that rule ("-" for none).
+func newTestCommand() *cobra.Command {
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	expect := fs.String("expect", "", "Fail unless every line matches this rule (\"-\" for no rule)")
+	return optionsCommand(&cobra.Command{
+		Use:   "test [flags] [LINE...]",
+		Short: "Show which rule matches sample lines",
+	}, fs, func(fs *flag.FlagSet, args []string) error {
+		opts, err := parseOptions(fs, args)
+		if err != nil {
+			return err
+		}
+		ruleSet, err := rules.LoadFromFile(opts.config)
+		if err != nil {
+			return fmt.Errorf("load rules: %w", err)
+		}
+		lines := opts.args
+		if len(lines) == 0 {
+			if lines, err = readLines(os.Stdin); err != nil {
+				return fmt.Errorf("read stdin: %w", err)
+			}
+		}
+		failed := 0
+		for _, line := range lines {
+			winner := writeTest(os.Stdout, ruleSet, line)
+			if *expect != "" && winner != *expect {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d lines did not match %s", failed, len(lines), *expect)
+		}
+		return nil
+	})
+}
+
+// writeTest prints how ruleSet treats line and returns the winning rule's
+// name, or "-".
+func writeTest(w io.Writer, ruleSet rules.RuleSet, line string) string {
+	m, ok := ruleSet.Match(line)
+	if !ok {
+		fmt.Fprintf(w, "%-8s  -  %s\n", strings.ToUpper(string(rules.SeverityNormal)), line)
+		return "-"
+	}
+	fmt.Fprintf(w, "%-8s  %s  %s\n", strings.ToUpper(string(m.Rule.Severity)), m.Rule.Name, line)
+	if len(m.Chain) > 0 {
+		fmt.Fprintf(w, "          via: %s -> %q\n", strings.Join(m.Chain, " -> "), m.Derived)
+	}
+	if len(m.Captures) > 0 {
+		names := make([]string, 0, len(m.Captures))
+		for name := range m.Captures {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for i, name := range names {
+			names[i] = fmt.Sprintf("%s=%q", name, m.Captures[name])
+		}
+		fmt.Fprintf(w, "          captures: %s\n", strings.Join(names, " "))
+	}
+	var shadowed []string
+	for _, other := range ruleSet.MatchAll(line) {
+		if other.Rule.Name != m.Rule.Name && !slices.Contains(m.Chain, other.Rule.Name) {
+			shadowed = append(shadowed, other.Rule.Name)
+		}
+	}
+	if len(shadowed) > 0 {
+		fmt.Fprintf(w, "          also matches: %s\n", strings.Join(shadowed, ", "))
+	}
+	return m.Rule.Name
+}
+
+// readLines returns the non-empty lines of r.
+func readLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		if line := strings.TrimRight(scanner.Text(), "\r"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"watcher/internal/update"
+)
+
+// version and rulePacksVersion are stamped by `make build` through -ldflags.
+var (
+	version          = "dev"
+	rulePacksVersion = "dev"
+)
+
+// updateCheckTimeout bounds the --check-update request so startup never hangs on the network.
+const updateCheckTimeout = 3 * time.Second
+
+// newUpdateCommand implements `update [--check]`: fetch the signed release
+// manifest and, unless only checking, install the newer binary in place.
+func newUpdateCommand() *cobra.Command {
+	fs := flag.NewFlagSet("update", flag.ExitOnError)
+	checkOnly := fs.Bool("check", false, "Only report whether a newer release exists")
+	cmd := optionsCommand(&cobra.Command{
+		Use:   "update [--check]",
+		Short: "Install the latest signed release",
+	}, fs, func(fs *flag.FlagSet, args []string) error {
+		opts, err := parseOptions(fs, args)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := signalContext()
+		defer cancel()
+		client := update.Client{URL: opts.updateURL}
+		manifest, err := client.Fetch(ctx)
+		if err != nil {
+			return fmt.Errorf("check for update: %w", err)
+		}
+		fmt.Printf("installed: %s (rule packs %s)\n", version, rulePacksVersion)
+		fmt.Printf("latest:    %s (rule packs %s)\n", manifest.Version, manifest.RulePacks)
+		if !update.Newer(manifest.Version, version) {
+			fmt.Println("already up to date")
+			return nil
+		}
+		if manifest.Notes != "" {
+			fmt.Printf("\n%s\n\n", manifest.Notes)
+		}
+		if *checkOnly {
+			return nil
+		}
+		path, err := client.Install(ctx, manifest)
+		if err != nil {
+			return fmt.Errorf("install update: %w", err)
+		}
+		fmt.Printf("updated %s to %s\n", path, manifest.Version)
+		return nil
+	})
+	return cmd
+}
+
+// checkForUpdate backs --check-update: a short, best-effort lookup that
+// returns a one-line notice when a newer release (or rule pack) exists.
+func checkForUpdate(url string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), updateCheckTimeout)
+	defer cancel()
+	client := update.Client{URL: url, HTTP: &http.Client{Timeout: updateCheckTimeout}}
+	manifest, err := client.Fetch(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "update check failed: %v\n", err)
+		return ""
+	}
+	if !update.Newer(manifest.Version, version) {
+		return ""
+	}
+	notice := fmt.Sprintf("spectra %s is available (running %s)", manifest.Version, version)
+	if update.Newer(manifest.RulePacks, rulePacksVersion) {
+		notice += fmt.Sprintf(" with rule pack updates (%s → %s)", rulePacksVersion, manifest.RulePacks)
+	}
+	return notice + " · run `spectra-watch update`"
+}
@@ -0,0 +1,467 @@
+package archive
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// EVTX layout: a 4 KiB file header, then 64 KiB chunks, each a 512-byte
+// header followed by records. A record's body is binary XML whose element
+// names and templates are stored once per chunk and referenced by offset.
+const (
+	evtxFileMagic    = "ElfFile\x00"
+	evtxChunkMagic   = "ElfChnk\x00"
+	evtxRecordMagic  = "\x2a\x2a\x00\x00"
+	evtxHeaderSize   = 4096
+	evtxChunkSize    = 64 << 10
+	evtxChunkHeader  = 512
+	evtxRecordHeader = 24
+	// evtxMaxDepth bounds nested binary XML (values that are themselves
+	// fragments) so a corrupt chunk cannot recurse without end.
+	evtxMaxDepth = 8
+)
+
+// readEvtx renders every record of an .evtx file, chunk by chunk. Chunks that
+// do not carry the chunk signature (never written, or damaged) are skipped,
+// as are records that fail to decode, so a partly corrupt image still yields
+// what it can.
+func readEvtx(r io.Reader, fn func(Record) bool) error {
+	header := make([]byte, evtxHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return fmt.Errorf("file header: %w", err)
+	}
+	if !bytes.HasPrefix(header, []byte(evtxFileMagic)) {
+		return errors.New("not an evtx file")
+	}
+	chunk := make([]byte, evtxChunkSize)
+	for base := int64(evtxHeaderSize); ; base += evtxChunkSize {
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil
+			}
+			return err
+		}
+		if !bytes.HasPrefix(chunk, []byte(evtxChunkMagic)) {
+			continue
+		}
+		end := min(int(binary.LittleEndian.Uint32(chunk[48:])), evtxChunkSize)
+		for pos := evtxChunkHeader; pos+evtxRecordHeader <= end; {
+			if string(chunk[pos:pos+4]) != evtxRecordMagic {
+				break
+			}
+			size := int(binary.LittleEndian.Uint32(chunk[pos+4:]))
+			if size < evtxRecordHeader+4 || pos+size > evtxChunkSize {
+				break
+			}
+			stamp := filetime(binary.LittleEndian.Uint64(chunk[pos+16:]))
+			d := &binXML{chunk: chunk}
+			root := &xmlElement{}
+			d.fragment(pos+evtxRecordHeader, pos+size-4, nil, root, 0)
+			if d.err == nil && len(root.children) > 0 {
+				line := evtxLine(stamp, root.children[0])
+				if !fn(Record{Line: line, Offset: base + int64(pos+size), Time: stamp}) {
+					return nil
+				}
+			}
+			pos += size
+		}
+	}
+}
+
+// xmlElement is a decoded element: enough of XML to read an event.
+type xmlElement struct {
+	name     string
+	attrs    map[string]string
+	children []*xmlElement
+	text     strings.Builder
+}
+
+func (e *xmlElement) child(name string) *xmlElement {
+	for _, c := range e.children {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// binXML decodes binary XML within one chunk; offsets are chunk-relative.
+type binXML struct {
+	chunk []byte
+	err   error
+}
+
+// pendingAttr is an attribute whose value token comes next.
+type pendingAttr struct {
+	el   *xmlElement
+	name string
+}
+
+// subValue is one template substitution: its type and where its bytes are.
+type subValue struct {
+	typ        byte
+	start, end int
+}
+
+func (d *binXML) fail(format string, args ...any) {
+	if d.err == nil {
+		d.err = fmt.Errorf(format, args...)
+	}
+}
+
+func (d *binXML) bytesAt(pos, n int) []byte {
+	if d.err != nil || pos < 0 || n < 0 || pos+n > len(d.chunk) {
+		d.fail("offset %d+%d outside chunk", pos, n)
+		return make([]byte, n)
+	}
+	return d.chunk[pos : pos+n]
+}
+
+func (d *binXML) u8(pos int) byte    { return d.bytesAt(pos, 1)[0] }
+func (d *binXML) u16(pos int) uint16 { return binary.LittleEndian.Uint16(d.bytesAt(pos, 2)) }
+func (d *binXML) u32(pos int) uint32 { return binary.LittleEndian.Uint32(d.bytesAt(pos, 4)) }
+
+// name reads the name string at off (next offset, hash, length, UTF-16,
+// NUL) and returns it with its size, which the caller skips when the name is
+// stored inline at the current position.
+func (d *binXML) name(off int) (string, int) {
+	n := int(d.u16(off + 6))
+	return utf16String(d.bytesAt(off+8, n*2)), 8 + n*2 + 2
+}
+
+// fragment decodes tokens in [pos, end) into parent until an end-of-fragment
+// token, returning the position after it.
+func (d *binXML) fragment(pos, end int, subs []subValue, parent *xmlElement, depth int) int {
+	if depth > evtxMaxDepth {
+		d.fail("binary xml nested too deep")
+		return end
+	}
+	stack := []*xmlElement{parent}
+	var attr *pendingAttr
+	for pos < end && d.err == nil {
+		tok := d.u8(pos)
+		top := stack[len(stack)-1]
+		switch tok &^ 0x40 {
+		case 0x00: // end of fragment
+			return pos + 1
+		case 0x01: // open start element
+			nameOff := int(d.u32(pos + 7))
+			pos += 11
+			name, size := d.name(nameOff)
+			if nameOff == pos {
+				pos += size
+			}
+			if tok&0x40 != 0 {
+				pos += 4 // attribute list size
+			}
+			el := &xmlElement{name: name}
+			top.children = append(top.children, el)
+			stack = append(stack, el)
+		case 0x02: // close start element
+			pos++
+		case 0x03, 0x04: // close empty element, end element
+			pos++
+			attr = nil
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case 0x05: // value
+			if typ := d.u8(pos + 1); typ != 0x01 {
+				d.fail("value of type %#x", typ)
+				return end
+			}
+			n := int(d.u16(pos + 2))
+			appendText(top, &attr, utf16String(d.bytesAt(pos+4, n*2)))
+			pos += 4 + n*2
+		case 0x06: // attribute
+			nameOff := int(d.u32(pos + 1))
+			pos += 5
+			name, size := d.name(nameOff)
+			if nameOff == pos {
+				pos += size
+			}
+			if top.attrs == nil {
+				top.attrs = make(map[string]string)
+			}
+			top.attrs[name] = ""
+			attr = &pendingAttr{el: top, name: name}
+		case 0x07: // CDATA
+			n := int(d.u16(pos + 1))
+			appendText(top, &attr, utf16String(d.bytesAt(pos+3, n*2)))
+			pos += 3 + n*2
+		case 0x08: // character reference
+			appendText(top, &attr, string(rune(d.u16(pos+1))))
+			pos += 3
+		case 0x09, 0x0a: // entity reference, processing instruction target
+			nameOff := int(d.u32(pos + 1))
+			pos += 5
+			if _, size := d.name(nameOff); nameOff == pos {
+				pos += size
+			}
+		case 0x0b: // processing instruction data
+			pos += 3 + int(d.u16(pos+1))*2
+		case 0x0c: // template instance
+			pos = d.template(pos, top, depth)
+		case 0x0d, 0x0e: // normal, optional substitution
+			index := int(d.u16(pos + 1))
+			pos += 4
+			if index < len(subs) {
+				d.substitute(subs[index], top, &attr, depth)
+			}
+		case 0x0f: // fragment header
+			pos += 4
+		default:
+			d.fail("unknown token %#x at %d", tok, pos)
+		}
+	}
+	return pos
+}
+
+// template decodes a template instance: the definition (inline the first
+// time the chunk uses it, else referenced by offset) filled in with the
+// substitution values that follow.
+func (d *binXML) template(pos int, parent *xmlElement, depth int) int {
+	defOff := int(d.u32(pos + 6))
+	pos += 10
+	dataSize := int(d.u32(defOff + 20))
+	if defOff == pos {
+		pos += 24 + dataSize
+	}
+	count := int(d.u32(pos))
+	pos += 4
+	if count > len(d.chunk)/4 {
+		d.fail("%d substitutions", count)
+		return pos
+	}
+	subs := make([]subValue, count)
+	valuePos := pos + count*4
+	for i := range subs {
+		size := int(d.u16(pos + i*4))
+		subs[i] = subValue{typ: d.u8(pos + i*4 + 2), start: valuePos, end: valuePos + size}
+		valuePos += size
+	}
+	d.fragment(defOff+24, defOff+24+dataSize, subs, parent, depth+1)
+	return valuePos
+}
+
+// substitute places one substitution value: nested binary XML becomes child
+// elements, anything else text of the element or pending attribute.
+func (d *binXML) substitute(v subValue, el *xmlElement, attr **pendingAttr, depth int) {
+	if v.typ == 0x21 {
+		d.fragment(v.start, v.end, nil, el, depth+1)
+		return
+	}
+	data := d.bytesAt(v.start, v.end-v.start)
+	if d.err == nil {
+		appendText(el, attr, formatValue(v.typ, data))
+	}
+}
+
+// appendText adds s to the pending attribute, if one is waiting for its
+// value, else to the element's text.
+func appendText(el *xmlElement, attr **pendingAttr, s string) {
+	if a := *attr; a != nil {
+		a.el.attrs[a.name] += s
+		*attr = nil
+		return
+	}
+	el.text.WriteString(s)
+}
+
+// formatValue renders a substitution value of an EVTX value type.
+func formatValue(typ byte, data []byte) string {
+	le := binary.LittleEndian
+	switch typ {
+	case 0x00:
+		return ""
+	case 0x01:
+		return strings.TrimRight(utf16String(data), "\x00")
+	case 0x02:
+		return strings.TrimRight(string(data), "\x00")
+	case 0x03:
+		if len(data) >= 1 {
+			return strconv.Itoa(int(int8(data[0])))
+		}
+	case 0x04:
+		if len(data) >= 1 {
+			return strconv.Itoa(int(data[0]))
+		}
+	case 0x05:
+		if len(data) >= 2 {
+			return strconv.Itoa(int(int16(le.Uint16(data))))
+		}
+	case 0x06:
+		if len(data) >= 2 {
+			return strconv.Itoa(int(le.Uint16(data)))
+		}
+	case 0x07:
+		if len(data) >= 4 {
+			return strconv.Itoa(int(int32(le.Uint32(data))))
+		}
+	case 0x08:
+		if len(data) >= 4 {
+			return strconv.FormatUint(uint64(le.Uint32(data)), 10)
+		}
+	case 0x09:
+		if len(data) >= 8 {
+			return strconv.FormatInt(int64(le.Uint64(data)), 10)
+		}
+	case 0x0a:
+		if len(data) >= 8 {
+			return strconv.FormatUint(le.Uint64(data), 10)
+		}
+	case 0x0b:
+		if len(data) >= 4 {
+			return strconv.FormatFloat(float64(math.Float32frombits(le.Uint32(data))), 'g', -1, 32)
+		}
+	case 0x0c:
+		if len(data) >= 8 {
+			return strconv.FormatFloat(math.Float64frombits(le.Uint64(data)), 'g', -1, 64)
+		}
+	case 0x0d:
+		if len(data) >= 4 {
+			return strconv.FormatBool(le.Uint32(data) != 0)
+		}
+	case 0x0f:
+		if len(data) >= 16 {
+			return fmt.Sprintf("{%08X-%04X-%04X-%X-%X}", le.Uint32(data), le.Uint16(data[4:]), le.Uint16(data[6:]), data[8:10], data[10:16])
+		}
+	case 0x10, 0x15:
+		if len(data) >= 8 {
+			return fmt.Sprintf("0x%x", le.Uint64(data))
+		}
+		if len(data) >= 4 {
+			return fmt.Sprintf("0x%x", le.Uint32(data))
+		}
+	case 0x14:
+		if len(data) >= 4 {
+			return fmt.Sprintf("0x%x", le.Uint32(data))
+		}
+	case 0x11:
+		if len(data) >= 8 {
+			return filetime(le.Uint64(data)).Format(time.RFC3339Nano)
+		}
+	case 0x12:
+		if len(data) >= 16 {
+			t := time.Date(int(le.Uint16(data)), time.Month(le.Uint16(data[2:])), int(le.Uint16(data[6:])),
+				int(le.Uint16(data[8:])), int(le.Uint16(data[10:])), int(le.Uint16(data[12:])), int(le.Uint16(data[14:]))*int(time.Millisecond), time.UTC)
+			return t.Format(time.RFC3339Nano)
+		}
+	case 0x13:
+		return formatSID(data)
+	case 0x81:
+		return strings.Join(strings.FieldsFunc(utf16String(data), func(r rune) bool { return r == 0 }), ",")
+	}
+	return hex.EncodeToString(data)
+}
+
+// formatSID renders a binary security identifier as S-1-5-21-….
+func formatSID(data []byte) string {
+	if len(data) < 8 || len(data) < 8+int(data[1])*4 {
+		return hex.EncodeToString(data)
+	}
+	var authority uint64
+	for _, b := range data[2:8] {
+		authority = authority<<8 | uint64(b)
+	}
+	parts := []string{"S", strconv.Itoa(int(data[0])), strconv.FormatUint(authority, 10)}
+	for i := 0; i < int(data[1]); i++ {
+		parts = append(parts, strconv.FormatUint(uint64(binary.LittleEndian.Uint32(data[8+i*4:])), 10))
+	}
+	return strings.Join(parts, "-")
+}
+
+func utf16String(data []byte) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(data[i*2:])
+	}
+	return string(utf16.Decode(units))
+}
+
+// filetime converts a Windows FILETIME (100 ns ticks since 1601) to UTC.
+func filetime(ticks uint64) time.Time {
+	const epochDelta = 116444736000000000 // 1601-01-01 to 1970-01-01 in ticks
+	if ticks < epochDelta {
+		return time.Unix(0, 0).UTC()
+	}
+	return time.Unix(0, int64(ticks-epochDelta)*100).UTC()
+}
+
+// evtxLevels names the System/Level values; 0 (LogAlways) is what the
+// Security log uses for audit events.
+var evtxLevels = map[string]string{
+	"0": "Information",
+	"1": "Critical",
+	"2": "Error",
+	"3": "Warning",
+	"4": "Information",
+	"5": "Verbose",
+}
+
+// evtxLine renders an event as
+// `2006-01-02T15:04:05.000000Z COMPUTER Provider[EventID]: Level Name=value …`
+// with EventData (or UserData) fields in document order; values with spaces
+// are quoted.
+func evtxLine(stamp time.Time, event *xmlElement) string {
+	var b strings.Builder
+	b.WriteString(stamp.Format(stampLayout))
+	system := event.child("System")
+	if system == nil {
+		system = &xmlElement{}
+	}
+	text := func(name string) string {
+		if el := system.child(name); el != nil {
+			return strings.TrimSpace(el.text.String())
+		}
+		return ""
+	}
+	if computer := text("Computer"); computer != "" {
+		b.WriteString(" " + computer)
+	}
+	provider := "EventLog"
+	if el := system.child("Provider"); el != nil && el.attrs["Name"] != "" {
+		provider = el.attrs["Name"]
+	}
+	fmt.Fprintf(&b, " %s[%s]: ", provider, text("EventID"))
+	level, ok := evtxLevels[text("Level")]
+	if !ok {
+		level = "Level" + text("Level")
+	}
+	b.WriteString(level)
+	var data *xmlElement
+	if data = event.child("EventData"); data == nil {
+		if user := event.child("UserData"); user != nil && len(user.children) > 0 {
+			data = user.children[0]
+		}
+	}
+	if data != nil {
+		for i, field := range data.children {
+			name := field.attrs["Name"]
+			if field.name != "Data" {
+				name = field.name
+			}
+			if name == "" {
+				name = "Data" + strconv.Itoa(i+1)
+			}
+			b.WriteString(" " + name + "=" + quoteField(strings.TrimSpace(field.text.String())))
+		}
+	}
+	return b.String()
+}
+
+func quoteField(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\r\n\"=") {
+		return strconv.Quote(value)
+	}
+	return value
+}
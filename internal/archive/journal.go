@@ -0,0 +1,110 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// readJournalExport reads `journalctl -o export` output: entries separated by
+// a blank line, each field either `NAME=value` or, for values that are binary
+// or contain newlines, `NAME` followed by a little-endian uint64 length, the
+// data, and a newline.
+func readJournalExport(r io.Reader, fn func(Record) bool) error {
+	br := bufio.NewReaderSize(r, 64<<10)
+	var offset int64
+	fields := make(map[string]string)
+	flush := func() bool {
+		if len(fields) == 0 {
+			return true
+		}
+		line := journalLine(fields)
+		var stamp time.Time
+		if usec, err := strconv.ParseInt(fields["__REALTIME_TIMESTAMP"], 10, 64); err == nil {
+			stamp = time.UnixMicro(usec).UTC()
+		}
+		clear(fields)
+		return fn(Record{Line: line, Offset: offset, Time: stamp})
+	}
+	for {
+		raw, err := br.ReadBytes('\n')
+		offset += int64(len(raw))
+		if len(raw) == 0 && err != nil {
+			if err == io.EOF {
+				flush()
+				return nil
+			}
+			return err
+		}
+		line := bytes.TrimSuffix(raw, []byte("\n"))
+		if len(line) == 0 {
+			if !flush() {
+				return nil
+			}
+			continue
+		}
+		if name, value, ok := bytes.Cut(line, []byte("=")); ok {
+			fields[string(name)] = string(value)
+			continue
+		}
+		var size [8]byte
+		if _, err := io.ReadFull(br, size[:]); err != nil {
+			return fmt.Errorf("field %s: %w", line, io.ErrUnexpectedEOF)
+		}
+		n := binary.LittleEndian.Uint64(size[:])
+		if n > maxFieldBytes {
+			return fmt.Errorf("field %s: %d bytes is too large", line, n)
+		}
+		value := make([]byte, n+1)
+		if _, err := io.ReadFull(br, value); err != nil {
+			return fmt.Errorf("field %s: %w", line, io.ErrUnexpectedEOF)
+		}
+		offset += int64(len(size)) + int64(len(value))
+		fields[string(line)] = string(value[:n])
+	}
+}
+
+// maxFieldBytes bounds one binary journal field so a corrupt length cannot
+// exhaust memory.
+const maxFieldBytes = 16 << 20
+
+// journalLine renders an entry the way `journalctl -o short-iso` does, with
+// the stamp in UTC: `2006-01-02T15:04:05.000000Z host ident[pid]: message`.
+// Newlines inside the message become spaces so an entry stays one line.
+func journalLine(fields map[string]string) string {
+	var b strings.Builder
+	if usec, err := strconv.ParseInt(fields["__REALTIME_TIMESTAMP"], 10, 64); err == nil {
+		b.WriteString(time.UnixMicro(usec).UTC().Format(stampLayout))
+		b.WriteByte(' ')
+	}
+	if host := fields["_HOSTNAME"]; host != "" {
+		b.WriteString(host)
+		b.WriteByte(' ')
+	}
+	ident := first(fields, "SYSLOG_IDENTIFIER", "_COMM")
+	if ident == "" {
+		ident = "journal"
+	}
+	b.WriteString(ident)
+	if pid := first(fields, "SYSLOG_PID", "_PID"); pid != "" {
+		b.WriteString("[" + pid + "]")
+	}
+	b.WriteString(": ")
+	b.WriteString(strings.ReplaceAll(strings.TrimRight(fields["MESSAGE"], "\n"), "\n", " "))
+	return b.String()
+}
+
+// first returns the first of keys that is set in fields.
+func first(fields map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if value := fields[key]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
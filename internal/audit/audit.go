@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+)
+
+// Entry is one JSON line in the audit file.
+type Entry struct {
+	Time    time.Time         `json:"time"`
+	User    string            `json:"user"`
+	Host    string            `json:"host"`
+	Action  string            `json:"action"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// Log appends operator actions to a file as JSON lines. A nil *Log records
+// nothing, so callers need not check whether auditing is enabled.
+type Log struct {
+	mu   sync.Mutex
+	f    *os.File
+	user string
+	host string
+}
+
+// Open appends to path, creating it owner-readable only. Existing entries are
+// never rewritten or truncated.
+func Open(path string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	l := &Log{f: f, user: "unknown"}
+	if u, err := user.Current(); err == nil {
+		l.user = u.Username
+	}
+	l.host, _ = os.Hostname()
+	return l, nil
+}
+
+// Record writes one action with alternating key/value detail pairs and syncs
+// it to disk before returning.
+func (l *Log) Record(action string, kv ...string) error {
+	if l == nil {
+		return nil
+	}
+	entry := Entry{Time: time.Now().UTC(), User: l.user, Host: l.host, Action: action}
+	if len(kv) > 0 {
+		entry.Details = make(map[string]string, len(kv)/2)
+		for i := 0; i+1 < len(kv); i += 2 {
+			entry.Details[kv[i]] = kv[i+1]
+		}
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode audit entry: %w", err)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write audit log: %w", err)
+	}
+	if err := l.f.Sync(); err != nil {
+		return fmt.Errorf("sync audit log: %w", err)
+	}
+	return nil
+}
+
+// Close releases the file.
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	return l.f.Close()
+}
@@ -0,0 +1,269 @@
+// Package auditd reads Linux audit records, from the kernel's audit netlink
+// socket or from an audisp plugin socket, and groups the records of one
+// audit event (SYSCALL, EXECVE, PATH, PROCTITLE, …) into a single event.
+package auditd
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// EventTimeout is how long an event without its EOE record is held for
+	// more records, as auparse's default end_of_event_timeout.
+	EventTimeout = 2 * time.Second
+	// maxRecords bounds one event; PATH records past it are counted, not kept.
+	maxRecords = 64
+	// maxPending bounds events waiting for their EOE; the oldest is sent
+	// early when a burst of interleaved events goes past it.
+	maxPending = 1024
+	maxLine    = 1 << 20
+)
+
+// Record is one audit record: `type=SYSCALL msg=audit(1700000000.123:456): …`.
+type Record struct {
+	Type   string
+	Time   time.Time
+	Serial uint64
+	// Body is the key=value text after the `audit(…):` stamp.
+	Body string
+}
+
+// ParseRecord reads a record in the text format auditd logs and audisp
+// plugins receive. A leading `node=host` is dropped, and the fields the
+// enriched format appends after a 0x1d separator are kept as ordinary fields.
+func ParseRecord(line string) (Record, error) {
+	line = strings.TrimRight(line, "\r\n")
+	if strings.HasPrefix(line, "node=") {
+		_, line, _ = strings.Cut(line, " ")
+	}
+	rest, ok := strings.CutPrefix(line, "type=")
+	if !ok {
+		return Record{}, fmt.Errorf("audit record without type: %q", line)
+	}
+	typ, stamped, ok := strings.Cut(rest, " msg=")
+	if !ok {
+		return Record{}, fmt.Errorf("audit record without msg: %q", line)
+	}
+	r, err := parseStamped(stamped)
+	r.Type = typ
+	return r, err
+}
+
+// parseStamped reads `audit(1700000000.123:456): body`, the payload of a
+// netlink message and the tail of a text record.
+func parseStamped(s string) (Record, error) {
+	rest, ok := strings.CutPrefix(s, "audit(")
+	stamp, body, found := strings.Cut(rest, "):")
+	if !ok || !found {
+		return Record{}, fmt.Errorf("audit record without audit(…) stamp: %q", s)
+	}
+	secs, serial, ok := strings.Cut(stamp, ":")
+	if !ok {
+		return Record{}, fmt.Errorf("bad audit stamp %q", stamp)
+	}
+	n, err := strconv.ParseUint(serial, 10, 64)
+	if err != nil {
+		return Record{}, fmt.Errorf("bad audit serial %q", serial)
+	}
+	whole, frac, _ := strings.Cut(secs, ".")
+	sec, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return Record{}, fmt.Errorf("bad audit time %q", secs)
+	}
+	ms, _ := strconv.Atoi((frac + "000")[:3])
+	return Record{
+		Time:   time.Unix(sec, int64(ms)*int64(time.Millisecond)),
+		Serial: n,
+		Body:   strings.TrimSpace(strings.ReplaceAll(body, "\x1d", " ")),
+	}, nil
+}
+
+// Reader reads text records line by line, as an audisp af_unix plugin
+// writes them in its string format.
+type Reader struct {
+	scanner *bufio.Scanner
+}
+
+// NewReader reads records from r.
+func NewReader(r io.Reader) *Reader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 16<<10), maxLine)
+	return &Reader{scanner: scanner}
+}
+
+// Read returns the next record, skipping blank lines and lines that are not
+// audit records. It returns io.EOF when the stream ends.
+func (r *Reader) Read() (Record, error) {
+	for r.scanner.Scan() {
+		if rec, err := ParseRecord(r.scanner.Text()); err == nil {
+			return rec, nil
+		}
+	}
+	if err := r.scanner.Err(); err != nil {
+		return Record{}, err
+	}
+	return Record{}, io.EOF
+}
+
+// Event is the records sharing one serial, in arrival order.
+type Event struct {
+	Time    time.Time
+	Serial  uint64
+	Records []Record
+	// Dropped counts records past the per-event limit.
+	Dropped int
+	seen    time.Time
+}
+
+// Line renders the event on one line for rule matching:
+//
+//	audit(1700000000.123:456) SYSCALL arch=c000003e syscall=59 success=yes … | EXECVE argc=2 a0="curl" a1="-s" | PROCTITLE proctitle="curl -s"
+//
+// Hex-encoded values (command lines and paths with spaces or control
+// characters) are decoded and quoted; the EOE terminator is left out.
+func (e Event) Line() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "audit(%d.%03d:%d)", e.Time.Unix(), e.Time.Nanosecond()/int(time.Millisecond), e.Serial)
+	first := true
+	for _, r := range e.Records {
+		if r.Type == "EOE" {
+			continue
+		}
+		if !first {
+			b.WriteString(" |")
+		}
+		first = false
+		b.WriteString(" " + r.Type)
+		if body := decodeFields(r.Type, r.Body); body != "" {
+			b.WriteString(" " + body)
+		}
+	}
+	if e.Dropped > 0 {
+		fmt.Fprintf(&b, " | (+%d records)", e.Dropped)
+	}
+	return b.String()
+}
+
+// hexKeys are the fields the kernel hex-encodes when the value is not safe
+// to print as a quoted string; EXECVE arguments (a0, a1, …) are too.
+var hexKeys = map[string]bool{"proctitle": true, "cmd": true, "comm": true, "exe": true, "name": true, "cwd": true, "path": true, "data": true}
+
+func decodeFields(typ, body string) string {
+	fields := strings.Split(body, " ")
+	for i, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || !(hexKeys[key] || typ == "EXECVE" && isArg(key)) {
+			continue
+		}
+		if text, ok := decodeHex(value); ok {
+			fields[i] = key + "=" + strconv.Quote(text)
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// isArg reports whether key is an EXECVE argument: a0, a1, … (not argc, and
+// not a1_len or a1[0] of a split argument).
+func isArg(key string) bool {
+	if len(key) < 2 || key[0] != 'a' {
+		return false
+	}
+	_, err := strconv.Atoi(key[1:])
+	return err == nil
+}
+
+// decodeHex decodes an unquoted, upper-case hex value. NULs separate the
+// arguments of a proctitle, so they become spaces.
+func decodeHex(value string) (string, bool) {
+	if value == "" || value == "(null)" || len(value)%2 != 0 || strings.ToUpper(value) != value {
+		return "", false
+	}
+	raw, err := hex.DecodeString(value)
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimRight(strings.ReplaceAll(string(raw), "\x00", " "), " "), true
+}
+
+// Assembler collects records into events. An event is complete when its EOE
+// record arrives; user-space messages (PAM logins, sudo, service starts)
+// are single records and complete at once; anything else is sent once
+// EventTimeout passes without a new record for it.
+type Assembler struct {
+	pending map[uint64]*Event
+}
+
+// NewAssembler returns an empty assembler.
+func NewAssembler() *Assembler {
+	return &Assembler{pending: make(map[uint64]*Event)}
+}
+
+// Add takes one record and returns the events it completes.
+func (a *Assembler) Add(r Record, now time.Time) []Event {
+	if standalone(r.Type) {
+		if _, ok := a.pending[r.Serial]; !ok {
+			return []Event{{Time: r.Time, Serial: r.Serial, Records: []Record{r}}}
+		}
+	}
+	e, ok := a.pending[r.Serial]
+	if !ok {
+		e = &Event{Time: r.Time, Serial: r.Serial}
+		a.pending[r.Serial] = e
+	}
+	e.seen = now
+	if len(e.Records) < maxRecords || r.Type == "EOE" {
+		e.Records = append(e.Records, r)
+	} else {
+		e.Dropped++
+	}
+	var done []Event
+	if r.Type == "EOE" {
+		delete(a.pending, r.Serial)
+		done = append(done, *e)
+	}
+	if len(a.pending) > maxPending {
+		done = append(done, a.take(func(*Event) bool { return true }, len(a.pending)-maxPending)...)
+	}
+	return done
+}
+
+// Expire returns the events that have waited EventTimeout for more records.
+func (a *Assembler) Expire(now time.Time) []Event {
+	return a.take(func(e *Event) bool { return now.Sub(e.seen) >= EventTimeout }, len(a.pending))
+}
+
+// Flush returns every pending event, for when the source stops.
+func (a *Assembler) Flush() []Event {
+	return a.take(func(*Event) bool { return true }, len(a.pending))
+}
+
+// take removes up to n events that match, lowest serial first.
+func (a *Assembler) take(match func(*Event) bool, n int) []Event {
+	var serials []uint64
+	for serial, e := range a.pending {
+		if match(e) {
+			serials = append(serials, serial)
+		}
+	}
+	sort.Slice(serials, func(i, j int) bool { return serials[i] < serials[j] })
+	var done []Event
+	for _, serial := range serials[:min(n, len(serials))] {
+		done = append(done, *a.pending[serial])
+		delete(a.pending, serial)
+	}
+	return done
+}
+
+// standalone reports whether records of this type are whole events: the
+// user-space message ranges (1100–1199 and 2100–2999).
+func standalone(typ string) bool {
+	n, ok := typeNumbers[typ]
+	return ok && (n >= 1100 && n < 1200 || n >= 2100 && n < 3000)
+}
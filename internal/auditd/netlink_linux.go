@@ -0,0 +1,77 @@
+package auditd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// nlgrpReadlog is AUDIT_NLGRP_READLOG, the multicast group that copies every
+// audit record to read-only listeners (Linux 3.16 and later).
+const nlgrpReadlog = 1
+
+// Conn reads records from the kernel's audit netlink socket as a read-only
+// multicast listener, so it runs alongside auditd instead of replacing it.
+// Joining needs CAP_AUDIT_READ.
+type Conn struct {
+	f       *os.File
+	buf     []byte
+	pending []Record
+}
+
+// Dial joins the audit multicast group. The socket is non-blocking
+// underneath, so closing the Conn interrupts a pending Read.
+func Dial() (*Conn, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC|unix.SOCK_NONBLOCK, unix.NETLINK_AUDIT)
+	if err != nil {
+		return nil, fmt.Errorf("audit netlink: %w", err)
+	}
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK, Groups: 1 << (nlgrpReadlog - 1)}); err != nil {
+		unix.Close(fd)
+		if errors.Is(err, unix.EPERM) {
+			return nil, fmt.Errorf("audit netlink: %w (reading the audit log needs CAP_AUDIT_READ)", err)
+		}
+		return nil, fmt.Errorf("audit netlink: %w", err)
+	}
+	// A burst of execve records can outrun the reader; a larger buffer
+	// rides it out. Failing to grow it is not fatal.
+	_ = unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_RCVBUF, 1<<20)
+	return &Conn{f: os.NewFile(uintptr(fd), "audit-netlink"), buf: make([]byte, 1<<16)}, nil
+}
+
+// Read returns the next record. When the socket buffer overflowed, records
+// were lost; that is not fatal and reading goes on.
+func (c *Conn) Read() (Record, error) {
+	for len(c.pending) == 0 {
+		n, err := c.f.Read(c.buf)
+		if errors.Is(err, unix.ENOBUFS) {
+			continue
+		}
+		if err != nil {
+			return Record{}, err
+		}
+		msgs, err := syscall.ParseNetlinkMessage(c.buf[:n])
+		if err != nil {
+			continue
+		}
+		for _, msg := range msgs {
+			rec, err := parseStamped(string(msg.Data))
+			if err != nil {
+				continue
+			}
+			rec.Type = TypeName(msg.Header.Type)
+			c.pending = append(c.pending, rec)
+		}
+	}
+	rec := c.pending[0]
+	c.pending = c.pending[1:]
+	return rec, nil
+}
+
+// Close leaves the group and closes the socket.
+func (c *Conn) Close() error {
+	return c.f.Close()
+}
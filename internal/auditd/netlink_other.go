@@ -0,0 +1,26 @@
+//go:build !linux
+
+package auditd
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Conn is the audit netlink socket, which only Linux has.
+type Conn struct{}
+
+// Dial reports that the audit netlink socket is not available here.
+func Dial() (*Conn, error) {
+	return nil, fmt.Errorf("audit netlink: not supported on %s", runtime.GOOS)
+}
+
+// Read never returns a record.
+func (c *Conn) Read() (Record, error) {
+	return Record{}, fmt.Errorf("audit netlink: not supported on %s", runtime.GOOS)
+}
+
+// Close does nothing.
+func (c *Conn) Close() error {
+	return nil
+}
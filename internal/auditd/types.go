@@ -0,0 +1,83 @@
+package auditd
+
+import "fmt"
+
+// typeNames are the record types from linux/audit.h and libaudit that
+// commonly reach a log; others render as UNKNOWN[n], as ausearch does.
+var typeNames = map[uint16]string{
+	1006: "LOGIN",
+	1100: "USER_AUTH",
+	1101: "USER_ACCT",
+	1102: "USER_MGMT",
+	1103: "CRED_ACQ",
+	1104: "CRED_DISP",
+	1105: "USER_START",
+	1106: "USER_END",
+	1107: "USER_AVC",
+	1108: "USER_CHAUTHTOK",
+	1109: "USER_ERR",
+	1110: "CRED_REFR",
+	1111: "USYS_CONFIG",
+	1112: "USER_LOGIN",
+	1113: "USER_LOGOUT",
+	1114: "ADD_USER",
+	1115: "DEL_USER",
+	1116: "ADD_GROUP",
+	1117: "DEL_GROUP",
+	1119: "GRP_AUTH",
+	1120: "SYSTEM_BOOT",
+	1121: "SYSTEM_SHUTDOWN",
+	1123: "USER_CMD",
+	1124: "USER_TTY",
+	1130: "SERVICE_START",
+	1131: "SERVICE_STOP",
+	1300: "SYSCALL",
+	1302: "PATH",
+	1303: "IPC",
+	1304: "SOCKETCALL",
+	1305: "CONFIG_CHANGE",
+	1306: "SOCKADDR",
+	1307: "CWD",
+	1309: "EXECVE",
+	1311: "IPC_SET_PERM",
+	1320: "EOE",
+	1321: "BPRM_FCAPS",
+	1322: "CAPSET",
+	1323: "MMAP",
+	1324: "NETFILTER_PKT",
+	1325: "NETFILTER_CFG",
+	1326: "SECCOMP",
+	1327: "PROCTITLE",
+	1328: "FEATURE_CHANGE",
+	1329: "REPLACE",
+	1330: "KERN_MODULE",
+	1331: "FANOTIFY",
+	1334: "BPF",
+	1400: "AVC",
+	1401: "SELINUX_ERR",
+	1402: "AVC_PATH",
+	1403: "MAC_POLICY_LOAD",
+	1404: "MAC_STATUS",
+	1405: "MAC_CONFIG_CHANGE",
+	1700: "ANOM_PROMISCUOUS",
+	1701: "ANOM_ABEND",
+	1702: "ANOM_LINK",
+	1703: "ANOM_CREAT",
+}
+
+// typeNumbers maps names back, for telling user-space records apart.
+var typeNumbers = func() map[string]uint16 {
+	m := make(map[string]uint16, len(typeNames))
+	for n, name := range typeNames {
+		m[name] = n
+	}
+	return m
+}()
+
+// TypeName names a numeric record type.
+func TypeName(n uint16) string {
+	if name, ok := typeNames[n]; ok {
+		return name
+	}
+	return fmt.Sprintf("UNKNOWN[%d]", n)
+}
@@ -0,0 +1,45 @@
+// Package clock abstracts the passage of time for code whose behavior depends
+// on it (pipeline hold windows, TUI ticks, paced replay), so tests can drive
+// it deterministically with testkit.Clock instead of sleeping.
+package clock
+
+import "time"
+
+// Clock tells the time and schedules wake-ups.
+type Clock interface {
+	Now() time.Time
+	// NewTicker delivers the time every d on C; like time.Ticker, a slow
+	// reader misses ticks rather than queueing them.
+	NewTicker(d time.Duration) Ticker
+	// After delivers the time once d has passed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// Ticker is a time.Ticker behind the Clock interface.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// System is the wall clock.
+var System Clock = system{}
+
+// Or returns c, or System when c is nil, so a zero-valued config field means
+// real time.
+func Or(c Clock) Clock {
+	if c == nil {
+		return System
+	}
+	return c
+}
+
+type system struct{}
+
+func (system) Now() time.Time                         { return time.Now() }
+func (system) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (system) NewTicker(d time.Duration) Ticker       { return systemTicker{time.NewTicker(d)} }
+
+type systemTicker struct{ t *time.Ticker }
+
+func (t systemTicker) C() <-chan time.Time { return t.t.C }
+func (t systemTicker) Stop()               { t.t.Stop() }
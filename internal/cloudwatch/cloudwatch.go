@@ -0,0 +1,213 @@
+// Package cloudwatch tails AWS CloudWatch Logs groups through the service's
+// JSON API (FilterLogEvents), signed with SigV4, so Lambda and ECS logs can be
+// watched like local files without the AWS SDK.
+package cloudwatch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// pollEvery is how often Tail asks for new events once caught up.
+	pollEvery = 2 * time.Second
+	// lateWindow is how far back each poll looks again, for events ingested
+	// after newer ones were already returned; seen event IDs are skipped.
+	lateWindow = 30 * time.Second
+	// multilineSeparator joins the lines of a multi-line message, the same
+	// marker the pipeline uses for stitched continuation lines.
+	multilineSeparator = " ⏎ "
+)
+
+// Client calls the CloudWatch Logs API of one region.
+type Client struct {
+	endpoint string
+	region   string
+	creds    Credentials
+	http     *http.Client
+	now      func() time.Time
+}
+
+// NewClient uses the region and credentials from the environment or the
+// shared AWS files (see loadRegion, loadCredentials). AWS_ENDPOINT_URL_CLOUDWATCH_LOGS
+// or AWS_ENDPOINT_URL points it at another endpoint, e.g. LocalStack.
+func NewClient() (*Client, error) {
+	region, err := loadRegion()
+	if err != nil {
+		return nil, fmt.Errorf("cloudwatch: %w", err)
+	}
+	creds, err := loadCredentials()
+	if err != nil {
+		return nil, fmt.Errorf("cloudwatch: %w", err)
+	}
+	endpoint := "https://logs." + region + ".amazonaws.com"
+	if strings.HasPrefix(region, "cn-") {
+		endpoint += ".cn"
+	}
+	for _, name := range []string{"AWS_ENDPOINT_URL_CLOUDWATCH_LOGS", "AWS_ENDPOINT_URL"} {
+		if url := os.Getenv(name); url != "" {
+			endpoint = strings.TrimRight(url, "/")
+			break
+		}
+	}
+	return &Client{endpoint: endpoint, region: region, creds: creds, http: &http.Client{Timeout: 30 * time.Second}, now: time.Now}, nil
+}
+
+// Event is one log event as FilterLogEvents returns it.
+type Event struct {
+	Stream    string `json:"logStreamName"`
+	Timestamp int64  `json:"timestamp"`
+	Message   string `json:"message"`
+	ID        string `json:"eventId"`
+}
+
+// Time is when the event happened.
+func (e Event) Time() time.Time {
+	return time.UnixMilli(e.Timestamp)
+}
+
+// Line renders the event as `2006-01-02T15:04:05.000Z stream: message`, with
+// the lines of a multi-line message joined by the pipeline's ` ⏎ ` marker.
+func (e Event) Line() string {
+	msg := strings.TrimRight(e.Message, "\r\n")
+	msg = strings.ReplaceAll(strings.ReplaceAll(msg, "\r\n", "\n"), "\n", multilineSeparator)
+	return e.Time().UTC().Format("2006-01-02T15:04:05.000Z07:00") + " " + e.Stream + ": " + msg
+}
+
+type filterInput struct {
+	Group        string   `json:"logGroupName"`
+	Streams      []string `json:"logStreamNames,omitempty"`
+	StreamPrefix string   `json:"logStreamNamePrefix,omitempty"`
+	StartTime    int64    `json:"startTime,omitempty"`
+	NextToken    string   `json:"nextToken,omitempty"`
+}
+
+type filterOutput struct {
+	Events    []Event `json:"events"`
+	NextToken string  `json:"nextToken"`
+}
+
+// apiError is the JSON error body of a failed call.
+type apiError struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+func (c *Client) call(ctx context.Context, action string, in, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", action, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s: %w", action, err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "Logs_20140328."+action)
+	sign(req, body, c.creds, c.region, c.now())
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", action, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return fmt.Errorf("%s: %w", action, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var ae apiError
+		if json.Unmarshal(data, &ae) == nil && ae.Type != "" {
+			typ := ae.Type[strings.LastIndex(ae.Type, "#")+1:]
+			return fmt.Errorf("%s: %s: %s", action, typ, ae.Message)
+		}
+		return fmt.Errorf("%s: %s", action, resp.Status)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decode %s: %w", action, err)
+	}
+	return nil
+}
+
+// Target is what to tail: a log group, optionally narrowed to one stream, or
+// to the streams starting with a prefix when Stream ends in `*`.
+type Target struct {
+	Group  string
+	Stream string
+}
+
+// ParseTarget reads `group[:stream]`. Group names cannot contain a colon.
+func ParseTarget(spec string) (Target, error) {
+	group, stream, _ := strings.Cut(spec, ":")
+	if group == "" {
+		return Target{}, fmt.Errorf("cloudwatch %q: want group[:stream]", spec)
+	}
+	return Target{Group: group, Stream: stream}, nil
+}
+
+func (t Target) String() string {
+	if t.Stream == "" {
+		return t.Group
+	}
+	return t.Group + ":" + t.Stream
+}
+
+// Tail calls fn with the target's events from since on, oldest first, less
+// those whose IDs are in skip, and keeps polling for new ones until ctx is
+// done or fn returns false. A failed call ends it with the error; to resume,
+// call it again from the last event passed to fn, skipping the IDs passed at
+// that millisecond (several events can share it).
+func (c *Client) Tail(ctx context.Context, t Target, since time.Time, skip []string, fn func(Event) bool) error {
+	in := filterInput{Group: t.Group}
+	switch {
+	case strings.HasSuffix(t.Stream, "*"):
+		in.StreamPrefix = strings.TrimSuffix(t.Stream, "*")
+	case t.Stream != "":
+		in.Streams = []string{t.Stream}
+	}
+	cursor := since.UnixMilli()
+	seen := make(map[string]int64)
+	for _, id := range skip {
+		seen[id] = cursor
+	}
+	for {
+		in.StartTime = max(cursor-lateWindow.Milliseconds(), since.UnixMilli())
+		in.NextToken = ""
+		for {
+			var out filterOutput
+			if err := c.call(ctx, "FilterLogEvents", in, &out); err != nil {
+				return err
+			}
+			for _, evt := range out.Events {
+				if _, dup := seen[evt.ID]; dup {
+					continue
+				}
+				seen[evt.ID] = evt.Timestamp
+				cursor = max(cursor, evt.Timestamp)
+				if !fn(evt) {
+					return nil
+				}
+			}
+			if out.NextToken == "" || out.NextToken == in.NextToken {
+				break
+			}
+			in.NextToken = out.NextToken
+		}
+		for id, ts := range seen {
+			if ts < cursor-2*lateWindow.Milliseconds() {
+				delete(seen, id)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(pollEvery):
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package cloudwatch
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Credentials sign requests; SessionToken is set for temporary credentials.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// loadCredentials follows the usual AWS lookup, minus instance and container
+// roles: AWS_ACCESS_KEY_ID / AWS_SECRET_ACCESS_KEY (and AWS_SESSION_TOKEN),
+// else the AWS_PROFILE (or default) section of the shared credentials file.
+func loadCredentials() (Credentials, error) {
+	if id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"); id != "" && secret != "" {
+		return Credentials{AccessKeyID: id, SecretAccessKey: secret, SessionToken: os.Getenv("AWS_SESSION_TOKEN")}, nil
+	}
+	path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if path == "" {
+		path = awsFile("credentials")
+	}
+	section, err := iniSection(path, profile())
+	if err != nil {
+		return Credentials{}, fmt.Errorf("no AWS credentials (set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, or profile %q in %s): %w", profile(), path, err)
+	}
+	creds := Credentials{
+		AccessKeyID:     section["aws_access_key_id"],
+		SecretAccessKey: section["aws_secret_access_key"],
+		SessionToken:    section["aws_session_token"],
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return Credentials{}, fmt.Errorf("no AWS credentials in profile %q of %s", profile(), path)
+	}
+	return creds, nil
+}
+
+// loadRegion reads AWS_REGION or AWS_DEFAULT_REGION, else the profile's
+// region in the shared config file.
+func loadRegion() (string, error) {
+	for _, name := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region := os.Getenv(name); region != "" {
+			return region, nil
+		}
+	}
+	path := os.Getenv("AWS_CONFIG_FILE")
+	if path == "" {
+		path = awsFile("config")
+	}
+	name := "profile " + profile()
+	if profile() == "default" {
+		name = "default"
+	}
+	if section, err := iniSection(path, name); err == nil && section["region"] != "" {
+		return section["region"], nil
+	}
+	return "", fmt.Errorf("no AWS region (set AWS_REGION or region in %s)", path)
+}
+
+func profile() string {
+	if name := os.Getenv("AWS_PROFILE"); name != "" {
+		return name
+	}
+	return "default"
+}
+
+func awsFile(name string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".aws", name)
+	}
+	return filepath.Join(home, ".aws", name)
+}
+
+// iniSection returns the keys of one [section] of an AWS-style INI file.
+func iniSection(path, name string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var (
+		values  map[string]string
+		current string
+	)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = strings.TrimSpace(line[1 : len(line)-1])
+			if current == name && values == nil {
+				values = make(map[string]string)
+			}
+			continue
+		}
+		if current != name {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok {
+			values[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if values == nil {
+		return nil, fmt.Errorf("no [%s] section", name)
+	}
+	return values, nil
+}
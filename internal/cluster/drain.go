@@ -0,0 +1,193 @@
+// Package cluster groups log lines into message templates with Drain
+// (He et al., "Drain: An Online Log Parsing Approach with Fixed Depth Tree",
+// ICWS 2017), so an alert storm of slightly different messages reads as a few
+// templates with counts.
+package cluster
+
+import (
+	"strings"
+
+	"watcher/internal/rules"
+)
+
+// Wildcard replaces the tokens in which a template's lines differ.
+const Wildcard = "<*>"
+
+const (
+	// prefixDepth is how many leading tokens route a line through the tree.
+	prefixDepth = 2
+	// maxChildren bounds the distinct tokens under one tree node; the rest
+	// share a wildcard branch.
+	maxChildren = 100
+	// DefaultMaxClusters bounds the templates kept; the least recently used
+	// one is forgotten first.
+	DefaultMaxClusters = 2000
+)
+
+// Cluster is one message template and how many lines it has taken.
+type Cluster struct {
+	ID     int
+	tokens []string
+	Size   int
+	used   uint64
+	leaf   *node
+}
+
+// Template is the cluster's message with varying tokens as Wildcard.
+func (c *Cluster) Template() string {
+	return strings.Join(c.tokens, " ")
+}
+
+type node struct {
+	children map[string]*node
+	clusters []*Cluster
+}
+
+// Drain assigns lines to clusters. It is not safe for concurrent use.
+type Drain struct {
+	similarity  float64
+	maxClusters int
+	root        map[int]*node
+	count       int
+	nextID      int
+	clock       uint64
+}
+
+// New returns a Drain that joins a line to a template when at least
+// similarity (0 to 1) of their tokens agree.
+func New(similarity float64) *Drain {
+	return &Drain{similarity: similarity, maxClusters: DefaultMaxClusters, root: make(map[int]*node)}
+}
+
+// Add files line under its most similar template, widening the template where
+// they differ, or starts a new cluster. Lines are compared by their shape
+// (rules.LineShape): timestamp and host dropped, numbers, IPs, and hex ids
+// masked.
+func (d *Drain) Add(line string) *Cluster {
+	tokens := strings.Fields(rules.LineShape(line))
+	d.clock++
+	leaf := d.leaf(tokens)
+	if c := d.closest(leaf, tokens); c != nil {
+		for i, tok := range tokens {
+			if c.tokens[i] != tok {
+				c.tokens[i] = Wildcard
+			}
+		}
+		c.Size++
+		c.used = d.clock
+		return c
+	}
+	if d.count >= d.maxClusters {
+		d.evict()
+	}
+	d.nextID++
+	d.count++
+	c := &Cluster{ID: d.nextID, tokens: tokens, Size: 1, used: d.clock, leaf: leaf}
+	leaf.clusters = append(leaf.clusters, c)
+	return c
+}
+
+// leaf walks (and grows) the tree by token count, then by the first
+// prefixDepth tokens. Tokens holding a mask or a digit are likely variables
+// and go down the wildcard branch, as does anything past maxChildren.
+func (d *Drain) leaf(tokens []string) *node {
+	n := d.root[len(tokens)]
+	if n == nil {
+		n = &node{children: make(map[string]*node)}
+		d.root[len(tokens)] = n
+	}
+	for i := 0; i < prefixDepth && i < len(tokens); i++ {
+		key := tokens[i]
+		if variable(key) {
+			key = Wildcard
+		}
+		child := n.children[key]
+		if child == nil {
+			if len(n.children) >= maxChildren {
+				key = Wildcard
+				child = n.children[key]
+			}
+			if child == nil {
+				child = &node{children: make(map[string]*node)}
+				n.children[key] = child
+			}
+		}
+		n = child
+	}
+	return n
+}
+
+// closest returns the leaf's cluster most similar to tokens, if it reaches
+// the threshold. Ties go to the template with more wildcards already, which
+// keeps a widened template from splitting again.
+func (d *Drain) closest(leaf *node, tokens []string) *Cluster {
+	var (
+		best      *Cluster
+		bestSim   = -1.0
+		bestWilds = -1
+	)
+	for _, c := range leaf.clusters {
+		same, wilds := 0, 0
+		for i, tok := range c.tokens {
+			switch {
+			case tok == Wildcard:
+				wilds++
+			case tok == tokens[i]:
+				same++
+			}
+		}
+		sim := 1.0
+		if len(tokens) > 0 {
+			sim = float64(same) / float64(len(tokens))
+		}
+		if sim > bestSim || (sim == bestSim && wilds > bestWilds) {
+			best, bestSim, bestWilds = c, sim, wilds
+		}
+	}
+	if best == nil || bestSim < d.similarity {
+		return nil
+	}
+	return best
+}
+
+// evict forgets the least recently used cluster.
+func (d *Drain) evict() {
+	var oldest *Cluster
+	d.walk(func(c *Cluster) {
+		if oldest == nil || c.used < oldest.used {
+			oldest = c
+		}
+	})
+	if oldest == nil {
+		return
+	}
+	list := oldest.leaf.clusters
+	for i, c := range list {
+		if c == oldest {
+			oldest.leaf.clusters = append(list[:i], list[i+1:]...)
+			break
+		}
+	}
+	d.count--
+}
+
+func (d *Drain) walk(fn func(*Cluster)) {
+	var visit func(*node)
+	visit = func(n *node) {
+		for _, c := range n.clusters {
+			fn(c)
+		}
+		for _, child := range n.children {
+			visit(child)
+		}
+	}
+	for _, n := range d.root {
+		visit(n)
+	}
+}
+
+// variable reports whether a token looks like a parameter rather than part
+// of the message.
+func variable(tok string) bool {
+	return (strings.HasPrefix(tok, "<") && strings.HasSuffix(tok, ">")) || strings.ContainsAny(tok, "0123456789")
+}
@@ -0,0 +1,126 @@
+package coverage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"watcher/internal/rules"
+	"watcher/internal/search"
+)
+
+// maxSamples bounds the example locations kept per rule and per overlap.
+const maxSamples = 5
+
+// Location is one corpus line.
+type Location struct {
+	Path   string `json:"path"`
+	LineNo int    `json:"line_no"`
+}
+
+// RuleCoverage records where one rule fired.
+type RuleCoverage struct {
+	Name     string         `json:"name"`
+	Severity rules.Severity `json:"severity"`
+	Hits     int            `json:"hits"`
+	// Wins counts the lines where this rule was the one Match reports, i.e.
+	// what the operator would actually see.
+	Wins    int        `json:"wins"`
+	Samples []Location `json:"samples,omitempty"`
+}
+
+// Overlap is a pair of rules that matched the same lines. Winner is the rule
+// Match picks (severity, then declaration order); Shadowed never shows there.
+type Overlap struct {
+	Winner   string     `json:"winner"`
+	Shadowed string     `json:"shadowed"`
+	Lines    int        `json:"lines"`
+	Samples  []Location `json:"samples,omitempty"`
+}
+
+// Report is the result of running a ruleset over a corpus.
+type Report struct {
+	Files     int            `json:"files"`
+	Lines     int            `json:"lines"`
+	Matched   int            `json:"matched"`
+	Rules     []RuleCoverage `json:"rules"`
+	Uncovered []string       `json:"uncovered"`
+	Overlaps  []Overlap      `json:"overlaps"`
+}
+
+// Analyze runs every rule against every line of files. Unreadable files are
+// reported in the joined error; the rest of the corpus still counts.
+func Analyze(ctx context.Context, rs rules.RuleSet, files []string) (Report, error) {
+	report := Report{Files: len(files)}
+	index := make(map[string]int, len(rs.Rules))
+	for _, rule := range rs.Rules {
+		index[rule.Name] = len(report.Rules)
+		report.Rules = append(report.Rules, RuleCoverage{Name: rule.Name, Severity: rule.Severity})
+	}
+	overlaps := make(map[[2]string]*Overlap)
+
+	var errs []error
+	for _, path := range files {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+		err := search.EachLine(path, func(lineNo int, line string) bool {
+			report.Lines++
+			matches := rs.MatchAll(line)
+			if len(matches) == 0 {
+				return true
+			}
+			report.Matched++
+			loc := Location{Path: path, LineNo: lineNo}
+			for i, m := range matches {
+				rc := &report.Rules[index[m.Rule.Name]]
+				rc.Hits++
+				if i == 0 {
+					rc.Wins++
+				}
+				if len(rc.Samples) < maxSamples {
+					rc.Samples = append(rc.Samples, loc)
+				}
+				if i == 0 {
+					continue
+				}
+				key := [2]string{matches[0].Rule.Name, m.Rule.Name}
+				o := overlaps[key]
+				if o == nil {
+					o = &Overlap{Winner: key[0], Shadowed: key[1]}
+					overlaps[key] = o
+				}
+				o.Lines++
+				if len(o.Samples) < maxSamples {
+					o.Samples = append(o.Samples, loc)
+				}
+			}
+			return true
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("corpus %s: %w", path, err))
+		}
+	}
+
+	for _, rc := range report.Rules {
+		if rc.Hits == 0 {
+			report.Uncovered = append(report.Uncovered, rc.Name)
+		}
+	}
+	for _, o := range overlaps {
+		report.Overlaps = append(report.Overlaps, *o)
+	}
+	sort.Slice(report.Overlaps, func(i, j int) bool {
+		a, b := report.Overlaps[i], report.Overlaps[j]
+		if a.Lines != b.Lines {
+			return a.Lines > b.Lines
+		}
+		if a.Winner != b.Winner {
+			return a.Winner < b.Winner
+		}
+		return a.Shadowed < b.Shadowed
+	})
+	return report, errors.Join(errs...)
+}
@@ -0,0 +1,128 @@
+package coverage
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// WriteText prints a human-readable summary: per-rule hits, uncovered rules,
+// then overlaps with a sample location each.
+func WriteText(w io.Writer, r Report) error {
+	fmt.Fprintf(w, "%d lines in %d files, %d matched\n\n", r.Lines, r.Files, r.Matched)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "RULE\tSEVERITY\tHITS\tWINS\tSAMPLE")
+	for _, rc := range r.Rules {
+		sample := "-"
+		if len(rc.Samples) > 0 {
+			sample = rc.Samples[0].String()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\n", rc.Name, rc.Severity, rc.Hits, rc.Wins, sample)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(r.Uncovered) > 0 {
+		fmt.Fprintf(w, "\nuncovered (%d): %s\n", len(r.Uncovered), strings.Join(r.Uncovered, ", "))
+	}
+	if len(r.Overlaps) > 0 {
+		fmt.Fprintf(w, "\noverlaps (%d):\n", len(r.Overlaps))
+		for _, o := range r.Overlaps {
+			fmt.Fprintf(w, "  %s shadows %s on %d lines (e.g. %s)\n", o.Winner, o.Shadowed, o.Lines, o.Samples[0])
+		}
+	}
+	return nil
+}
+
+// WriteJSON emits the report as one indented JSON document.
+func WriteJSON(w io.Writer, r Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		return fmt.Errorf("encode report: %w", err)
+	}
+	return nil
+}
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit emits JUnit XML for CI dashboards: one "rules" suite where a rule
+// without hits fails, and one "overlaps" suite where each overlapping pair
+// fails only when failOverlaps is set (otherwise it is listed as passing).
+func WriteJUnit(w io.Writer, r Report, failOverlaps bool) error {
+	rulesSuite := junitSuite{Name: "coverage.rules"}
+	for _, rc := range r.Rules {
+		c := junitCase{Name: rc.Name, Classname: "coverage.rules." + string(rc.Severity)}
+		if rc.Hits == 0 {
+			c.Failure = &junitFailure{Message: "rule matched no corpus lines", Type: "uncovered"}
+			rulesSuite.Failures++
+		} else {
+			c.SystemOut = fmt.Sprintf("%d hits, %d wins\n%s", rc.Hits, rc.Wins, joinLocations(rc.Samples))
+		}
+		rulesSuite.Cases = append(rulesSuite.Cases, c)
+	}
+	rulesSuite.Tests = len(rulesSuite.Cases)
+
+	overlapSuite := junitSuite{Name: "coverage.overlaps"}
+	for _, o := range r.Overlaps {
+		c := junitCase{Name: o.Winner + " / " + o.Shadowed, Classname: "coverage.overlaps"}
+		detail := fmt.Sprintf("%s shadows %s on %d lines\n%s", o.Winner, o.Shadowed, o.Lines, joinLocations(o.Samples))
+		if failOverlaps {
+			c.Failure = &junitFailure{Message: "rules match the same lines", Type: "overlap", Text: detail}
+			overlapSuite.Failures++
+		} else {
+			c.SystemOut = detail
+		}
+		overlapSuite.Cases = append(overlapSuite.Cases, c)
+	}
+	overlapSuite.Tests = len(overlapSuite.Cases)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitSuites{Suites: []junitSuite{rulesSuite, overlapSuite}}); err != nil {
+		return fmt.Errorf("encode junit: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func (l Location) String() string {
+	return fmt.Sprintf("%s:%d", l.Path, l.LineNo)
+}
+
+func joinLocations(locs []Location) string {
+	parts := make([]string, len(locs))
+	for i, l := range locs {
+		parts[i] = l.String()
+	}
+	return strings.Join(parts, "\n")
+}
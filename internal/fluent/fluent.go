@@ -0,0 +1,222 @@
+// Package fluent reads the Fluentd forward protocol: MessagePack arrays sent
+// over TCP by fluentd, td-agent, and fluent-bit `forward` outputs, in Message,
+// Forward, PackedForward, and CompressedPackedForward modes.
+package fluent
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Entry is one event: the tag it was routed with, its time, and its record.
+type Entry struct {
+	Tag    string
+	Time   time.Time
+	Record map[string]any
+}
+
+// messageKeys are the record fields, in order of preference, that hold the
+// log text itself.
+var messageKeys = []string{"message", "log", "msg", "MESSAGE"}
+
+// Line renders the entry for rule matching: the message field, then the other
+// fields as key=value in name order. Trailing newlines (Docker's `log`) are
+// dropped.
+func (e Entry) Line() string {
+	var b strings.Builder
+	text := ""
+	for _, key := range messageKeys {
+		if v, ok := e.Record[key]; ok {
+			text = key
+			b.WriteString(strings.TrimRight(stringify(v), "\r\n"))
+			break
+		}
+	}
+	keys := make([]string, 0, len(e.Record))
+	for key := range e.Record {
+		if key != text {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%s=%s", key, stringify(e.Record[key]))
+	}
+	return b.String()
+}
+
+func stringify(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// Reader decodes forward protocol messages from one connection.
+type Reader struct {
+	dec decoder
+}
+
+// NewReader reads messages from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{dec: decoder{r: bufio.NewReaderSize(r, 64<<10)}}
+}
+
+// Read returns the entries of the next message and its `chunk` option, which
+// the sender expects acknowledged (see Ack) when non-empty. io.EOF marks a
+// clean end of the connection.
+func (r *Reader) Read() ([]Entry, string, error) {
+	v, err := r.dec.decode()
+	if err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, "", fmt.Errorf("forward: truncated message")
+		}
+		return nil, "", err
+	}
+	msg, ok := v.([]any)
+	if !ok || len(msg) < 2 {
+		return nil, "", fmt.Errorf("forward: expected [tag, ...], got %T", v)
+	}
+	tag, ok := asString(msg[0])
+	if !ok {
+		return nil, "", fmt.Errorf("forward: tag is %T, not a string", msg[0])
+	}
+	var options map[string]any
+	switch body := msg[1].(type) {
+	case []any:
+		// Forward mode: [tag, [[time, record], ...], options?]
+		if len(msg) > 2 {
+			options, _ = msg[2].(map[string]any)
+		}
+		entries := make([]Entry, 0, len(body))
+		for _, item := range body {
+			entry, err := pair(tag, item)
+			if err != nil {
+				return nil, "", err
+			}
+			entries = append(entries, entry)
+		}
+		return entries, chunkOf(options), nil
+	case []byte, string:
+		// PackedForward mode: [tag, msgpack stream of [time, record], options?]
+		if len(msg) > 2 {
+			options, _ = msg[2].(map[string]any)
+		}
+		raw, _ := asBytes(body)
+		entries, err := unpack(tag, raw, options)
+		return entries, chunkOf(options), err
+	}
+	// Message mode: [tag, time, record, options?]
+	if len(msg) < 3 {
+		return nil, "", fmt.Errorf("forward: message mode needs [tag, time, record]")
+	}
+	if len(msg) > 3 {
+		options, _ = msg[3].(map[string]any)
+	}
+	entry, err := pair(tag, []any{msg[1], msg[2]})
+	if err != nil {
+		return nil, "", err
+	}
+	return []Entry{entry}, chunkOf(options), nil
+}
+
+// unpack decodes a PackedForward body, gunzipping it first when the options
+// say `compressed: gzip`.
+func unpack(tag string, raw []byte, options map[string]any) ([]Entry, error) {
+	var src io.Reader = bytes.NewReader(raw)
+	if c, _ := asString(options["compressed"]); c == "gzip" {
+		gz, err := gzip.NewReader(src)
+		if err != nil {
+			return nil, fmt.Errorf("forward: gunzip: %w", err)
+		}
+		defer gz.Close()
+		src = io.LimitReader(gz, maxBytes)
+	}
+	dec := decoder{r: bufio.NewReader(src)}
+	var entries []Entry
+	for {
+		item, err := dec.decode()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("forward: packed entries: %w", err)
+		}
+		entry, err := pair(tag, item)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// pair reads a [time, record] entry.
+func pair(tag string, item any) (Entry, error) {
+	arr, ok := item.([]any)
+	if !ok || len(arr) < 2 {
+		return Entry{}, fmt.Errorf("forward: expected [time, record], got %T", item)
+	}
+	record, ok := arr[1].(map[string]any)
+	if !ok {
+		return Entry{}, fmt.Errorf("forward: record is %T, not a map", arr[1])
+	}
+	entry := Entry{Tag: tag, Record: record}
+	switch t := arr[0].(type) {
+	case time.Time:
+		entry.Time = t
+	case int64:
+		entry.Time = time.Unix(t, 0)
+	case uint64:
+		entry.Time = time.Unix(int64(t), 0)
+	case float64:
+		entry.Time = time.Unix(0, int64(t*float64(time.Second)))
+	default:
+		return Entry{}, fmt.Errorf("forward: time is %T", arr[0])
+	}
+	return entry, nil
+}
+
+func chunkOf(options map[string]any) string {
+	chunk, _ := asString(options["chunk"])
+	return chunk
+}
+
+func asString(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	}
+	return "", false
+}
+
+func asBytes(v any) ([]byte, bool) {
+	switch v := v.(type) {
+	case []byte:
+		return v, true
+	case string:
+		return []byte(v), true
+	}
+	return nil, false
+}
+
+// Ack is the response to a message sent with a chunk option: {"ack": chunk}.
+func Ack(chunk string) []byte {
+	return appendStr(appendStr([]byte{0x81}, "ack"), chunk)
+}
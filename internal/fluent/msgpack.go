@@ -0,0 +1,245 @@
+package fluent
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// Limits guard against hostile or corrupt length prefixes.
+const (
+	maxBytes     = 16 << 20
+	maxElements  = 1 << 20
+	maxNesting   = 32
+	eventTimeExt = 0
+)
+
+// decoder reads the MessagePack subset Fluentd speaks: every type except
+// extensions other than EventTime, which decodes to a time.Time. Maps decode
+// to map[string]any with non-string keys formatted.
+type decoder struct {
+	r *bufio.Reader
+}
+
+func (d decoder) byte() (byte, error) {
+	return d.r.ReadByte()
+}
+
+func (d decoder) bytes(n uint64) ([]byte, error) {
+	if n > maxBytes {
+		return nil, fmt.Errorf("msgpack: %d byte value exceeds %d", n, maxBytes)
+	}
+	buf := make([]byte, n)
+	_, err := io.ReadFull(d.r, buf)
+	return buf, err
+}
+
+func (d decoder) uint(size int) (uint64, error) {
+	buf, err := d.bytes(uint64(size))
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(buf[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(buf)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(buf)), nil
+	}
+	return binary.BigEndian.Uint64(buf), nil
+}
+
+func (d decoder) int(size int) (int64, error) {
+	u, err := d.uint(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return int64(int8(u)), nil
+	case 2:
+		return int64(int16(u)), nil
+	case 4:
+		return int64(int32(u)), nil
+	}
+	return int64(u), nil
+}
+
+// decode reads one value. io.EOF means none was left; input ending inside a
+// value is io.ErrUnexpectedEOF.
+func (d decoder) decode() (any, error) {
+	if _, err := d.r.Peek(1); err != nil {
+		return nil, err
+	}
+	v, err := d.value(0)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return v, err
+}
+
+func (d decoder) value(depth int) (any, error) {
+	if depth > maxNesting {
+		return nil, fmt.Errorf("msgpack: nested deeper than %d", maxNesting)
+	}
+	c, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c >= 0x80 && c <= 0x8f:
+		return d.mapOf(uint64(c&0x0f), depth)
+	case c >= 0x90 && c <= 0x9f:
+		return d.arrayOf(uint64(c&0x0f), depth)
+	case c >= 0xa0 && c <= 0xbf:
+		return d.str(uint64(c & 0x1f))
+	}
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.uint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return d.bytes(n)
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.uint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.ext(n)
+	case 0xca:
+		u, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := d.uint(8)
+		return math.Float64frombits(u), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := d.uint(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if u > math.MaxInt64 {
+			return u, nil
+		}
+		return int64(u), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		return d.int(1 << (c - 0xd0))
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.ext(1 << (c - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.uint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(n)
+	case 0xdc, 0xdd:
+		n, err := d.uint(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.arrayOf(n, depth)
+	case 0xde, 0xdf:
+		n, err := d.uint(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.mapOf(n, depth)
+	}
+	return nil, fmt.Errorf("msgpack: unknown type byte 0x%02x", c)
+}
+
+func (d decoder) str(n uint64) (string, error) {
+	buf, err := d.bytes(n)
+	return string(buf), err
+}
+
+// ext reads an extension body of n bytes; only EventTime is understood.
+func (d decoder) ext(n uint64) (any, error) {
+	typ, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+	buf, err := d.bytes(n)
+	if err != nil {
+		return nil, err
+	}
+	if int8(typ) != eventTimeExt || n != 8 {
+		return nil, fmt.Errorf("msgpack: unsupported extension type %d", int8(typ))
+	}
+	sec := binary.BigEndian.Uint32(buf[:4])
+	nsec := binary.BigEndian.Uint32(buf[4:])
+	return time.Unix(int64(sec), int64(nsec)), nil
+}
+
+func (d decoder) arrayOf(n uint64, depth int) ([]any, error) {
+	if n > maxElements {
+		return nil, fmt.Errorf("msgpack: %d element array exceeds %d", n, maxElements)
+	}
+	out := make([]any, 0, min(n, 64))
+	for i := uint64(0); i < n; i++ {
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func (d decoder) mapOf(n uint64, depth int) (map[string]any, error) {
+	if n > maxElements {
+		return nil, fmt.Errorf("msgpack: %d entry map exceeds %d", n, maxElements)
+	}
+	out := make(map[string]any, min(n, 64))
+	for i := uint64(0); i < n; i++ {
+		k, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			if b, isBytes := k.([]byte); isBytes {
+				key = string(b)
+			} else {
+				key = fmt.Sprint(k)
+			}
+		}
+		out[key] = v
+	}
+	return out, nil
+}
+
+// appendStr encodes s as a MessagePack string.
+func appendStr(buf []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		buf = append(buf, 0xa0|byte(n))
+	case n < 1<<8:
+		buf = append(buf, 0xd9, byte(n))
+	case n < 1<<16:
+		buf = append(buf, 0xda)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf = append(buf, 0xdb)
+		buf = binary.BigEndian.AppendUint32(buf, uint32(n))
+	}
+	return append(buf, s...)
+}
@@ -0,0 +1,238 @@
+// Package gelf reads and writes Graylog Extended Log Format messages: JSON
+// payloads that may be gzip/zlib compressed and, over UDP, split into chunks.
+package gelf
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is one GELF message. Extra holds additional fields without their
+// leading underscore.
+type Message struct {
+	Version      string
+	Host         string
+	ShortMessage string
+	FullMessage  string
+	Timestamp    time.Time
+	Level        int
+	Extra        map[string]interface{}
+}
+
+const (
+	maxChunks    = 128
+	chunkHeader  = 12
+	chunkTimeout = 5 * time.Second
+	// MaxUDPChunk is the chunk size Graylog recommends for WAN-safe datagrams.
+	MaxUDPChunk = 8192
+)
+
+var (
+	chunkMagic = []byte{0x1e, 0x0f}
+	fieldName  = regexp.MustCompile(`[^\w.\-]`)
+)
+
+// Parse decodes a complete (already reassembled) payload, compressed or not.
+func Parse(payload []byte) (Message, error) {
+	raw, err := decompress(payload)
+	if err != nil {
+		return Message{}, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return Message{}, fmt.Errorf("parse gelf: %w", err)
+	}
+	msg := Message{Extra: make(map[string]interface{})}
+	for key, value := range fields {
+		switch key {
+		case "version":
+			msg.Version = fmt.Sprint(value)
+		case "host":
+			msg.Host = fmt.Sprint(value)
+		case "short_message":
+			msg.ShortMessage = fmt.Sprint(value)
+		case "full_message":
+			msg.FullMessage = fmt.Sprint(value)
+		case "timestamp":
+			if ts, ok := value.(float64); ok {
+				msg.Timestamp = time.Unix(0, int64(ts*float64(time.Second)))
+			}
+		case "level":
+			if level, ok := value.(float64); ok {
+				msg.Level = int(level)
+			}
+		default:
+			if strings.HasPrefix(key, "_") {
+				msg.Extra[strings.TrimPrefix(key, "_")] = value
+			}
+		}
+	}
+	if msg.ShortMessage == "" {
+		return Message{}, fmt.Errorf("parse gelf: missing short_message")
+	}
+	return msg, nil
+}
+
+func decompress(payload []byte) ([]byte, error) {
+	switch {
+	case len(payload) > 2 && payload[0] == 0x1f && payload[1] == 0x8b:
+		r, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("gunzip gelf: %w", err)
+		}
+		defer r.Close()
+		return io.ReadAll(io.LimitReader(r, 8<<20))
+	case len(payload) > 2 && payload[0] == 0x78:
+		r, err := zlib.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("inflate gelf: %w", err)
+		}
+		defer r.Close()
+		return io.ReadAll(io.LimitReader(r, 8<<20))
+	}
+	return payload, nil
+}
+
+// Line renders the message as a single log line for rule matching:
+// "host short_message key=value ..." with additional fields in name order.
+func (m Message) Line() string {
+	var b strings.Builder
+	if m.Host != "" {
+		b.WriteString(m.Host)
+		b.WriteByte(' ')
+	}
+	b.WriteString(m.ShortMessage)
+	keys := make([]string, 0, len(m.Extra))
+	for key := range m.Extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&b, " %s=%v", key, m.Extra[key])
+	}
+	return b.String()
+}
+
+// Marshal encodes the message as uncompressed GELF 1.1 JSON. Extra field
+// names are sanitized; "id" is renamed since Graylog reserves "_id".
+func (m Message) Marshal() ([]byte, error) {
+	out := map[string]interface{}{
+		"version":       "1.1",
+		"host":          m.Host,
+		"short_message": m.ShortMessage,
+		"level":         m.Level,
+	}
+	if m.FullMessage != "" {
+		out["full_message"] = m.FullMessage
+	}
+	if !m.Timestamp.IsZero() {
+		out["timestamp"] = float64(m.Timestamp.UnixNano()) / float64(time.Second)
+	}
+	for key, value := range m.Extra {
+		key = fieldName.ReplaceAllString(key, "_")
+		if key == "id" {
+			key = "field_id"
+		}
+		out["_"+key] = value
+	}
+	return json.Marshal(out)
+}
+
+// Chunk splits payload into GELF chunks of at most size bytes each, or
+// returns it whole when it already fits.
+func Chunk(payload []byte, size int) ([][]byte, error) {
+	if len(payload) <= size {
+		return [][]byte{payload}, nil
+	}
+	body := size - chunkHeader
+	count := (len(payload) + body - 1) / body
+	if count > maxChunks {
+		return nil, fmt.Errorf("gelf message of %d bytes needs %d chunks (max %d)", len(payload), count, maxChunks)
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	chunks := make([][]byte, 0, count)
+	for seq := 0; seq < count; seq++ {
+		end := (seq + 1) * body
+		if end > len(payload) {
+			end = len(payload)
+		}
+		chunk := make([]byte, 0, chunkHeader+end-seq*body)
+		chunk = append(chunk, chunkMagic...)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(seq), byte(count))
+		chunk = append(chunk, payload[seq*body:end]...)
+		chunks = append(chunks, chunk)
+	}
+	return chunks, nil
+}
+
+// Assembler reassembles chunked UDP datagrams. Incomplete messages are
+// discarded after five seconds, as Graylog does.
+type Assembler struct {
+	mu      sync.Mutex
+	pending map[string]*partial
+}
+
+type partial struct {
+	parts    [][]byte
+	received int
+	started  time.Time
+}
+
+// NewAssembler returns an empty assembler.
+func NewAssembler() *Assembler {
+	return &Assembler{pending: make(map[string]*partial)}
+}
+
+// Add takes one datagram and returns the full payload once every chunk of its
+// message has arrived; unchunked datagrams are returned immediately.
+func (a *Assembler) Add(datagram []byte, now time.Time) ([]byte, bool, error) {
+	if !bytes.HasPrefix(datagram, chunkMagic) {
+		return datagram, true, nil
+	}
+	if len(datagram) < chunkHeader {
+		return nil, false, fmt.Errorf("gelf chunk too short")
+	}
+	id := string(datagram[2:10])
+	seq, count := int(datagram[10]), int(datagram[11])
+	if count == 0 || count > maxChunks || seq >= count {
+		return nil, false, fmt.Errorf("gelf chunk %d of %d invalid", seq, count)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for key, p := range a.pending {
+		if now.Sub(p.started) > chunkTimeout {
+			delete(a.pending, key)
+		}
+	}
+	p, ok := a.pending[id]
+	if !ok {
+		p = &partial{parts: make([][]byte, count), started: now}
+		a.pending[id] = p
+	}
+	if len(p.parts) != count {
+		return nil, false, fmt.Errorf("gelf chunk count changed mid-message")
+	}
+	if p.parts[seq] == nil {
+		p.parts[seq] = append([]byte{}, datagram[chunkHeader:]...)
+		p.received++
+	}
+	if p.received < count {
+		return nil, false, nil
+	}
+	delete(a.pending, id)
+	return bytes.Join(p.parts, nil), true, nil
+}
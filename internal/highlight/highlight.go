@@ -1,8 +1,11 @@
 package highlight
 
 import (
-	"sort"
+	"cmp"
+	"slices"
 	"strings"
+	"sync"
+	"unicode/utf8"
 )
 
 // Fragment stores a segment of text with an emphasis flag.
@@ -11,35 +14,62 @@ type Fragment struct {
 	Emphasized bool
 }
 
-// BuildFragments splits the provided line by highlight ranges.
+// MaxSpans caps the highlight ranges one line can carry; a line with more
+// (a pattern matching every character of a hostile line) keeps the first
+// ones and shows the rest plain.
+const MaxSpans = 256
+
+// BuildFragments splits the provided line by highlight ranges. Fragment
+// texts are substrings of line and the slice is carved from a shared slab, so
+// a line usually costs no allocation of its own. Ranges may be unsorted,
+// overlapping, out of bounds, or split a multi-byte character; they are
+// clamped to the line and widened to whole characters.
 func BuildFragments(line string, spans [][2]int) []Fragment {
 	if len(spans) == 0 {
-		return []Fragment{{Text: line}}
+		return Plain(line)
 	}
 
-	sort.Slice(spans, func(i, j int) bool {
-		return spans[i][0] < spans[j][0]
+	slices.SortFunc(spans, func(a, b [2]int) int {
+		return cmp.Compare(a[0], b[0])
 	})
+	if len(spans) > MaxSpans {
+		spans = spans[:MaxSpans]
+	}
 
-	fragments := make([]Fragment, 0, len(spans)*2+1)
-	cursor := 0
-	for _, span := range spans {
-		start := clamp(span[0], 0, len(line))
-		end := clamp(span[1], 0, len(line))
-		if start > cursor {
-			fragments = appendFragment(fragments, Fragment{Text: line[cursor:start]})
+	fragments := carve(len(spans)*2 + 1)
+	cursor, last := 0, -1
+	add := func(start, end int, emphasized bool) {
+		if end <= start {
+			return
 		}
-		if end > start {
-			fragments = appendFragment(fragments, Fragment{Text: line[start:end], Emphasized: true})
+		// Merge with the previous fragment when it has the same emphasis
+		// and ends where this one starts.
+		if n := len(fragments); n > 0 && last == start && fragments[n-1].Emphasized == emphasized {
+			fragments[n-1].Text = line[start-len(fragments[n-1].Text) : end]
+		} else {
+			fragments = append(fragments, Fragment{Text: line[start:end], Emphasized: emphasized})
 		}
-		cursor = end
+		last = end
 	}
-	if cursor < len(line) {
-		fragments = appendFragment(fragments, Fragment{Text: line[cursor:]})
+	for _, span := range spans {
+		start := runeStart(line, clamp(span[0], cursor, len(line)))
+		end := runeEnd(line, clamp(span[1], 0, len(line)))
+		add(cursor, start, false)
+		cursor = start
+		add(start, end, true)
+		if end > cursor {
+			cursor = end
+		}
 	}
+	add(cursor, len(line), false)
 	return fragments
 }
 
+// Plain is line as a single unemphasized fragment.
+func Plain(line string) []Fragment {
+	return append(carve(1), Fragment{Text: line})
+}
+
 func clamp(val, min, max int) int {
 	if val < min {
 		return min
@@ -50,20 +80,49 @@ func clamp(val, min, max int) int {
 	return val
 }
 
-func appendFragment(list []Fragment, frag Fragment) []Fragment {
-	if frag.Text == "" {
-		return list
+// runeStart moves i back to the first byte of the character it falls in.
+func runeStart(line string, i int) int {
+	for i > 0 && i < len(line) && !utf8.RuneStart(line[i]) {
+		i--
 	}
-	// Merge with previous fragment if the emphasis flag matches.
-	if len(list) > 0 {
-		last := &list[len(list)-1]
-		if last.Emphasized == frag.Emphasized {
-			last.Text = last.Text + frag.Text
-			return list
-		}
+	return i
+}
+
+// runeEnd moves i forward past the character it falls in.
+func runeEnd(line string, i int) int {
+	for i > 0 && i < len(line) && !utf8.RuneStart(line[i]) {
+		i++
+	}
+	return i
+}
+
+// slabSize is how many fragments one shared allocation holds. A slab lives
+// as long as any line cut from it, so it is kept small.
+const slabSize = 256
+
+type slab struct {
+	buf []Fragment
+}
+
+var slabs = sync.Pool{New: func() any { return &slab{} }}
+
+// carve returns an empty slice with room for n fragments cut from a slab, so
+// many lines share one allocation. Its capacity is exactly n: appending past
+// it reallocates instead of writing into another line's fragments. Slab
+// space is never reused, which keeps handing slices to consumers safe.
+func carve(n int) []Fragment {
+	if n > slabSize/4 {
+		return make([]Fragment, 0, n)
+	}
+	s := slabs.Get().(*slab)
+	if cap(s.buf)-len(s.buf) < n {
+		s.buf = make([]Fragment, 0, slabSize)
 	}
-	list = append(list, frag)
-	return list
+	at := len(s.buf)
+	out := s.buf[at : at : at+n]
+	s.buf = s.buf[:at+n]
+	slabs.Put(s)
+	return out
 }
 
 // String renders the fragments into plain text, ignoring emphasis.
@@ -0,0 +1,136 @@
+// Package loki live-tails Grafana Loki through its tail WebSocket API
+// (/loki/api/v1/tail), so logs already centralized in Loki can be watched
+// with spectra rules. The WebSocket client is built in; it needs no
+// dependency beyond the standard library.
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"watcher/internal/websocket"
+)
+
+// backfillLimit caps the entries Loki replays from the start time when a
+// tail (re)connects.
+const backfillLimit = 1000
+
+// Client tails one Loki server.
+type Client struct {
+	base   *url.URL
+	header http.Header
+}
+
+// NewClient reads the same environment as logcli: LOKI_ADDR (default
+// http://localhost:3100, may include a path prefix), LOKI_USERNAME and
+// LOKI_PASSWORD or LOKI_BEARER_TOKEN for authentication, and LOKI_ORG_ID for
+// the X-Scope-OrgID tenant header.
+func NewClient() (*Client, error) {
+	addr := os.Getenv("LOKI_ADDR")
+	if addr == "" {
+		addr = "http://localhost:3100"
+	}
+	base, err := url.Parse(strings.TrimRight(addr, "/"))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("loki: LOKI_ADDR %q: want http(s)://host[:port][/prefix]", addr)
+	}
+	header := http.Header{}
+	switch user, token := os.Getenv("LOKI_USERNAME"), os.Getenv("LOKI_BEARER_TOKEN"); {
+	case user != "":
+		req := &http.Request{Header: header}
+		req.SetBasicAuth(user, os.Getenv("LOKI_PASSWORD"))
+	case token != "":
+		header.Set("Authorization", "Bearer "+token)
+	}
+	if org := os.Getenv("LOKI_ORG_ID"); org != "" {
+		header.Set("X-Scope-OrgID", org)
+	}
+	return &Client{base: base, header: header}, nil
+}
+
+// Entry is one log line with the labels of its stream.
+type Entry struct {
+	Labels map[string]string
+	Time   time.Time
+	Line   string
+	// Dropped, when non-zero, marks a notice instead of a line: Loki skipped
+	// that many entries because the tail could not keep up.
+	Dropped int
+}
+
+// tailResponse is one message of the tail stream.
+type tailResponse struct {
+	Streams []struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	} `json:"streams"`
+	Dropped []json.RawMessage `json:"dropped_entries"`
+}
+
+// Tail calls fn with the entries matching the LogQL query from since on,
+// oldest first within each batch, until ctx is done or fn returns false. It
+// returns an error when the connection ends otherwise (Loki closes tails
+// after its tail_max_duration); to resume, call it again from just after the
+// last entry passed to fn.
+func (c *Client) Tail(ctx context.Context, query string, since time.Time, fn func(Entry) bool) error {
+	u := *c.base
+	u.Path += "/loki/api/v1/tail"
+	u.RawQuery = url.Values{
+		"query": {query},
+		"start": {strconv.FormatInt(since.UnixNano(), 10)},
+		"limit": {strconv.Itoa(backfillLimit)},
+	}.Encode()
+	conn, err := websocket.Dial(ctx, &u, c.header)
+	if err != nil {
+		return fmt.Errorf("tail %s: %w", c.base.Host, err)
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+				return fmt.Errorf("tail %s: connection closed", c.base.Host)
+			}
+			return fmt.Errorf("tail %s: %w", c.base.Host, err)
+		}
+		var resp tailResponse
+		if err := json.Unmarshal(msg, &resp); err != nil {
+			return fmt.Errorf("tail %s: decode: %w", c.base.Host, err)
+		}
+		var batch []Entry
+		for _, s := range resp.Streams {
+			for _, v := range s.Values {
+				ns, err := strconv.ParseInt(v[0], 10, 64)
+				if err != nil {
+					return fmt.Errorf("tail %s: timestamp %q: %w", c.base.Host, v[0], err)
+				}
+				batch = append(batch, Entry{Labels: s.Stream, Time: time.Unix(0, ns), Line: v[1]})
+			}
+		}
+		sort.SliceStable(batch, func(i, j int) bool { return batch[i].Time.Before(batch[j].Time) })
+		if len(resp.Dropped) > 0 {
+			batch = append(batch, Entry{Dropped: len(resp.Dropped)})
+		}
+		for _, entry := range batch {
+			if !fn(entry) {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package nats
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// pullBatch and pullExpires shape each pull request: up to pullBatch
+	// messages, or a 408 status once pullExpires passes with fewer.
+	pullBatch   = 256
+	pullExpires = 5 * time.Second
+)
+
+// Consumer is a JetStream durable pull consumer. The server keeps its
+// position, so a consumer bound again after a restart continues after the
+// last acknowledged message.
+type Consumer struct {
+	c       *Conn
+	stream  string
+	durable string
+}
+
+// apiError is the error member of a JetStream API response.
+type apiError struct {
+	Error *struct {
+		Code        int    `json:"code"`
+		Description string `json:"description"`
+	} `json:"error"`
+}
+
+func (e apiError) err(op string) error {
+	if e.Error == nil {
+		return nil
+	}
+	return fmt.Errorf("%s: %s (%d)", op, e.Error.Description, e.Error.Code)
+}
+
+// Durable binds the durable consumer named durable on the stream that
+// captures subject, creating it on first use with explicit acks, delivery of
+// new messages only, and subject as its filter.
+func (c *Conn) Durable(subject, durable string) (*Consumer, error) {
+	stream, err := c.streamFor(subject)
+	if err != nil {
+		return nil, err
+	}
+	req, _ := json.Marshal(map[string]any{
+		"stream_name": stream,
+		"config": map[string]any{
+			"durable_name":   durable,
+			"ack_policy":     "explicit",
+			"deliver_policy": "new",
+			"filter_subject": subject,
+		},
+	})
+	msg, err := c.Request("$JS.API.CONSUMER.CREATE."+stream+"."+durable, req)
+	if err != nil {
+		return nil, err
+	}
+	var resp apiError
+	if err := json.Unmarshal(msg.Data, &resp); err != nil {
+		return nil, fmt.Errorf("consumer %s: decode: %w", durable, err)
+	}
+	if err := resp.err("consumer " + durable); err != nil {
+		return nil, err
+	}
+	return &Consumer{c: c, stream: stream, durable: durable}, nil
+}
+
+// streamFor asks JetStream which stream stores subject.
+func (c *Conn) streamFor(subject string) (string, error) {
+	req, _ := json.Marshal(map[string]string{"subject": subject})
+	msg, err := c.Request("$JS.API.STREAM.NAMES", req)
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		apiError
+		Streams []string `json:"streams"`
+	}
+	if err := json.Unmarshal(msg.Data, &resp); err != nil {
+		return "", fmt.Errorf("stream lookup: decode: %w", err)
+	}
+	if err := resp.err("stream lookup"); err != nil {
+		return "", err
+	}
+	if len(resp.Streams) == 0 {
+		return "", fmt.Errorf("no JetStream stream captures %q", subject)
+	}
+	return resp.Streams[0], nil
+}
+
+// Stream returns the name of the stream the consumer reads.
+func (k *Consumer) Stream() string {
+	return k.stream
+}
+
+// Each pulls messages and calls fn with each, acknowledging it once fn
+// returns true, until fn returns false or the connection fails.
+func (k *Consumer) Each(fn func(Msg) bool) error {
+	inbox := k.c.inbox + ".pull"
+	sid, err := k.c.Subscribe(inbox)
+	if err != nil {
+		return err
+	}
+	next := "$JS.API.CONSUMER.MSG.NEXT." + k.stream + "." + k.durable
+	req, _ := json.Marshal(map[string]int64{"batch": pullBatch, "expires": int64(pullExpires)})
+	for {
+		if err := k.c.Publish(next, inbox, req); err != nil {
+			return err
+		}
+		for got := 0; got < pullBatch; {
+			msg, err := k.c.Next()
+			if err != nil {
+				return err
+			}
+			if msg.Sid != sid {
+				continue
+			}
+			switch {
+			case msg.Status == 100:
+				continue
+			case msg.Status == 404 || msg.Status == 408:
+				got = pullBatch
+				continue
+			case msg.Status != 0:
+				return fmt.Errorf("consumer %s: %d %s", k.durable, msg.Status, msg.Description)
+			}
+			if !fn(msg) {
+				return nil
+			}
+			if err := k.c.Publish(msg.Reply, "", []byte("+ACK")); err != nil {
+				return err
+			}
+			got++
+		}
+	}
+}
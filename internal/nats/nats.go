@@ -0,0 +1,288 @@
+// Package nats is a minimal NATS client for the `nats:` source: core
+// subscriptions, plus JetStream durable pull consumers for at-least-once
+// delivery that resumes where it left off. It speaks the text protocol
+// directly, so it needs no dependency beyond the standard library.
+package nats
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// readTimeout bounds the wait for any traffic; the server pings idle
+	// clients every two minutes, so silence past this means the link is dead.
+	readTimeout = 5 * time.Minute
+	maxPayload  = 64 << 20
+)
+
+// Msg is one delivered message. Status and Description are set (e.g. 408
+// Request Timeout) for the header-only status messages JetStream sends to
+// pull requests.
+type Msg struct {
+	Subject     string
+	Reply       string
+	Status      int
+	Description string
+	Data        []byte
+	// Sid is the subscription it was delivered to. JetStream keeps the
+	// stored subject in Subject, so pulled messages are told apart by Sid.
+	Sid int
+}
+
+// Conn is one client connection. Next must be called from a single
+// goroutine; Publish and Subscribe may be called from any.
+type Conn struct {
+	conn  net.Conn
+	r     *bufio.Reader
+	wmu   sync.Mutex
+	sid   int
+	inbox string
+	// replies is set once the request inbox is subscribed.
+	replies bool
+	seq     int
+}
+
+// serverInfo is the part of the server's INFO we use.
+type serverInfo struct {
+	TLSRequired bool `json:"tls_required"`
+	Headers     bool `json:"headers"`
+}
+
+// Dial connects to the server named by NATS_URL (default
+// nats://127.0.0.1:4222; tls:// forces TLS, user:password@ or token@ in the
+// URL authenticates), or NATS_USER/NATS_PASSWORD or NATS_TOKEN.
+func Dial(ctx context.Context) (*Conn, error) {
+	raw := os.Getenv("NATS_URL")
+	if raw == "" {
+		raw = "nats://127.0.0.1:4222"
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "nats://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return nil, fmt.Errorf("nats: NATS_URL %q: want nats://host[:port]", raw)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, fmt.Errorf("nats: %w", err)
+	}
+	c := &Conn{conn: conn, r: bufio.NewReaderSize(conn, 64<<10)}
+	if err := c.handshake(u); err != nil {
+		c.conn.Close()
+		return nil, fmt.Errorf("nats %s: %w", host, err)
+	}
+	c.inbox = "_INBOX." + strconv.FormatInt(time.Now().UnixNano(), 36)
+	return c, nil
+}
+
+// handshake reads INFO, upgrades to TLS when asked to, sends CONNECT, and
+// waits for the PONG that confirms it was accepted.
+func (c *Conn) handshake(u *url.URL) error {
+	c.conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	defer c.conn.SetReadDeadline(time.Time{})
+	line, err := c.readLine()
+	if err != nil {
+		return err
+	}
+	op, rest, _ := strings.Cut(line, " ")
+	if !strings.EqualFold(op, "INFO") {
+		return fmt.Errorf("expected INFO, got %q", line)
+	}
+	var info serverInfo
+	if err := json.Unmarshal([]byte(rest), &info); err != nil {
+		return fmt.Errorf("decode INFO: %w", err)
+	}
+	if info.TLSRequired || u.Scheme == "tls" {
+		tc := tls.Client(c.conn, &tls.Config{ServerName: u.Hostname()})
+		if err := tc.Handshake(); err != nil {
+			return fmt.Errorf("tls: %w", err)
+		}
+		c.conn, c.r = tc, bufio.NewReaderSize(tc, 64<<10)
+	}
+	opts := map[string]any{
+		"verbose": false, "pedantic": false, "lang": "go", "name": "spectra",
+		"protocol": 1, "headers": info.Headers, "no_responders": info.Headers,
+	}
+	user, pass, token := os.Getenv("NATS_USER"), os.Getenv("NATS_PASSWORD"), os.Getenv("NATS_TOKEN")
+	if u.User != nil {
+		if p, ok := u.User.Password(); ok {
+			user, pass = u.User.Username(), p
+		} else {
+			token = u.User.Username()
+		}
+	}
+	if user != "" {
+		opts["user"], opts["pass"] = user, pass
+	}
+	if token != "" {
+		opts["auth_token"] = token
+	}
+	body, _ := json.Marshal(opts)
+	if err := c.write("CONNECT " + string(body) + "\r\nPING\r\n"); err != nil {
+		return err
+	}
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return err
+		}
+		switch op, rest, _ := strings.Cut(line, " "); strings.ToUpper(op) {
+		case "PONG":
+			return nil
+		case "-ERR":
+			return fmt.Errorf("%s", strings.Trim(rest, "' "))
+		}
+	}
+}
+
+// Subscribe starts delivery of subject (wildcards allowed) and returns the
+// subscription id its messages carry in Msg.Sid.
+func (c *Conn) Subscribe(subject string) (int, error) {
+	c.wmu.Lock()
+	c.sid++
+	sid := c.sid
+	c.wmu.Unlock()
+	return sid, c.write(fmt.Sprintf("SUB %s %d\r\n", subject, sid))
+}
+
+// Publish sends data to subject, asking for responses on reply if set.
+func (c *Conn) Publish(subject, reply string, data []byte) error {
+	head := "PUB " + subject
+	if reply != "" {
+		head += " " + reply
+	}
+	return c.write(fmt.Sprintf("%s %d\r\n%s\r\n", head, len(data), data))
+}
+
+// Next returns the next message, answering server pings meanwhile. -ERR from
+// the server is returned as an error.
+func (c *Conn) Next() (Msg, error) {
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(readTimeout))
+		line, err := c.readLine()
+		if err != nil {
+			return Msg{}, err
+		}
+		op, rest, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(op) {
+		case "PING":
+			if err := c.write("PONG\r\n"); err != nil {
+				return Msg{}, err
+			}
+		case "MSG":
+			return c.readMsg(strings.Fields(rest), false)
+		case "HMSG":
+			return c.readMsg(strings.Fields(rest), true)
+		case "-ERR":
+			return Msg{}, fmt.Errorf("server error: %s", strings.Trim(rest, "' "))
+		}
+	}
+}
+
+// readMsg reads the payload of `MSG subject sid [reply] size` or
+// `HMSG subject sid [reply] hsize size`.
+func (c *Conn) readMsg(args []string, headers bool) (Msg, error) {
+	want := 3
+	if headers {
+		want = 4
+	}
+	if len(args) != want && len(args) != want+1 {
+		return Msg{}, fmt.Errorf("malformed message line %q", args)
+	}
+	msg := Msg{Subject: args[0]}
+	msg.Sid, _ = strconv.Atoi(args[1])
+	if len(args) == want+1 {
+		msg.Reply = args[2]
+	}
+	total, err := strconv.Atoi(args[len(args)-1])
+	if err != nil || total < 0 || total > maxPayload {
+		return Msg{}, fmt.Errorf("bad message size %q", args[len(args)-1])
+	}
+	hsize := 0
+	if headers {
+		if hsize, err = strconv.Atoi(args[len(args)-2]); err != nil || hsize > total {
+			return Msg{}, fmt.Errorf("bad header size %q", args[len(args)-2])
+		}
+	}
+	buf := make([]byte, total+2)
+	if _, err := io.ReadFull(c.r, buf); err != nil {
+		return Msg{}, err
+	}
+	if hsize > 0 {
+		// The header block starts with a `NATS/1.0 [status [description]]` line.
+		status, _, _ := strings.Cut(string(buf[:hsize]), "\r\n")
+		if fields := strings.Fields(status); len(fields) > 1 {
+			msg.Status, _ = strconv.Atoi(fields[1])
+			msg.Description = strings.Join(fields[2:], " ")
+		}
+	}
+	msg.Data = buf[hsize:total]
+	return msg, nil
+}
+
+// Request publishes data to subject and returns the first reply. It must not
+// run concurrently with Next.
+func (c *Conn) Request(subject string, data []byte) (Msg, error) {
+	if !c.replies {
+		if _, err := c.Subscribe(c.inbox + ".req.*"); err != nil {
+			return Msg{}, err
+		}
+		c.replies = true
+	}
+	c.seq++
+	reply := c.inbox + ".req." + strconv.Itoa(c.seq)
+	if err := c.Publish(subject, reply, data); err != nil {
+		return Msg{}, err
+	}
+	for {
+		msg, err := c.Next()
+		if err != nil {
+			return Msg{}, err
+		}
+		if msg.Subject != reply {
+			continue
+		}
+		if msg.Status == 503 {
+			return Msg{}, fmt.Errorf("%s: no responders (is JetStream enabled?)", subject)
+		}
+		return msg, nil
+	}
+}
+
+func (c *Conn) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (c *Conn) write(s string) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	_, err := io.WriteString(c.conn, s)
+	return err
+}
+
+// Close ends the connection.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,203 @@
+package notify
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"watcher/internal/pipeline"
+	"watcher/internal/rules"
+)
+
+// untagged groups digest events that carry no tags.
+const untagged = "untagged"
+
+// maxSampleRunes shortens sample lines so one digest stays a single message.
+const maxSampleRunes = 200
+
+type digestConfig struct {
+	Every   time.Duration `yaml:"every"`
+	Tags    []string      `yaml:"tags"`
+	Top     *int          `yaml:"top"`
+	Samples *int          `yaml:"samples"`
+}
+
+// digest collects the events routed to one sink and sends them as a summary
+// per tag every so often instead of one by one. It is only used from the
+// Dispatcher's Run loop, so it needs no locking.
+type digest struct {
+	every   time.Duration
+	tags    []string // tag patterns; nil: every tag
+	top     int
+	samples int
+
+	since  time.Time
+	groups map[string]*tagGroup
+}
+
+// tagGroup is what one digest period saw for one tag; samples are the first
+// distinct lines.
+type tagGroup struct {
+	count    int
+	severity map[rules.Severity]int
+	rules    map[string]int
+	samples  []string
+}
+
+func buildDigest(dc digestConfig) (*digest, error) {
+	dg := &digest{every: dc.Every, top: 3, samples: 2}
+	if dg.every == 0 {
+		dg.every = time.Hour
+	}
+	if dg.every < 0 {
+		return nil, fmt.Errorf("digest: every must be positive")
+	}
+	if dc.Top != nil {
+		dg.top = *dc.Top
+	}
+	if dc.Samples != nil {
+		dg.samples = *dc.Samples
+	}
+	if dg.top < 0 || dg.samples < 0 {
+		return nil, fmt.Errorf("digest: top and samples cannot be negative")
+	}
+	if len(dc.Tags) > 0 {
+		dg.tags = dc.Tags
+	}
+	return dg, nil
+}
+
+// add counts evt under each of its tags the digest covers (one group per tag,
+// also when a pattern like `net.*` selects several).
+func (dg *digest) add(evt pipeline.HighlightedEvent, now time.Time) {
+	if dg.groups == nil {
+		dg.groups = make(map[string]*tagGroup)
+	}
+	if dg.since.IsZero() {
+		dg.since = now
+	}
+	tags := evt.Tags
+	if len(tags) == 0 {
+		tags = []string{untagged}
+	}
+	for _, tag := range tags {
+		if dg.tags != nil && !rules.AnyTagMatches(dg.tags, []string{tag}) {
+			continue
+		}
+		g := dg.groups[tag]
+		if g == nil {
+			g = &tagGroup{severity: make(map[rules.Severity]int), rules: make(map[string]int)}
+			dg.groups[tag] = g
+		}
+		g.count++
+		g.severity[evt.Severity]++
+		g.rules[evt.RuleName]++
+		if sample := truncateRunes(evt.Line, maxSampleRunes); len(g.samples) < dg.samples && !slices.Contains(g.samples, sample) {
+			g.samples = append(g.samples, sample)
+		}
+	}
+}
+
+// due reports whether a period with events has run its course.
+func (dg *digest) due(now time.Time) bool {
+	return len(dg.groups) > 0 && now.Sub(dg.since) >= dg.every
+}
+
+// drain returns one summary event per tag, in tag order, and starts a new
+// period.
+func (dg *digest) drain(now time.Time) []pipeline.HighlightedEvent {
+	tags := make([]string, 0, len(dg.groups))
+	for tag := range dg.groups {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	period := now.Sub(dg.since)
+	out := make([]pipeline.HighlightedEvent, 0, len(tags))
+	for _, tag := range tags {
+		out = append(out, dg.groups[tag].event(tag, period, dg.top, now))
+	}
+	dg.groups, dg.since = nil, time.Time{}
+	return out
+}
+
+// event renders a group as one message, e.g.
+// `digest auth: 42 events in 1h · 3 critical, 39 medium · top: ssh brute 30, sudo 12`,
+// with the numbers and samples also as fields.
+func (g *tagGroup) event(tag string, period time.Duration, top int, now time.Time) pipeline.HighlightedEvent {
+	sevs := make([]rules.Severity, 0, len(g.severity))
+	for sev := range g.severity {
+		sevs = append(sevs, sev)
+	}
+	sort.Slice(sevs, func(i, j int) bool { return rules.SeverityRank(sevs[i]) < rules.SeverityRank(sevs[j]) })
+	bySeverity := make([]string, len(sevs))
+	for i, sev := range sevs {
+		bySeverity[i] = fmt.Sprintf("%d %s", g.severity[sev], sev)
+	}
+	names := make([]string, 0, len(g.rules))
+	for name := range g.rules {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if g.rules[names[i]] != g.rules[names[j]] {
+			return g.rules[names[i]] > g.rules[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > top {
+		names = names[:top]
+	}
+	topRules := make([]string, len(names))
+	for i, name := range names {
+		topRules[i] = fmt.Sprintf("%s %d", name, g.rules[name])
+	}
+
+	window := shortDuration(period)
+	line := fmt.Sprintf("digest %s: %d events in %s · %s", tag, g.count, window, strings.Join(bySeverity, ", "))
+	if len(topRules) > 0 {
+		line += " · top: " + strings.Join(topRules, ", ")
+	}
+	captures := map[string]string{
+		"digest_count":  strconv.Itoa(g.count),
+		"digest_window": window,
+	}
+	if len(topRules) > 0 {
+		captures["top_rules"] = strings.Join(topRules, ", ")
+	}
+	for i, sample := range g.samples {
+		captures["sample_"+strconv.Itoa(i+1)] = sample
+	}
+	severity := rules.SeverityNormal
+	if len(sevs) > 0 {
+		severity = sevs[0]
+	}
+	return pipeline.HighlightedEvent{
+		Timestamp: now,
+		Line:      line,
+		RuleName:  "digest",
+		Severity:  severity,
+		Tags:      []string{tag, "digest"},
+		Captures:  captures,
+	}
+}
+
+// shortDuration prints d to the minute without zero units: 1h, 1h30m, 45m.
+func shortDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Minute {
+		return "<1m"
+	}
+	s := d.String()
+	s = strings.TrimSuffix(s, "0s")
+	return strings.Replace(s, "h0m", "h", 1)
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
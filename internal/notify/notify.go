@@ -0,0 +1,420 @@
+// Package notify schedules forwarding sinks: quiet hours per sink, routing by
+// time of day or an on-call calendar, periodic per-tag digests, and escalation
+// of severe events that nobody acknowledged in time. The policy comes from the --notify YAML file.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"watcher/internal/pipeline"
+	"watcher/internal/rules"
+	"watcher/internal/sink"
+)
+
+// maxPending bounds how many unacknowledged events wait for escalation; the
+// oldest are forgotten beyond it.
+const maxPending = 1024
+
+// checkEvery is how often pending escalations and calendars are re-examined.
+const checkEvery = 5 * time.Second
+
+// Sender delivers one event to an external system (sink.GELF satisfies it).
+type Sender interface {
+	Send(evt pipeline.HighlightedEvent) error
+}
+
+type policyFile struct {
+	Timezone   string                `yaml:"timezone"`
+	Sinks      map[string]sinkConfig `yaml:"sinks"`
+	Routes     []routeConfig         `yaml:"routes"`
+	Escalation *escalationConfig     `yaml:"escalation"`
+}
+
+type sinkConfig struct {
+	GELF        string        `yaml:"gelf"`
+	MinSeverity string        `yaml:"min_severity"`
+	Filter      string        `yaml:"filter"`
+	QuietHours  []string      `yaml:"quiet_hours"`
+	Digest      *digestConfig `yaml:"digest"`
+}
+
+type routeConfig struct {
+	When     []string `yaml:"when"`
+	Tags     []string `yaml:"tags"`
+	Calendar string   `yaml:"calendar"`
+	Sinks    []string `yaml:"sinks"`
+}
+
+type escalationConfig struct {
+	After       time.Duration `yaml:"after"`
+	MinSeverity string        `yaml:"min_severity"`
+	Sinks       []string      `yaml:"sinks"`
+}
+
+type target struct {
+	name string
+	out  Sender
+	min  rules.Severity
+	// filter narrows routed events further (pipeline.ParseFilter); nil
+	// passes all.
+	filter *pipeline.Filter
+	quiet  []Window
+	// digest, when set, replaces immediate delivery with periodic summaries.
+	digest *digest
+}
+
+type route struct {
+	when     []Window
+	tags     []string // tag patterns; nil: every event
+	calendar *Calendar
+	sinks    []string
+}
+
+type pendingEvent struct {
+	key string
+	due time.Time
+	evt pipeline.HighlightedEvent
+}
+
+// Dispatcher routes matched events to sinks according to a policy. A nil
+// *Dispatcher ignores acknowledgements, so callers need not check whether
+// --notify is set.
+type Dispatcher struct {
+	loc      *time.Location
+	sinks    map[string]*target
+	order    []string
+	routes   []route
+	after    time.Duration
+	escMin   rules.Severity
+	escSinks []string
+	now      func() time.Time
+
+	mu      sync.Mutex
+	pending []pendingEvent
+	failed  atomic.Uint64
+}
+
+// Load reads a policy file. Calendar paths are relative to the policy file.
+func Load(path string) (*Dispatcher, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("load notify policy: %w", err)
+	}
+	var pf policyFile
+	if err := yaml.Unmarshal(content, &pf); err != nil {
+		return nil, fmt.Errorf("parse notify policy: %w", err)
+	}
+	d := &Dispatcher{loc: time.Local, sinks: make(map[string]*target), now: time.Now}
+	if pf.Timezone != "" {
+		if d.loc, err = time.LoadLocation(pf.Timezone); err != nil {
+			return nil, fmt.Errorf("notify timezone: %w", err)
+		}
+	}
+	for name, sc := range pf.Sinks {
+		t, err := buildTarget(name, sc)
+		if err != nil {
+			return nil, err
+		}
+		d.sinks[name] = t
+		d.order = append(d.order, name)
+	}
+	sort.Strings(d.order)
+	for i, rc := range pf.Routes {
+		r, err := d.buildRoute(path, rc)
+		if err != nil {
+			return nil, fmt.Errorf("route %d: %w", i+1, err)
+		}
+		d.routes = append(d.routes, r)
+	}
+	if esc := pf.Escalation; esc != nil {
+		if esc.After <= 0 {
+			return nil, fmt.Errorf("escalation: after must be positive")
+		}
+		if err := d.checkSinks(esc.Sinks); err != nil {
+			return nil, fmt.Errorf("escalation: %w", err)
+		}
+		d.after = esc.After
+		d.escSinks = esc.Sinks
+		if d.escMin, err = rules.ParseSeverity(coalesce(esc.MinSeverity, "critical")); err != nil {
+			return nil, fmt.Errorf("escalation: %w", err)
+		}
+	}
+	return d, nil
+}
+
+func buildTarget(name string, sc sinkConfig) (*target, error) {
+	if sc.GELF == "" {
+		return nil, fmt.Errorf("sink %q: missing gelf destination", name)
+	}
+	out, err := sink.NewGELF(sc.GELF)
+	if err != nil {
+		return nil, fmt.Errorf("sink %q: %w", name, err)
+	}
+	t := &target{name: name, out: out}
+	if t.min, err = rules.ParseSeverity(coalesce(sc.MinSeverity, "low")); err != nil {
+		return nil, fmt.Errorf("sink %q: %w", name, err)
+	}
+	if t.filter, err = pipeline.ParseFilter(sc.Filter); err != nil {
+		return nil, fmt.Errorf("sink %q: %w", name, err)
+	}
+	for _, spec := range sc.QuietHours {
+		w, err := ParseWindow(spec)
+		if err != nil {
+			return nil, fmt.Errorf("sink %q: %w", name, err)
+		}
+		t.quiet = append(t.quiet, w)
+	}
+	if sc.Digest != nil {
+		if t.digest, err = buildDigest(*sc.Digest); err != nil {
+			return nil, fmt.Errorf("sink %q: %w", name, err)
+		}
+	}
+	return t, nil
+}
+
+func (d *Dispatcher) buildRoute(policyPath string, rc routeConfig) (route, error) {
+	var r route
+	for _, spec := range rc.When {
+		w, err := ParseWindow(spec)
+		if err != nil {
+			return route{}, err
+		}
+		r.when = append(r.when, w)
+	}
+	r.tags = rc.Tags
+	if rc.Calendar != "" {
+		calPath := rc.Calendar
+		if !filepath.IsAbs(calPath) {
+			calPath = filepath.Join(filepath.Dir(policyPath), calPath)
+		}
+		cal, err := LoadCalendar(calPath)
+		if err != nil {
+			return route{}, err
+		}
+		for _, shift := range cal.shifts {
+			if err := d.checkSinks(shift.Sinks); err != nil {
+				return route{}, fmt.Errorf("calendar %s: %w", calPath, err)
+			}
+		}
+		r.calendar = cal
+	} else if len(rc.Sinks) == 0 {
+		return route{}, fmt.Errorf("needs sinks or a calendar")
+	}
+	if err := d.checkSinks(rc.Sinks); err != nil {
+		return route{}, err
+	}
+	r.sinks = rc.Sinks
+	return r, nil
+}
+
+func (d *Dispatcher) checkSinks(names []string) error {
+	for _, name := range names {
+		if _, ok := d.sinks[name]; !ok {
+			return fmt.Errorf("unknown sink %q", name)
+		}
+	}
+	return nil
+}
+
+// Run dispatches events until the channel closes or ctx is done. Unmatched
+// lines, gap markers, and errors are skipped. When the channel closes, digests
+// still collecting are sent regardless of their period and quiet hours.
+func (d *Dispatcher) Run(ctx context.Context, events <-chan pipeline.HighlightedEvent) {
+	ticker := time.NewTicker(checkEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.refreshCalendars()
+			d.escalateDue()
+			d.sendDigests(false)
+		case evt, ok := <-events:
+			if !ok {
+				d.sendDigests(true)
+				return
+			}
+			if evt.RuleName == "" || evt.Err != nil || evt.Gap > 0 {
+				continue
+			}
+			d.Dispatch(evt)
+		}
+	}
+}
+
+// Dispatch sends evt to every routed sink whose threshold and filter it meets
+// and whose quiet hours are not in effect (digest sinks collect it instead), and queues
+// it for escalation when due.
+func (d *Dispatcher) Dispatch(evt pipeline.HighlightedEvent) {
+	now := d.now().In(d.loc)
+	for _, name := range d.routed(evt, now) {
+		t := d.sinks[name]
+		if !rules.MeetsThreshold(evt.Severity, t.min) || !t.filter.Match(evt) {
+			continue
+		}
+		if t.digest != nil {
+			t.digest.add(evt, now)
+			continue
+		}
+		if anyContains(t.quiet, now) {
+			continue
+		}
+		d.send(t, evt)
+	}
+	if d.after > 0 && rules.MeetsThreshold(evt.Severity, d.escMin) {
+		d.mu.Lock()
+		if len(d.pending) >= maxPending {
+			d.pending = d.pending[1:]
+		}
+		d.pending = append(d.pending, pendingEvent{key: ackKey(evt.Path, evt.Line), due: now.Add(d.after), evt: evt})
+		d.mu.Unlock()
+	}
+}
+
+// routed lists the sinks that receive evt at now: the union of every route
+// active then whose tags (if any) select one of the event's, or every sink
+// when the policy has no routes.
+func (d *Dispatcher) routed(evt pipeline.HighlightedEvent, now time.Time) []string {
+	if len(d.routes) == 0 {
+		return d.order
+	}
+	seen := make(map[string]bool)
+	var names []string
+	add := func(list []string) {
+		for _, name := range list {
+			// A reloaded calendar may name sinks the policy does not define.
+			if _, ok := d.sinks[name]; ok && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	for _, r := range d.routes {
+		if len(r.when) > 0 && !anyContains(r.when, now) {
+			continue
+		}
+		if r.tags != nil && !rules.AnyTagMatches(r.tags, evt.Tags) {
+			continue
+		}
+		add(r.sinks)
+		if r.calendar != nil {
+			for _, shift := range r.calendar.OnCall(now) {
+				add(shift.Sinks)
+			}
+		}
+	}
+	return names
+}
+
+// escalateDue forwards every pending event past its deadline to the
+// escalation sinks, ignoring their quiet hours, and forgets it.
+func (d *Dispatcher) escalateDue() {
+	now := d.now().In(d.loc)
+	d.mu.Lock()
+	var due []pipeline.HighlightedEvent
+	kept := d.pending[:0]
+	for _, p := range d.pending {
+		if now.Before(p.due) {
+			kept = append(kept, p)
+			continue
+		}
+		due = append(due, p.evt)
+	}
+	d.pending = kept
+	d.mu.Unlock()
+	for _, evt := range due {
+		evt.Tags = append(append([]string(nil), evt.Tags...), "escalated")
+		for _, name := range d.escSinks {
+			d.send(d.sinks[name], evt)
+		}
+	}
+}
+
+// sendDigests sends every digest whose period is over, unless its sink is in
+// quiet hours (it keeps collecting until they end); final sends them all.
+func (d *Dispatcher) sendDigests(final bool) {
+	now := d.now().In(d.loc)
+	for _, name := range d.order {
+		t := d.sinks[name]
+		if t.digest == nil {
+			continue
+		}
+		if !final && (!t.digest.due(now) || anyContains(t.quiet, now)) {
+			continue
+		}
+		for _, evt := range t.digest.drain(now) {
+			d.send(t, evt)
+		}
+	}
+}
+
+func (d *Dispatcher) refreshCalendars() {
+	for _, r := range d.routes {
+		if r.calendar != nil {
+			// A broken edit keeps the previous rotation in force.
+			r.calendar.Refresh()
+		}
+	}
+}
+
+func (d *Dispatcher) send(t *target, evt pipeline.HighlightedEvent) {
+	if err := t.out.Send(evt); err != nil {
+		d.failed.Add(1)
+	}
+}
+
+// Ack cancels the pending escalation of the event read from path as line.
+func (d *Dispatcher) Ack(path, line string) {
+	if d == nil {
+		return
+	}
+	key := ackKey(path, line)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	kept := d.pending[:0]
+	for _, p := range d.pending {
+		if p.key != key {
+			kept = append(kept, p)
+		}
+	}
+	d.pending = kept
+}
+
+// AckAll cancels every pending escalation.
+func (d *Dispatcher) AckAll() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pending = nil
+}
+
+// Failed reports how many deliveries to any sink failed.
+func (d *Dispatcher) Failed() uint64 {
+	if d == nil {
+		return 0
+	}
+	return d.failed.Load()
+}
+
+func ackKey(path, line string) string {
+	return path + "\x00" + line
+}
+
+func coalesce(val, fallback string) string {
+	if val == "" {
+		return fallback
+	}
+	return val
+}
@@ -0,0 +1,189 @@
+package notify
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Window is a recurring daily time range such as "22:00-07:00" or
+// "mon-fri 09:00-18:00". A range whose end is before its start runs past
+// midnight; the day list then names the day it starts on.
+type Window struct {
+	days       [7]bool
+	start, end int // minutes since midnight; end may be 1440
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseWindow reads "[days ]HH:MM-HH:MM", where days is a comma separated list
+// of three-letter names or ranges ("mon-fri,sun"). Without days it applies daily.
+func ParseWindow(spec string) (Window, error) {
+	var w Window
+	fields := strings.Fields(strings.ToLower(spec))
+	var hours string
+	switch len(fields) {
+	case 1:
+		hours = fields[0]
+		for i := range w.days {
+			w.days[i] = true
+		}
+	case 2:
+		if err := w.parseDays(fields[0]); err != nil {
+			return Window{}, fmt.Errorf("window %q: %w", spec, err)
+		}
+		hours = fields[1]
+	default:
+		return Window{}, fmt.Errorf("window %q: want [days ]HH:MM-HH:MM", spec)
+	}
+	from, to, ok := strings.Cut(hours, "-")
+	if !ok {
+		return Window{}, fmt.Errorf("window %q: want HH:MM-HH:MM", spec)
+	}
+	var err error
+	if w.start, err = parseClock(from); err != nil {
+		return Window{}, fmt.Errorf("window %q: %w", spec, err)
+	}
+	if w.end, err = parseClock(to); err != nil {
+		return Window{}, fmt.Errorf("window %q: %w", spec, err)
+	}
+	if w.start == w.end {
+		return Window{}, fmt.Errorf("window %q: empty range", spec)
+	}
+	return w, nil
+}
+
+func (w *Window) parseDays(list string) error {
+	for _, part := range strings.Split(list, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, ok := weekdays[from]
+		if !ok {
+			return fmt.Errorf("unknown day %q", from)
+		}
+		last := first
+		if isRange {
+			if last, ok = weekdays[to]; !ok {
+				return fmt.Errorf("unknown day %q", to)
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			w.days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func parseClock(value string) (int, error) {
+	h, m, ok := strings.Cut(value, ":")
+	hour, herr := strconv.Atoi(h)
+	minute, merr := strconv.Atoi(m)
+	if !ok || herr != nil || merr != nil || hour < 0 || minute < 0 || minute > 59 || hour*60+minute > 24*60 {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+	return hour*60 + minute, nil
+}
+
+// Contains reports whether t (in its own location) falls inside the window.
+func (w Window) Contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	if w.start < w.end {
+		return w.days[day] && minute >= w.start && minute < w.end
+	}
+	if minute >= w.start {
+		return w.days[day]
+	}
+	return minute < w.end && w.days[(day+6)%7]
+}
+
+// anyContains reports whether t falls inside at least one window.
+func anyContains(windows []Window, t time.Time) bool {
+	for _, w := range windows {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Shift is one on-call calendar entry: the sinks that receive events routed
+// by the calendar between Start and End.
+type Shift struct {
+	Start time.Time `yaml:"start"`
+	End   time.Time `yaml:"end"`
+	Who   string    `yaml:"who"`
+	Sinks []string  `yaml:"sinks"`
+}
+
+// Calendar is an on-call rotation loaded from a YAML list of shifts.
+type Calendar struct {
+	path    string
+	modTime time.Time
+	shifts  []Shift
+}
+
+// LoadCalendar reads the shift list at path.
+func LoadCalendar(path string) (*Calendar, error) {
+	c := &Calendar{path: path}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Calendar) load() error {
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return fmt.Errorf("load calendar: %w", err)
+	}
+	content, err := os.ReadFile(c.path)
+	if err != nil {
+		return fmt.Errorf("load calendar: %w", err)
+	}
+	var shifts []Shift
+	if err := yaml.Unmarshal(content, &shifts); err != nil {
+		return fmt.Errorf("parse calendar %s: %w", c.path, err)
+	}
+	for i, s := range shifts {
+		if !s.End.After(s.Start) {
+			return fmt.Errorf("calendar %s: shift %d ends before it starts", c.path, i+1)
+		}
+	}
+	c.shifts = shifts
+	c.modTime = info.ModTime()
+	return nil
+}
+
+// Refresh reloads the file when it changed since the last load. A broken edit
+// keeps the previous shifts and returns the error.
+func (c *Calendar) Refresh() error {
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return fmt.Errorf("load calendar: %w", err)
+	}
+	if info.ModTime().Equal(c.modTime) {
+		return nil
+	}
+	return c.load()
+}
+
+// OnCall returns the shifts active at t.
+func (c *Calendar) OnCall(t time.Time) []Shift {
+	var active []Shift
+	for _, s := range c.shifts {
+		if !t.Before(s.Start) && t.Before(s.End) {
+			active = append(active, s)
+		}
+	}
+	return active
+}
@@ -0,0 +1,50 @@
+package parsers
+
+import (
+	"regexp"
+	"strings"
+)
+
+type haproxyParser struct{}
+
+var (
+	haproxyHTTP = regexp.MustCompile(`^(?P<client_ip>\S+):(?P<client_port>\d+) \[(?P<accept_date>[^\]]+)\] (?P<frontend>\S+) (?P<backend>[^/\s]+)/(?P<server>\S+) (?P<tq>-?\d+)/(?P<tw>-?\d+)/(?P<tc>-?\d+)/(?P<tr>-?\d+)/\+?(?P<tt>-?\d+) (?P<status>-?\d+) \+?(?P<bytes>\d+) \S+ \S+ (?P<termination_state>\S{4}) (?P<actconn>\d+)/(?P<feconn>\d+)/(?P<beconn>\d+)/(?P<srv_conn>\d+)/\+?(?P<retries>\d+) (?P<srv_queue>\d+)/(?P<backend_queue>\d+)(?: \{[^}]*\})*(?: "(?P<request>[^"]*)")?`)
+	haproxyTCP  = regexp.MustCompile(`^(?P<client_ip>\S+):(?P<client_port>\d+) \[(?P<accept_date>[^\]]+)\] (?P<frontend>\S+) (?P<backend>[^/\s]+)/(?P<server>\S+) (?P<tw>-?\d+)/(?P<tc>-?\d+)/\+?(?P<tt>-?\d+) \+?(?P<bytes>\d+) (?P<termination_state>\S{2}) (?P<actconn>\d+)/(?P<feconn>\d+)/(?P<beconn>\d+)/(?P<srv_conn>\d+)/\+?(?P<retries>\d+) (?P<srv_queue>\d+)/(?P<backend_queue>\d+)`)
+)
+
+func init() {
+	register(haproxyParser{})
+}
+
+func (haproxyParser) Name() string { return "haproxy" }
+
+// Parse understands the default HTTP and TCP log formats. Fields: pid,
+// client_ip, client_port, accept_date, frontend, backend, server, the timers
+// tq/tw/tc/tr/tt (ms, -1 when the phase never completed), status, bytes,
+// termination_state, the connection counts, srv_queue/backend_queue, and for
+// HTTP the request split into method, path, and http_version. mode is http or tcp.
+func (haproxyParser) Parse(line string) (map[string]string, bool) {
+	pid, msg, ok := program(line, "haproxy")
+	if !ok {
+		return nil, false
+	}
+	fields := map[string]string{"pid": pid}
+	if m := haproxyHTTP.FindStringSubmatch(msg); m != nil {
+		submatches(haproxyHTTP.SubexpNames(), m, fields)
+		fields["mode"] = "http"
+		if parts := strings.Fields(fields["request"]); len(parts) >= 2 {
+			fields["method"], fields["path"] = parts[0], parts[1]
+			if len(parts) > 2 {
+				fields["http_version"] = parts[2]
+			}
+		}
+		return fields, true
+	}
+	if m := haproxyTCP.FindStringSubmatch(msg); m != nil {
+		submatches(haproxyTCP.SubexpNames(), m, fields)
+		fields["mode"] = "tcp"
+		return fields, true
+	}
+	fields["event"] = "other"
+	return fields, true
+}
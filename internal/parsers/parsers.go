@@ -0,0 +1,84 @@
+package parsers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Parser extracts named fields from one daemon's log lines. Parse reports
+// false for lines that daemon did not write.
+type Parser interface {
+	Name() string
+	Parse(line string) (map[string]string, bool)
+}
+
+// MaxFields caps the fields every parser reads from one line: postfix's
+// key=value attributes and the named groups the pattern-based parsers copy
+// (see submatches), so a line of thousands of pairs cannot grow a capture map
+// without bound.
+const MaxFields = 64
+
+var registry = map[string]Parser{}
+
+func register(p Parser) {
+	registry[p.Name()] = p
+}
+
+// Lookup returns the parser registered under name.
+func Lookup(name string) (Parser, error) {
+	p, ok := registry[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return nil, fmt.Errorf("unknown parser %q (have %s)", name, strings.Join(Names(), ", "))
+	}
+	return p, nil
+}
+
+// Names lists the registered parsers.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// program splits `... prog[pid]: message` at the given program tag, returning
+// the pid (possibly empty) and the message.
+func program(line, tag string) (pid, msg string, ok bool) {
+	for offset := 0; ; {
+		i := strings.Index(line[offset:], tag)
+		if i < 0 {
+			return "", "", false
+		}
+		i += offset
+		offset = i + len(tag)
+		if i > 0 && line[i-1] != ' ' && line[i-1] != '/' {
+			continue // part of a longer word, e.g. "xsshd"
+		}
+		rest := line[offset:]
+		if strings.HasPrefix(rest, "[") {
+			if end := strings.Index(rest, "]: "); end > 0 {
+				return rest[1:end], rest[end+3:], true
+			}
+		}
+		if strings.HasPrefix(rest, ": ") {
+			return "", rest[2:], true
+		}
+	}
+}
+
+// submatches names the groups of a match, skipping unmatched optional groups
+// and stopping once into holds MaxFields.
+func submatches(names, values []string, into map[string]string) {
+	for i, name := range names {
+		if len(into) >= MaxFields {
+			return
+		}
+		if i == 0 || name == "" || values[i] == "" {
+			continue
+		}
+		into[name] = values[i]
+	}
+}
@@ -0,0 +1,125 @@
+package parsers
+
+import (
+	"regexp"
+	"strings"
+)
+
+type postfixParser struct{}
+
+var (
+	postfixTag      = regexp.MustCompile(`postfix(?:-\w+)?/(?P<service>[\w/-]+)\[(?P<pid>\d+)\]: (?P<msg>.*)$`)
+	postfixQueueID  = regexp.MustCompile(`^([0-9A-F]{6,12}|[0-9B-DF-HJ-NP-TV-Zb-df-hj-np-tv-z]{10,16}): `)
+	postfixHostAddr = regexp.MustCompile(`^(\S+?)\[([^\]]+)\](?::(\d+))?$`)
+	postfixConnect  = regexp.MustCompile(`^(connect|disconnect|lost connection after \w+) from (\S+?\[[^\]]+\])`)
+	postfixReject   = regexp.MustCompile(`^(?:NOQUEUE: )?(reject|warning|hold|discard): (\w+) from (\S+?\[[^\]]+\]): (\d{3}) (\d\.\d\.\d+) (.*)$`)
+	postfixStatus   = regexp.MustCompile(`^(\w+)(?: \((.*)\))?$`)
+)
+
+func init() {
+	register(postfixParser{})
+}
+
+func (postfixParser) Name() string { return "postfix" }
+
+// Parse yields service (smtpd, smtp, qmgr, cleanup…), pid, queue_id, the
+// key=value attributes (from, to, relay, delay, delays, dsn, size, nrcpt,
+// message-id), status with status_detail, client/client_ip split from
+// `host[ip]`, relay_host/relay_ip, and for rejects action, stage, code, and reason.
+func (postfixParser) Parse(line string) (map[string]string, bool) {
+	m := postfixTag.FindStringSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+	fields := map[string]string{"service": m[1], "pid": m[2]}
+	msg := m[3]
+	if q := postfixQueueID.FindStringSubmatch(msg); q != nil {
+		fields["queue_id"] = q[1]
+		msg = msg[len(q[0]):]
+	}
+	switch {
+	case postfixConnect.MatchString(msg):
+		c := postfixConnect.FindStringSubmatch(msg)
+		fields["event"] = strings.Fields(c[1])[0]
+		splitHost(fields, "client", c[2])
+		return fields, true
+	case postfixReject.MatchString(msg):
+		r := postfixReject.FindStringSubmatch(msg)
+		fields["event"] = "reject"
+		fields["action"], fields["stage"] = r[1], r[2]
+		splitHost(fields, "client", r[3])
+		fields["code"], fields["dsn"], fields["reason"] = r[4], r[5], r[6]
+		return fields, true
+	}
+	for _, attr := range splitAttrs(msg) {
+		key, value, ok := strings.Cut(attr, "=")
+		if !ok || strings.ContainsAny(key, " ") {
+			continue
+		}
+		value = strings.Trim(value, "<>")
+		switch key {
+		case "status":
+			if s := postfixStatus.FindStringSubmatch(value); s != nil {
+				fields["status"] = s[1]
+				if s[2] != "" {
+					fields["status_detail"] = s[2]
+				}
+				continue
+			}
+		case "client", "relay":
+			splitHost(fields, key, value)
+			continue
+		}
+		fields[key] = value
+	}
+	if _, ok := fields["event"]; !ok {
+		fields["event"] = "message"
+	}
+	return fields, true
+}
+
+// splitAttrs splits `a=1, b=2 (x, y), c=3` on the commas between attributes,
+// stopping at MaxFields.
+func splitAttrs(msg string) []string {
+	var out []string
+	depth, start := 0, 0
+	for i := 0; i < len(msg) && len(out) < MaxFields; i++ {
+		switch msg[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				out = append(out, strings.TrimSpace(msg[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if len(out) == MaxFields {
+		return out
+	}
+	return append(out, strings.TrimSpace(msg[start:]))
+}
+
+// splitHost turns `mx.example.com[203.0.113.5]:25` into prefix, prefix_ip and prefix_port
+// (client keeps its bare name under "client"; relay uses relay_host).
+func splitHost(fields map[string]string, prefix, value string) {
+	m := postfixHostAddr.FindStringSubmatch(value)
+	if m == nil {
+		fields[prefix] = value
+		return
+	}
+	name := prefix
+	if prefix == "relay" {
+		name = "relay_host"
+		fields["relay"] = value
+	}
+	fields[name] = m[1]
+	fields[prefix+"_ip"] = m[2]
+	if m[3] != "" {
+		fields[prefix+"_port"] = m[3]
+	}
+}
@@ -0,0 +1,58 @@
+package parsers
+
+import "regexp"
+
+type sshdParser struct{}
+
+var sshdMessages = []*regexp.Regexp{
+	regexp.MustCompile(`^(?P<event>Accepted|Failed) (?P<method>\S+) for (?P<invalid>invalid user )?(?P<user>\S*) from (?P<ip>\S+) port (?P<port>\d+)(?: (?P<protocol>ssh2))?`),
+	regexp.MustCompile(`^(?P<event>Invalid user) (?P<user>\S*) from (?P<ip>\S+)(?: port (?P<port>\d+))?`),
+	regexp.MustCompile(`^(?P<event>Disconnected from|Connection closed by|Connection reset by) (?:(?P<invalid>invalid user |authenticating user |user )(?P<user>\S+) )?(?P<ip>\S+) port (?P<port>\d+)`),
+	regexp.MustCompile(`^error: (?P<event>maximum authentication attempts exceeded) for (?P<invalid>invalid user )?(?P<user>\S+) from (?P<ip>\S+) port (?P<port>\d+)`),
+	regexp.MustCompile(`^(?P<event>Received disconnect) from (?P<ip>\S+) port (?P<port>\d+)`),
+	regexp.MustCompile(`^(?P<event>Did not receive identification string) from (?P<ip>\S+)(?: port (?P<port>\d+))?`),
+}
+
+var sshdEvents = map[string]string{
+	"Accepted":             "accepted",
+	"Failed":               "failed",
+	"Invalid user":         "invalid_user",
+	"Disconnected from":    "disconnected",
+	"Connection closed by": "closed",
+	"Connection reset by":  "reset",
+	"maximum authentication attempts exceeded": "max_auth_tries",
+	"Received disconnect":                      "disconnected",
+	"Did not receive identification string":    "no_ident",
+}
+
+func init() {
+	register(sshdParser{})
+}
+
+func (sshdParser) Name() string { return "sshd" }
+
+// Parse yields pid, event (accepted|failed|invalid_user|disconnected|closed|reset|max_auth_tries|no_ident),
+// method (password|publickey|keyboard-interactive/pam…), user, invalid_user (true when sshd
+// flagged the account), ip, port, and protocol.
+func (sshdParser) Parse(line string) (map[string]string, bool) {
+	pid, msg, ok := program(line, "sshd")
+	if !ok {
+		return nil, false
+	}
+	fields := map[string]string{"pid": pid}
+	for _, re := range sshdMessages {
+		m := re.FindStringSubmatch(msg)
+		if m == nil {
+			continue
+		}
+		submatches(re.SubexpNames(), m, fields)
+		fields["event"] = sshdEvents[fields["event"]]
+		if fields["invalid"] == "invalid user " || fields["event"] == "invalid_user" {
+			fields["invalid_user"] = "true"
+		}
+		delete(fields, "invalid")
+		return fields, true
+	}
+	fields["event"] = "other"
+	return fields, true
+}
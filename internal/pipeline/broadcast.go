@@ -0,0 +1,114 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+)
+
+// Broadcaster fans one highlighted stream out to many consumers (TUI, sinks,
+// mirrors). Each subscriber owns a buffer; a subscriber that falls behind loses
+// events instead of stalling the pipeline for everyone else.
+type Broadcaster struct {
+	in     <-chan HighlightedEvent
+	mu     sync.Mutex
+	subs   map[*subscriber]struct{}
+	closed bool
+}
+
+type subscriber struct {
+	ch      chan HighlightedEvent
+	dropped uint64
+}
+
+// Subscription is a consumer's view of a Broadcaster.
+type Subscription struct {
+	b   *Broadcaster
+	sub *subscriber
+}
+
+// NewBroadcaster fans in out once Start is called, so every consumer can
+// subscribe first: until then in is not read and its producers wait, rather
+// than publishing to nobody.
+func NewBroadcaster(in <-chan HighlightedEvent) *Broadcaster {
+	return &Broadcaster{in: in, subs: make(map[*subscriber]struct{})}
+}
+
+// Start relays to subscribers until in closes or ctx is done. Call it once.
+func (b *Broadcaster) Start(ctx context.Context) {
+	in := b.in
+	go func() {
+		defer b.close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case evt, ok := <-in:
+				if !ok {
+					return
+				}
+				b.publish(evt)
+			}
+		}
+	}()
+}
+
+// Subscribe registers a consumer with the given buffer size.
+func (b *Broadcaster) Subscribe(buffer int) Subscription {
+	if buffer < 0 {
+		buffer = 0
+	}
+	sub := &subscriber{ch: make(chan HighlightedEvent, buffer)}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(sub.ch)
+		return Subscription{b: b, sub: sub}
+	}
+	b.subs[sub] = struct{}{}
+	return Subscription{b: b, sub: sub}
+}
+
+// Events returns the subscriber's channel; it closes when the source stream ends.
+func (s Subscription) Events() <-chan HighlightedEvent {
+	return s.sub.ch
+}
+
+// Dropped reports how many events were discarded because the buffer was full.
+func (s Subscription) Dropped() uint64 {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	return s.sub.dropped
+}
+
+// Cancel detaches the subscriber and closes its channel.
+func (s Subscription) Cancel() {
+	s.b.mu.Lock()
+	defer s.b.mu.Unlock()
+	if _, ok := s.b.subs[s.sub]; !ok {
+		return
+	}
+	delete(s.b.subs, s.sub)
+	close(s.sub.ch)
+}
+
+func (b *Broadcaster) publish(evt HighlightedEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs {
+		select {
+		case sub.ch <- evt:
+		default:
+			sub.dropped++
+		}
+	}
+}
+
+func (b *Broadcaster) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for sub := range b.subs {
+		close(sub.ch)
+		delete(b.subs, sub)
+	}
+}
@@ -0,0 +1,35 @@
+package pipeline
+
+import (
+	"context"
+
+	"watcher/internal/cluster"
+)
+
+// Cluster labels each line with the message template it falls under (see
+// cluster.Drain), so a storm of alerts differing only in ids, addresses, or
+// names can be shown as one template with a count. Errors, gap markers, and
+// outage events pass through unlabeled. A similarity of 0 returns in
+// unchanged.
+func Cluster(ctx context.Context, in <-chan HighlightedEvent, similarity float64) <-chan HighlightedEvent {
+	if similarity <= 0 {
+		return in
+	}
+	drain := cluster.New(similarity)
+	out := make(chan HighlightedEvent)
+	go func() {
+		defer close(out)
+		for evt := range in {
+			if evt.Err == nil && evt.Gap == 0 && evt.Conn == nil && evt.Line != "" {
+				c := drain.Add(evt.Line)
+				evt.ClusterID, evt.Template = c.ID, c.Template()
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case out <- evt:
+			}
+		}
+	}()
+	return out
+}
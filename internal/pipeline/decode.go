@@ -0,0 +1,158 @@
+package pipeline
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is a source's detected text encoding.
+type Encoding int
+
+const (
+	EncodingUnknown Encoding = iota
+	EncodingUTF8
+	EncodingUTF16LE
+	EncodingUTF16BE
+)
+
+func (e Encoding) String() string {
+	switch e {
+	case EncodingUTF8:
+		return "utf-8"
+	case EncodingUTF16LE:
+		return "utf-16le"
+	case EncodingUTF16BE:
+		return "utf-16be"
+	}
+	return "unknown"
+}
+
+// Decoder turns one source's raw lines into UTF-8. Sources are split on '\n'
+// bytes before decoding, so UTF-16 lines arrive with a stray NUL from the
+// newline's other byte; the decoder keeps the detected encoding for the life
+// of the source to strip it consistently. Each source needs its own Decoder.
+type Decoder struct {
+	enc Encoding
+}
+
+// DetectEncoding guesses the encoding of the start of a stream: a byte order
+// mark if present, else the position of NUL bytes in mostly-ASCII UTF-16.
+func DetectEncoding(head []byte) Encoding {
+	switch {
+	case bytes.HasPrefix(head, []byte{0xEF, 0xBB, 0xBF}):
+		return EncodingUTF8
+	case bytes.HasPrefix(head, []byte{0xFF, 0xFE}):
+		return EncodingUTF16LE
+	case bytes.HasPrefix(head, []byte{0xFE, 0xFF}):
+		return EncodingUTF16BE
+	}
+	if len(head) < 4 {
+		return EncodingUnknown
+	}
+	var even, odd int
+	for i, b := range head {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			even++
+		} else {
+			odd++
+		}
+	}
+	half := len(head) / 2
+	switch {
+	case odd*10 >= half*6 && even*10 < half:
+		return EncodingUTF16LE
+	case even*10 >= half*6 && odd*10 < half:
+		return EncodingUTF16BE
+	case even+odd == 0:
+		return EncodingUTF8
+	}
+	return EncodingUnknown
+}
+
+// detectLine is DetectEncoding for a line cut out of the middle of a UTF-16
+// stream, which carries one stray NUL byte from the preceding or following newline.
+func detectLine(line string) Encoding {
+	raw := []byte(line)
+	if len(raw)%2 == 1 {
+		switch {
+		case raw[0] == 0:
+			if enc := DetectEncoding(raw[1:]); enc == EncodingUTF16LE {
+				return enc
+			}
+		case raw[len(raw)-1] == 0:
+			if enc := DetectEncoding(raw[:len(raw)-1]); enc == EncodingUTF16BE {
+				return enc
+			}
+		}
+	}
+	return DetectEncoding(raw)
+}
+
+// Residue reports whether a raw line is nothing but the stray byte of a
+// UTF-16 newline (the last "line" of a file ending in a newline); callers drop it.
+func (d *Decoder) Residue(line string) bool {
+	return line == "\x00" && (d.enc == EncodingUTF16LE || d.enc == EncodingUTF16BE)
+}
+
+// Encoding reports what the decoder has settled on so far.
+func (d *Decoder) Encoding() Encoding {
+	return d.enc
+}
+
+// Decode converts one line. The first line with enough bytes fixes the
+// encoding; UTF-8 lines with invalid sequences are read as Latin-1 so legacy
+// single-byte logs stay legible instead of filling with U+FFFD. Lines longer
+// than MaxLineBytes are cut (see TruncateLine).
+func (d *Decoder) Decode(line string) string {
+	return TruncateLine(d.decode(line), MaxLineBytes)
+}
+
+func (d *Decoder) decode(line string) string {
+	if d.enc == EncodingUnknown && line != "" {
+		d.enc = detectLine(line)
+	}
+	switch d.enc {
+	case EncodingUTF16LE:
+		return decodeUTF16(strings.TrimPrefix(strings.TrimPrefix(line, "\x00"), "\xFF\xFE"), false)
+	case EncodingUTF16BE:
+		return decodeUTF16(strings.TrimPrefix(strings.TrimSuffix(line, "\x00"), "\xFE\xFF"), true)
+	}
+	line = strings.TrimPrefix(line, "\xEF\xBB\xBF")
+	if utf8.ValidString(line) {
+		return line
+	}
+	return latin1(line)
+}
+
+func decodeUTF16(raw string, bigEndian bool) string {
+	units := make([]uint16, 0, len(raw)/2)
+	for i := 0; i+1 < len(raw); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(raw[i])<<8|uint16(raw[i+1]))
+		} else {
+			units = append(units, uint16(raw[i+1])<<8|uint16(raw[i]))
+		}
+	}
+	return strings.TrimRight(string(utf16.Decode(units)), "\r\x00")
+}
+
+func latin1(raw string) string {
+	var b strings.Builder
+	b.Grow(len(raw) * 2)
+	for i := 0; i < len(raw); {
+		r, size := utf8.DecodeRuneInString(raw[i:])
+		if r == utf8.RuneError && size <= 1 {
+			b.WriteRune(rune(raw[i]))
+			i++
+			continue
+		}
+		b.WriteRune(r)
+		i += size
+	}
+	return b.String()
+}
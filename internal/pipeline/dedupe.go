@@ -0,0 +1,127 @@
+package pipeline
+
+import (
+	"context"
+	"time"
+
+	"watcher/internal/clock"
+)
+
+// Dedupe folds identical lines that arrive from different sources within
+// window (say syslog and the application's own log) into the first copy,
+// whose Sources then lists every path it came from. Each event is held for
+// window before it is passed on; errors and gap markers pass through at once.
+// A zero window returns in unchanged; a nil clk is the wall clock.
+func Dedupe(ctx context.Context, in <-chan HighlightedEvent, window time.Duration, clk clock.Clock) <-chan HighlightedEvent {
+	if window <= 0 {
+		return in
+	}
+	clk = clock.Or(clk)
+	out := make(chan HighlightedEvent)
+	go func() {
+		defer close(out)
+		buf := dedupeBuffer{byLine: make(map[string][]*heldEvent)}
+		ticker := clk.NewTicker(sweepEvery(window))
+		defer ticker.Stop()
+		emit := func(events []HighlightedEvent) bool {
+			for _, evt := range events {
+				select {
+				case <-ctx.Done():
+					return false
+				case out <- evt:
+				}
+			}
+			return true
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C():
+				if !emit(buf.expired(now, window)) {
+					return
+				}
+			case evt, ok := <-in:
+				if !ok {
+					emit(buf.expired(time.Time{}, 0))
+					return
+				}
+				if evt.Err != nil || evt.Gap > 0 || evt.Conn != nil {
+					if !emit([]HighlightedEvent{evt}) {
+						return
+					}
+					continue
+				}
+				buf.add(evt, clk.Now())
+			}
+		}
+	}()
+	return out
+}
+
+type heldEvent struct {
+	evt HighlightedEvent
+	at  time.Time
+}
+
+// dedupeBuffer keeps held events in arrival order plus an index by line text.
+type dedupeBuffer struct {
+	queue  []*heldEvent
+	byLine map[string][]*heldEvent
+}
+
+// add folds evt into the oldest held copy of the same line that did not come
+// from evt's source, or holds it as a new event. A repeat from a source already
+// listed is a new occurrence, not a duplicate, and so is a copy matched by
+// another pipeline.
+func (b *dedupeBuffer) add(evt HighlightedEvent, now time.Time) {
+	for _, held := range b.byLine[evt.Line] {
+		if held.evt.Pipeline != evt.Pipeline || held.evt.fromSource(evt.Path) {
+			continue
+		}
+		if len(held.evt.Sources) == 0 {
+			held.evt.Sources = []string{held.evt.Path}
+		}
+		held.evt.Sources = append(held.evt.Sources, evt.Path)
+		return
+	}
+	held := &heldEvent{evt: evt, at: now}
+	b.queue = append(b.queue, held)
+	b.byLine[evt.Line] = append(b.byLine[evt.Line], held)
+}
+
+// expired removes and returns, oldest first, the events held at least window
+// before now. A zero now releases everything.
+func (b *dedupeBuffer) expired(now time.Time, window time.Duration) []HighlightedEvent {
+	var ready []HighlightedEvent
+	n := 0
+	for _, held := range b.queue {
+		if !now.IsZero() && now.Sub(held.at) < window {
+			break
+		}
+		ready = append(ready, held.evt)
+		// Held copies of a line expire oldest first, so this one leads its list.
+		if same := b.byLine[held.evt.Line][1:]; len(same) > 0 {
+			b.byLine[held.evt.Line] = same
+		} else {
+			delete(b.byLine, held.evt.Line)
+		}
+		n++
+	}
+	b.queue = b.queue[n:]
+	return ready
+}
+
+// fromSource reports whether path is the event's source or among the sources
+// folded into it.
+func (e HighlightedEvent) fromSource(path string) bool {
+	if e.Path == path {
+		return true
+	}
+	for _, p := range e.Sources {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,385 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"watcher/internal/rules"
+)
+
+// Filter selects the events one sink receives, from an expression such as
+// `tag=security && severity>=high`. A nil *Filter passes everything.
+type Filter struct {
+	expr  string
+	match func(*HighlightedEvent) bool
+}
+
+// ParseFilter compiles a filter expression: conditions joined with `&&` and
+// `||` (`&&` binds tighter), negated with `!`, grouped with parentheses.
+// A condition is `field op value`:
+//
+//   - severity: =, !=, >=, >, <=, < against a severity name; `>=` is "at
+//     least as severe".
+//   - rule, source, pipeline: = and != with * and ? wildcards, ~ and !~ with
+//     a regular expression. A source pattern without a slash matches the
+//     file's base name, one with a slash the whole path.
+//   - tag: = is true when any tag matches the tag pattern (`net.*`), != when
+//     none does; ~ and !~ test each tag against a regular expression.
+//   - any other name is a capture: = and != take a value pattern as in
+//     severity_map and where (`5xx`, `cidr:10.0.0.0/8`, case-insensitive
+//     text), >=, >, <=, < compare numbers, ~ and !~ take a regular expression.
+//     A missing capture satisfies only != and !~.
+//
+// Values may be quoted with "…" or '…' when they contain spaces or
+// parentheses. An empty expression yields a nil *Filter.
+func ParseFilter(expr string) (*Filter, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+	p := &filterParser{src: expr}
+	match, err := p.or()
+	if err == nil {
+		p.space()
+		if !p.done() {
+			err = p.errorf("unexpected %q", p.src[p.pos:])
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("filter %q: %w", expr, err)
+	}
+	return &Filter{expr: expr, match: match}, nil
+}
+
+// Match reports whether evt passes the filter.
+func (f *Filter) Match(evt HighlightedEvent) bool {
+	return f == nil || f.match(&evt)
+}
+
+func (f *Filter) String() string {
+	if f == nil {
+		return ""
+	}
+	return f.expr
+}
+
+// Apply passes on the events of in that match, closing the result when in
+// closes. A nil filter returns in itself.
+func (f *Filter) Apply(ctx context.Context, in <-chan HighlightedEvent) <-chan HighlightedEvent {
+	if f == nil {
+		return in
+	}
+	out := make(chan HighlightedEvent)
+	go func() {
+		defer close(out)
+		for evt := range in {
+			if !f.match(&evt) {
+				continue
+			}
+			select {
+			case out <- evt:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+type predicate = func(*HighlightedEvent) bool
+
+// filterParser is a recursive-descent parser over the expression text.
+type filterParser struct {
+	src string
+	pos int
+}
+
+func (p *filterParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("at %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *filterParser) done() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *filterParser) space() {
+	for !p.done() && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// eat consumes tok after optional space.
+func (p *filterParser) eat(tok string) bool {
+	p.space()
+	if strings.HasPrefix(p.src[p.pos:], tok) {
+		p.pos += len(tok)
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) or() (predicate, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.eat("||") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(evt *HighlightedEvent) bool { return l(evt) || right(evt) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) and() (predicate, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.eat("&&") {
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(evt *HighlightedEvent) bool { return l(evt) && right(evt) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) unary() (predicate, error) {
+	p.space()
+	if strings.HasPrefix(p.src[p.pos:], "!") && !strings.HasPrefix(p.src[p.pos:], "!=") {
+		p.pos++
+		inner, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(evt *HighlightedEvent) bool { return !inner(evt) }, nil
+	}
+	if p.eat("(") {
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.eat(")") {
+			return nil, p.errorf("missing )")
+		}
+		return inner, nil
+	}
+	return p.condition()
+}
+
+// filterOps are the comparison operators, longest first.
+var filterOps = []string{"!=", ">=", "<=", "!~", "==", "=", ">", "<", "~"}
+
+func (p *filterParser) condition() (predicate, error) {
+	p.space()
+	start := p.pos
+	for !p.done() && isFieldByte(p.src[p.pos]) {
+		p.pos++
+	}
+	field := p.src[start:p.pos]
+	if field == "" {
+		if p.done() {
+			return nil, p.errorf("expected a condition")
+		}
+		return nil, p.errorf("expected a field name, got %q", p.src[p.pos:])
+	}
+	p.space()
+	var op string
+	for _, candidate := range filterOps {
+		if strings.HasPrefix(p.src[p.pos:], candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		return nil, p.errorf("expected an operator after %q", field)
+	}
+	p.pos += len(op)
+	if op == "==" {
+		op = "="
+	}
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	pred, err := compileCondition(field, op, value)
+	if err != nil {
+		p.pos = start
+		return nil, p.errorf("%s%s%s: %v", field, op, value, err)
+	}
+	return pred, nil
+}
+
+func isFieldByte(c byte) bool {
+	return c == '_' || c == '.' || c == '-' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// value reads a quoted string, or a bare word up to space, `)`, `&&`, `||`.
+func (p *filterParser) value() (string, error) {
+	p.space()
+	if p.done() {
+		return "", p.errorf("missing value")
+	}
+	if q := p.src[p.pos]; q == '"' || q == '\'' {
+		end := strings.IndexByte(p.src[p.pos+1:], q)
+		if end < 0 {
+			return "", p.errorf("unterminated %c", q)
+		}
+		value := p.src[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return value, nil
+	}
+	start := p.pos
+	for !p.done() {
+		rest := p.src[p.pos:]
+		if rest[0] == ' ' || rest[0] == '\t' || rest[0] == ')' || strings.HasPrefix(rest, "&&") || strings.HasPrefix(rest, "||") {
+			break
+		}
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("missing value")
+	}
+	return p.src[start:p.pos], nil
+}
+
+func compileCondition(field, op, value string) (predicate, error) {
+	if op == "~" || op == "!~" {
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, err
+		}
+		test := regexCondition(field, re)
+		if op == "!~" {
+			return func(evt *HighlightedEvent) bool { return !test(evt) }, nil
+		}
+		return test, nil
+	}
+	switch strings.ToLower(field) {
+	case "severity":
+		return severityCondition(op, value)
+	case "rule":
+		return globCondition(op, value, func(evt *HighlightedEvent) string { return evt.RuleName })
+	case "source":
+		return sourceCondition(op, value)
+	case "pipeline":
+		return globCondition(op, value, func(evt *HighlightedEvent) string { return evt.Pipeline })
+	case "tag":
+		return tagCondition(op, value)
+	default:
+		return captureCondition(field, op, value)
+	}
+}
+
+func regexCondition(field string, re *regexp.Regexp) predicate {
+	switch strings.ToLower(field) {
+	case "severity":
+		return func(evt *HighlightedEvent) bool { return re.MatchString(string(evt.Severity)) }
+	case "rule":
+		return func(evt *HighlightedEvent) bool { return re.MatchString(evt.RuleName) }
+	case "source":
+		return func(evt *HighlightedEvent) bool { return re.MatchString(evt.Path) }
+	case "pipeline":
+		return func(evt *HighlightedEvent) bool { return re.MatchString(evt.Pipeline) }
+	case "tag":
+		return func(evt *HighlightedEvent) bool {
+			for _, tag := range evt.Tags {
+				if re.MatchString(tag) {
+					return true
+				}
+			}
+			return false
+		}
+	default:
+		return func(evt *HighlightedEvent) bool {
+			value, ok := evt.Captures[field]
+			return ok && re.MatchString(value)
+		}
+	}
+}
+
+func severityCondition(op, value string) (predicate, error) {
+	sev, err := rules.ParseSeverity(value)
+	if err != nil {
+		return nil, err
+	}
+	limit := rules.SeverityRank(sev)
+	// Ranks count down: critical is 0, so "more severe" is a lower rank.
+	compare := map[string]func(int) bool{
+		"=":  func(r int) bool { return r == limit },
+		"!=": func(r int) bool { return r != limit },
+		">=": func(r int) bool { return r <= limit },
+		">":  func(r int) bool { return r < limit },
+		"<=": func(r int) bool { return r >= limit },
+		"<":  func(r int) bool { return r > limit },
+	}[op]
+	return func(evt *HighlightedEvent) bool { return compare(rules.SeverityRank(evt.Severity)) }, nil
+}
+
+func globCondition(op, pattern string, get func(*HighlightedEvent) string) (predicate, error) {
+	if op != "=" && op != "!=" {
+		return nil, fmt.Errorf("use =, !=, ~ or !~")
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	return func(evt *HighlightedEvent) bool {
+		ok, _ := path.Match(pattern, get(evt))
+		return ok == (op == "=")
+	}, nil
+}
+
+func sourceCondition(op, pattern string) (predicate, error) {
+	if strings.ContainsAny(pattern, `/\`) {
+		return globCondition(op, filepath.ToSlash(pattern), func(evt *HighlightedEvent) string {
+			return filepath.ToSlash(evt.Path)
+		})
+	}
+	return globCondition(op, pattern, func(evt *HighlightedEvent) string {
+		return filepath.Base(evt.Path)
+	})
+}
+
+func tagCondition(op, pattern string) (predicate, error) {
+	if op != "=" && op != "!=" {
+		return nil, fmt.Errorf("use =, !=, ~ or !~")
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	patterns := []string{pattern}
+	return func(evt *HighlightedEvent) bool {
+		return rules.AnyTagMatches(patterns, evt.Tags) == (op == "=")
+	}, nil
+}
+
+func captureCondition(name, op, value string) (predicate, error) {
+	pattern := value
+	switch op {
+	case ">=", ">", "<=", "<":
+		pattern = op + value
+	}
+	match, err := rules.ValueMatcher(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if op == "!=" {
+		return func(evt *HighlightedEvent) bool {
+			v, ok := evt.Captures[name]
+			return !ok || !match(v)
+		}, nil
+	}
+	return func(evt *HighlightedEvent) bool {
+		v, ok := evt.Captures[name]
+		return ok && match(v)
+	}, nil
+}
@@ -0,0 +1,94 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+
+	"watcher/internal/watch"
+)
+
+// Branch is one of several named pipelines Fork runs over the same lines,
+// each with its own rules, show-all, and severity floor.
+type Branch struct {
+	Name   string
+	Stream Stream
+}
+
+// forkBuffer lets one branch run a little ahead of a busier one.
+const forkBuffer = 256
+
+// Fork feeds every line to each branch's stream and merges what they emit,
+// labeling each event with its branch's name. Errors, gap markers, and
+// connection markers reach every branch (a gap still flushes a stitched
+// line) but come out of the first one only, so they are not repeated. A
+// slow branch holds back the others, as a slow stage holds back Connect.
+// Events are numbered anew (Seq) as they merge, so a Seq is unique across
+// branches. A single unnamed branch is just its stream's Connect.
+func Fork(ctx context.Context, lines <-chan watch.LogEvent, branches []Branch) <-chan HighlightedEvent {
+	if len(branches) == 1 && branches[0].Name == "" {
+		return branches[0].Stream.Connect(ctx, lines)
+	}
+	inputs := make([]chan watch.LogEvent, len(branches))
+	for i := range inputs {
+		inputs[i] = make(chan watch.LogEvent, forkBuffer)
+	}
+	go func() {
+		defer func() {
+			for _, in := range inputs {
+				close(in)
+			}
+		}()
+		for {
+			var evt watch.LogEvent
+			var ok bool
+			select {
+			case <-ctx.Done():
+				return
+			case evt, ok = <-lines:
+				if !ok {
+					return
+				}
+			}
+			for _, in := range inputs {
+				select {
+				case <-ctx.Done():
+					return
+				case in <- evt:
+				}
+			}
+		}
+	}()
+	out := make(chan HighlightedEvent)
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		seq uint64
+	)
+	for i, b := range branches {
+		wg.Add(1)
+		go func(first bool, b Branch, in <-chan watch.LogEvent) {
+			defer wg.Done()
+			for evt := range b.Stream.Connect(ctx, in) {
+				if !first && (evt.Err != nil || evt.Gap > 0 || evt.Conn != nil) {
+					continue
+				}
+				evt.Pipeline = b.Name
+				mu.Lock()
+				seq++
+				evt.Seq = seq
+				select {
+				case <-ctx.Done():
+					mu.Unlock()
+					return
+				case out <- evt:
+				}
+				mu.Unlock()
+			}
+		}(i == 0, b, inputs[i])
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
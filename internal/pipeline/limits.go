@@ -0,0 +1,32 @@
+package pipeline
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Limits on what one event may grow to, so a hostile or corrupt source (a
+// binary file tailed by mistake, a runaway stack trace) cannot make matching,
+// highlighting, or the dashboard allocate without bound.
+const (
+	// MaxLineBytes caps one decoded line; sources already refuse lines past
+	// 1 MiB, but rules and the dashboard gain nothing from more than this.
+	MaxLineBytes = 64 << 10
+	// MaxStitchedBytes caps an event stitched from continuation lines. A
+	// continuation that would pass it starts a new event instead.
+	MaxStitchedBytes = 256 << 10
+)
+
+// TruncateLine cuts line to at most max bytes, at a rune boundary, and notes
+// how many bytes were dropped. Shorter lines are returned unchanged.
+func TruncateLine(line string, max int) string {
+	if len(line) <= max {
+		return line
+	}
+	marker := fmt.Sprintf(" … [%d bytes truncated]", len(line)-max)
+	cut := max
+	for cut > 0 && !utf8.RuneStart(line[cut]) {
+		cut--
+	}
+	return line[:cut] + marker
+}
@@ -2,79 +2,305 @@ package pipeline
 
 import (
 	"context"
+	"strings"
 	"time"
 
+	"watcher/internal/clock"
 	"watcher/internal/highlight"
 	"watcher/internal/rules"
+	"watcher/internal/secrets"
 	"watcher/internal/watch"
 )
 
 // HighlightedEvent is consumed by the TUI layer.
 type HighlightedEvent struct {
 	Timestamp time.Time
-	Path      string
-	Line      string
-	RuleName  string
-	Severity  rules.Severity
-	Color     string
-	Tags      []string
+	// Seq is the order Connect received the event in, from 1 and strictly
+	// increasing across every source, so events whose Timestamps are equal
+	// (a burst within one clock tick, or lines stamped to the second) still
+	// sort and export in arrival order (the sinks' seq). Zero outside
+	// Connect. The dashboard numbers its own lines instead (displayLine
+	// Seq), since it also shows lines no pipeline produced.
+	// Fork renumbers the merged events of its branches, so Seq stays unique
+	// (and increasing) across pipelines.
+	Seq uint64
+	// Pipeline names the Fork branch that matched the event; empty when a
+	// single pipeline runs.
+	Pipeline string
+	Path     string
+	// Sources lists every path an identical line arrived from when Dedupe
+	// folded copies together (Path first); nil for a single source.
+	Sources []string
+	// Offset is the file position just past the line (0 for non-file
+	// sources), so the line and its neighbours can be reread from disk with
+	// watch.ReadContext after the buffer has moved on.
+	Offset int64
+	// LineNo is the line's number within its source (see watch.LogEvent),
+	// shown in the dashboard gutter; zero for errors and markers.
+	LineNo int64
+	// Backlog marks a line that predates the session (see
+	// watch.LogEvent); actions are not run for it.
+	Backlog  bool
+	Line     string
+	RuleName string
+	Severity rules.Severity
+	Color    string
+	Tags     []string
+	Captures map[string]string
+	// Chain names the rules whose emitted lines led to RuleName, first to
+	// last, and Derived is the line RuleName matched (see rules.Match); both
+	// are empty for a match on the raw line.
+	Chain     []string
+	Derived   string
 	Fragments []highlight.Fragment
 	Err       error
+	Gap       time.Duration
+	// Conn marks a source going down or coming back; see watch.ConnEvent.
+	Conn *watch.ConnEvent
+	// ClusterID names the message template the Cluster stage filed the line
+	// under, and Template is that template as of this line; later lines can
+	// only widen it. Zero and empty without the stage.
+	ClusterID int
+	Template  string
 }
 
 type Stream struct {
 	rules       rules.RuleSet
 	showAll     bool
 	minSeverity rules.Severity
+	multiline   time.Duration
+	clock       clock.Clock
 }
 
 // New creates a pipeline stream from a ruleset.
 func New(rs rules.RuleSet, showAll bool, min rules.Severity) Stream {
-	return Stream{rules: rs, showAll: showAll, minSeverity: min}
+	return Stream{rules: rs, showAll: showAll, minSeverity: min, clock: clock.System}
 }
 
-// Connect wires a tail stream to highlighted output.
+// WithClock returns a stream that stamps live lines and times multiline
+// stitching with c instead of the wall clock.
+func (s Stream) WithClock(c clock.Clock) Stream {
+	s.clock = clock.Or(c)
+	return s
+}
+
+// WithMultiline returns a stream that stitches continuation lines (indented
+// lines, "Caused by:", "... N more") onto the line before them, per source.
+// A line is held until the next non-continuation line from the same source
+// or until wait passes without one. Zero turns stitching off.
+func (s Stream) WithMultiline(wait time.Duration) Stream {
+	s.multiline = wait
+	return s
+}
+
+// Connect wires a tail stream to highlighted output. Every source path gets
+// its own parser state (encoding detection, multiline buffer).
 func (s Stream) Connect(ctx context.Context, in <-chan watch.LogEvent) <-chan HighlightedEvent {
 	out := make(chan HighlightedEvent)
 	go func() {
 		defer close(out)
+		var seq uint64
+		publish := func(evt HighlightedEvent) {
+			seq++
+			evt.Seq = seq
+			out <- evt
+		}
+		parsers := make(map[string]*sourceParser)
+		parserFor := func(path string) *sourceParser {
+			p, ok := parsers[path]
+			if !ok {
+				p = &sourceParser{}
+				parsers[path] = p
+			}
+			return p
+		}
+		var flush <-chan time.Time
+		if s.multiline > 0 {
+			ticker := s.clock.NewTicker(max(s.multiline/2, minSweep))
+			defer ticker.Stop()
+			flush = ticker.C()
+		}
 		for {
 			select {
 			case <-ctx.Done():
 				return
+			case now := <-flush:
+				for _, p := range parsers {
+					if p.expired(now, s.multiline) {
+						evt, _ := p.take()
+						s.send(publish, evt)
+					}
+				}
 			case evt, ok := <-in:
 				if !ok {
+					for _, p := range parsers {
+						if evt, ok := p.take(); ok {
+							s.send(publish, evt)
+						}
+					}
 					return
 				}
 				if evt.Err != nil {
-					out <- HighlightedEvent{Timestamp: time.Now(), Path: evt.Path, Err: evt.Err}
+					publish(HighlightedEvent{Timestamp: s.clock.Now(), Path: evt.Path, Err: evt.Err})
+					continue
+				}
+				if evt.Conn != nil {
+					publish(HighlightedEvent{Timestamp: s.clock.Now(), Path: evt.Path, Severity: rules.SeverityNormal, Conn: evt.Conn})
+					continue
+				}
+				p := parserFor(evt.Path)
+				if evt.Gap > 0 {
+					if held, ok := p.take(); ok {
+						s.send(publish, held)
+					}
+					publish(HighlightedEvent{Timestamp: s.clock.Now(), Path: evt.Path, Severity: rules.SeverityNormal, Gap: evt.Gap})
 					continue
 				}
-				match, matched := s.rules.Match(evt.Line)
-				highlightEvt := HighlightedEvent{
-					Timestamp: time.Now(),
-					Path:      evt.Path,
-					Line:      evt.Line,
-					Severity:  rules.SeverityNormal,
+				if p.decoder.Residue(evt.Line) {
+					continue
 				}
-				if matched {
-					if !s.showAll && !rules.MeetsThreshold(match.Rule.Severity, s.minSeverity) {
-						continue
+				evt.Line = p.decoder.Decode(evt.Line)
+				if evt.Offset > 0 {
+					// An offset going back means the file was truncated or
+					// replaced, so numbering starts again at its first line.
+					if evt.Offset <= p.offset {
+						p.lines = 0
 					}
-					highlightEvt.RuleName = match.Rule.Name
-					highlightEvt.Severity = match.Rule.Severity
-					highlightEvt.Color = match.Rule.Color
-					highlightEvt.Tags = match.Rule.Tags
-					highlightEvt.Fragments = highlight.BuildFragments(evt.Line, match.HighlightSpans)
-				} else {
-					if !s.showAll {
-						continue
+					p.offset = evt.Offset
+				}
+				p.lines++
+				evt.LineNo = p.lines
+				if s.multiline > 0 {
+					if ready, ok := p.stitch(evt, s.clock.Now()); ok {
+						s.send(publish, ready)
 					}
-					highlightEvt.Fragments = []highlight.Fragment{{Text: evt.Line}}
+					continue
 				}
-				out <- highlightEvt
+				s.send(publish, evt)
 			}
 		}
 	}()
 	return out
 }
+
+// send processes one complete line and publishes it unless it is dropped.
+func (s Stream) send(publish func(HighlightedEvent), evt watch.LogEvent) {
+	highlightEvt, keep := s.Process(evt)
+	if !keep {
+		return
+	}
+	highlightEvt.Timestamp = evt.Time
+	if highlightEvt.Timestamp.IsZero() {
+		highlightEvt.Timestamp = s.clock.Now()
+	}
+	publish(highlightEvt)
+}
+
+// Process matches one line against the rules, applying the same severity
+// threshold and secret redaction as Connect. It reports false for lines the
+// stream would drop. Timestamp is left for the caller to set.
+//
+// Dropped lines, the bulk of a busy source outside show-all mode, return
+// before anything is built for them, so they cost no allocation.
+func (s Stream) Process(evt watch.LogEvent) (HighlightedEvent, bool) {
+	match, matched := s.rules.Match(evt.Line)
+	if !matched && evt.Rule != "" {
+		return s.forwarded(evt)
+	}
+	if !matched {
+		if !s.showAll {
+			return HighlightedEvent{}, false
+		}
+		return HighlightedEvent{
+			Path:      evt.Path,
+			Offset:    evt.Offset,
+			LineNo:    evt.LineNo,
+			Backlog:   evt.Backlog,
+			Line:      evt.Line,
+			Severity:  rules.SeverityNormal,
+			Fragments: highlight.Plain(evt.Line),
+		}, true
+	}
+	if !s.showAll && !rules.MeetsThreshold(match.Rule.Severity, s.minSeverity) {
+		return HighlightedEvent{}, false
+	}
+	line, spans := evt.Line, match.HighlightSpans
+	scan := s.rules.Current().Secrets
+	if len(match.Secrets) > 0 && scan.RedactsSecrets() {
+		redaction := secrets.Redact(evt.Line, match.Secrets)
+		line = redaction.Line
+		spans = append(redaction.MapSpans(spans), redaction.Spans...)
+		match.Captures = redactCaptures(match.Captures, match.RawCaptures, evt.Line, match.Secrets)
+	}
+	// A derived line may be decoded text (base64_decode), so it is scanned
+	// on its own.
+	if match.Derived != "" && scan.RedactsSecrets() {
+		match.Derived = secrets.Redact(match.Derived, scan.Scanner().Scan(match.Derived)).Line
+	}
+	return HighlightedEvent{
+		Path:      evt.Path,
+		Offset:    evt.Offset,
+		LineNo:    evt.LineNo,
+		Backlog:   evt.Backlog,
+		Line:      line,
+		RuleName:  match.Rule.Name,
+		Severity:  match.Rule.Severity,
+		Color:     match.Rule.Color,
+		Tags:      match.Rule.Tags,
+		Captures:  match.Captures,
+		Chain:     match.Chain,
+		Derived:   match.Derived,
+		Fragments: highlight.BuildFragments(line, spans),
+	}, true
+}
+
+// forwarded keeps the match a forwarding agent made (watch.LogEvent Rule and
+// Severity) for a line these rules do not match, so an aggregator with older
+// or narrower rules still sees the agent's alert. A severity this process
+// does not know counts as low.
+func (s Stream) forwarded(evt watch.LogEvent) (HighlightedEvent, bool) {
+	severity, err := rules.ParseSeverity(evt.Severity)
+	if err != nil || severity == rules.SeverityNormal {
+		severity = rules.SeverityLow
+	}
+	if !s.showAll && !rules.MeetsThreshold(severity, s.minSeverity) {
+		return HighlightedEvent{}, false
+	}
+	return HighlightedEvent{
+		Path:      evt.Path,
+		Offset:    evt.Offset,
+		LineNo:    evt.LineNo,
+		Backlog:   evt.Backlog,
+		Line:      evt.Line,
+		RuleName:  evt.Rule,
+		Severity:  severity,
+		Fragments: highlight.Plain(evt.Line),
+	}, true
+}
+
+// redactCaptures masks any capture value that contains text flagged as a
+// secret. A transform may have rewritten the value past recognition, so a
+// value whose raw (pre-transform) form held a secret is masked whole.
+func redactCaptures(captures, raw map[string]string, line string, found []secrets.Finding) map[string]string {
+	if len(captures) == 0 {
+		return captures
+	}
+	out := make(map[string]string, len(captures))
+	for name, value := range captures {
+		original, ok := raw[name]
+		if !ok {
+			original = value
+		}
+		transformed := value != original
+		for _, f := range found {
+			secret, mask := line[f.Start:f.End], "[REDACTED:"+f.Kind+"]"
+			if transformed && strings.Contains(original, secret) {
+				value = mask
+				break
+			}
+			value = strings.ReplaceAll(value, secret, mask)
+		}
+		out[name] = value
+	}
+	return out
+}
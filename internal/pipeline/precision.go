@@ -0,0 +1,48 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Precision is how many fractional-second digits a rendered timestamp shows.
+// Events always carry full resolution; this only affects display and text
+// output, so a burst within one second can be told apart on screen.
+type Precision int
+
+const (
+	Seconds Precision = iota
+	Millis
+	Micros
+)
+
+// ParsePrecision reads s, ms, or us; empty means seconds.
+func ParsePrecision(value string) (Precision, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "s":
+		return Seconds, nil
+	case "ms":
+		return Millis, nil
+	case "us", "µs":
+		return Micros, nil
+	}
+	return Seconds, fmt.Errorf("unknown precision %q (want s, ms, or us)", value)
+}
+
+// Layout extends a time layout ending in seconds ("15:04:05") with the
+// fractional digits p calls for.
+func (p Precision) Layout(base string) string {
+	switch p {
+	case Millis:
+		return base + ".000"
+	case Micros:
+		return base + ".000000"
+	}
+	return base
+}
+
+// RFC3339 is time.RFC3339 at precision p, with fixed-width fractions so text
+// output sorts as it reads.
+func (p Precision) RFC3339() string {
+	return p.Layout("2006-01-02T15:04:05") + "Z07:00"
+}
@@ -0,0 +1,187 @@
+package pipeline
+
+import (
+	"context"
+	"time"
+
+	"watcher/internal/clock"
+	"watcher/internal/watch"
+)
+
+// maxReorderHeld bounds how many events Reorder holds; past it the earliest
+// is released without waiting for quieter sources.
+const maxReorderHeld = 10000
+
+// minSweep is the shortest interval the windowed stages check their held
+// events at, so a window of a few nanoseconds cannot make a ticker panic or
+// spin.
+const minSweep = time.Millisecond
+
+// sweepEvery is how often a stage holding events for window checks them: a
+// quarter of the window, but at least minSweep.
+func sweepEvery(window time.Duration) time.Duration {
+	return max(window/4, minSweep)
+}
+
+// Reorder merges events from several sources by the timestamps written in
+// the lines themselves rather than by arrival, so a backfill of two files or
+// two busy live streams interleave the way things happened. Lines without a
+// recognizable timestamp take their source's previous one (or the arrival time
+// if none has been seen); lines from one source keep their order.
+//
+// An event is released once every source that delivered something in the
+// last window has an event waiting, so it is known to be the earliest; a
+// source silent for window is not waited for. A lone source is never delayed,
+// and a live line (stamped close to its arrival) at most by window, even when
+// another source's clock runs ahead. Errors and gap markers pass through at
+// once. A zero window returns in unchanged; a nil clk is the wall clock.
+func Reorder(ctx context.Context, in <-chan HighlightedEvent, window time.Duration, clk clock.Clock) <-chan HighlightedEvent {
+	if window <= 0 {
+		return in
+	}
+	clk = clock.Or(clk)
+	out := make(chan HighlightedEvent)
+	go func() {
+		defer close(out)
+		m := newMerger(clk.Now())
+		ticker := clk.NewTicker(sweepEvery(window))
+		defer ticker.Stop()
+		emit := func(events []HighlightedEvent) bool {
+			for _, evt := range events {
+				select {
+				case <-ctx.Done():
+					return false
+				case out <- evt:
+				}
+			}
+			return true
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C():
+				if !emit(m.ready(now, window)) {
+					return
+				}
+			case evt, ok := <-in:
+				if !ok {
+					emit(m.ready(time.Time{}, 0))
+					return
+				}
+				if evt.Err != nil || evt.Gap > 0 || evt.Conn != nil {
+					if !emit([]HighlightedEvent{evt}) {
+						return
+					}
+					continue
+				}
+				now := clk.Now()
+				m.add(evt, now)
+				if !emit(m.ready(now, window)) {
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// mergeSource is one source's held events, in arrival order, and what is
+// known about its clock.
+type mergeSource struct {
+	queue    []heldMerge
+	lastKey  time.Time
+	lastSeen time.Time
+}
+
+type heldMerge struct {
+	evt HighlightedEvent
+	key time.Time
+	at  time.Time
+	seq uint64
+}
+
+// overdue reports whether a live line (stamped within window of its arrival)
+// has waited window already. Backfilled lines wait as long as a source that
+// may still precede them is active, up to maxReorderHeld.
+func (h heldMerge) overdue(now time.Time, window time.Duration) bool {
+	return now.Sub(h.at) >= window && h.key.After(h.at.Add(-window))
+}
+
+// before orders by timestamp, then by arrival for equal stamps.
+func (h heldMerge) before(other heldMerge) bool {
+	if !h.key.Equal(other.key) {
+		return h.key.Before(other.key)
+	}
+	return h.seq < other.seq
+}
+
+// merger is a k-way merge over per-source queues. Nothing is released during
+// the first window after start, so every source that starts together (a
+// backfill) is known before the merge begins.
+type merger struct {
+	sources map[string]*mergeSource
+	started time.Time
+	held    int
+	seq     uint64
+}
+
+func newMerger(started time.Time) *merger {
+	return &merger{sources: make(map[string]*mergeSource), started: started}
+}
+
+func (m *merger) add(evt HighlightedEvent, now time.Time) {
+	// Each pipeline's copy of a source is its own ordered stream.
+	name := evt.Pipeline + "\x00" + evt.Path
+	src, ok := m.sources[name]
+	if !ok {
+		src = &mergeSource{}
+		m.sources[name] = src
+	}
+	key, ok := watch.ParseTimestamp(evt.Line, now)
+	switch {
+	case ok:
+	case !src.lastKey.IsZero():
+		key = src.lastKey
+	default:
+		key = now
+	}
+	// A source's own lines stay in order even if its stamps step back.
+	if key.Before(src.lastKey) {
+		key = src.lastKey
+	}
+	src.lastKey = key
+	src.lastSeen = now
+	src.queue = append(src.queue, heldMerge{evt: evt, key: key, at: now, seq: m.seq})
+	m.seq++
+	m.held++
+}
+
+// ready removes and returns, earliest first, the events that no recently
+// active source can still precede. A zero now releases everything.
+func (m *merger) ready(now time.Time, window time.Duration) []HighlightedEvent {
+	var out []HighlightedEvent
+	for m.held > 0 {
+		var next *mergeSource
+		blocked, overdue := !now.IsZero() && now.Sub(m.started) < window, now.IsZero()
+		for _, src := range m.sources {
+			if len(src.queue) == 0 {
+				if !now.IsZero() && now.Sub(src.lastSeen) < window {
+					blocked = true
+				}
+				continue
+			}
+			if next == nil || src.queue[0].before(next.queue[0]) {
+				next = src
+			}
+			overdue = overdue || src.queue[0].overdue(now, window)
+		}
+		if blocked && !overdue && m.held <= maxReorderHeld {
+			break
+		}
+		out = append(out, next.queue[0].evt)
+		next.queue = next.queue[1:]
+		m.held--
+	}
+	return out
+}
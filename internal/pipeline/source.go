@@ -0,0 +1,89 @@
+package pipeline
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"watcher/internal/watch"
+)
+
+// MultilineSeparator joins stitched continuation lines so a stack trace stays
+// one event (and one row in the UI) while remaining visibly multi-line.
+const MultilineSeparator = " ⏎ "
+
+var moreFrames = regexp.MustCompile(`^\.\.\. \d+ (more|common frames omitted)`)
+
+// isContinuation reports whether line continues the previous one: indented
+// lines (stack frames, wrapped messages) and Java's "Caused by:"/"... N more".
+func isContinuation(line string) bool {
+	if line == "" {
+		return false
+	}
+	if line[0] == ' ' || line[0] == '\t' {
+		return true
+	}
+	return strings.HasPrefix(line, "Caused by:") || moreFrames.MatchString(line)
+}
+
+// sourceParser is the state one source carries between lines: its detected
+// encoding and, with multiline stitching on, the event still collecting
+// continuation lines. Connect keeps one per source path, so interleaved
+// sources never decode or stitch with each other's state.
+type sourceParser struct {
+	decoder   Decoder
+	pending   *watch.LogEvent
+	pendingAt time.Time
+	// stitched collects the pending line and its continuations, so a long
+	// trace is joined in one pass rather than recopied per line.
+	stitched strings.Builder
+	// lines counts the lines delivered so far, for LogEvent.LineNo. It
+	// restarts when offset shows the file was truncated or replaced.
+	lines  int64
+	offset int64
+}
+
+// stitch buffers evt as the head of a possibly multi-line event and returns
+// the previous head once evt shows it is complete. A continuation that would
+// take the event past MaxStitchedBytes completes it and starts the next one.
+func (p *sourceParser) stitch(evt watch.LogEvent, now time.Time) (watch.LogEvent, bool) {
+	if p.pending != nil && isContinuation(evt.Line) {
+		size := p.stitched.Len()
+		if size == 0 {
+			size = len(p.pending.Line)
+		}
+		if size+len(MultilineSeparator)+len(evt.Line) <= MaxStitchedBytes {
+			if p.stitched.Len() == 0 {
+				p.stitched.WriteString(p.pending.Line)
+			}
+			p.stitched.WriteString(MultilineSeparator)
+			p.stitched.WriteString(strings.TrimSpace(evt.Line))
+			p.pendingAt = now
+			return watch.LogEvent{}, false
+		}
+	}
+	prev, ok := p.take()
+	p.pending = &evt
+	p.pendingAt = now
+	return prev, ok
+}
+
+// take returns and clears the buffered event, if any.
+func (p *sourceParser) take() (watch.LogEvent, bool) {
+	if p.pending == nil {
+		return watch.LogEvent{}, false
+	}
+	evt := *p.pending
+	if p.stitched.Len() > 0 {
+		evt.Line = p.stitched.String()
+		p.stitched.Reset()
+	}
+	p.pending = nil
+	return evt, true
+}
+
+// expired reports whether the buffered event has waited longer than wait for
+// continuations.
+func (p *sourceParser) expired(now time.Time, wait time.Duration) bool {
+	return p.pending != nil && now.Sub(p.pendingAt) >= wait
+}
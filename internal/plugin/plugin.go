@@ -0,0 +1,211 @@
+// Package plugin runs user-supplied Go source files as a pipeline stage. Each
+// file in the --plugins directory is interpreted (Yaegi, no compiler needed)
+// and must define
+//
+//	import "spectra/plugin"
+//
+//	func Transform(evt plugin.Event) (plugin.Event, bool)
+//
+// returning the possibly changed event and false to drop it. Plugins run in
+// file name order on every matched event, after matching and thresholds.
+// Their stdout and stderr are discarded.
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync/atomic"
+
+	"github.com/traefik/yaegi/interp"
+	"github.com/traefik/yaegi/stdlib"
+
+	"watcher/internal/highlight"
+	"watcher/internal/pipeline"
+	"watcher/internal/rules"
+)
+
+// Event is the view of a highlighted event that plugins read and change.
+// Severity must stay one of critical, high, medium, low, or normal.
+type Event struct {
+	Path     string
+	Line     string
+	Rule     string
+	Severity string
+	Tags     []string
+	Captures map[string]string
+}
+
+// Transform is the signature every plugin file exports.
+type Transform func(Event) (Event, bool)
+
+// Plugin is one interpreted file.
+type Plugin struct {
+	Name      string
+	transform Transform
+	failures  atomic.Uint64
+}
+
+// Failures reports how many events the plugin panicked on or returned an
+// invalid severity for; those events pass through unchanged.
+func (p *Plugin) Failures() uint64 {
+	return p.failures.Load()
+}
+
+// symbols exposes Event to interpreted code as package "spectra/plugin".
+var symbols = interp.Exports{
+	"spectra/plugin/plugin": {
+		"Event": reflect.ValueOf((*Event)(nil)),
+	},
+}
+
+// LoadDir interprets every .go file in dir, in name order.
+func LoadDir(dir string) ([]*Plugin, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	plugins := make([]*Plugin, 0, len(paths))
+	for _, path := range paths {
+		p, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		plugins = append(plugins, p)
+	}
+	return plugins, nil
+}
+
+// Load interprets one plugin file and looks up its Transform function.
+func Load(path string) (*Plugin, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("load plugin: %w", err)
+	}
+	// Plugin output would land on the dashboard's screen.
+	i := interp.New(interp.Options{Stdout: io.Discard, Stderr: io.Discard})
+	if err := i.Use(stdlib.Symbols); err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", path, err)
+	}
+	if err := i.Use(symbols); err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", path, err)
+	}
+	prog, err := i.Compile(string(src))
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", path, err)
+	}
+	if _, err := i.Execute(prog); err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", path, err)
+	}
+	pkg := prog.PackageName()
+	v, err := i.Eval(pkg + ".Transform")
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: no Transform function: %w", path, err)
+	}
+	fn, ok := v.Interface().(func(Event) (Event, bool))
+	if !ok {
+		return nil, fmt.Errorf("plugin %s: Transform must be func(plugin.Event) (plugin.Event, bool)", path)
+	}
+	return &Plugin{Name: filepath.Base(path), transform: fn}, nil
+}
+
+// Stage runs matched events through the plugins in order. Unmatched lines,
+// errors, and gap markers pass through untouched. With no plugins in is
+// returned unchanged.
+func Stage(ctx context.Context, in <-chan pipeline.HighlightedEvent, plugins []*Plugin) <-chan pipeline.HighlightedEvent {
+	if len(plugins) == 0 {
+		return in
+	}
+	out := make(chan pipeline.HighlightedEvent)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case evt, ok := <-in:
+				if !ok {
+					return
+				}
+				if evt.RuleName != "" && evt.Err == nil && evt.Gap == 0 {
+					if evt, ok = apply(plugins, evt); !ok {
+						continue
+					}
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case out <- evt:
+				}
+			}
+		}
+	}()
+	return out
+}
+
+func apply(plugins []*Plugin, evt pipeline.HighlightedEvent) (pipeline.HighlightedEvent, bool) {
+	for _, p := range plugins {
+		view, keep, err := p.run(toEvent(evt))
+		if err != nil {
+			p.failures.Add(1)
+			continue
+		}
+		if !keep {
+			return evt, false
+		}
+		if evt, err = fromEvent(evt, view); err != nil {
+			p.failures.Add(1)
+		}
+	}
+	return evt, true
+}
+
+// run calls the plugin, turning a panic in interpreted code into an error.
+func (p *Plugin) run(evt Event) (out Event, keep bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("plugin %s: %v", p.Name, r)
+		}
+	}()
+	out, keep = p.transform(evt)
+	return out, keep, nil
+}
+
+func toEvent(evt pipeline.HighlightedEvent) Event {
+	captures := make(map[string]string, len(evt.Captures))
+	for k, v := range evt.Captures {
+		captures[k] = v
+	}
+	return Event{
+		Path:     evt.Path,
+		Line:     evt.Line,
+		Rule:     evt.RuleName,
+		Severity: string(evt.Severity),
+		Tags:     append([]string(nil), evt.Tags...),
+		Captures: captures,
+	}
+}
+
+// fromEvent copies a plugin's changes onto evt. A rewritten line loses its
+// match highlighting, since the old spans no longer apply.
+func fromEvent(evt pipeline.HighlightedEvent, view Event) (pipeline.HighlightedEvent, error) {
+	sev, err := rules.ParseSeverity(view.Severity)
+	if err != nil {
+		return evt, err
+	}
+	if view.Line != evt.Line {
+		evt.Fragments = highlight.BuildFragments(view.Line, nil)
+	}
+	evt.Path = view.Path
+	evt.Line = view.Line
+	evt.RuleName = view.Rule
+	evt.Severity = sev
+	evt.Tags = view.Tags
+	evt.Captures = view.Captures
+	return evt, nil
+}
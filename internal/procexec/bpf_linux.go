@@ -0,0 +1,378 @@
+package procexec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// ringSize is the BPF ring buffer the program writes events into.
+	ringSize = 256 << 10
+	// eventSize is the record the program emits: pid_tgid, uid_gid, the
+	// 16-byte comm, and the first 256 bytes of the executed path.
+	eventSize = 8 + 8 + 16 + 256
+
+	bpfMapCreate = 0
+	bpfProgLoad  = 5
+
+	bpfMapTypeRingbuf     = 27
+	bpfProgTypeTracepoint = 5
+	bpfPseudoMapFD        = 1
+
+	helperGetCurrentPidTgid     = 14
+	helperGetCurrentUidGid      = 15
+	helperGetCurrentComm        = 16
+	helperProbeReadKernelString = 115
+	helperRingbufOutput         = 130
+
+	ringBusy    = 1 << 31
+	ringDiscard = 1 << 30
+)
+
+// tracefsRoots are where the tracepoint ids are looked up.
+var tracefsRoots = []string{"/sys/kernel/tracing", "/sys/kernel/debug/tracing"}
+
+// Monitor is the loaded program and the ring buffer it fills.
+type Monitor struct {
+	mu       sync.Mutex
+	closed   bool
+	mapFD    int
+	progFD   int
+	perfFD   int
+	consumer []byte
+	producer []byte
+	data     []byte
+}
+
+// Open loads the exec program, attaches it to sched:sched_process_exec, and
+// maps its ring buffer. It needs Linux 5.8 or later, CAP_BPF and
+// CAP_PERFMON (or root), and tracefs.
+func Open() (*Monitor, error) {
+	id, err := tracepointID("sched", "sched_process_exec")
+	if err != nil {
+		return nil, err
+	}
+	m := &Monitor{mapFD: -1, progFD: -1, perfFD: -1}
+	if m.mapFD, err = createRingbuf(); err != nil {
+		return nil, err
+	}
+	if m.progFD, err = loadProgram(m.mapFD); err != nil {
+		m.Close()
+		return nil, err
+	}
+	if err := m.attach(id); err != nil {
+		m.Close()
+		return nil, err
+	}
+	if err := m.mapRing(); err != nil {
+		m.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// Read returns the next execution, waiting for one. It returns
+// os.ErrClosed once the monitor is closed.
+func (m *Monitor) Read() (Event, error) {
+	for {
+		m.mu.Lock()
+		if m.closed {
+			m.mu.Unlock()
+			return Event{}, os.ErrClosed
+		}
+		raw, ok := m.next()
+		fd := m.mapFD
+		m.mu.Unlock()
+		if ok {
+			e := decode(raw)
+			enrich(&e, "/proc")
+			return e, nil
+		}
+		// A bounded wait, so Close is noticed even if no process starts.
+		fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+		if _, err := unix.Poll(fds, 250); err != nil && !errors.Is(err, unix.EINTR) {
+			return Event{}, fmt.Errorf("exec monitor: poll: %w", err)
+		}
+	}
+}
+
+// Close detaches the program and releases the ring buffer.
+func (m *Monitor) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil
+	}
+	m.closed = true
+	if m.producer != nil {
+		unix.Munmap(m.producer)
+	}
+	if m.consumer != nil {
+		unix.Munmap(m.consumer)
+	}
+	for _, fd := range []int{m.perfFD, m.progFD, m.mapFD} {
+		if fd >= 0 {
+			unix.Close(fd)
+		}
+	}
+	return nil
+}
+
+// next consumes one record from the ring, skipping discarded ones.
+func (m *Monitor) next() ([]byte, bool) {
+	consumerPos := (*uint64)(unsafe.Pointer(&m.consumer[0]))
+	producerPos := (*uint64)(unsafe.Pointer(&m.producer[0]))
+	cons := atomic.LoadUint64(consumerPos)
+	prod := atomic.LoadUint64(producerPos)
+	for cons < prod {
+		off := cons & (ringSize - 1)
+		header := atomic.LoadUint32((*uint32)(unsafe.Pointer(&m.data[off])))
+		if header&ringBusy != 0 {
+			return nil, false
+		}
+		n := uint64(header &^ (ringBusy | ringDiscard))
+		// The data pages are mapped twice in a row, so a record that wraps
+		// around the end is still contiguous.
+		var raw []byte
+		if header&ringDiscard == 0 {
+			raw = append([]byte(nil), m.data[off+8:off+8+n]...)
+		}
+		cons += (n + 8 + 7) &^ 7
+		atomic.StoreUint64(consumerPos, cons)
+		if raw != nil {
+			return raw, true
+		}
+	}
+	return nil, false
+}
+
+func decode(raw []byte) Event {
+	if len(raw) < eventSize {
+		raw = append(raw, make([]byte, eventSize-len(raw))...)
+	}
+	pidTgid := binary.LittleEndian.Uint64(raw[0:8])
+	uidGid := binary.LittleEndian.Uint64(raw[8:16])
+	return Event{
+		PID:      int(pidTgid >> 32),
+		UID:      uint32(uidGid),
+		GID:      uint32(uidGid >> 32),
+		Comm:     cString(raw[16:32]),
+		Filename: cString(raw[32:eventSize]),
+	}
+}
+
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+func tracepointID(group, name string) (uint64, error) {
+	var lastErr error
+	for _, root := range tracefsRoots {
+		raw, err := os.ReadFile(filepath.Join(root, "events", group, name, "id"))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return strconv.ParseUint(strings.TrimSpace(string(raw)), 10, 64)
+	}
+	return 0, fmt.Errorf("exec monitor: tracepoint %s:%s not found (is tracefs mounted?): %w", group, name, lastErr)
+}
+
+func bpf(cmd uintptr, attr unsafe.Pointer, size uintptr) (int, error) {
+	fd, _, errno := unix.Syscall(unix.SYS_BPF, cmd, uintptr(attr), size)
+	if errno != 0 {
+		return -1, errno
+	}
+	return int(fd), nil
+}
+
+func createRingbuf() (int, error) {
+	attr := struct {
+		mapType    uint32
+		keySize    uint32
+		valueSize  uint32
+		maxEntries uint32
+		flags      uint32
+	}{mapType: bpfMapTypeRingbuf, maxEntries: ringSize}
+	fd, err := bpf(bpfMapCreate, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+	if errors.Is(err, unix.EPERM) {
+		// Before Linux 5.11 BPF memory counts against RLIMIT_MEMLOCK.
+		unix.Setrlimit(unix.RLIMIT_MEMLOCK, &unix.Rlimit{Cur: unix.RLIM_INFINITY, Max: unix.RLIM_INFINITY})
+		fd, err = bpf(bpfMapCreate, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+	}
+	if err != nil {
+		return -1, fmt.Errorf("exec monitor: create ring buffer: %w%s", err, permissionHint(err))
+	}
+	return fd, nil
+}
+
+// insn is one eBPF instruction.
+type insn struct {
+	code uint8
+	regs uint8 // dst in the low nibble, src in the high one
+	off  int16
+	imm  int32
+}
+
+func op(code, dst, src uint8, off int16, imm int32) insn {
+	return insn{code: code, regs: dst | src<<4, off: off, imm: imm}
+}
+
+// program is, in C:
+//
+//	struct event e;
+//	e.pid_tgid = bpf_get_current_pid_tgid();
+//	e.uid_gid = bpf_get_current_uid_gid();
+//	bpf_get_current_comm(e.comm, 16);
+//	bpf_probe_read_kernel_str(e.filename, 256, (void *)ctx + (ctx->__data_loc_filename & 0xffff));
+//	bpf_ringbuf_output(&events, &e, sizeof(e), 0);
+//	return 0;
+//
+// with e at the bottom of the 512-byte stack (r10-288).
+func program(mapFD int) []insn {
+	const (
+		movReg  = 0xbf // BPF_ALU64 | BPF_MOV | BPF_X
+		movImm  = 0xb7 // BPF_ALU64 | BPF_MOV | BPF_K
+		addImm  = 0x07 // BPF_ALU64 | BPF_ADD | BPF_K
+		addReg  = 0x0f // BPF_ALU64 | BPF_ADD | BPF_X
+		andImm  = 0x57 // BPF_ALU64 | BPF_AND | BPF_K
+		storeDW = 0x7b // BPF_STX | BPF_MEM | BPF_DW
+		loadW   = 0x61 // BPF_LDX | BPF_MEM | BPF_W
+		ldImm64 = 0x18 // BPF_LD | BPF_DW | BPF_IMM
+		call    = 0x85
+		exit    = 0x95
+		base    = -eventSize
+	)
+	return []insn{
+		op(movReg, 6, 1, 0, 0),
+		op(call, 0, 0, 0, helperGetCurrentPidTgid),
+		op(storeDW, 10, 0, base, 0),
+		op(call, 0, 0, 0, helperGetCurrentUidGid),
+		op(storeDW, 10, 0, base+8, 0),
+		op(movReg, 1, 10, 0, 0),
+		op(addImm, 1, 0, 0, base+16),
+		op(movImm, 2, 0, 0, 16),
+		op(call, 0, 0, 0, helperGetCurrentComm),
+		op(loadW, 3, 6, 8, 0),
+		op(andImm, 3, 0, 0, 0xffff),
+		op(addReg, 3, 6, 0, 0),
+		op(movReg, 1, 10, 0, 0),
+		op(addImm, 1, 0, 0, base+32),
+		op(movImm, 2, 0, 0, 256),
+		op(call, 0, 0, 0, helperProbeReadKernelString),
+		op(ldImm64, 1, bpfPseudoMapFD, 0, int32(mapFD)),
+		op(0, 0, 0, 0, 0),
+		op(movReg, 2, 10, 0, 0),
+		op(addImm, 2, 0, 0, base),
+		op(movImm, 3, 0, 0, eventSize),
+		op(movImm, 4, 0, 0, 0),
+		op(call, 0, 0, 0, helperRingbufOutput),
+		op(movImm, 0, 0, 0, 0),
+		op(exit, 0, 0, 0, 0),
+	}
+}
+
+func loadProgram(mapFD int) (int, error) {
+	insns := program(mapFD)
+	license := []byte("GPL\x00")
+	name := [16]byte{}
+	copy(name[:], "spectra_exec")
+	load := func(logBuf []byte) (int, error) {
+		attr := struct {
+			progType    uint32
+			insnCount   uint32
+			insns       uint64
+			license     uint64
+			logLevel    uint32
+			logSize     uint32
+			logBuf      uint64
+			kernVersion uint32
+			progFlags   uint32
+			progName    [16]byte
+		}{
+			progType:  bpfProgTypeTracepoint,
+			insnCount: uint32(len(insns)),
+			insns:     uint64(uintptr(unsafe.Pointer(&insns[0]))),
+			license:   uint64(uintptr(unsafe.Pointer(&license[0]))),
+			progName:  name,
+		}
+		if len(logBuf) > 0 {
+			attr.logLevel = 1
+			attr.logSize = uint32(len(logBuf))
+			attr.logBuf = uint64(uintptr(unsafe.Pointer(&logBuf[0])))
+		}
+		return bpf(bpfProgLoad, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+	}
+	fd, err := load(nil)
+	if err == nil {
+		return fd, nil
+	}
+	// Load again with the verifier log, to say why.
+	logBuf := make([]byte, 64<<10)
+	if _, retry := load(logBuf); retry != nil {
+		if log := strings.TrimSpace(cString(logBuf)); log != "" {
+			return -1, fmt.Errorf("exec monitor: load program: %w%s\n%s", err, permissionHint(err), log)
+		}
+	}
+	return -1, fmt.Errorf("exec monitor: load program: %w%s", err, permissionHint(err))
+}
+
+func (m *Monitor) attach(tracepoint uint64) error {
+	attr := unix.PerfEventAttr{
+		Type:        unix.PERF_TYPE_TRACEPOINT,
+		Config:      tracepoint,
+		Sample_type: unix.PERF_SAMPLE_RAW,
+		Sample:      1,
+		Wakeup:      1,
+	}
+	attr.Size = uint32(unsafe.Sizeof(attr))
+	// One event suffices: a tracepoint runs its programs on every CPU.
+	fd, err := unix.PerfEventOpen(&attr, -1, 0, -1, unix.PERF_FLAG_FD_CLOEXEC)
+	if err != nil {
+		return fmt.Errorf("exec monitor: open tracepoint: %w%s", err, permissionHint(err))
+	}
+	m.perfFD = fd
+	if err := unix.IoctlSetInt(fd, unix.PERF_EVENT_IOC_SET_BPF, m.progFD); err != nil {
+		return fmt.Errorf("exec monitor: attach program: %w", err)
+	}
+	if err := unix.IoctlSetInt(fd, unix.PERF_EVENT_IOC_ENABLE, 0); err != nil {
+		return fmt.Errorf("exec monitor: enable tracepoint: %w", err)
+	}
+	return nil
+}
+
+// mapRing maps the consumer page read-write and the producer page plus the
+// (doubly mapped) data pages read-only.
+func (m *Monitor) mapRing() error {
+	page := os.Getpagesize()
+	var err error
+	if m.consumer, err = unix.Mmap(m.mapFD, 0, page, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED); err != nil {
+		return fmt.Errorf("exec monitor: map ring buffer: %w", err)
+	}
+	if m.producer, err = unix.Mmap(m.mapFD, int64(page), page+2*ringSize, unix.PROT_READ, unix.MAP_SHARED); err != nil {
+		return fmt.Errorf("exec monitor: map ring buffer: %w", err)
+	}
+	m.data = m.producer[page:]
+	return nil
+}
+
+func permissionHint(err error) string {
+	if errors.Is(err, unix.EPERM) || errors.Is(err, unix.EACCES) {
+		return " (needs root, or CAP_BPF and CAP_PERFMON)"
+	}
+	return ""
+}
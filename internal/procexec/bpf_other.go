@@ -0,0 +1,27 @@
+//go:build !linux
+
+package procexec
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// Monitor is the eBPF exec monitor, which only Linux has.
+type Monitor struct{}
+
+// Open reports that the exec monitor is not available here.
+func Open() (*Monitor, error) {
+	return nil, fmt.Errorf("exec monitor: eBPF is not supported on %s", runtime.GOOS)
+}
+
+// Read never returns an event.
+func (m *Monitor) Read() (Event, error) {
+	return Event{}, os.ErrClosed
+}
+
+// Close does nothing.
+func (m *Monitor) Close() error {
+	return nil
+}
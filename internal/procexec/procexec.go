@@ -0,0 +1,81 @@
+// Package procexec reports process executions as log lines, from an eBPF
+// program on the sched_process_exec tracepoint, so rules have something to
+// match on a host where nothing writes a log file.
+package procexec
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxArgv bounds the command line kept per event.
+const maxArgv = 4096
+
+// Event is one successful execve.
+type Event struct {
+	PID      int
+	PPID     int
+	UID      uint32
+	GID      uint32
+	Comm     string
+	Parent   string
+	Filename string
+	// Argv is empty when the process exited before /proc could be read.
+	Argv []string
+}
+
+// Line renders the event for rule matching, always in this field order:
+//
+//	exec pid=4242 ppid=981 parent=bash uid=1000 gid=1000 comm=curl filename=/usr/bin/curl argv="curl -s https://example.com"
+//
+// filename and argv are quoted when they contain spaces, quotes, or
+// control characters.
+func (e Event) Line() string {
+	argv := strings.Join(e.Argv, " ")
+	if len(argv) > maxArgv {
+		argv = argv[:maxArgv] + "…"
+	}
+	return fmt.Sprintf("exec pid=%d ppid=%d parent=%s uid=%d gid=%d comm=%s filename=%s argv=%q",
+		e.PID, e.PPID, field(e.Parent), e.UID, e.GID, field(e.Comm), field(e.Filename), argv)
+}
+
+// field quotes a value that would otherwise run into the next key=value.
+func field(s string) string {
+	if s == "" {
+		return "-"
+	}
+	if strings.ContainsAny(s, " \"=") || strconv.Quote(s) != `"`+s+`"` {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// enrich fills what the kernel event does not carry from /proc: the command
+// line, the parent pid, and the parent's name. A process that has already
+// exited keeps only what the tracepoint saw.
+func enrich(e *Event, proc string) {
+	dir := filepath.Join(proc, strconv.Itoa(e.PID))
+	if raw, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+		raw = bytes.TrimRight(raw, "\x00")
+		if len(raw) > 0 {
+			e.Argv = strings.Split(string(raw), "\x00")
+		}
+	}
+	if stat, err := os.ReadFile(filepath.Join(dir, "stat")); err == nil {
+		// The comm in parentheses may itself hold spaces or ")".
+		if end := bytes.LastIndexByte(stat, ')'); end > 0 {
+			if fields := strings.Fields(string(stat[end+1:])); len(fields) > 1 {
+				e.PPID, _ = strconv.Atoi(fields[1])
+			}
+		}
+	}
+	if e.PPID > 0 {
+		if comm, err := os.ReadFile(filepath.Join(proc, strconv.Itoa(e.PPID), "comm")); err == nil {
+			e.Parent = strings.TrimSpace(string(comm))
+		}
+	}
+}
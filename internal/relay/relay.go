@@ -0,0 +1,138 @@
+// Package relay is the wire format between a spectra agent and the
+// aggregator it forwards to: a TLS stream of JSON records, one per line, each
+// naming the host and file it was read from.
+package relay
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+// maxRecordBytes bounds one encoded record: a maximum-length line plus its
+// JSON escaping and the other fields.
+const maxRecordBytes = 8 << 20
+
+// Record is one forwarded event. Rule and Severity are what the agent's rules
+// made of the line; the aggregator matches it again with its own.
+type Record struct {
+	Host     string    `json:"host"`
+	Path     string    `json:"path"`
+	Time     time.Time `json:"time"`
+	Line     string    `json:"line"`
+	Rule     string    `json:"rule,omitempty"`
+	Severity string    `json:"severity,omitempty"`
+}
+
+// Source is the record's path as the aggregator shows it, host:path, so
+// the same file on two hosts stays two sources.
+func (r Record) Source() string {
+	return r.Host + ":" + r.Path
+}
+
+// Marshal encodes the record as one line, newline included.
+func (r Record) Marshal() ([]byte, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// Reader decodes records from a connection.
+type Reader struct {
+	scanner *bufio.Scanner
+}
+
+// NewReader reads records from r.
+func NewReader(r io.Reader) *Reader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxRecordBytes)
+	return &Reader{scanner: scanner}
+}
+
+// Read returns the next record, or io.EOF once the agent hangs up. A record
+// without a host or path is an error.
+func (r *Reader) Read() (Record, error) {
+	for r.scanner.Scan() {
+		if len(r.scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(r.scanner.Bytes(), &rec); err != nil {
+			return Record{}, fmt.Errorf("decode record: %w", err)
+		}
+		if rec.Host == "" || rec.Path == "" {
+			return Record{}, errors.New("record without host or path")
+		}
+		return rec, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return Record{}, err
+	}
+	return Record{}, io.EOF
+}
+
+// ClientConfig is the agent's TLS configuration for dialing addr. The
+// aggregator's certificate is verified against caFile, or the system roots
+// when it is empty; certFile and keyFile, if given, are the agent's own
+// certificate for an aggregator that requires one.
+func ClientConfig(addr, caFile, certFile, keyFile string) (*tls.Config, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("forward address %q: want host:port", addr)
+	}
+	cfg := &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		if cfg.RootCAs, err = certPool(caFile); err != nil {
+			return nil, err
+		}
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load agent certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// ServerConfig is the aggregator's TLS configuration. With caFile set, every
+// agent must present a certificate it signed.
+func ServerConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("agents listener needs a certificate and key")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load aggregator certificate: %w", err)
+	}
+	cfg := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		if cfg.ClientCAs, err = certPool(caFile); err != nil {
+			return nil, err
+		}
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+func certPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%s: no PEM certificates", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,85 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Action is the command a rule runs for its matches (rule `exec`). Arguments
+// go straight to the program, never through a shell, so a capture value
+// cannot start another command; Render also refuses one that would make an
+// argument start with `-`, which the program would read as an option.
+// ${name} in an argument is replaced by the capture of that name, or by the
+// rule name or source path for ${rule} and ${path}.
+type Action struct {
+	Rule string
+	Exec []string
+	// AutoApprove runs the command without asking; otherwise the dashboard
+	// queues it for confirmation.
+	AutoApprove bool
+}
+
+var actionPlaceholder = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// compileAction validates a rule's exec list. The program itself must be
+// fixed text; only its arguments may carry placeholders.
+func compileAction(def RuleDefinition) (*Action, error) {
+	if len(def.Exec) == 0 {
+		if def.AutoApprove {
+			return nil, fmt.Errorf("auto_approve without exec")
+		}
+		return nil, nil
+	}
+	program := strings.TrimSpace(def.Exec[0])
+	if program == "" {
+		return nil, fmt.Errorf("exec: empty program")
+	}
+	if strings.Contains(program, "${") {
+		return nil, fmt.Errorf("exec: program %q may not contain placeholders", program)
+	}
+	return &Action{Rule: def.Name, Exec: append([]string(nil), def.Exec...), AutoApprove: def.AutoApprove}, nil
+}
+
+// Render fills in the placeholders for one match. A placeholder naming a
+// capture the match does not have is an error, so a command never runs with
+// an argument silently left empty, and so is an argument that only starts
+// with `-` once filled in (a capture of `--flush` injecting an option).
+func (a Action) Render(path string, captures map[string]string) ([]string, error) {
+	argv := make([]string, len(a.Exec))
+	for i, arg := range a.Exec {
+		var missing string
+		argv[i] = actionPlaceholder.ReplaceAllStringFunc(arg, func(token string) string {
+			name := actionPlaceholder.FindStringSubmatch(token)[1]
+			switch name {
+			case "rule":
+				return a.Rule
+			case "path":
+				return path
+			}
+			value, ok := captures[name]
+			if !ok || value == "" {
+				missing = name
+			}
+			return value
+		})
+		if missing != "" {
+			return nil, fmt.Errorf("action for %q: no capture %q", a.Rule, missing)
+		}
+		if strings.HasPrefix(argv[i], "-") && !strings.HasPrefix(arg, "-") {
+			return nil, fmt.Errorf("action for %q: argument %q would start with '-'", a.Rule, argv[i])
+		}
+	}
+	return argv, nil
+}
+
+// Actions returns the exec actions of the rule set by rule name.
+func (rs RuleSet) Actions() map[string]Action {
+	out := make(map[string]Action)
+	for _, rule := range rs.Rules {
+		if rule.Action != nil {
+			out[rule.Name] = *rule.Action
+		}
+	}
+	return out
+}
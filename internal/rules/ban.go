@@ -0,0 +1,54 @@
+package rules
+
+import (
+	"fmt"
+	"time"
+)
+
+// Ban turns a rule's matches into ban list entries (rule `ban`): once the
+// address in Capture has matched After times within Within, it is banned for
+// For. Defaults: capture ip, after 1, within 10m, for 1h.
+type Ban struct {
+	Capture string        `yaml:"capture"`
+	After   int           `yaml:"after"`
+	Within  time.Duration `yaml:"within"`
+	For     time.Duration `yaml:"for"`
+}
+
+// compileBan validates a rule's ban settings and fills in the defaults.
+func compileBan(def *Ban) (*Ban, error) {
+	if def == nil {
+		return nil, nil
+	}
+	ban := *def
+	if ban.Capture == "" {
+		ban.Capture = "ip"
+	}
+	if ban.After == 0 {
+		ban.After = 1
+	}
+	if ban.After < 1 {
+		return nil, fmt.Errorf("ban: after must be at least 1")
+	}
+	if ban.Within == 0 {
+		ban.Within = 10 * time.Minute
+	}
+	if ban.For == 0 {
+		ban.For = time.Hour
+	}
+	if ban.Within < 0 || ban.For < time.Second {
+		return nil, fmt.Errorf("ban: within must be positive and for at least 1s")
+	}
+	return &ban, nil
+}
+
+// Bans returns the ban settings of the rule set by rule name.
+func (rs RuleSet) Bans() map[string]Ban {
+	out := make(map[string]Ban)
+	for _, rule := range rs.Rules {
+		if rule.Ban != nil {
+			out[rule.Name] = *rule.Ban
+		}
+	}
+	return out
+}
@@ -0,0 +1,127 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxChainDepth bounds how many derived lines one raw line can lead to, so a
+// rule file cannot make matching arbitrarily expensive.
+const maxChainDepth = 4
+
+// compileEmit validates a rule's emits template: ${name} placeholders, as in
+// exec arguments, filled from the rule's captures.
+func compileEmit(def RuleDefinition) (string, error) {
+	if def.Emits == "" {
+		return "", nil
+	}
+	if strings.TrimSpace(def.Emits) == "" {
+		return "", fmt.Errorf("emits: empty template")
+	}
+	if !actionPlaceholder.MatchString(def.Emits) {
+		return "", fmt.Errorf("emits: template %q uses no capture", def.Emits)
+	}
+	return def.Emits, nil
+}
+
+// renderEmit fills in an emits template. Like an exec action, a placeholder
+// naming a missing or empty capture means nothing is emitted.
+func renderEmit(tmpl string, captures map[string]string) (string, bool) {
+	ok := true
+	line := actionPlaceholder.ReplaceAllStringFunc(tmpl, func(token string) string {
+		value := captures[actionPlaceholder.FindStringSubmatch(token)[1]]
+		if value == "" {
+			ok = false
+		}
+		return value
+	})
+	return line, ok
+}
+
+// checkChains validates requires across the whole file, disabled rules
+// included: each must name another rule with emits, and following requires
+// upward must end at a rule that matches raw lines within maxChainDepth.
+func checkChains(defs []RuleDefinition) error {
+	byName := make(map[string]RuleDefinition, len(defs))
+	for _, def := range defs {
+		byName[def.Name] = def
+	}
+	for _, def := range defs {
+		if def.Requires == "" {
+			continue
+		}
+		steps, current := 0, def
+		for current.Requires != "" {
+			parent, ok := byName[current.Requires]
+			switch {
+			case !ok:
+				return fmt.Errorf("rule %q: requires unknown rule %q", current.Name, current.Requires)
+			case parent.Emits == "":
+				return fmt.Errorf("rule %q: requires %q, which has no emits", current.Name, current.Requires)
+			case parent.Name == def.Name:
+				return fmt.Errorf("rule %q: requires forms a cycle", def.Name)
+			}
+			if steps++; steps > maxChainDepth {
+				return fmt.Errorf("rule %q: chain deeper than %d rules", def.Name, maxChainDepth)
+			}
+			current = parent
+		}
+	}
+	return nil
+}
+
+// chain follows m through the rules requiring its rule, one derived line per
+// step, and returns each further match in order; none when m's rule emits
+// nothing or no dependent rule matches. A chained match keeps the captures
+// and highlight spans of the steps before it, and records them in Chain.
+func (rs RuleSet) chain(m Match) []Match {
+	var out []Match
+	for len(out) < maxChainDepth && m.Rule.Emits != "" {
+		derived, ok := renderEmit(m.Rule.Emits, m.Captures)
+		if !ok {
+			break
+		}
+		next, ok := rs.matchRequiring(m.Rule.Name, derived)
+		if !ok {
+			break
+		}
+		captures := make(map[string]string, len(m.Captures)+len(next.Captures))
+		for name, value := range m.Captures {
+			captures[name] = value
+		}
+		for name, value := range next.Captures {
+			captures[name] = value
+		}
+		raw := make(map[string]string, len(m.RawCaptures)+len(next.RawCaptures))
+		for name, value := range m.RawCaptures {
+			raw[name] = value
+		}
+		for name, value := range next.RawCaptures {
+			raw[name] = value
+		}
+		next.Captures, next.RawCaptures = captures, raw
+		next.HighlightSpans = m.HighlightSpans
+		next.Chain = append(append([]string(nil), m.Chain...), m.Rule.Name)
+		next.Derived = derived
+		out = append(out, next)
+		m = next
+	}
+	return out
+}
+
+// matchRequiring tests a derived line against the rules requiring the named
+// rule, in match order.
+func (rs RuleSet) matchRequiring(name, line string) (Match, bool) {
+	var literalHits *hitSet
+	defer func() { rs.literals.release(literalHits) }()
+	parsed := parsedLine{line: line}
+	for i, rule := range rs.sortedRules() {
+		if rule.Requires != name {
+			continue
+		}
+		if m, ok := rs.matchRule(i, rule, line, &parsed, &literalHits); ok {
+			return m, true
+		}
+	}
+	return Match{}, false
+}
@@ -0,0 +1,106 @@
+package rules
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// cidrTrie is a binary radix tree of network prefixes, one bit per level, so
+// checking an address against any number of ranges costs at most 128 steps.
+// IPv4 prefixes are stored in their IPv4-mapped IPv6 form, so one tree holds
+// both families and `::ffff:10.0.0.1` falls in 10.0.0.0/8.
+type cidrTrie struct {
+	root cidrNode
+}
+
+type cidrNode struct {
+	child [2]*cidrNode
+	// end marks the last bit of a prefix: every address below it matches.
+	end bool
+}
+
+// parseCIDRs builds a trie from comma-separated prefixes; a bare address
+// counts as a single-host prefix.
+func parseCIDRs(list string) (*cidrTrie, error) {
+	t := &cidrTrie{}
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(item)
+			if addrErr != nil {
+				return nil, fmt.Errorf("cidr %q: %w", item, err)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		t.insert(prefix.Masked())
+	}
+	if t.root.child[0] == nil && t.root.child[1] == nil && !t.root.end {
+		return nil, fmt.Errorf("cidr: no prefixes")
+	}
+	return t, nil
+}
+
+func (t *cidrTrie) insert(prefix netip.Prefix) {
+	addr, bits := prefix.Addr(), prefix.Bits()
+	if addr.Is4() {
+		bits += 96
+	}
+	key := addr.As16()
+	node := &t.root
+	for i := 0; i < bits; i++ {
+		if node.end {
+			return // a shorter prefix already covers this one
+		}
+		bit := key[i/8] >> (7 - i%8) & 1
+		if node.child[bit] == nil {
+			node.child[bit] = &cidrNode{}
+		}
+		node = node.child[bit]
+	}
+	node.end = true
+	node.child = [2]*cidrNode{}
+}
+
+func (t *cidrTrie) contains(addr netip.Addr) bool {
+	key := addr.As16()
+	node := &t.root
+	for i := 0; i < 128; i++ {
+		if node.end {
+			return true
+		}
+		if node = node.child[key[i/8]>>(7-i%8)&1]; node == nil {
+			return false
+		}
+	}
+	return node.end
+}
+
+// parseIP reads a capture value as an address, tolerating an IPv6 zone and
+// brackets; ok is false for anything that is not an address.
+func parseIP(value string) (netip.Addr, bool) {
+	value = strings.Trim(strings.TrimSpace(value), "[]")
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.WithZone(""), true
+}
+
+// cidrMatcher compiles the list of `cidr:10.0.0.0/8,fd00::/8`: the value is
+// an address in one of the ranges, or with outside (`!cidr:`) an address in
+// none of them. A value that is not an address matches neither.
+func cidrMatcher(list string, outside bool) (func(string) bool, error) {
+	trie, err := parseCIDRs(list)
+	if err != nil {
+		return nil, err
+	}
+	return func(value string) bool {
+		addr, ok := parseIP(value)
+		return ok && trie.contains(addr) != outside
+	}, nil
+}
@@ -3,21 +3,133 @@ package rules
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"gopkg.in/yaml.v3"
 )
 
-// LoadFromFile reads a YAML rule configuration and compiles it.
-func LoadFromFile(path string) (RuleSet, error) {
-	content, err := os.ReadFile(path)
+// LoadFromFile reads a YAML rule configuration and compiles it. Files listed
+// under `include` (relative to the including file) contribute their rules
+// first, in order; each file's templates are instantiated after its own rules.
+// Only the top-level file's `secrets` and `severities` blocks apply; the
+// latter replace the process-wide custom levels (see UseSeverities), and the
+// previous ones are restored if loading fails. It is for the rule file a
+// command starts with; files loaded once events flow go through Load.
+func LoadFromFile(path string) (rs RuleSet, err error) {
+	rf, defs, err := readWithIncludes(path)
+	if err != nil {
+		return RuleSet{}, err
+	}
+
+	prev := levels.Load()
+	if err := UseSeverities(rf.Severities); err != nil {
+		return RuleSet{}, err
+	}
+	defer func() {
+		if err != nil {
+			levels.Store(prev)
+		}
+	}()
+	return compileFile(rf, defs)
+}
+
+// Load reads and compiles a rule file like LoadFromFile but leaves the
+// process-wide levels alone, since thresholds, sinks, and the dashboard
+// already rank events by them: pipeline branch files and the dashboard's
+// rule switcher use it. The file's `severities` may only repeat levels
+// already in use.
+func Load(path string) (RuleSet, error) {
+	rf, defs, err := readWithIncludes(path)
+	if err != nil {
+		return RuleSet{}, err
+	}
+	if err := checkSeverities(rf.Severities); err != nil {
+		return RuleSet{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return compileFile(rf, defs)
+}
+
+// ReadSeverities returns the levels a rule file defines (built-in ones
+// included, most urgent first) without installing them.
+func ReadSeverities(path string) ([]SeverityLevel, error) {
+	rf, err := readRuleFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return compileSeverities(rf.Severities)
+}
+
+func readWithIncludes(path string) (ruleFile, []RuleDefinition, error) {
+	rf, err := readRuleFile(path)
+	if err != nil {
+		return ruleFile{}, nil, err
+	}
+	defs, err := expandIncludes(path, rf, map[string]bool{})
 	if err != nil {
+		return ruleFile{}, nil, err
+	}
+	return rf, defs, nil
+}
+
+func compileFile(rf ruleFile, defs []RuleDefinition) (rs RuleSet, err error) {
+	rs, err = Compile(defs)
+	if err != nil {
+		return RuleSet{}, err
+	}
+	rs.Secrets = rf.Secrets
+	if rs.RateAlerts, err = compileRateAlerts(rf.Rates); err != nil {
 		return RuleSet{}, err
 	}
+	if rs.TagStyles, err = compileTagStyles(rf.TagStyles); err != nil {
+		return RuleSet{}, err
+	}
+	rs.Entities = entitiesOrDefault(rf.Entities)
+	return rs, nil
+}
+
+func readRuleFile(path string) (ruleFile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ruleFile{}, err
+	}
 
 	var rf ruleFile
 	if err := yaml.Unmarshal(content, &rf); err != nil {
-		return RuleSet{}, fmt.Errorf("parse rules: %w", err)
+		return ruleFile{}, fmt.Errorf("parse rules: %w", err)
+	}
+	return rf, nil
+}
+
+func expandIncludes(path string, rf ruleFile, visiting map[string]bool) ([]RuleDefinition, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if visiting[abs] {
+		return nil, fmt.Errorf("include cycle at %s", path)
 	}
+	visiting[abs] = true
+	defer delete(visiting, abs)
 
-	return Compile(rf.Rules)
+	var defs []RuleDefinition
+	for _, inc := range rf.Include {
+		if !filepath.IsAbs(inc) {
+			inc = filepath.Join(filepath.Dir(path), inc)
+		}
+		child, err := readRuleFile(inc)
+		if err != nil {
+			return nil, fmt.Errorf("include %s: %w", inc, err)
+		}
+		childDefs, err := expandIncludes(inc, child, visiting)
+		if err != nil {
+			return nil, err
+		}
+		defs = append(defs, childDefs...)
+	}
+	generated, err := expandTemplates(rf.Templates)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	defs = append(defs, rf.Rules...)
+	return append(defs, generated...), nil
 }
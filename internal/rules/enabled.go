@@ -0,0 +1,67 @@
+package rules
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SetEnabled rewrites the `enabled` field of the named rule in a YAML rule
+// file: false is written out, true removes the field. Only rules listed
+// directly in the file qualify; rules from includes or templates must be
+// changed where they are defined.
+func SetEnabled(path, name string, enabled bool) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return fmt.Errorf("parse rules: %w", err)
+	}
+	list, err := rulesNode(&doc)
+	if err != nil {
+		return err
+	}
+	rule := findRuleNode(list, name)
+	if rule == nil {
+		return fmt.Errorf("rule %q is not defined in %s", name, path)
+	}
+	setEnabledField(rule, enabled)
+	return writeRuleDoc(path, &doc)
+}
+
+func findRuleNode(list *yaml.Node, name string) *yaml.Node {
+	for _, item := range list.Content {
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			if item.Content[i].Value == "name" && item.Content[i+1].Value == name {
+				return item
+			}
+		}
+	}
+	return nil
+}
+
+func setEnabledField(rule *yaml.Node, enabled bool) {
+	for i := 0; i+1 < len(rule.Content); i += 2 {
+		if rule.Content[i].Value != "enabled" {
+			continue
+		}
+		if enabled {
+			rule.Content = append(rule.Content[:i], rule.Content[i+2:]...)
+		} else {
+			rule.Content[i+1].SetString("false")
+			rule.Content[i+1].Tag = "!!bool"
+		}
+		return
+	}
+	if !enabled {
+		rule.Content = append(rule.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: "enabled"},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "false"})
+	}
+}
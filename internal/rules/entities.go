@@ -0,0 +1,13 @@
+package rules
+
+// DefaultEntities are the capture names treated as identities (who or what
+// an event is about) when a rule file has no `entities` list. They cover the
+// built-in parsers' user and address fields.
+var DefaultEntities = []string{"user", "invalid_user", "username", "ip", "client_ip", "src_ip", "session", "session_id"}
+
+func entitiesOrDefault(names []string) []string {
+	if len(names) == 0 {
+		return append([]string(nil), DefaultEntities...)
+	}
+	return names
+}
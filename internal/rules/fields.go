@@ -0,0 +1,134 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"watcher/internal/parsers"
+)
+
+// fieldCondition requires a parsed field to match a regex in full.
+type fieldCondition struct {
+	name string
+	re   *regexp.Regexp
+}
+
+func compileFields(fields map[string]string) ([]fieldCondition, error) {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	conds := make([]fieldCondition, 0, len(names))
+	for _, name := range names {
+		re, err := regexp.Compile(`^(?:` + fields[name] + `)$`)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", name, err)
+		}
+		conds = append(conds, fieldCondition{name: name, re: re})
+	}
+	return conds, nil
+}
+
+// fieldsMatch reports whether every condition holds; a missing field never matches.
+func (r Rule) fieldsMatch(fields map[string]string) bool {
+	for _, cond := range r.fields {
+		value, ok := fields[cond.name]
+		if !ok || !cond.re.MatchString(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// captureCondition requires a capture's value to match a value pattern (see
+// ValueMatcher), e.g. `src_ip: "!cidr:10.0.0.0/8"`.
+type captureCondition struct {
+	capture string
+	match   func(string) bool
+}
+
+// compileWhere validates a rule's `where` conditions, in capture name order.
+func compileWhere(defs map[string]string) ([]captureCondition, error) {
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	conds := make([]captureCondition, 0, len(names))
+	for _, name := range names {
+		match, err := ValueMatcher(defs[name])
+		if err != nil {
+			return nil, fmt.Errorf("where %s: %w", name, err)
+		}
+		conds = append(conds, captureCondition{capture: name, match: match})
+	}
+	return conds, nil
+}
+
+// whereMatch reports whether every `where` condition holds on the (already
+// transformed) captures; a missing capture never matches.
+func (r Rule) whereMatch(captures map[string]string) bool {
+	for _, cond := range r.where {
+		value, ok := captures[cond.capture]
+		if !ok || !cond.match(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// parsedLine caches parser output for one line so rules sharing a parser parse it once.
+type parsedLine struct {
+	line    string
+	results map[string]parseResult
+}
+
+type parseResult struct {
+	fields map[string]string
+	ok     bool
+}
+
+func (p *parsedLine) get(parser parsers.Parser) (map[string]string, bool) {
+	if res, ok := p.results[parser.Name()]; ok {
+		return res.fields, res.ok
+	}
+	if p.results == nil {
+		p.results = make(map[string]parseResult)
+	}
+	fields, ok := parser.Parse(p.line)
+	p.results[parser.Name()] = parseResult{fields: fields, ok: ok}
+	return fields, ok
+}
+
+// fieldSpans highlights the values of the fields a rule tested, where they
+// appear in the line as whole tokens.
+func fieldSpans(line string, fields map[string]string, conds []fieldCondition) [][2]int {
+	var spans [][2]int
+	for _, cond := range conds {
+		value := fields[cond.name]
+		if value == "" {
+			continue
+		}
+		for offset := 0; offset < len(line); {
+			i := strings.Index(line[offset:], value)
+			if i < 0 {
+				break
+			}
+			start, end := offset+i, offset+i+len(value)
+			if (start == 0 || !isWordByte(line[start-1])) && (end == len(line) || !isWordByte(line[end])) {
+				spans = append(spans, [2]int{start, end})
+				break
+			}
+			offset = start + 1
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+	return spans
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
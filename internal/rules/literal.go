@@ -0,0 +1,164 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// MatchMode selects how a rule's pattern is compared against a line.
+type MatchMode string
+
+const (
+	MatchRegex   MatchMode = "regex"
+	MatchLiteral MatchMode = "literal"
+	MatchPrefix  MatchMode = "prefix"
+	MatchSuffix  MatchMode = "suffix"
+)
+
+// ParseMatchMode converts the YAML `match` field, defaulting to regex.
+func ParseMatchMode(value string) (MatchMode, error) {
+	switch MatchMode(strings.ToLower(strings.TrimSpace(value))) {
+	case "", MatchRegex:
+		return MatchRegex, nil
+	case MatchLiteral:
+		return MatchLiteral, nil
+	case MatchPrefix:
+		return MatchPrefix, nil
+	case MatchSuffix:
+		return MatchSuffix, nil
+	default:
+		return "", fmt.Errorf("unknown match mode %q", value)
+	}
+}
+
+// trieNode indexes literal patterns byte by byte; rules lists the positions
+// (in sortedRules order) of rules whose pattern ends at this node.
+type trieNode struct {
+	children map[byte]*trieNode
+	rules    []int
+}
+
+func (n *trieNode) insert(key string, rule int) {
+	node := n
+	for i := 0; i < len(key); i++ {
+		child := node.children[key[i]]
+		if child == nil {
+			if node.children == nil {
+				node.children = make(map[byte]*trieNode)
+			}
+			child = &trieNode{}
+			node.children[key[i]] = child
+		}
+		node = child
+	}
+	node.rules = append(node.rules, rule)
+}
+
+// literalIndex answers which non-regex rules match a line in one pass per
+// trie instead of one string comparison per rule.
+type literalIndex struct {
+	contains trieNode
+	prefix   trieNode
+	suffix   trieNode
+	size     int
+	// sets recycles hit sets, so a line costs no allocation here.
+	sets sync.Pool
+}
+
+// hitSet is the per-line answer of hits: hit[i] for rule i of sortedRules.
+type hitSet struct {
+	hit []bool
+}
+
+func newLiteralIndex(sorted []Rule) *literalIndex {
+	idx := &literalIndex{size: len(sorted)}
+	empty := true
+	for i, rule := range sorted {
+		switch rule.Mode {
+		case MatchLiteral:
+			idx.contains.insert(rule.Pattern, i)
+		case MatchPrefix:
+			idx.prefix.insert(rule.Pattern, i)
+		case MatchSuffix:
+			idx.suffix.insert(reverse(rule.Pattern), i)
+		default:
+			continue
+		}
+		empty = false
+	}
+	if empty {
+		return nil
+	}
+	return idx
+}
+
+// hits marks every literal, prefix, and suffix rule that matches line. Hand
+// the set back with release once the line is decided.
+func (idx *literalIndex) hits(line string) *hitSet {
+	set, _ := idx.sets.Get().(*hitSet)
+	if set == nil {
+		set = &hitSet{hit: make([]bool, idx.size)}
+	}
+	hit := set.hit
+	walk := func(root *trieNode, at func(int) byte, n int) {
+		node := root
+		for i := 0; i < n && node != nil; i++ {
+			node = node.children[at(i)]
+			if node != nil {
+				for _, r := range node.rules {
+					hit[r] = true
+				}
+			}
+		}
+	}
+	walk(&idx.prefix, func(i int) byte { return line[i] }, len(line))
+	walk(&idx.suffix, func(i int) byte { return line[len(line)-1-i] }, len(line))
+	if idx.contains.children != nil {
+		for start := 0; start < len(line); start++ {
+			if idx.contains.children[line[start]] == nil {
+				continue
+			}
+			rest := line[start:]
+			walk(&idx.contains, func(i int) byte { return rest[i] }, len(rest))
+		}
+	}
+	return set
+}
+
+func (idx *literalIndex) release(set *hitSet) {
+	if set == nil {
+		return
+	}
+	clear(set.hit)
+	idx.sets.Put(set)
+}
+
+// literalSpans locates a non-regex rule's pattern for highlighting.
+func literalSpans(rule Rule, line string) [][2]int {
+	switch rule.Mode {
+	case MatchPrefix:
+		return [][2]int{{0, len(rule.Pattern)}}
+	case MatchSuffix:
+		return [][2]int{{len(line) - len(rule.Pattern), len(line)}}
+	}
+	var spans [][2]int
+	for offset := 0; offset < len(line); {
+		i := strings.Index(line[offset:], rule.Pattern)
+		if i < 0 {
+			break
+		}
+		start := offset + i
+		spans = append(spans, [2]int{start, start + len(rule.Pattern)})
+		offset = start + len(rule.Pattern)
+	}
+	return spans
+}
+
+func reverse(value string) string {
+	b := []byte(value)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
@@ -0,0 +1,46 @@
+package rules
+
+import (
+	"strings"
+)
+
+// CountSeverities reads a rule file (with its includes and templates) and
+// counts its enabled rules per severity, without compiling it or installing
+// its custom severities, so many files can be previewed side by side.
+func CountSeverities(path string) (map[Severity]int, error) {
+	rf, err := readRuleFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defs, err := expandIncludes(path, rf, map[string]bool{})
+	if err != nil {
+		return nil, err
+	}
+	custom := make(map[Severity]bool, len(rf.Severities))
+	for _, level := range rf.Severities {
+		custom[Severity(strings.ToLower(strings.TrimSpace(string(level.Name))))] = true
+	}
+	counts := make(map[Severity]int)
+	for _, def := range defs {
+		if !def.IsEnabled() {
+			continue
+		}
+		sev := Severity(strings.ToLower(strings.TrimSpace(string(def.Severity))))
+		if !custom[sev] {
+			sev = builtinSeverity(sev)
+		}
+		counts[sev]++
+	}
+	return counts, nil
+}
+
+// builtinSeverity maps a rule's severity onto the built-in levels the way
+// normalizeSeverity does when no custom levels are installed.
+func builtinSeverity(s Severity) Severity {
+	for _, level := range builtinLevels {
+		if level.Name == s {
+			return s
+		}
+	}
+	return SeverityMedium
+}
@@ -0,0 +1,64 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RateAlert fires a synthetic event when the number of events at or above
+// Severity in the running Window reaches Factor times the previous window
+// (and at least MinCount), e.g. "high-severity count doubled in 10 minutes".
+type RateAlert struct {
+	Name     string        `yaml:"name"`
+	Severity Severity      `yaml:"severity"`
+	Window   time.Duration `yaml:"window"`
+	Factor   float64       `yaml:"factor"`
+	MinCount int           `yaml:"min_count"`
+	Alert    Severity      `yaml:"alert_severity"`
+}
+
+// Describe summarizes the condition, e.g. "high+ ×2 per 10m0s (min 5)".
+func (a RateAlert) Describe() string {
+	return fmt.Sprintf("%s+ ×%g per %s (min %d)", a.Severity, a.Factor, a.Window, a.MinCount)
+}
+
+// compileRateAlerts validates the definitions and fills in defaults: a 10m
+// window, factor 2, a minimum of 5 events, and a high alert severity.
+func compileRateAlerts(defs []RateAlert) ([]RateAlert, error) {
+	out := make([]RateAlert, 0, len(defs))
+	for _, def := range defs {
+		def.Name = strings.TrimSpace(def.Name)
+		if def.Name == "" {
+			return nil, fmt.Errorf("rate alert missing name")
+		}
+		severity, err := ParseSeverity(string(def.Severity))
+		if err != nil {
+			return nil, fmt.Errorf("rate alert %q: %w", def.Name, err)
+		}
+		def.Severity = severity
+		if def.Alert == "" {
+			def.Alert = SeverityHigh
+		}
+		if def.Alert, err = ParseSeverity(string(def.Alert)); err != nil {
+			return nil, fmt.Errorf("rate alert %q: alert_severity: %w", def.Name, err)
+		}
+		if def.Window == 0 {
+			def.Window = 10 * time.Minute
+		}
+		if def.Window < time.Second {
+			return nil, fmt.Errorf("rate alert %q: window must be at least 1s", def.Name)
+		}
+		if def.Factor == 0 {
+			def.Factor = 2
+		}
+		if def.Factor < 1 {
+			return nil, fmt.Errorf("rate alert %q: factor must be at least 1", def.Name)
+		}
+		if def.MinCount == 0 {
+			def.MinCount = 5
+		}
+		out = append(out, def)
+	}
+	return out, nil
+}
@@ -0,0 +1,142 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// SeverityLevel is one severity in rank order. Higher ranks are more urgent;
+// the built-in levels sit at 500 (critical) down to 100 (normal) so custom
+// ones can go between or around them.
+type SeverityLevel struct {
+	Name  Severity `yaml:"name"`
+	Rank  int      `yaml:"rank"`
+	Color string   `yaml:"color"`
+}
+
+var builtinLevels = []SeverityLevel{
+	{Name: SeverityCritical, Rank: 500},
+	{Name: SeverityHigh, Rank: 400},
+	{Name: SeverityMedium, Rank: 300},
+	{Name: SeverityLow, Rank: 200},
+	{Name: SeverityNormal, Rank: 100},
+}
+
+// levels holds every known severity, most urgent first. It is process-wide
+// because ranks are compared wherever events flow (thresholds, sinks, the
+// dashboard); LoadFromFile installs a rule file's custom levels at startup,
+// and later loads (Load) must agree with them.
+var levels atomic.Pointer[[]SeverityLevel]
+
+func init() {
+	all := append([]SeverityLevel(nil), builtinLevels...)
+	levels.Store(&all)
+}
+
+// Severities returns every known severity level, most urgent first.
+func Severities() []SeverityLevel {
+	return append([]SeverityLevel(nil), *levels.Load()...)
+}
+
+// SeverityColor returns the configured color of a custom level, or "" for
+// built-in levels, which the themes style themselves.
+func SeverityColor(s Severity) string {
+	for _, level := range *levels.Load() {
+		if level.Name == s {
+			return level.Color
+		}
+	}
+	return ""
+}
+
+// BuiltinFloor maps a severity onto the most urgent built-in level that is not
+// more urgent than it, for destinations with a fixed scale (syslog levels).
+func BuiltinFloor(s Severity) Severity {
+	rank := SeverityRank(s)
+	for _, b := range builtinLevels {
+		if SeverityRank(b.Name) >= rank {
+			return b.Name
+		}
+	}
+	return SeverityNormal
+}
+
+// UseSeverities replaces the custom levels (rule file `severities`) after
+// validating them; nil restores the built-in five.
+func UseSeverities(custom []SeverityLevel) error {
+	all, err := compileSeverities(custom)
+	if err != nil {
+		return err
+	}
+	levels.Store(&all)
+	return nil
+}
+
+// checkSeverities validates custom levels against the installed ones: each
+// must already be in use under the same rank.
+func checkSeverities(custom []SeverityLevel) error {
+	all, err := compileSeverities(custom)
+	if err != nil {
+		return err
+	}
+	installed := *levels.Load()
+	for _, level := range all {
+		found := false
+		for _, other := range installed {
+			if other.Name == level.Name {
+				if other.Rank != level.Rank {
+					return fmt.Errorf("severity %q has rank %d here but %d in the running rule file", level.Name, level.Rank, other.Rank)
+				}
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("severity %q is not defined by the running rule file", level.Name)
+		}
+	}
+	return nil
+}
+
+// compileSeverities checks custom levels and merges them with the built-in
+// ones in rank order. Names are lowercased; names and ranks must be unique and
+// may not reuse a built-in one.
+func compileSeverities(custom []SeverityLevel) ([]SeverityLevel, error) {
+	all := append([]SeverityLevel(nil), builtinLevels...)
+	for _, level := range custom {
+		level.Name = Severity(strings.ToLower(strings.TrimSpace(string(level.Name))))
+		if level.Name == "" {
+			return nil, fmt.Errorf("severity level without a name")
+		}
+		if level.Rank <= 0 {
+			return nil, fmt.Errorf("severity %q: rank must be positive", level.Name)
+		}
+		if level.Color != "" && !colorPattern.MatchString(level.Color) {
+			return nil, fmt.Errorf("severity %q: invalid color %q", level.Name, level.Color)
+		}
+		for _, other := range all {
+			if other.Name == level.Name || (level.Name == "med" && other.Name == SeverityMedium) {
+				return nil, fmt.Errorf("severity %q is already defined", level.Name)
+			}
+			if other.Rank == level.Rank {
+				return nil, fmt.Errorf("severity %q: rank %d is already taken by %q", level.Name, level.Rank, other.Name)
+			}
+		}
+		all = append(all, level)
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Rank > all[j].Rank })
+	return all, nil
+}
+
+// customSeverity looks up a level by name, for the custom ones the severity
+// parsers do not know.
+func customSeverity(name string) (Severity, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, level := range *levels.Load() {
+		if string(level.Name) == name {
+			return level.Name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,147 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// severityCase is one compiled `severity_map` entry: when the capture's value
+// matches, the event takes severity.
+type severityCase struct {
+	capture  string
+	match    func(string) bool
+	severity Severity
+}
+
+// compileSeverityMap validates a rule's `severity_map`, which grades events
+// by capture value instead of giving every match the rule's severity:
+//
+//	severity_map:
+//	  status: {"5xx": high, "4xx": medium}
+//	  duration_ms: {">=5000": high, ">=1000": low}
+//
+// A key is a comparison with a number (>=, >, <=, <, =), a digit pattern in
+// which x stands for any digit ("5xx"), network ranges ("cidr:10.0.0.0/8",
+// or "!cidr:…" for addresses outside them), or a literal value (compared
+// without case).
+func compileSeverityMap(defs map[string]map[string]Severity) ([]severityCase, error) {
+	captures := make([]string, 0, len(defs))
+	for capture := range defs {
+		captures = append(captures, capture)
+	}
+	sort.Strings(captures)
+	var cases []severityCase
+	for _, capture := range captures {
+		patterns := make([]string, 0, len(defs[capture]))
+		for pattern := range defs[capture] {
+			patterns = append(patterns, pattern)
+		}
+		sort.Strings(patterns)
+		for _, pattern := range patterns {
+			severity, err := ParseSeverity(string(defs[capture][pattern]))
+			if err != nil {
+				return nil, fmt.Errorf("severity_map %s %q: %w", capture, pattern, err)
+			}
+			match, err := ValueMatcher(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("severity_map %s: %w", capture, err)
+			}
+			cases = append(cases, severityCase{capture: capture, match: match, severity: severity})
+		}
+	}
+	return cases, nil
+}
+
+// ValueMatcher compiles one severity_map key, `where` condition, or capture
+// comparison in a sink filter expression.
+func ValueMatcher(pattern string) (func(string) bool, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return nil, fmt.Errorf("empty value pattern")
+	}
+	if list, ok := strings.CutPrefix(pattern, "cidr:"); ok {
+		return cidrMatcher(list, false)
+	}
+	if list, ok := strings.CutPrefix(pattern, "!cidr:"); ok {
+		return cidrMatcher(list, true)
+	}
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		rest, ok := strings.CutPrefix(pattern, op)
+		if !ok {
+			continue
+		}
+		limit, err := strconv.ParseFloat(strings.TrimSpace(rest), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %s needs a number", pattern, op)
+		}
+		return func(value string) bool {
+			n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				return false
+			}
+			switch op {
+			case ">=":
+				return n >= limit
+			case "<=":
+				return n <= limit
+			case ">":
+				return n > limit
+			case "<":
+				return n < limit
+			default:
+				return n == limit
+			}
+		}, nil
+	}
+	if isDigitPattern(pattern) {
+		return func(value string) bool {
+			if len(value) != len(pattern) {
+				return false
+			}
+			for i := 0; i < len(pattern); i++ {
+				if p := pattern[i]; p == 'x' || p == 'X' {
+					if value[i] < '0' || value[i] > '9' {
+						return false
+					}
+				} else if value[i] != p {
+					return false
+				}
+			}
+			return true
+		}, nil
+	}
+	return func(value string) bool { return strings.EqualFold(value, pattern) }, nil
+}
+
+// isDigitPattern reports whether pattern is digits and x wildcards with at
+// least one wildcard.
+func isDigitPattern(pattern string) bool {
+	wild := false
+	for _, r := range pattern {
+		switch {
+		case r == 'x' || r == 'X':
+			wild = true
+		case r < '0' || r > '9':
+			return false
+		}
+	}
+	return wild
+}
+
+// severityFor grades a match by its captures: the most urgent severity_map
+// entry that matches wins, else the rule's own severity.
+func (r Rule) severityFor(captures map[string]string) Severity {
+	best, found := r.Severity, false
+	for _, c := range r.severityMap {
+		value, ok := captures[c.capture]
+		if !ok || !c.match(value) {
+			continue
+		}
+		if !found || SeverityRank(c.severity) < SeverityRank(best) {
+			best, found = c.severity, true
+		}
+	}
+	return best
+}
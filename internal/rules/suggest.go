@@ -0,0 +1,168 @@
+package rules
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	syslogPrefix = regexp.MustCompile(`^(?:[A-Z][a-z]{2}\s+\d{1,2}\s+\d{2}:\d{2}:\d{2}|\d{4}-\d{2}-\d{2}[T ][\d:.]+(?:Z|[+-]\d{2}:?\d{2})?)\s+\S+\s+`)
+	programTag   = regexp.MustCompile(`^([A-Za-z0-9_./-]+)(\[\d+\])?:\s*`)
+	variableTok  = regexp.MustCompile(`\d{1,3}(?:\.\d{1,3}){3}|\b[0-9a-fA-F]{8,}\b|\d+`)
+	ipv4Token    = regexp.MustCompile(`^\d{1,3}(?:\.\d{1,3}){3}$`)
+	hexToken     = regexp.MustCompile(`^[0-9a-fA-F]{8,}$`)
+)
+
+// maxSuggestedLiteral bounds how much of a line is copied into a suggested pattern.
+const maxSuggestedLiteral = 160
+
+// SuggestRule proposes a rule that generalizes an unmatched line: timestamps and
+// host prefixes are dropped, numbers/hex ids become classes and IPs become captures.
+func SuggestRule(line string) RuleDefinition {
+	body := strings.TrimSpace(syslogPrefix.ReplaceAllString(line, ""))
+	name := "suggested event"
+	var pattern strings.Builder
+	if m := programTag.FindStringSubmatch(body); m != nil {
+		name = m[1] + " event"
+		pattern.WriteString(regexp.QuoteMeta(m[1]))
+		if m[2] != "" {
+			pattern.WriteString(`\[\d+\]`)
+		}
+		pattern.WriteString(`:\s*`)
+		body = body[len(m[0]):]
+	}
+	if len(body) > maxSuggestedLiteral {
+		body = body[:maxSuggestedLiteral]
+	}
+
+	ipCount := 0
+	cursor := 0
+	for _, loc := range variableTok.FindAllStringIndex(body, -1) {
+		pattern.WriteString(regexp.QuoteMeta(body[cursor:loc[0]]))
+		token := body[loc[0]:loc[1]]
+		switch {
+		case ipv4Token.MatchString(token):
+			ipCount++
+			capture := "ip"
+			if ipCount > 1 {
+				capture = fmt.Sprintf("ip%d", ipCount)
+			}
+			fmt.Fprintf(&pattern, `(?P<%s>\d{1,3}(?:\.\d{1,3}){3})`, capture)
+		case hexToken.MatchString(token) && !isDigits(token):
+			pattern.WriteString(`[0-9a-fA-F]+`)
+		default:
+			pattern.WriteString(`\d+`)
+		}
+		cursor = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(body[cursor:]))
+
+	return RuleDefinition{
+		Name:        name,
+		Pattern:     pattern.String(),
+		Severity:    SeverityMedium,
+		Tags:        []string{"suggested"},
+		Description: fmt.Sprintf("Suggested from: %s", strings.TrimSpace(line)),
+	}
+}
+
+// AppendToFile adds definitions to the rules list of a YAML rule file, validating them first.
+func AppendToFile(path string, defs ...RuleDefinition) error {
+	if _, err := Compile(defs); err != nil {
+		return err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return fmt.Errorf("parse rules: %w", err)
+	}
+	list, err := rulesNode(&doc)
+	if err != nil {
+		return err
+	}
+	for _, def := range defs {
+		var node yaml.Node
+		if err := node.Encode(def); err != nil {
+			return fmt.Errorf("encode rule %q: %w", def.Name, err)
+		}
+		list.Content = append(list.Content, &node)
+	}
+	return writeRuleDoc(path, &doc)
+}
+
+// writeRuleDoc re-encodes an edited rule file with the repo's two-space indent.
+func writeRuleDoc(path string, doc *yaml.Node) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("encode rules: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("encode rules: %w", err)
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// rulesNode finds (or creates) the `rules:` sequence in a parsed rule file.
+func rulesNode(doc *yaml.Node) (*yaml.Node, error) {
+	if doc.Kind == 0 {
+		doc.Kind = yaml.DocumentNode
+	}
+	if len(doc.Content) == 0 {
+		doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.MappingNode})
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("rule file root must be a mapping")
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "rules" {
+			list := root.Content[i+1]
+			if list.Kind != yaml.SequenceNode {
+				return nil, fmt.Errorf("rules must be a list")
+			}
+			return list, nil
+		}
+	}
+	list := &yaml.Node{Kind: yaml.SequenceNode}
+	root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "rules"}, list)
+	return list, nil
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return value != ""
+}
+
+// LineShape reduces a line to its message shape, as SuggestRule reads it: the
+// timestamp and host prefix dropped, IPs, hex ids, and numbers replaced with
+// <ip>, <hex>, and <n>. Lines that differ only in those values share a shape.
+func LineShape(line string) string {
+	body := strings.TrimSpace(syslogPrefix.ReplaceAllString(line, ""))
+	if len(body) > maxSuggestedLiteral {
+		body = body[:maxSuggestedLiteral]
+	}
+	return variableTok.ReplaceAllStringFunc(body, func(token string) string {
+		switch {
+		case ipv4Token.MatchString(token):
+			return "<ip>"
+		case hexToken.MatchString(token) && !isDigits(token):
+			return "<hex>"
+		default:
+			return "<n>"
+		}
+	})
+}
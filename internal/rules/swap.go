@@ -0,0 +1,64 @@
+package rules
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Swap lets a running pipeline change rule files without a restart. Rule sets
+// obtained from Swap.RuleSet (and tag filters of them) match with whatever set
+// was stored last, so a stream built once keeps following the active file.
+type Swap struct {
+	current atomic.Pointer[swapState]
+}
+
+// swapState is one stored set plus its tag-filtered views, built on first use.
+type swapState struct {
+	rs       RuleSet
+	filtered sync.Map
+}
+
+// NewSwap starts with rs as the active set.
+func NewSwap(rs RuleSet) *Swap {
+	s := &Swap{}
+	s.Store(rs)
+	return s
+}
+
+// Store makes rs the active set for every rule set derived from s.
+func (s *Swap) Store(rs RuleSet) {
+	rs.swap, rs.swapTags = nil, nil
+	s.current.Store(&swapState{rs: rs})
+}
+
+// RuleSet returns the active set wired to follow later Stores.
+func (s *Swap) RuleSet() RuleSet {
+	rs := s.current.Load().rs
+	rs.swap = s
+	return rs
+}
+
+// view returns the active set, filtered by tags when given.
+func (s *Swap) view(tags []string) RuleSet {
+	st := s.current.Load()
+	if len(tags) == 0 {
+		return st.rs
+	}
+	key := strings.Join(tags, "\x00")
+	if v, ok := st.filtered.Load(key); ok {
+		return v.(RuleSet)
+	}
+	filtered := st.rs.FilterByTags(tags)
+	st.filtered.Store(key, filtered)
+	return filtered
+}
+
+// Current resolves a swappable set to the rules it matches with right now;
+// other sets are returned unchanged.
+func (rs RuleSet) Current() RuleSet {
+	if rs.swap == nil {
+		return rs
+	}
+	return rs.swap.view(rs.swapTags)
+}
@@ -0,0 +1,68 @@
+package rules
+
+import (
+	"path"
+	"strings"
+)
+
+// Tags are hierarchical, with levels separated by dots (`net.ssh.auth`).
+// Tag patterns select them case-insensitively: a plain name matches that tag
+// only, `*` in a level matches like a shell glob within it (`*.auth`,
+// `net.ssh*`), and a final `*` level matches one or more levels, so `net.*`
+// selects `net.ssh` and `net.ssh.auth` but not `net` itself.
+
+// TagMatches reports whether tag is selected by pattern.
+func TagMatches(pattern, tag string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	tag = strings.ToLower(tag)
+	if pattern == "" {
+		return false
+	}
+	if !strings.Contains(pattern, "*") {
+		return pattern == tag
+	}
+	want, have := strings.Split(pattern, "."), strings.Split(tag, ".")
+	for i, level := range want {
+		if i >= len(have) {
+			return false
+		}
+		if level == "*" && i == len(want)-1 {
+			return true
+		}
+		if ok, err := path.Match(level, have[i]); err != nil || !ok {
+			return false
+		}
+	}
+	return len(want) == len(have)
+}
+
+// AnyTagMatches reports whether any of tags is selected by any of patterns.
+func AnyTagMatches(patterns, tags []string) bool {
+	for _, pattern := range patterns {
+		for _, tag := range tags {
+			if TagMatches(pattern, tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// TagStyleFor returns the style for tag from compiled tag styles: its own,
+// else the one of the most specific pattern matching it.
+func TagStyleFor(styles map[string]TagStyle, tag string) (TagStyle, bool) {
+	tag = strings.ToLower(tag)
+	if ts, ok := styles[tag]; ok {
+		return ts, true
+	}
+	best, found := "", false
+	for pattern := range styles {
+		if !strings.Contains(pattern, "*") || !TagMatches(pattern, tag) {
+			continue
+		}
+		if !found || len(pattern) > len(best) || (len(pattern) == len(best) && pattern < best) {
+			best, found = pattern, true
+		}
+	}
+	return styles[best], found
+}
@@ -0,0 +1,37 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TagStyle is extra styling applied to every line carrying a tag, on top of
+// its severity color. Colors are hex (#RRGGBB or #RGB) or ANSI numbers.
+type TagStyle struct {
+	Foreground string `yaml:"foreground"`
+	Background string `yaml:"background"`
+	Bold       bool   `yaml:"bold"`
+	Italic     bool   `yaml:"italic"`
+	Underline  bool   `yaml:"underline"`
+}
+
+var colorPattern = regexp.MustCompile(`^(#[0-9a-fA-F]{6}|#[0-9a-fA-F]{3}|[0-9]{1,3})$`)
+
+// compileTagStyles validates colors and lowercases tag names so lookups
+// match rule tags case-insensitively.
+func compileTagStyles(styles map[string]TagStyle) (map[string]TagStyle, error) {
+	if len(styles) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]TagStyle, len(styles))
+	for tag, style := range styles {
+		for _, color := range []string{style.Foreground, style.Background} {
+			if color != "" && !colorPattern.MatchString(color) {
+				return nil, fmt.Errorf("tag style %q: invalid color %q", tag, color)
+			}
+		}
+		out[strings.ToLower(strings.TrimSpace(tag))] = style
+	}
+	return out, nil
+}
@@ -0,0 +1,121 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RuleTemplate is a rule body with {{param}} placeholders instantiated once
+// per entry of Instances at load time:
+//
+//	templates:
+//	  - params: [service]
+//	    rule:
+//	      name: "{{service}} failed"
+//	      pattern: '{{service|regex}}\[\d+\]: .*failed'
+//	      severity: high
+//	    instances:
+//	      - service: nginx
+//	      - service: php-fpm
+//
+// Placeholders may appear in any string of the rule, including tags and
+// fields. The regex filter escapes the value for use inside a pattern; lower
+// and upper change its case.
+type RuleTemplate struct {
+	Params    []string            `yaml:"params"`
+	Rule      yaml.Node           `yaml:"rule"`
+	Instances []map[string]string `yaml:"instances"`
+}
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*(?:\|\s*([a-z]+)\s*)?\}\}`)
+
+// expandTemplates instantiates every template in declaration order.
+func expandTemplates(templates []RuleTemplate) ([]RuleDefinition, error) {
+	var defs []RuleDefinition
+	for idx, tmpl := range templates {
+		if tmpl.Rule.Kind == 0 {
+			return nil, fmt.Errorf("template %d: missing rule", idx+1)
+		}
+		declared := make(map[string]bool, len(tmpl.Params))
+		for _, param := range tmpl.Params {
+			declared[param] = true
+		}
+		for n, values := range tmpl.Instances {
+			for name := range values {
+				if !declared[name] {
+					return nil, fmt.Errorf("template %d instance %d: unknown param %q", idx+1, n+1, name)
+				}
+			}
+			for _, param := range tmpl.Params {
+				if _, ok := values[param]; !ok {
+					return nil, fmt.Errorf("template %d instance %d: missing param %q", idx+1, n+1, param)
+				}
+			}
+			node := cloneNode(&tmpl.Rule)
+			if err := substitute(node, values); err != nil {
+				return nil, fmt.Errorf("template %d instance %d: %w", idx+1, n+1, err)
+			}
+			var def RuleDefinition
+			if err := node.Decode(&def); err != nil {
+				return nil, fmt.Errorf("template %d instance %d: %w", idx+1, n+1, err)
+			}
+			defs = append(defs, def)
+		}
+	}
+	return defs, nil
+}
+
+// cloneNode deep-copies a node, replacing aliases with copies of their anchors
+// so substitution never writes through to a shared anchor.
+func cloneNode(n *yaml.Node) *yaml.Node {
+	if n == nil {
+		return nil
+	}
+	if n.Kind == yaml.AliasNode {
+		return cloneNode(n.Alias)
+	}
+	out := *n
+	out.Anchor = ""
+	out.Content = make([]*yaml.Node, len(n.Content))
+	for i, child := range n.Content {
+		out.Content[i] = cloneNode(child)
+	}
+	return &out
+}
+
+func substitute(n *yaml.Node, values map[string]string) error {
+	if n.Kind == yaml.ScalarNode {
+		var err error
+		n.Value = placeholderPattern.ReplaceAllStringFunc(n.Value, func(token string) string {
+			parts := placeholderPattern.FindStringSubmatch(token)
+			value, ok := values[parts[1]]
+			if !ok {
+				err = fmt.Errorf("undeclared param %q", parts[1])
+				return token
+			}
+			switch parts[2] {
+			case "":
+				return value
+			case "regex":
+				return regexp.QuoteMeta(value)
+			case "lower":
+				return strings.ToLower(value)
+			case "upper":
+				return strings.ToUpper(value)
+			default:
+				err = fmt.Errorf("unknown filter %q", parts[2])
+				return token
+			}
+		})
+		return err
+	}
+	for _, child := range n.Content {
+		if err := substitute(child, values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package rules
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TransformDefinition is one step of a capture's `transforms` list: either a
+// builtin name (lower, upper, trim, ip_anonymize, to_int, base64_decode) or a lookup table
+// written as `{map: {from: to}, default: value}`.
+type TransformDefinition struct {
+	Name    string
+	Map     map[string]string
+	Default string
+}
+
+// UnmarshalYAML accepts a bare name or a map lookup.
+func (t *TransformDefinition) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		t.Name = node.Value
+		return nil
+	}
+	var lookup struct {
+		Map     map[string]string `yaml:"map"`
+		Default string            `yaml:"default"`
+	}
+	if err := node.Decode(&lookup); err != nil {
+		return err
+	}
+	if lookup.Map == nil {
+		return fmt.Errorf("line %d: transform must be a name or {map: ...}", node.Line)
+	}
+	t.Name, t.Map, t.Default = "map", lookup.Map, lookup.Default
+	return nil
+}
+
+// MarshalYAML writes the same shapes UnmarshalYAML reads.
+func (t TransformDefinition) MarshalYAML() (interface{}, error) {
+	if t.Name != "map" {
+		return t.Name, nil
+	}
+	out := map[string]interface{}{"map": t.Map}
+	if t.Default != "" {
+		out["default"] = t.Default
+	}
+	return out, nil
+}
+
+type transformFunc func(string) string
+
+// captureTransform is the compiled transform chain for one capture name.
+type captureTransform struct {
+	capture string
+	steps   []transformFunc
+}
+
+func compileTransforms(defs map[string][]TransformDefinition) ([]captureTransform, error) {
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := make([]captureTransform, 0, len(names))
+	for _, name := range names {
+		ct := captureTransform{capture: name}
+		for _, def := range defs[name] {
+			step, err := builtinTransform(def)
+			if err != nil {
+				return nil, fmt.Errorf("transform for %q: %w", name, err)
+			}
+			ct.steps = append(ct.steps, step)
+		}
+		out = append(out, ct)
+	}
+	return out, nil
+}
+
+func builtinTransform(def TransformDefinition) (transformFunc, error) {
+	switch def.Name {
+	case "lower":
+		return strings.ToLower, nil
+	case "upper":
+		return strings.ToUpper, nil
+	case "trim":
+		return strings.TrimSpace, nil
+	case "ip_anonymize":
+		return anonymizeIP, nil
+	case "to_int":
+		return toInt, nil
+	case "base64_decode":
+		return decodeBase64, nil
+	case "map":
+		table, fallback := def.Map, def.Default
+		return func(value string) string {
+			if mapped, ok := table[value]; ok {
+				return mapped
+			}
+			if fallback != "" {
+				return fallback
+			}
+			return value
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown transform %q", def.Name)
+	}
+}
+
+// anonymizeIP zeroes the host part of an address: the last octet of IPv4,
+// everything past /48 for IPv6. Anything else is returned unchanged.
+func anonymizeIP(value string) string {
+	ip := net.ParseIP(strings.TrimSpace(value))
+	if ip == nil {
+		return value
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String()
+}
+
+// toInt normalizes numbers written with thousands separators, signs, or a
+// fraction ("1,024", "+7", "3.9") to a plain integer; non-numbers are unchanged.
+func toInt(value string) string {
+	cleaned := strings.NewReplacer(",", "", "_", "").Replace(strings.TrimSpace(value))
+	if n, err := strconv.ParseInt(cleaned, 10, 64); err == nil {
+		return strconv.FormatInt(n, 10)
+	}
+	if f, err := strconv.ParseFloat(cleaned, 64); err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return value
+}
+
+// decodeBase64 decodes standard or URL-safe base64, padded or not. Values
+// that do not decode to valid UTF-8 text are unchanged.
+func decodeBase64(value string) string {
+	trimmed := strings.TrimRight(strings.TrimSpace(value), "=")
+	for _, enc := range []*base64.Encoding{base64.RawStdEncoding, base64.RawURLEncoding} {
+		if decoded, err := enc.DecodeString(trimmed); err == nil && utf8.Valid(decoded) {
+			return string(decoded)
+		}
+	}
+	return value
+}
+
+// applyTransforms rewrites captures in place.
+func (r Rule) applyTransforms(captures map[string]string) {
+	for _, ct := range r.transforms {
+		value, ok := captures[ct.capture]
+		if !ok {
+			continue
+		}
+		for _, step := range ct.steps {
+			value = step(value)
+		}
+		captures[ct.capture] = value
+	}
+}
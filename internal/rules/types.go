@@ -2,9 +2,15 @@ package rules
 
 import (
 	"fmt"
+	"maps"
 	"regexp"
 	"sort"
 	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"watcher/internal/parsers"
+	"watcher/internal/secrets"
 )
 
 // Severity represents the importance level a rule assigns to a match.
@@ -18,24 +24,28 @@ const (
 	SeverityNormal   Severity = "normal"
 )
 
-var orderedSeverities = []Severity{
-	SeverityCritical,
-	SeverityHigh,
-	SeverityMedium,
-	SeverityLow,
-	SeverityNormal,
-}
-
 // Rule captures a compiled regular expression with metadata for styling.
 type Rule struct {
 	Name        string
 	Pattern     string
+	Mode        MatchMode
+	Parser      string
 	regex       *regexp.Regexp
+	parser      parsers.Parser
+	fields      []fieldCondition
+	where       []captureCondition
+	transforms  []captureTransform
+	severityMap []severityCase
 	Severity    Severity
 	Color       string
 	Tags        []string
 	Description string
-	order       int
+	Action      *Action
+	Ban         *Ban
+	// Emits and Requires chain rules; see RuleDefinition.
+	Emits    string
+	Requires string
+	order    int
 }
 
 // Match contains the context returned when a rule triggers.
@@ -43,87 +53,396 @@ type Match struct {
 	Rule           Rule
 	Captures       map[string]string
 	HighlightSpans [][2]int
+	Secrets        []secrets.Finding
+	// RawCaptures are the capture values as read from the line, before the
+	// rule's transforms; secret redaction compares against them. It is
+	// Captures itself when the rule has no transforms.
+	RawCaptures map[string]string
+	// Chain names the rules whose emitted lines led to this match, first
+	// to last, and Derived is the line Rule matched; both are empty for a
+	// match on the raw line.
+	Chain   []string
+	Derived string
 }
 
 // RuleSet provides matching behavior for a set of compiled rules.
 type RuleSet struct {
-	Rules []Rule
+	Rules      []Rule
+	Secrets    SecretScan
+	RateAlerts []RateAlert
+	TagStyles  map[string]TagStyle
+	// Entities names the captures that identify users, hosts, or sessions
+	// across events (rule file `entities`, else DefaultEntities).
+	Entities []string
+	literals *literalIndex
+	// sorted is Rules in match order, built once by newRuleSet since Match
+	// runs for every line.
+	sorted []Rule
+	// swap, when set, supplies the rules Match uses (see Swap); swapTags is
+	// the tag filter applied to them.
+	swap     *Swap
+	swapTags []string
+}
+
+// SecretScan configures the built-in leaked secret detector (AWS keys, JWTs, high-entropy tokens).
+type SecretScan struct {
+	Enabled    bool     `yaml:"enabled"`
+	Severity   Severity `yaml:"severity"`
+	Redact     *bool    `yaml:"redact"`
+	MinEntropy float64  `yaml:"min_entropy"`
+}
+
+// RedactsSecrets reports whether secret findings must be masked before display or export.
+func (c SecretScan) RedactsSecrets() bool {
+	return c.Enabled && (c.Redact == nil || *c.Redact)
+}
+
+// Scanner builds the detector described by the configuration.
+func (c SecretScan) Scanner() secrets.Scanner {
+	scanner := secrets.NewScanner()
+	if c.MinEntropy > 0 {
+		scanner.MinEntropy = c.MinEntropy
+	}
+	return scanner
 }
 
 // Compile validates all rules and prepares regexes.
 func Compile(defs []RuleDefinition) (RuleSet, error) {
 	compiled := make([]Rule, 0, len(defs))
 	for _, def := range defs {
-		if def.Pattern == "" {
+		if def.Pattern == "" && def.Parser == "" {
 			return RuleSet{}, fmt.Errorf("rule %q missing pattern", def.Name)
 		}
-		re, err := regexp.Compile(def.Pattern)
+		mode, err := ParseMatchMode(def.Match)
+		if err != nil {
+			return RuleSet{}, fmt.Errorf("rule %q: %w", def.Name, err)
+		}
+		var parser parsers.Parser
+		var fields []fieldCondition
+		if def.Parser != "" {
+			if parser, err = parsers.Lookup(def.Parser); err != nil {
+				return RuleSet{}, fmt.Errorf("rule %q: %w", def.Name, err)
+			}
+			if fields, err = compileFields(def.Fields); err != nil {
+				return RuleSet{}, fmt.Errorf("rule %q: %w", def.Name, err)
+			}
+		} else if len(def.Fields) > 0 {
+			return RuleSet{}, fmt.Errorf("rule %q: fields require a parser", def.Name)
+		}
+		if def.Pattern == "" {
+			mode = MatchRegex
+		}
+		var re *regexp.Regexp
+		if mode == MatchRegex && def.Pattern != "" {
+			re, err = regexp.Compile(def.Pattern)
+			if err != nil {
+				return RuleSet{}, fmt.Errorf("compile %q: %w", def.Name, err)
+			}
+		}
+		transforms, err := compileTransforms(def.Transforms)
+		if err != nil {
+			return RuleSet{}, fmt.Errorf("rule %q: %w", def.Name, err)
+		}
+		action, err := compileAction(def)
+		if err != nil {
+			return RuleSet{}, fmt.Errorf("rule %q: %w", def.Name, err)
+		}
+		ban, err := compileBan(def.Ban)
+		if err != nil {
+			return RuleSet{}, fmt.Errorf("rule %q: %w", def.Name, err)
+		}
+		severityMap, err := compileSeverityMap(def.SeverityMap)
+		if err != nil {
+			return RuleSet{}, fmt.Errorf("rule %q: %w", def.Name, err)
+		}
+		where, err := compileWhere(def.Where)
 		if err != nil {
-			return RuleSet{}, fmt.Errorf("compile %q: %w", def.Name, err)
+			return RuleSet{}, fmt.Errorf("rule %q: %w", def.Name, err)
+		}
+		if len(where) > 0 && mode != MatchRegex && parser == nil {
+			return RuleSet{}, fmt.Errorf("rule %q: where needs captures (a regex pattern or a parser)", def.Name)
+		}
+		emits, err := compileEmit(def)
+		if err != nil {
+			return RuleSet{}, fmt.Errorf("rule %q: %w", def.Name, err)
+		}
+		if emits != "" && mode != MatchRegex && parser == nil {
+			return RuleSet{}, fmt.Errorf("rule %q: emits needs captures (a regex pattern or a parser)", def.Name)
+		}
+		if !def.IsEnabled() {
+			continue
 		}
 		severity := normalizeSeverity(def.Severity)
 		compiled = append(compiled, Rule{
 			Name:        def.Name,
 			Pattern:     def.Pattern,
+			Mode:        mode,
+			Parser:      def.Parser,
 			regex:       re,
+			parser:      parser,
+			fields:      fields,
+			where:       where,
+			transforms:  transforms,
+			severityMap: severityMap,
+			Action:      action,
+			Ban:         ban,
 			Severity:    severity,
 			Color:       def.Color,
 			Tags:        append([]string{}, def.Tags...),
 			Description: def.Description,
+			Emits:       emits,
+			Requires:    def.Requires,
 			order:       len(compiled),
 		})
 	}
-	return RuleSet{Rules: compiled}, nil
+	if err := checkChains(defs); err != nil {
+		return RuleSet{}, err
+	}
+	return newRuleSet(compiled, SecretScan{}), nil
+}
+
+// newRuleSet indexes the literal, prefix, and suffix rules of a rule list.
+func newRuleSet(list []Rule, scan SecretScan) RuleSet {
+	rs := RuleSet{Rules: list, Secrets: scan, sorted: sortRules(list)}
+	rs.literals = newLiteralIndex(rs.sorted)
+	return rs
 }
 
 // Match evaluates the line against the rule set returning the first match ordered by severity then declaration order.
+// When secret scanning is enabled, findings ride along on rule matches and otherwise produce a synthetic match.
+// When the matching rule emits a derived line, the rules requiring it are tried on that line, and the last
+// match down the chain is returned in its place.
 func (rs RuleSet) Match(line string) (Match, bool) {
-	if len(rs.Rules) == 0 {
+	if rs.swap != nil {
+		return rs.Current().Match(line)
+	}
+	var found []secrets.Finding
+	if rs.Secrets.Enabled {
+		found = rs.Secrets.Scanner().Scan(line)
+	}
+	if len(rs.Rules) == 0 && len(found) == 0 {
 		return Match{}, false
 	}
 
-	for _, rule := range rs.sortedRules() {
-		locs := rule.regex.FindAllStringIndex(line, -1)
-		if len(locs) == 0 {
+	var literalHits *hitSet
+	parsed := parsedLine{line: line}
+	for i, rule := range rs.sortedRules() {
+		if rule.Requires != "" {
 			continue
 		}
-		captures := captureMap(rule.regex, line)
-		return Match{Rule: rule, Captures: captures, HighlightSpans: toPairs(locs)}, true
+		if m, ok := rs.matchRule(i, rule, line, &parsed, &literalHits); ok {
+			rs.literals.release(literalHits)
+			if chained := rs.chain(m); len(chained) > 0 {
+				m = chained[len(chained)-1]
+			}
+			m.Secrets = found
+			return m, true
+		}
 	}
+	rs.literals.release(literalHits)
 
+	if len(found) > 0 {
+		return rs.secretMatch(found), true
+	}
 	return Match{}, false
 }
 
-// FilterByTags returns a new ruleset containing only rules that match any tag in the provided selection.
+// MatchAll returns every rule the line satisfies in match order (severity, then
+// declaration), each followed by the matches chained from it. Unlike Match
+// it ignores the secret detector; it exists for tooling that needs to see
+// overlaps, such as rule coverage reports.
+func (rs RuleSet) MatchAll(line string) []Match {
+	if rs.swap != nil {
+		return rs.Current().MatchAll(line)
+	}
+	var (
+		out         []Match
+		literalHits *hitSet
+	)
+	parsed := parsedLine{line: line}
+	for i, rule := range rs.sortedRules() {
+		if rule.Requires != "" {
+			continue
+		}
+		if m, ok := rs.matchRule(i, rule, line, &parsed, &literalHits); ok {
+			out = append(out, m)
+		}
+	}
+	rs.literals.release(literalHits)
+	for i := len(out) - 1; i >= 0; i-- {
+		if chained := rs.chain(out[i]); len(chained) > 0 {
+			out = append(out[:i+1], append(chained, out[i+1:]...)...)
+		}
+	}
+	return out
+}
+
+// matchRule tests one rule at position i of sortedRules. Literal index hits and
+// parser output are computed lazily and shared across the rules of one line.
+func (rs RuleSet) matchRule(i int, rule Rule, line string, parsed *parsedLine, literalHits **hitSet) (Match, bool) {
+	var (
+		spans         [][2]int
+		regexCaptures map[string]string
+	)
+	switch {
+	case rule.Mode != MatchRegex:
+		if rs.literals == nil {
+			return Match{}, false
+		}
+		if *literalHits == nil {
+			*literalHits = rs.literals.hits(line)
+		}
+		if !(*literalHits).hit[i] {
+			return Match{}, false
+		}
+		spans = literalSpans(rule, line)
+	case rule.regex != nil:
+		// One pass yields both the highlight spans and, from the first
+		// match, the capture groups.
+		locs := rule.regex.FindAllStringSubmatchIndex(line, -1)
+		if len(locs) == 0 {
+			return Match{}, false
+		}
+		spans = toPairs(locs)
+		regexCaptures = captureMap(rule.regex, line, locs[0])
+	}
+	var captures map[string]string
+	if rule.parser != nil {
+		fields, ok := parsed.get(rule.parser)
+		if !ok || !rule.fieldsMatch(fields) {
+			return Match{}, false
+		}
+		captures = make(map[string]string, len(fields))
+		for name, value := range fields {
+			captures[name] = value
+		}
+		if spans == nil {
+			spans = fieldSpans(line, fields, rule.fields)
+		}
+	}
+	if regexCaptures != nil {
+		if captures == nil {
+			captures = regexCaptures
+		}
+		for name, value := range regexCaptures {
+			captures[name] = value
+		}
+	}
+	raw := captures
+	if len(rule.transforms) > 0 && captures != nil {
+		raw = maps.Clone(captures)
+	}
+	rule.applyTransforms(captures)
+	if !rule.whereMatch(captures) {
+		return Match{}, false
+	}
+	rule.Severity = rule.severityFor(captures)
+	return Match{Rule: rule, Captures: captures, HighlightSpans: spans, RawCaptures: raw}, true
+}
+
+func (rs RuleSet) secretMatch(found []secrets.Finding) Match {
+	severity := SeverityHigh
+	if rs.Secrets.Severity != "" {
+		severity = normalizeSeverity(rs.Secrets.Severity)
+	}
+	tags := []string{"secret"}
+	spans := make([][2]int, 0, len(found))
+	for _, f := range found {
+		spans = append(spans, [2]int{f.Start, f.End})
+		if !containsTag(tags, f.Kind) {
+			tags = append(tags, f.Kind)
+		}
+	}
+	rule := Rule{
+		Name:        "leaked secret",
+		Severity:    severity,
+		Tags:        tags,
+		Description: "Built-in detector for credentials and high-entropy tokens.",
+		order:       len(rs.Rules),
+	}
+	return Match{Rule: rule, HighlightSpans: spans, Secrets: found}
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterByTags returns a new ruleset containing only rules with a tag selected
+// by any of the given tag patterns (see TagMatches).
 func (rs RuleSet) FilterByTags(tags []string) RuleSet {
 	if len(tags) == 0 {
 		return rs
 	}
-	selected := make(map[string]struct{}, len(tags))
+	if rs.swap != nil {
+		out := rs.swap.view(tags)
+		out.swap, out.swapTags = rs.swap, append([]string(nil), tags...)
+		return out
+	}
+	selected := make([]string, 0, len(tags))
 	for _, tag := range tags {
-		if tag == "" {
-			continue
+		if strings.TrimSpace(tag) != "" {
+			selected = append(selected, tag)
 		}
-		selected[strings.ToLower(tag)] = struct{}{}
 	}
 	if len(selected) == 0 {
 		return rs
 	}
 	filtered := make([]Rule, 0, len(rs.Rules))
 	for _, rule := range rs.Rules {
-		for _, tag := range rule.Tags {
-			if _, ok := selected[strings.ToLower(tag)]; ok {
-				filtered = append(filtered, rule)
-				break
-			}
+		if AnyTagMatches(selected, rule.Tags) {
+			filtered = append(filtered, rule)
 		}
 	}
-	return RuleSet{Rules: filtered}
+	out := newRuleSet(filtered, rs.Secrets)
+	out.RateAlerts = rs.RateAlerts
+	out.TagStyles = rs.TagStyles
+	return out
+}
+
+// Prepend returns a copy of rs with extra declared ahead of its own rules, so
+// they win over rules of the same severity.
+func (rs RuleSet) Prepend(extra []Rule) RuleSet {
+	list := make([]Rule, 0, len(extra)+len(rs.Rules))
+	list = append(append(list, extra...), rs.Rules...)
+	for i := range list {
+		list[i].order = i
+	}
+	out := newRuleSet(list, rs.Secrets)
+	out.RateAlerts = rs.RateAlerts
+	out.TagStyles = rs.TagStyles
+	out.Entities = rs.Entities
+	return out
 }
 
+// MatchOrder returns the active rules in the order Match tries them: severity,
+// then declaration.
+func (rs RuleSet) MatchOrder() []Rule {
+	return append([]Rule(nil), rs.Current().sortedRules()...)
+}
+
+// HasConditions reports whether the rule narrows its pattern with fields,
+// where, or severity_map, or takes part in a chain (emits, requires), which
+// only spectra evaluates.
+func (r Rule) HasConditions() bool {
+	return len(r.fields) > 0 || len(r.where) > 0 || len(r.severityMap) > 0 || r.Emits != "" || r.Requires != ""
+}
+
+// sortedRules returns the rules in match order: severity, then declaration.
 func (rs RuleSet) sortedRules() []Rule {
-	copyRules := make([]Rule, len(rs.Rules))
-	copy(copyRules, rs.Rules)
+	if len(rs.sorted) == len(rs.Rules) {
+		return rs.sorted
+	}
+	return sortRules(rs.Rules)
+}
+
+func sortRules(rules []Rule) []Rule {
+	copyRules := make([]Rule, len(rules))
+	copy(copyRules, rules)
 	sort.SliceStable(copyRules, func(i, j int) bool {
 		iScore := severityScore(copyRules[i].Severity)
 		jScore := severityScore(copyRules[j].Severity)
@@ -135,14 +454,15 @@ func (rs RuleSet) sortedRules() []Rule {
 	return copyRules
 }
 
-// SeverityRank exposes the ordering used for comparisons (lower is more urgent).
+// SeverityRank exposes the ordering used for comparisons (lower is more
+// urgent). It is the position in Severities, so custom levels slot in.
 func SeverityRank(s Severity) int {
-	for idx, val := range orderedSeverities {
-		if val == s {
+	for idx, level := range *levels.Load() {
+		if level.Name == s {
 			return idx
 		}
 	}
-	return len(orderedSeverities)
+	return len(*levels.Load())
 }
 
 func severityScore(s Severity) int {
@@ -162,6 +482,9 @@ func normalizeSeverity(s Severity) Severity {
 	case "normal":
 		return SeverityNormal
 	default:
+		if custom, ok := customSeverity(string(s)); ok {
+			return custom
+		}
 		return SeverityMedium
 	}
 }
@@ -180,6 +503,9 @@ func ParseSeverity(value string) (Severity, error) {
 	case "normal":
 		return SeverityNormal, nil
 	default:
+		if custom, ok := customSeverity(value); ok {
+			return custom, nil
+		}
 		return "", fmt.Errorf("unknown severity %q", value)
 	}
 }
@@ -189,18 +515,19 @@ func MeetsThreshold(value, min Severity) bool {
 	return SeverityRank(value) <= SeverityRank(min)
 }
 
-func captureMap(re *regexp.Regexp, line string) map[string]string {
-	names := re.SubexpNames()
-	matches := re.FindStringSubmatch(line)
-	if len(matches) == 0 {
-		return nil
-	}
+// captureMap reads the named groups of one match from its submatch indices;
+// a group that did not participate captures "".
+func captureMap(re *regexp.Regexp, line string, loc []int) map[string]string {
 	captures := make(map[string]string)
-	for i, name := range names {
+	for i, name := range re.SubexpNames() {
 		if i == 0 || name == "" {
 			continue
 		}
-		captures[name] = matches[i]
+		if start := loc[2*i]; start >= 0 {
+			captures[name] = line[start:loc[2*i+1]]
+		} else {
+			captures[name] = ""
+		}
 	}
 	return captures
 }
@@ -208,7 +535,7 @@ func captureMap(re *regexp.Regexp, line string) map[string]string {
 func toPairs(spans [][]int) [][2]int {
 	out := make([][2]int, 0, len(spans))
 	for _, span := range spans {
-		if len(span) != 2 {
+		if len(span) < 2 {
 			continue
 		}
 		out = append(out, [2]int{span[0], span[1]})
@@ -218,14 +545,59 @@ func toPairs(spans [][]int) [][2]int {
 
 // RuleDefinition mirrors the YAML representation for easier parsing.
 type RuleDefinition struct {
-	Name        string   `yaml:"name"`
-	Pattern     string   `yaml:"pattern"`
-	Severity    Severity `yaml:"severity"`
-	Color       string   `yaml:"color"`
-	Tags        []string `yaml:"tags"`
-	Description string   `yaml:"description"`
+	Name    string            `yaml:"name"`
+	Pattern string            `yaml:"pattern,omitempty"`
+	Match   string            `yaml:"match,omitempty"`
+	Parser  string            `yaml:"parser,omitempty"`
+	Fields  map[string]string `yaml:"fields,omitempty"`
+	// Transforms normalize capture values (by capture name) before they are
+	// shown, grouped, charted, or compared with a baseline.
+	Transforms map[string][]TransformDefinition `yaml:"transforms,omitempty"`
+	Severity   Severity                         `yaml:"severity"`
+	// SeverityMap grades matches by capture value (capture, then value
+	// pattern to severity); Severity applies when nothing matches.
+	SeverityMap map[string]map[string]Severity `yaml:"severity_map,omitempty"`
+	// Where adds conditions on capture values (capture to value pattern, as
+	// in SeverityMap, e.g. "!cidr:10.0.0.0/8"); a line failing one does not
+	// match the rule.
+	Where       map[string]string `yaml:"where,omitempty"`
+	Color       string            `yaml:"color,omitempty"`
+	Tags        []string          `yaml:"tags,omitempty"`
+	Description string            `yaml:"description,omitempty"`
+	// Enabled set to false keeps the rule in the file (still validated) but
+	// out of matching; the TUI writes it when a filter is persisted.
+	Enabled *bool `yaml:"enabled,omitempty"`
+	// Exec is a command (program, then arguments) to run for each match; see
+	// Action. AutoApprove skips the dashboard's confirmation.
+	Exec        []string `yaml:"exec,omitempty"`
+	AutoApprove bool     `yaml:"auto_approve,omitempty"`
+	// Ban feeds the rule's matches into the ban list (--ban-file, --ban-listen).
+	Ban *Ban `yaml:"ban,omitempty"`
+	// Emits builds a derived line from the captures (${name} placeholders)
+	// for the rules that name this one in Requires; those rules never see
+	// raw lines. Only the rule that wins on the raw line emits: a rule of
+	// higher severity (or earlier at the same severity) without Emits that
+	// also matches the line shadows this one, and no chain starts. See
+	// RuleSet.Match.
+	Emits    string `yaml:"emits,omitempty"`
+	Requires string `yaml:"requires,omitempty"`
+}
+
+// IsEnabled reports whether the rule takes part in matching; unset means yes.
+func (d RuleDefinition) IsEnabled() bool {
+	return d.Enabled == nil || *d.Enabled
 }
 
 type ruleFile struct {
-	Rules []RuleDefinition `yaml:"rules"`
+	Include    []string            `yaml:"include"`
+	Rules      []RuleDefinition    `yaml:"rules"`
+	Templates  []RuleTemplate      `yaml:"templates"`
+	Secrets    SecretScan          `yaml:"secrets"`
+	Rates      []RateAlert         `yaml:"rate_alerts"`
+	TagStyles  map[string]TagStyle `yaml:"tag_styles"`
+	Entities   []string            `yaml:"entities"`
+	Severities []SeverityLevel     `yaml:"severities"`
+	// Anchors is never read; it gives shared YAML anchors (&name) a home so
+	// rules and templates can reuse them with aliases and `<<:` merges.
+	Anchors yaml.Node `yaml:"anchors"`
 }
@@ -0,0 +1,304 @@
+package search
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"watcher/internal/archive"
+	"watcher/internal/pipeline"
+	"watcher/internal/watch"
+)
+
+// Result is one matched historical line.
+type Result struct {
+	pipeline.HighlightedEvent
+	LineNo int
+}
+
+// Options tunes a historical search.
+type Options struct {
+	// Since drops lines (and whole files last modified) before this time; zero keeps everything.
+	Since time.Time
+	// Workers bounds how many files are scanned at once.
+	Workers int
+}
+
+const maxLineBytes = 1 << 20
+
+// Expand resolves path patterns into files. `*` and `?` match within one path
+// segment and `**` across segments; a directory means every file below it.
+// With rotated set, a plain file also brings in its rotated siblings
+// (auth.log.1, auth.log.2.gz, auth.log-20240101.gz).
+func Expand(patterns []string, rotated bool) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+	for _, pattern := range patterns {
+		if !hasMeta(pattern) {
+			info, err := os.Stat(pattern)
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				add(pattern)
+				if rotated {
+					for _, sibling := range rotatedSiblings(pattern) {
+						add(sibling)
+					}
+				}
+				continue
+			}
+			pattern = filepath.Join(pattern, "**")
+		}
+		re, err := globRegexp(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", pattern, err)
+		}
+		base := globBase(pattern)
+		err = filepath.WalkDir(base, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if path == base {
+					return err
+				}
+				if d != nil && d.IsDir() {
+					return fs.SkipDir // unreadable subdirectory
+				}
+				return nil
+			}
+			if d.Type().IsRegular() && re.MatchString(filepath.ToSlash(path)) {
+				add(path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("walk %s: %w", pattern, err)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Run scans files in parallel through stream and returns matches ordered by
+// time. Unreadable files do not stop the search; their errors are joined into
+// the returned error alongside the results.
+func Run(ctx context.Context, files []string, stream pipeline.Stream, opts Options) ([]Result, error) {
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	jobs := make(chan string)
+	var (
+		mu      sync.Mutex
+		results []Result
+		errs    []error
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				found, err := scanFile(ctx, path, stream, opts.Since)
+				mu.Lock()
+				results = append(results, found...)
+				if err != nil {
+					errs = append(errs, err)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+feed:
+	for _, path := range files {
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- path:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if !a.Timestamp.Equal(b.Timestamp) {
+			return a.Timestamp.Before(b.Timestamp)
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.LineNo < b.LineNo
+	})
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return results, errors.Join(errs...)
+}
+
+// scanFile matches every line of one (possibly gzipped) file. Lines without a
+// recognizable timestamp inherit the previous line's, or the file's mtime.
+func scanFile(ctx context.Context, path string, stream pipeline.Stream, since time.Time) ([]Result, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !since.IsZero() && info.ModTime().Before(since) {
+		return nil, nil
+	}
+	ref := info.ModTime()
+	last := ref
+	var out []Result
+	err = EachLine(path, func(lineNo int, line string) bool {
+		if lineNo%4096 == 0 && ctx.Err() != nil {
+			return false
+		}
+		if ts, ok := watch.ParseTimestamp(line, ref); ok {
+			last = ts
+		}
+		if !since.IsZero() && last.Before(since) {
+			return true
+		}
+		evt, keep := stream.Process(watch.LogEvent{Path: path, Line: line})
+		if !keep {
+			return true
+		}
+		evt.Timestamp = last
+		out = append(out, Result{HighlightedEvent: evt, LineNo: lineNo})
+		return true
+	})
+	return out, err
+}
+
+// EachLine calls fn with every line of a file, gunzipping .gz files and
+// decoding UTF-16 and Latin-1 the way live sources do. Journal exports and
+// .evtx files yield one line per record, as when watched; other binary files
+// (wtmp, journal files) yield no lines. fn returns false to stop early.
+func EachLine(path string, fn func(lineNo int, line string) bool) error {
+	if format := archive.Detect(path); format != archive.FormatNone {
+		lineNo := 0
+		return archive.Each(path, format, func(rec archive.Record) bool {
+			lineNo++
+			return fn(lineNo, rec.Line)
+		})
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("gunzip %s: %w", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	br := bufio.NewReaderSize(r, 64<<10)
+	head, _ := br.Peek(512)
+	enc := pipeline.DetectEncoding(head)
+	if bytes.IndexByte(head, 0) >= 0 && enc != pipeline.EncodingUTF16LE && enc != pipeline.EncodingUTF16BE {
+		return nil
+	}
+	var decoder pipeline.Decoder
+
+	scanner := bufio.NewScanner(br)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxLineBytes)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		if decoder.Residue(scanner.Text()) {
+			continue
+		}
+		if !fn(lineNo, strings.TrimRight(decoder.Decode(scanner.Text()), "\r")) {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	return nil
+}
+
+func hasMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// globBase is the directory prefix of pattern that contains no wildcards.
+func globBase(pattern string) string {
+	dir := pattern
+	for hasMeta(dir) {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated [")
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+var rotatedSuffix = regexp.MustCompile(`^[.-]\d+(?:\.gz)?$`)
+
+func rotatedSiblings(path string) []string {
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return nil
+	}
+	base := filepath.Base(path)
+	var out []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.Type().IsRegular() && strings.HasPrefix(name, base) && rotatedSuffix.MatchString(name[len(base):]) {
+			out = append(out, filepath.Join(filepath.Dir(path), name))
+		}
+	}
+	return out
+}
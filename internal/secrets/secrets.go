@@ -0,0 +1,183 @@
+package secrets
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Finding marks a likely secret as a [start,end) byte range within a line.
+type Finding struct {
+	Kind  string
+	Start int
+	End   int
+}
+
+// Scanner detects leaked credentials through known key shapes and token entropy.
+type Scanner struct {
+	MinEntropy float64
+	MinLength  int
+}
+
+var (
+	awsAccessKey = regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA)[0-9A-Z]{16}\b`)
+	awsSecretKey = regexp.MustCompile(`(?i)aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})`)
+	jwtToken     = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)
+	tokenCandid  = regexp.MustCompile(`[A-Za-z0-9+/_-]+=*`)
+)
+
+// NewScanner returns a scanner with the default entropy threshold.
+func NewScanner() Scanner {
+	return Scanner{MinEntropy: 3.5, MinLength: 20}
+}
+
+// Scan returns non-overlapping findings ordered by position.
+func (s Scanner) Scan(line string) []Finding {
+	var findings []Finding
+	for _, loc := range awsAccessKey.FindAllStringIndex(line, -1) {
+		findings = appendFinding(findings, Finding{Kind: "aws-access-key", Start: loc[0], End: loc[1]})
+	}
+	for _, loc := range awsSecretKey.FindAllStringSubmatchIndex(line, -1) {
+		findings = appendFinding(findings, Finding{Kind: "aws-secret-key", Start: loc[2], End: loc[3]})
+	}
+	for _, loc := range jwtToken.FindAllStringIndex(line, -1) {
+		findings = appendFinding(findings, Finding{Kind: "jwt", Start: loc[0], End: loc[1]})
+	}
+	minLength := s.MinLength
+	if minLength <= 0 {
+		minLength = 20
+	}
+	for _, loc := range tokenCandid.FindAllStringIndex(line, -1) {
+		token := line[loc[0]:loc[1]]
+		if len(token) < minLength || !mixedClasses(token) {
+			continue
+		}
+		if Entropy(token) < s.MinEntropy {
+			continue
+		}
+		findings = appendFinding(findings, Finding{Kind: "high-entropy", Start: loc[0], End: loc[1]})
+	}
+	sort.Slice(findings, func(i, j int) bool {
+		return findings[i].Start < findings[j].Start
+	})
+	return findings
+}
+
+// Entropy computes the Shannon entropy of value in bits per byte.
+func Entropy(value string) float64 {
+	if value == "" {
+		return 0
+	}
+	var counts [256]int
+	for i := 0; i < len(value); i++ {
+		counts[value[i]]++
+	}
+	total := float64(len(value))
+	entropy := 0.0
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / total
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// Redaction holds a redacted line plus the mapping needed to translate original offsets.
+type Redaction struct {
+	Line   string
+	Spans  [][2]int
+	ranges []redactedRange
+}
+
+type redactedRange struct {
+	origStart, origEnd int
+	newStart, newEnd   int
+}
+
+// Redact replaces each finding with a `[REDACTED:kind]` marker.
+func Redact(line string, findings []Finding) Redaction {
+	if len(findings) == 0 {
+		return Redaction{Line: line}
+	}
+	var b strings.Builder
+	b.Grow(len(line))
+	out := Redaction{}
+	cursor := 0
+	for _, f := range findings {
+		if f.Start < cursor || f.End > len(line) || f.Start >= f.End {
+			continue
+		}
+		b.WriteString(line[cursor:f.Start])
+		newStart := b.Len()
+		b.WriteString("[REDACTED:" + f.Kind + "]")
+		out.ranges = append(out.ranges, redactedRange{origStart: f.Start, origEnd: f.End, newStart: newStart, newEnd: b.Len()})
+		out.Spans = append(out.Spans, [2]int{newStart, b.Len()})
+		cursor = f.End
+	}
+	b.WriteString(line[cursor:])
+	out.Line = b.String()
+	return out
+}
+
+// Offset maps a byte offset in the original line onto the redacted line.
+func (r Redaction) Offset(pos int) int {
+	shift := 0
+	for _, rr := range r.ranges {
+		if pos < rr.origStart {
+			break
+		}
+		if pos < rr.origEnd {
+			return rr.newStart
+		}
+		shift = rr.newEnd - rr.origEnd
+	}
+	return pos + shift
+}
+
+// MapSpans translates highlight spans from the original line onto the redacted line.
+func (r Redaction) MapSpans(spans [][2]int) [][2]int {
+	if len(r.ranges) == 0 {
+		return spans
+	}
+	out := make([][2]int, 0, len(spans))
+	for _, span := range spans {
+		start, end := r.Offset(span[0]), r.Offset(span[1])
+		for _, rr := range r.ranges {
+			if span[1] > rr.origStart && span[1] <= rr.origEnd {
+				end = rr.newEnd
+			}
+		}
+		if end > start {
+			out = append(out, [2]int{start, end})
+		}
+	}
+	return out
+}
+
+func appendFinding(list []Finding, f Finding) []Finding {
+	for _, existing := range list {
+		if f.Start < existing.End && existing.Start < f.End {
+			return list
+		}
+	}
+	return append(list, f)
+}
+
+func mixedClasses(token string) bool {
+	var upper, lower, digit bool
+	for _, r := range token {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	return upper && lower && digit
+}
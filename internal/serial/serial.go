@@ -0,0 +1,29 @@
+// Package serial opens serial consoles (USB adapters, router and board
+// consoles) as raw 8N1 byte streams at a fixed baud rate.
+package serial
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultBaud is the rate used when a spec names none; it is what most
+// embedded boards and network gear use for their console.
+const DefaultBaud = 115200
+
+// ParseSpec splits `/dev/ttyUSB0@115200` into the device and its baud rate.
+func ParseSpec(spec string) (string, int, error) {
+	device, rate, found := strings.Cut(spec, "@")
+	if device == "" {
+		return "", 0, fmt.Errorf("serial: want device[@baud], got %q", spec)
+	}
+	if !found {
+		return device, DefaultBaud, nil
+	}
+	baud, err := strconv.Atoi(rate)
+	if err != nil || baud <= 0 {
+		return "", 0, fmt.Errorf("serial: bad baud rate %q", rate)
+	}
+	return device, baud, nil
+}
@@ -0,0 +1,22 @@
+//go:build darwin || freebsd || netbsd || openbsd
+
+package serial
+
+import "golang.org/x/sys/unix"
+
+const (
+	getTermios = unix.TIOCGETA
+	setTermios = unix.TIOCSETA
+)
+
+// setSpeed stores the rate itself: BSD termios speeds are plain numbers,
+// whose width differs between systems.
+func setSpeed(t *unix.Termios, baud int) error {
+	t.Ispeed = speedAs(t.Ispeed, baud)
+	t.Ospeed = speedAs(t.Ospeed, baud)
+	return nil
+}
+
+func speedAs[T int32 | uint32 | uint64](_ T, baud int) T {
+	return T(baud)
+}
@@ -0,0 +1,14 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package serial
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// Open reports that serial consoles are not supported on this platform.
+func Open(device string, baud int) (*os.File, error) {
+	return nil, fmt.Errorf("serial: %s: not supported on %s", device, runtime.GOOS)
+}
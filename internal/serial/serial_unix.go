@@ -0,0 +1,56 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package serial
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// Open opens device for reading and writing in raw mode: 8 data bits, no
+// parity, one stop bit, no flow control, no echo or line editing. The file is
+// non-blocking underneath, so closing it interrupts a pending Read.
+func Open(device string, baud int) (*os.File, error) {
+	f, err := os.OpenFile(device, os.O_RDWR|unix.O_NOCTTY|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, fmt.Errorf("serial: %w", err)
+	}
+	conn, err := f.SyscallConn()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("serial: %s: %w", device, err)
+	}
+	var setErr error
+	err = conn.Control(func(fd uintptr) {
+		setErr = configure(int(fd), baud)
+	})
+	if err == nil {
+		err = setErr
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("serial: %s: %w", device, err)
+	}
+	return f, nil
+}
+
+// configure puts the terminal in raw 8N1 mode at baud, as cfmakeraw does.
+func configure(fd, baud int) error {
+	t, err := unix.IoctlGetTermios(fd, getTermios)
+	if err != nil {
+		return fmt.Errorf("not a terminal: %w", err)
+	}
+	t.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON | unix.IXOFF
+	t.Oflag &^= unix.OPOST
+	t.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	t.Cflag &^= unix.CSIZE | unix.PARENB | unix.CSTOPB | unix.CRTSCTS
+	t.Cflag |= unix.CS8 | unix.CREAD | unix.CLOCAL
+	t.Cc[unix.VMIN] = 1
+	t.Cc[unix.VTIME] = 0
+	if err := setSpeed(t, baud); err != nil {
+		return err
+	}
+	return unix.IoctlSetTermios(fd, setTermios, t)
+}
@@ -0,0 +1,271 @@
+package settings
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Source identifies which configuration layer supplied a value.
+type Source string
+
+// Layers in increasing precedence order.
+const (
+	SourceDefault Source = "default"
+	SourceFile    Source = "file"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+)
+
+// EnvPrefix prefixes the environment variable for every flag (`--min-severity` → SPECTRA_MIN_SEVERITY).
+const EnvPrefix = "SPECTRA_"
+
+// Layer is the value one source proposed for a setting.
+type Layer struct {
+	Source Source
+	Origin string
+	Value  string
+}
+
+// Setting is the resolved value of a flag plus every layer that proposed one, lowest precedence first.
+type Setting struct {
+	Name   string
+	Value  string
+	Source Source
+	Layers []Layer
+}
+
+// Winner returns the layer that supplied the resolved value.
+func (s Setting) Winner() Layer {
+	return s.Layers[len(s.Layers)-1]
+}
+
+// EnvName maps a flag name to its environment variable.
+func EnvName(flagName string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// DefaultPath returns the per-user settings file (SPECTRA_SETTINGS overrides it).
+func DefaultPath() string {
+	if path := os.Getenv(EnvPrefix + "SETTINGS"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "spectra", "config.yaml")
+}
+
+// RulesDir returns the per-user directory the rule picker also searches for
+// *.rules.yaml files.
+func RulesDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "spectra")
+}
+
+// SessionPath resolves a --session name to its file: a name with a path
+// separator or a .json suffix is used as given, any other is stored under
+// sessions/ in the per-user spectra config directory. Empty stays empty.
+func SessionPath(name string) string {
+	if name == "" || strings.ContainsRune(name, filepath.Separator) || strings.HasSuffix(name, ".json") {
+		return name
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return name + ".json"
+	}
+	return filepath.Join(dir, "spectra", "sessions", name+".json")
+}
+
+// LoadFile reads a YAML settings file keyed by flag name. Lists are joined
+// with commas so `files: [a, b]` matches `--files=a,b`. A missing file yields no values.
+func LoadFile(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]yaml.Node
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("parse settings: %w", err)
+	}
+	values := make(map[string]string, len(raw))
+	for key, node := range raw {
+		switch node.Kind {
+		case yaml.ScalarNode:
+			values[key] = node.Value
+		case yaml.SequenceNode:
+			items := make([]string, 0, len(node.Content))
+			for _, item := range node.Content {
+				items = append(items, item.Value)
+			}
+			values[key] = strings.Join(items, ",")
+		default:
+			return nil, fmt.Errorf("setting %q must be a scalar or list", key)
+		}
+	}
+	return values, nil
+}
+
+// Resolve layers defaults < file < env < flags onto a parsed flag set: flags
+// not given on the command line take the highest remaining layer. Names in
+// skip (e.g. the settings path itself) are left alone.
+func Resolve(fs *flag.FlagSet, file map[string]string, filePath string, lookupEnv func(string) (string, bool), skip ...string) ([]Setting, error) {
+	skipped := make(map[string]bool, len(skip))
+	for _, name := range skip {
+		skipped[name] = true
+	}
+	for key := range file {
+		if fs.Lookup(key) == nil || skipped[key] {
+			return nil, fmt.Errorf("%s: unknown setting %q", filePath, key)
+		}
+	}
+	explicit := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = f.Value.String()
+	})
+
+	var resolved []Setting
+	var firstErr error
+	fs.VisitAll(func(f *flag.Flag) {
+		if skipped[f.Name] || firstErr != nil {
+			return
+		}
+		layers := []Layer{{Source: SourceDefault, Value: f.DefValue}}
+		if v, ok := file[f.Name]; ok {
+			layers = append(layers, Layer{Source: SourceFile, Origin: filePath, Value: v})
+		}
+		envName := EnvName(f.Name)
+		if v, ok := lookupEnv(envName); ok {
+			layers = append(layers, Layer{Source: SourceEnv, Origin: envName, Value: v})
+		}
+		if v, ok := explicit[f.Name]; ok {
+			layers = append(layers, Layer{Source: SourceFlag, Origin: "--" + f.Name, Value: v})
+		}
+		winner := layers[len(layers)-1]
+		if winner.Source == SourceFile || winner.Source == SourceEnv {
+			if err := f.Value.Set(winner.Value); err != nil {
+				firstErr = fmt.Errorf("%s %s: %w", winner.Source, coalesce(winner.Origin, f.Name), err)
+				return
+			}
+		}
+		resolved = append(resolved, Setting{Name: f.Name, Value: f.Value.String(), Source: winner.Source, Layers: layers})
+	})
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return resolved, nil
+}
+
+func coalesce(val, fallback string) string {
+	if val == "" {
+		return fallback
+	}
+	return val
+}
+
+// Profile is per-user UI state remembered between sessions under a profile name.
+type Profile struct {
+	Mode string `json:"mode,omitempty"`
+	// PulseTags, when positive, makes the sidebar pulse count the top this
+	// many tags instead of severities.
+	PulseTags int `json:"pulse_tags,omitempty"`
+}
+
+type stateFile struct {
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+// StatePath returns where profiles are persisted (SPECTRA_STATE overrides it).
+func StatePath() string {
+	if path := os.Getenv(EnvPrefix + "STATE"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "spectra", "state.json")
+}
+
+// LoadProfile reads one profile; a missing file or profile yields the zero Profile.
+func LoadProfile(path, name string) (Profile, error) {
+	state, err := readState(path)
+	if err != nil {
+		return Profile{}, err
+	}
+	return state.Profiles[name], nil
+}
+
+// SaveProfile stores one profile, leaving the others untouched.
+func SaveProfile(path, name string, p Profile) error {
+	if path == "" {
+		return fmt.Errorf("no state path")
+	}
+	state, err := readState(path)
+	if err != nil {
+		return err
+	}
+	state.Profiles[name] = p
+	content, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("save profile: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("save profile: %w", err)
+	}
+	return nil
+}
+
+// UpdateProfile applies fn to one stored profile and saves it, so changing
+// one setting keeps the others.
+func UpdateProfile(path, name string, fn func(*Profile)) error {
+	if path == "" {
+		return fmt.Errorf("no state path")
+	}
+	state, err := readState(path)
+	if err != nil {
+		return err
+	}
+	p := state.Profiles[name]
+	fn(&p)
+	return SaveProfile(path, name, p)
+}
+
+func readState(path string) (stateFile, error) {
+	state := stateFile{Profiles: map[string]Profile{}}
+	if path == "" {
+		return state, nil
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	if err := json.Unmarshal(content, &state); err != nil {
+		return state, fmt.Errorf("parse state: %w", err)
+	}
+	if state.Profiles == nil {
+		state.Profiles = map[string]Profile{}
+	}
+	return state, nil
+}
@@ -0,0 +1,116 @@
+package shipper
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+
+	"watcher/internal/rules"
+	"watcher/internal/watch"
+)
+
+// writeFluentBit emits a classic-format Fluent Bit configuration. Inputs are
+// tagged spectra.in.N; one rewrite_tag filter per rule, in match order, retags
+// the records it matches spectra.<severity>.<rule> (so later rule filters,
+// which only see spectra.in.*, skip them) and a final one retags the rest
+// spectra.normal.unmatched. modify filters then add spectra_rule and severity,
+// and the stdout output matches the routed severities.
+func writeFluentBit(w io.Writer, p Plan) error {
+	var body bytes.Buffer
+	inputs := 0
+	for _, s := range p.Sources {
+		tag := fmt.Sprintf("spectra.in.%d", inputs)
+		switch s.Kind {
+		case watch.SourceFile, watch.SourceGlob, watch.SourceDir:
+			path := s.Target
+			if s.Kind == watch.SourceDir {
+				path = strings.TrimRight(path, "/") + "/*"
+			}
+			fmt.Fprintf(&body, "[INPUT]\n    Name     tail\n    Path     %s\n    Path_Key source\n    Tag      %s\n\n", path, tag)
+		case watch.SourceUnix, watch.SourceUnixgram:
+			mode := "unix_tcp"
+			if s.Kind == watch.SourceUnixgram {
+				mode = "unix_udp"
+			}
+			body.WriteString("# The syslog input parses each line with its Parser; define one that\n# keeps the whole line in `log` if clients do not send syslog.\n")
+			fmt.Fprintf(&body, "[INPUT]\n    Name     syslog\n    Mode     %s\n    Path     %s\n    Tag      %s\n\n", mode, s.Target, tag)
+		case watch.SourceFluent:
+			host, port, err := net.SplitHostPort(listenAddress(s.Target))
+			if err != nil {
+				return fmt.Errorf("fluent source %q: %w", s.Target, err)
+			}
+			fmt.Fprintf(&body, "[INPUT]\n    Name     forward\n    Listen   %s\n    Port     %s\n    Tag      %s\n\n", host, port, tag)
+		default:
+			p.unsupported(s, "Fluent Bit")
+			continue
+		}
+		inputs++
+	}
+	if inputs == 0 {
+		return fmt.Errorf("no sources Fluent Bit can read")
+	}
+
+	retag := func(pattern, newTag string) {
+		fmt.Fprintf(&body, "[FILTER]\n    Name  rewrite_tag\n    Match spectra.in.*\n    Rule  $log %s %s false\n\n", pattern, newTag)
+	}
+	modify := func(match, rule string, severity rules.Severity) {
+		fmt.Fprintf(&body, "[FILTER]\n    Name  modify\n    Match %s\n", match)
+		if rule != "" {
+			fmt.Fprintf(&body, "    Add   spectra_rule %s\n", rule)
+		}
+		fmt.Fprintf(&body, "    Add   severity %s\n\n", severity)
+	}
+	type retagged struct {
+		tag  string
+		rule rules.Rule
+	}
+	var tags []retagged
+	seen := map[string]int{}
+	for _, rule := range p.Rules {
+		name := sanitize(rule.Name)
+		if seen[name]++; seen[name] > 1 {
+			name = fmt.Sprintf("%s_%d", name, seen[name])
+		}
+		tag := fmt.Sprintf("spectra.%s.%s", sanitize(string(rule.Severity)), name)
+		fmt.Fprintf(&body, "# rule %q\n", rule.Name)
+		retag(onigmoPattern(rule), tag)
+		tags = append(tags, retagged{tag: tag, rule: rule})
+	}
+	retag(".*", "spectra.normal.unmatched")
+	for _, t := range tags {
+		modify(t.tag, strings.ReplaceAll(t.rule.Name, " ", "_"), t.rule.Severity)
+	}
+	modify("spectra.normal.unmatched", "", rules.SeverityNormal)
+
+	routed := make([]string, len(p.Routed))
+	for i, level := range p.Routed {
+		routed[i] = sanitize(string(level))
+	}
+	fmt.Fprintf(&body, "[OUTPUT]\n    Name        stdout\n    Match_Regex ^spectra\\.(%s)\\.\n    Format      json_lines\n", strings.Join(routed, "|"))
+
+	header(w, "Fluent Bit", p)
+	fmt.Fprintln(w, "# Rules test the `log` key, which tail sets; forward records must carry it too.")
+	fmt.Fprintln(w)
+	_, err := body.WriteTo(w)
+	return err
+}
+
+// onigmoPattern renders a rule's pattern as a rewrite_tag regex: Onigmo spells
+// named groups (?<name>), and the Rule line is split on spaces.
+func onigmoPattern(rule rules.Rule) string {
+	pattern := rule.Pattern
+	switch rule.Mode {
+	case rules.MatchLiteral:
+		pattern = regexp.QuoteMeta(pattern)
+	case rules.MatchPrefix:
+		pattern = "^" + regexp.QuoteMeta(pattern)
+	case rules.MatchSuffix:
+		pattern = regexp.QuoteMeta(pattern) + "$"
+	default:
+		pattern = strings.ReplaceAll(pattern, "(?P<", "(?<")
+	}
+	return strings.ReplaceAll(pattern, " ", `\x20`)
+}
@@ -0,0 +1,108 @@
+// Package shipper translates spectra sources and rules into configuration for
+// a permanent log shipper (Vector or Fluent Bit), so a setup tried out
+// interactively can be deployed without spectra in the path.
+package shipper
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"watcher/internal/rules"
+	"watcher/internal/watch"
+)
+
+// Source is one --files entry the shipper can read.
+type Source struct {
+	Kind   watch.SourceKind
+	Target string
+}
+
+// Plan is what gets exported: the sources and rules that translate, the
+// severities routed to the output, and notes on everything left out.
+type Plan struct {
+	Sources []Source
+	Rules   []rules.Rule
+	// Routed lists the severities at or above the minimum, most urgent first.
+	Routed []rules.Severity
+	// Levels is every severity, most urgent first.
+	Levels []rules.Severity
+	Notes  []string
+}
+
+// NewPlan classifies specs and keeps the rules a shipper can evaluate: those
+// with a pattern and no fields, where, severity_map, or chaining. Rules are
+// kept in match order, so the first that matches wins as it does in spectra.
+func NewPlan(specs []string, rs rules.RuleSet, min rules.Severity) Plan {
+	var p Plan
+	for _, spec := range specs {
+		kind, target := watch.ClassifySource(spec)
+		p.Sources = append(p.Sources, Source{Kind: kind, Target: target})
+	}
+	for _, rule := range rs.MatchOrder() {
+		switch {
+		case rule.Pattern == "":
+			p.Notes = append(p.Notes, fmt.Sprintf("rule %q skipped: parser-only rules have no pattern", rule.Name))
+		case rule.HasConditions():
+			p.Notes = append(p.Notes, fmt.Sprintf("rule %q skipped: fields, where, severity_map, and chaining are spectra-only", rule.Name))
+		default:
+			p.Rules = append(p.Rules, rule)
+		}
+	}
+	for _, level := range rules.Severities() {
+		p.Levels = append(p.Levels, level.Name)
+		if rules.MeetsThreshold(level.Name, min) {
+			p.Routed = append(p.Routed, level.Name)
+		}
+	}
+	return p
+}
+
+// Write emits the plan in format, "vector" or "fluentbit".
+func Write(w io.Writer, format string, p Plan) error {
+	switch format {
+	case "vector":
+		return writeVector(w, p)
+	case "fluentbit":
+		return writeFluentBit(w, p)
+	}
+	return fmt.Errorf("unknown format %q (want vector or fluentbit)", format)
+}
+
+// unsupported records a source the format cannot read.
+func (p *Plan) unsupported(s Source, format string) {
+	p.Notes = append(p.Notes, fmt.Sprintf("source %s:%s skipped: %s has no equivalent input", s.Kind, s.Target, format))
+}
+
+// header writes the notes as comment lines.
+func header(w io.Writer, format string, p Plan) {
+	fmt.Fprintf(w, "# %s configuration exported by spectra-watch export-config.\n", format)
+	fmt.Fprintln(w, "# The output prints to stdout; point it at your destination before deploying.")
+	for _, note := range p.Notes {
+		fmt.Fprintf(w, "# - %s\n", note)
+	}
+	fmt.Fprintln(w)
+}
+
+// listenAddress fills in the wildcard host spectra's `:port` listeners imply.
+func listenAddress(addr string) string {
+	if host, port, err := net.SplitHostPort(addr); err == nil && host == "" {
+		return net.JoinHostPort("0.0.0.0", port)
+	}
+	return addr
+}
+
+// sanitize makes a rule name safe for component names and tags.
+func sanitize(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return strings.Trim(b.String(), "_")
+}
@@ -0,0 +1,132 @@
+package shipper
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"watcher/internal/rules"
+	"watcher/internal/watch"
+)
+
+// writeVector emits Vector YAML: one source per spec, a remap transform that
+// sets .spectra_rule, .severity, and .tags from the first matching rule, a
+// route per severity, and a console sink fed by the routed severities.
+func writeVector(w io.Writer, p Plan) error {
+	var body bytes.Buffer
+	var inputs, files []string
+	body.WriteString("sources:\n")
+	for i, s := range p.Sources {
+		name := fmt.Sprintf("spectra_%s_%d", sanitize(string(s.Kind)), i)
+		switch s.Kind {
+		case watch.SourceFile, watch.SourceGlob:
+			files = append(files, s.Target)
+			continue
+		case watch.SourceDir:
+			files = append(files, strings.TrimRight(s.Target, "/")+"/*")
+			continue
+		case watch.SourceUnix, watch.SourceUnixgram:
+			mode := "unix_stream"
+			if s.Kind == watch.SourceUnixgram {
+				mode = "unix_datagram"
+			}
+			fmt.Fprintf(&body, "  %s:\n    type: socket\n    mode: %s\n    path: %s\n", name, mode, yamlString(s.Target))
+		case watch.SourceGELFUDP:
+			fmt.Fprintf(&body, "  %s:\n    type: socket\n    mode: udp\n    address: %s\n    decoding:\n      codec: gelf\n",
+				name, yamlString(listenAddress(s.Target)))
+		case watch.SourceGELFTCP:
+			fmt.Fprintf(&body, "  %s:\n    type: socket\n    mode: tcp\n    address: %s\n    framing:\n      method: character_delimited\n      character_delimited:\n        delimiter: \"\\0\"\n    decoding:\n      codec: gelf\n",
+				name, yamlString(listenAddress(s.Target)))
+		case watch.SourceFluent:
+			fmt.Fprintf(&body, "  %s:\n    type: fluent\n    address: %s\n", name, yamlString(listenAddress(s.Target)))
+		case watch.SourceExec:
+			_, command := watch.ParseCommand(s.Target)
+			fmt.Fprintf(&body, "  %s:\n    type: exec\n    mode: streaming\n    command: [\"/bin/sh\", \"-c\", %s]\n    streaming:\n      respawn_on_exit: true\n",
+				name, yamlString(command))
+		case watch.SourceStdin:
+			fmt.Fprintf(&body, "  %s:\n    type: stdin\n", name)
+		default:
+			p.unsupported(s, "Vector")
+			continue
+		}
+		inputs = append(inputs, name)
+	}
+	if len(files) > 0 {
+		body.WriteString("  spectra_files:\n    type: file\n    include:\n")
+		for _, f := range files {
+			fmt.Fprintf(&body, "      - %s\n", yamlString(f))
+		}
+		inputs = append(inputs, "spectra_files")
+	}
+	if len(inputs) == 0 {
+		return fmt.Errorf("no sources Vector can read")
+	}
+
+	body.WriteString("\ntransforms:\n  spectra_rules:\n    type: remap\n    inputs:\n")
+	for _, in := range inputs {
+		fmt.Fprintf(&body, "      - %s\n", in)
+	}
+	body.WriteString("    source: |\n")
+	for _, line := range strings.Split(vrlProgram(p.Rules), "\n") {
+		fmt.Fprintf(&body, "      %s\n", line)
+	}
+	body.WriteString("  spectra_severity:\n    type: route\n    inputs:\n      - spectra_rules\n    route:\n")
+	for _, level := range p.Levels {
+		fmt.Fprintf(&body, "      %s: %s\n", sanitize(string(level)), yamlString(".severity == "+strconv.Quote(string(level))))
+	}
+
+	body.WriteString("\nsinks:\n  spectra_out:\n    type: console\n    inputs:\n")
+	for _, level := range p.Routed {
+		fmt.Fprintf(&body, "      - spectra_severity.%s\n", sanitize(string(level)))
+	}
+	body.WriteString("    encoding:\n      codec: json\n")
+
+	header(w, "Vector", p)
+	_, err := body.WriteTo(w)
+	return err
+}
+
+// vrlProgram is an if/else-if chain over the rules in match order, testing the
+// message (or a fluent record's `log`) as spectra tests the line.
+func vrlProgram(list []rules.Rule) string {
+	var b strings.Builder
+	b.WriteString("line = string(.message) ?? string(.log) ?? \"\"\n")
+	for i, rule := range list {
+		if i > 0 {
+			b.WriteString(" else ")
+		}
+		fmt.Fprintf(&b, "if %s {\n", vrlCondition(rule))
+		fmt.Fprintf(&b, "  .spectra_rule = %s\n", strconv.Quote(rule.Name))
+		fmt.Fprintf(&b, "  .severity = %s\n", strconv.Quote(string(rule.Severity)))
+		tags := make([]string, len(rule.Tags))
+		for j, tag := range rule.Tags {
+			tags[j] = strconv.Quote(tag)
+		}
+		fmt.Fprintf(&b, "  .tags = [%s]\n}", strings.Join(tags, ", "))
+	}
+	if len(list) > 0 {
+		b.WriteString(" else {\n  .severity = \"normal\"\n}")
+	} else {
+		b.WriteString(".severity = \"normal\"")
+	}
+	return b.String()
+}
+
+func vrlCondition(rule rules.Rule) string {
+	switch rule.Mode {
+	case rules.MatchLiteral:
+		return "contains(line, " + strconv.Quote(rule.Pattern) + ")"
+	case rules.MatchPrefix:
+		return "starts_with(line, " + strconv.Quote(rule.Pattern) + ")"
+	case rules.MatchSuffix:
+		return "ends_with(line, " + strconv.Quote(rule.Pattern) + ")"
+	}
+	return "match(line, r'" + strings.ReplaceAll(rule.Pattern, "'", `\'`) + "')"
+}
+
+// yamlString quotes s as a YAML double-quoted scalar.
+func yamlString(s string) string {
+	return strconv.Quote(s)
+}
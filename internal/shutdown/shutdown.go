@@ -0,0 +1,130 @@
+// Package shutdown stops the watcher in order: sources first, so nothing new
+// enters the pipeline, then the sinks once they have drained and flushed what
+// is already in flight, then whatever state is persisted on exit. Every step
+// shares one deadline and is reported on the terminal as it finishes.
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Phase orders shutdown work. Tasks of one phase run concurrently; a phase
+// starts once the previous one has finished or the deadline has passed.
+type Phase int
+
+const (
+	// Sources stop reading.
+	Sources Phase = iota
+	// Sinks drain their buffered events and flush.
+	Sinks
+	// Persist writes state that must survive the restart.
+	Persist
+	phaseCount
+)
+
+var phaseNames = [phaseCount]string{"stopping sources", "flushing sinks", "persisting state"}
+
+type task struct {
+	name string
+	fn   func(context.Context) error
+}
+
+// Coordinator collects shutdown tasks while the watcher starts and runs them
+// once, in phase order, when it stops.
+type Coordinator struct {
+	out      io.Writer
+	deadline time.Duration
+	mu       sync.Mutex
+	tasks    [phaseCount][]task
+	once     sync.Once
+	err      error
+}
+
+// New returns a coordinator that reports to out and gives the whole shutdown
+// deadline to finish; zero means no deadline.
+func New(out io.Writer, deadline time.Duration) *Coordinator {
+	return &Coordinator{out: out, deadline: deadline}
+}
+
+// Add registers fn under phase. fn should return when its work is done or ctx
+// (the shutdown deadline) expires.
+func (c *Coordinator) Add(phase Phase, name string, fn func(context.Context) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tasks[phase] = append(c.tasks[phase], task{name: name, fn: fn})
+}
+
+// Done returns a task that waits for done to close, for goroutines that
+// signal completion by closing a channel.
+func Done(done <-chan struct{}) func(context.Context) error {
+	return func(ctx context.Context) error {
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Shutdown runs every task in phase order and reports the first failure.
+// Later calls return the same result without running anything again.
+func (c *Coordinator) Shutdown() error {
+	c.once.Do(func() {
+		ctx := context.Background()
+		if c.deadline > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, c.deadline)
+			defer cancel()
+		}
+		c.mu.Lock()
+		phases := c.tasks
+		c.mu.Unlock()
+		var errs []error
+		for phase, tasks := range phases {
+			if len(tasks) == 0 {
+				continue
+			}
+			fmt.Fprintf(c.out, "shutdown: %s…\n", phaseNames[phase])
+			errs = append(errs, c.runPhase(ctx, tasks)...)
+		}
+		c.err = errors.Join(errs...)
+	})
+	return c.err
+}
+
+func (c *Coordinator) runPhase(ctx context.Context, tasks []task) []error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, t := range tasks {
+		wg.Add(1)
+		go func(t task) {
+			defer wg.Done()
+			start := time.Now()
+			err := t.fn(ctx)
+			took := time.Since(start).Round(time.Millisecond)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case errors.Is(err, context.DeadlineExceeded):
+				fmt.Fprintf(c.out, "  %s: gave up after %s\n", t.name, took)
+				errs = append(errs, fmt.Errorf("%s: shutdown deadline exceeded", t.name))
+			case err != nil:
+				fmt.Fprintf(c.out, "  %s: %v\n", t.name, err)
+				errs = append(errs, fmt.Errorf("%s: %w", t.name, err))
+			default:
+				fmt.Fprintf(c.out, "  %s: done (%s)\n", t.name, took)
+			}
+		}(t)
+	}
+	wg.Wait()
+	return errs
+}
@@ -0,0 +1,297 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"watcher/internal/pipeline"
+	"watcher/internal/rules"
+)
+
+// Ban list formats: one CIDR per line, or nftables set definitions to
+// `include` inside a table.
+const (
+	BanFormatCIDR = "cidr"
+	BanFormatNFT  = "nft"
+)
+
+// banSweep is how often expired bans are dropped and the file rewritten.
+const banSweep = 10 * time.Second
+
+// BanList keeps the addresses that tripped a rule's `ban` settings until their
+// ban expires, and publishes them as a file (rewritten on every change) and
+// over HTTP (/bans.txt, /bans.nft). The list starts empty on every run.
+type BanList struct {
+	bans   map[string]rules.Ban
+	ignore []netip.Prefix
+	path   string
+	format string
+	now    func() time.Time
+	failed atomic.Uint64
+
+	mu      sync.Mutex
+	hits    map[banKey][]time.Time
+	entries map[netip.Addr]banEntry
+}
+
+type banKey struct {
+	rule string
+	addr netip.Addr
+}
+
+type banEntry struct {
+	until time.Time
+	rule  string
+}
+
+// NewBanList builds the list for the rules' ban settings. path may be empty
+// (HTTP only); ignore lists prefixes that are never banned.
+func NewBanList(bans map[string]rules.Ban, path, format string, ignore []string) (*BanList, error) {
+	switch format {
+	case BanFormatCIDR, BanFormatNFT:
+	default:
+		return nil, fmt.Errorf("ban format %q: want %s or %s", format, BanFormatCIDR, BanFormatNFT)
+	}
+	b := &BanList{
+		bans:    bans,
+		path:    path,
+		format:  format,
+		now:     time.Now,
+		hits:    make(map[banKey][]time.Time),
+		entries: make(map[netip.Addr]banEntry),
+	}
+	for _, spec := range ignore {
+		if spec = strings.TrimSpace(spec); spec == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(spec)
+		if err != nil {
+			return nil, fmt.Errorf("ban ignore: %w", err)
+		}
+		b.ignore = append(b.ignore, prefix.Masked())
+	}
+	return b, nil
+}
+
+// Run feeds matched events into the list until the channel closes or ctx is
+// done, rewriting the file whenever an address is banned or a ban expires.
+func (b *BanList) Run(ctx context.Context, events <-chan pipeline.HighlightedEvent) {
+	b.writeFile()
+	ticker := time.NewTicker(banSweep)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if b.expire() {
+				b.writeFile()
+			}
+		case evt, ok := <-events:
+			if !ok {
+				return
+			}
+			if evt.RuleName == "" || evt.Err != nil || evt.Gap > 0 {
+				continue
+			}
+			if b.Observe(evt) {
+				b.writeFile()
+			}
+		}
+	}
+}
+
+// Failed reports how many times the ban file could not be written.
+func (b *BanList) Failed() uint64 {
+	return b.failed.Load()
+}
+
+// Observe counts a match against its rule's ban settings and reports whether
+// it banned a new address or extended a ban. Matches count at the event's
+// time; a file's backlog and replayed lines older than the rule's window are
+// history, not an attack in progress, and are not counted.
+func (b *BanList) Observe(evt pipeline.HighlightedEvent) bool {
+	ban, ok := b.bans[evt.RuleName]
+	if !ok || evt.Backlog {
+		return false
+	}
+	addr, err := netip.ParseAddr(strings.TrimSpace(evt.Captures[ban.Capture]))
+	if err != nil || b.ignored(addr.Unmap()) {
+		return false
+	}
+	addr = addr.Unmap()
+	now := b.now()
+	seen := evt.Timestamp
+	if seen.IsZero() || seen.After(now) {
+		seen = now
+	}
+	if now.Sub(seen) >= ban.Within {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	key := banKey{rule: evt.RuleName, addr: addr}
+	recent := b.hits[key][:0]
+	for _, at := range b.hits[key] {
+		if now.Sub(at) < ban.Within {
+			recent = append(recent, at)
+		}
+	}
+	recent = append(recent, seen)
+	if len(recent) < ban.After {
+		b.hits[key] = recent
+		return false
+	}
+	delete(b.hits, key)
+	until := now.Add(ban.For)
+	if current, ok := b.entries[addr]; ok && !current.until.Before(until) {
+		return false
+	}
+	b.entries[addr] = banEntry{until: until, rule: evt.RuleName}
+	return true
+}
+
+func (b *BanList) ignored(addr netip.Addr) bool {
+	for _, prefix := range b.ignore {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// expire drops ended bans and stale hit counts, reporting whether any ban ended.
+func (b *BanList) expire() bool {
+	now := b.now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	changed := false
+	for addr, entry := range b.entries {
+		if !now.Before(entry.until) {
+			delete(b.entries, addr)
+			changed = true
+		}
+	}
+	for key, hits := range b.hits {
+		if ban := b.bans[key.rule]; len(hits) == 0 || now.Sub(hits[len(hits)-1]) >= ban.Within {
+			delete(b.hits, key)
+		}
+	}
+	return changed
+}
+
+// Render formats the current bans, addresses in order.
+func (b *BanList) Render(format string) []byte {
+	now := b.now()
+	b.mu.Lock()
+	type ban struct {
+		addr netip.Addr
+		left time.Duration
+	}
+	list := make([]ban, 0, len(b.entries))
+	for addr, entry := range b.entries {
+		if left := entry.until.Sub(now); left > 0 {
+			list = append(list, ban{addr: addr, left: left})
+		}
+	}
+	b.mu.Unlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].addr.Less(list[j].addr) })
+
+	var buf bytes.Buffer
+	if format == BanFormatCIDR {
+		for _, e := range list {
+			fmt.Fprintf(&buf, "%s\n", netip.PrefixFrom(e.addr, e.addr.BitLen()))
+		}
+		return buf.Bytes()
+	}
+	fmt.Fprintf(&buf, "# spectra-watch ban list, %s; include inside a table\n", now.UTC().Format(time.RFC3339))
+	for _, family := range []struct {
+		name, typ string
+		v4        bool
+	}{{"spectra_ban_v4", "ipv4_addr", true}, {"spectra_ban_v6", "ipv6_addr", false}} {
+		var elems []string
+		for _, e := range list {
+			if e.addr.Is4() == family.v4 {
+				elems = append(elems, fmt.Sprintf("%s timeout %ds", e.addr, int(e.left.Seconds()+0.5)))
+			}
+		}
+		fmt.Fprintf(&buf, "set %s {\n\ttype %s\n\tflags timeout\n", family.name, family.typ)
+		if len(elems) > 0 {
+			fmt.Fprintf(&buf, "\telements = { %s }\n", strings.Join(elems, ", "))
+		}
+		buf.WriteString("}\n")
+	}
+	return buf.Bytes()
+}
+
+// ServeHTTP serves /bans.txt (CIDR list) and /bans.nft (nftables sets).
+func (b *BanList) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var format string
+	switch r.URL.Path {
+	case "/bans.txt":
+		format = BanFormatCIDR
+	case "/bans.nft":
+		format = BanFormatNFT
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(b.Render(format))
+}
+
+// Save drops ended bans and writes the file one last time, so what a
+// firewall loads after the watcher exits is the list as it stood at exit. It
+// is the ban list's shutdown task, run once Run has returned.
+func (b *BanList) Save() error {
+	b.expire()
+	if err := b.write(); err != nil {
+		b.failed.Add(1)
+		return fmt.Errorf("write ban file: %w", err)
+	}
+	return nil
+}
+
+// writeFile rewrites the ban file, counting a failure.
+func (b *BanList) writeFile() {
+	if err := b.write(); err != nil {
+		b.failed.Add(1)
+	}
+}
+
+// write replaces the ban file atomically so a firewall reload never reads
+// half of it.
+func (b *BanList) write() error {
+	if b.path == "" {
+		return nil
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(b.path), ".bans-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(b.Render(b.format))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), b.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
@@ -0,0 +1,114 @@
+package sink
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"watcher/internal/pipeline"
+	"watcher/internal/relay"
+)
+
+// forwardRedial is how long Forward waits after a failed dial before trying
+// again; events in between are dropped rather than each paying the timeout.
+const forwardRedial = 2 * time.Second
+
+// Forward sends events to a spectra aggregator (an `agents:` source) over
+// TLS, as relay records stamped with this host's name.
+type Forward struct {
+	addr    string
+	host    string
+	tls     *tls.Config
+	conn    net.Conn
+	retryAt time.Time
+	failed  atomic.Uint64
+}
+
+// NewForward returns a sink for the aggregator at addr (host:port). host
+// names this machine in the records.
+func NewForward(addr, host string, cfg *tls.Config) (*Forward, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, fmt.Errorf("forward address %q: want host:port", addr)
+	}
+	return &Forward{addr: addr, host: host, tls: cfg}, nil
+}
+
+// Run forwards events until the channel closes or ctx is done. Every line
+// the pipeline kept is sent, matched or (with show-all) not; gap markers,
+// source state changes, and errors stay local.
+func (f *Forward) Run(ctx context.Context, events <-chan pipeline.HighlightedEvent) {
+	defer f.close()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case evt, ok := <-events:
+			if !ok {
+				return
+			}
+			if evt.Err != nil || evt.Gap > 0 || evt.Conn != nil {
+				continue
+			}
+			if err := f.Send(evt); err != nil {
+				f.failed.Add(1)
+			}
+		}
+	}
+}
+
+// Failed reports how many events could not be delivered.
+func (f *Forward) Failed() uint64 {
+	return f.failed.Load()
+}
+
+// Send delivers one event, redialing once if the connection was lost. A
+// failed write closes the connection, since part of the record may have gone
+// out, so the retry (and the next event) starts on a fresh one.
+func (f *Forward) Send(evt pipeline.HighlightedEvent) error {
+	payload, err := relay.Record{
+		Host:     f.host,
+		Path:     evt.Path,
+		Time:     evt.Timestamp,
+		Line:     evt.Line,
+		Rule:     evt.RuleName,
+		Severity: string(evt.Severity),
+	}.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := f.write(payload); err != nil {
+		return f.write(payload)
+	}
+	return nil
+}
+
+func (f *Forward) write(payload []byte) error {
+	if f.conn == nil {
+		if time.Now().Before(f.retryAt) {
+			return fmt.Errorf("forward %s: waiting to redial", f.addr)
+		}
+		dialer := &tls.Dialer{NetDialer: &net.Dialer{Timeout: 5 * time.Second}, Config: f.tls}
+		conn, err := dialer.Dial("tcp", f.addr)
+		if err != nil {
+			f.retryAt = time.Now().Add(forwardRedial)
+			return fmt.Errorf("dial forward %s: %w", f.addr, err)
+		}
+		f.conn = conn
+	}
+	f.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	if _, err := f.conn.Write(payload); err != nil {
+		f.close()
+		return err
+	}
+	return nil
+}
+
+func (f *Forward) close() {
+	if f.conn != nil {
+		f.conn.Close()
+		f.conn = nil
+	}
+}
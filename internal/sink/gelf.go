@@ -0,0 +1,170 @@
+// Package sink forwards highlighted events to external systems. Each sink
+// consumes its own Broadcaster subscription, so a slow or unreachable
+// destination drops events instead of stalling the dashboard.
+package sink
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"watcher/internal/gelf"
+	"watcher/internal/pipeline"
+	"watcher/internal/rules"
+)
+
+// GELF sends matched events to Graylog over UDP (chunked when large) or TCP
+// (NUL-delimited).
+type GELF struct {
+	network string
+	addr    string
+	host    string
+	conn    net.Conn
+	failed  atomic.Uint64
+}
+
+// NewGELF parses a destination such as udp://graylog:12201 or tcp://graylog:12201.
+func NewGELF(dest string) (*GELF, error) {
+	u, err := url.Parse(dest)
+	if err != nil || u.Host == "" || (u.Scheme != "udp" && u.Scheme != "tcp") {
+		return nil, fmt.Errorf("gelf destination %q: want udp://host:port or tcp://host:port", dest)
+	}
+	host, _ := os.Hostname()
+	return &GELF{network: u.Scheme, addr: u.Host, host: host}, nil
+}
+
+// Run forwards events until the channel closes or ctx is done. Unmatched
+// lines, gap markers, and errors are skipped.
+func (g *GELF) Run(ctx context.Context, events <-chan pipeline.HighlightedEvent) {
+	defer g.close()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case evt, ok := <-events:
+			if !ok {
+				return
+			}
+			if evt.RuleName == "" || evt.Err != nil || evt.Gap > 0 {
+				continue
+			}
+			if err := g.Send(evt); err != nil {
+				g.failed.Add(1)
+			}
+		}
+	}
+}
+
+// Failed reports how many events could not be delivered.
+func (g *GELF) Failed() uint64 {
+	return g.failed.Load()
+}
+
+// Send delivers one event, redialing once if the connection was lost.
+func (g *GELF) Send(evt pipeline.HighlightedEvent) error {
+	payload, err := Message(evt, g.host).Marshal()
+	if err != nil {
+		return err
+	}
+	if err := g.write(payload); err != nil {
+		g.close()
+		return g.write(payload)
+	}
+	return nil
+}
+
+func (g *GELF) write(payload []byte) error {
+	if g.conn == nil {
+		conn, err := net.DialTimeout(g.network, g.addr, 5*time.Second)
+		if err != nil {
+			return fmt.Errorf("dial gelf %s: %w", g.addr, err)
+		}
+		g.conn = conn
+	}
+	g.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	if g.network == "tcp" {
+		_, err := g.conn.Write(append(payload, 0))
+		return err
+	}
+	chunks, err := gelf.Chunk(payload, gelf.MaxUDPChunk)
+	if err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		if _, err := g.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *GELF) close() {
+	if g.conn != nil {
+		g.conn.Close()
+		g.conn = nil
+	}
+}
+
+// Message maps an event to GELF: the line becomes short_message, severity a
+// syslog level, and rule, tags, source, receive sequence, pipeline, rule
+// chain, and captures additional fields.
+func Message(evt pipeline.HighlightedEvent, host string) gelf.Message {
+	extra := map[string]interface{}{
+		"rule":     evt.RuleName,
+		"severity": string(evt.Severity),
+		"source":   evt.Path,
+	}
+	if evt.Offset > 0 {
+		extra["offset"] = evt.Offset
+	}
+	if evt.Seq > 0 {
+		extra["seq"] = evt.Seq
+	}
+	if evt.Pipeline != "" {
+		extra["pipeline"] = evt.Pipeline
+	}
+	if len(evt.Sources) > 0 {
+		extra["sources"] = strings.Join(evt.Sources, ",")
+	}
+	if len(evt.Tags) > 0 {
+		extra["tags"] = strings.Join(evt.Tags, ",")
+	}
+	if len(evt.Chain) > 0 {
+		extra["chain"] = strings.Join(evt.Chain, ",")
+		extra["derived"] = evt.Derived
+	}
+	for name, value := range evt.Captures {
+		if _, taken := extra[name]; taken {
+			name = "capture_" + name
+		}
+		extra[name] = value
+	}
+	return gelf.Message{
+		Host:         host,
+		ShortMessage: evt.Line,
+		Timestamp:    evt.Timestamp,
+		Level:        syslogLevel(evt.Severity),
+		Extra:        extra,
+	}
+}
+
+// syslogLevel maps severities onto the syslog levels GELF uses.
+// Custom levels use the built-in level at or below them.
+func syslogLevel(sev rules.Severity) int {
+	switch rules.BuiltinFloor(sev) {
+	case rules.SeverityCritical:
+		return 2
+	case rules.SeverityHigh:
+		return 3
+	case rules.SeverityMedium:
+		return 4
+	case rules.SeverityLow:
+		return 5
+	}
+	return 6
+}
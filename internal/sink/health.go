@@ -0,0 +1,52 @@
+package sink
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Health collects the failure counters of the running sinks, so the
+// dashboard and the headless modes can say when events are not getting
+// through.
+type Health struct {
+	mu     sync.Mutex
+	names  []string
+	failed map[string]func() uint64
+}
+
+// NewHealth returns an empty collection.
+func NewHealth() *Health {
+	return &Health{failed: make(map[string]func() uint64)}
+}
+
+// Register adds a sink's counter of undelivered events. A nil Health
+// ignores it.
+func (h *Health) Register(name string, failed func() uint64) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.failed[name]; !ok {
+		h.names = append(h.names, name)
+	}
+	h.failed[name] = failed
+}
+
+// Summary lists the sinks that failed to deliver events, in registration
+// order, as "clickhouse 12, forward 3"; empty when none did.
+func (h *Health) Summary() string {
+	if h == nil {
+		return ""
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var parts []string
+	for _, name := range h.names {
+		if n := h.failed[name](); n > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", name, n))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,144 @@
+package sink
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"strings"
+
+	"watcher/internal/pipeline"
+)
+
+// recordKey identifies an archived event: the file it was read from, the
+// offset just past it, and a hash of the line as stored. A rotated file that
+// reaches the same offset with different content does not match.
+type recordKey struct {
+	source string
+	offset int64
+	hash   uint64
+}
+
+// LineHash is the content hash archived with each event (ClickHouse
+// `line_hash`), FNV-1a over the line as stored.
+func LineHash(line string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(line))
+	return h.Sum64()
+}
+
+// Recorded is the set of events a previous run already archived, so a restart
+// that backfills the same files does not send them again. It loads in the
+// background; Filter queues events until it is ready.
+type Recorded struct {
+	ready chan struct{}
+	keys  map[recordKey]struct{}
+}
+
+// loadRecorded starts load and returns at once. A failed load leaves the set
+// empty, so nothing is skipped.
+func loadRecorded(load func() (map[recordKey]struct{}, error)) *Recorded {
+	r := &Recorded{ready: make(chan struct{})}
+	go func() {
+		defer close(r.ready)
+		keys, err := load()
+		if err == nil {
+			r.keys = keys
+		}
+	}()
+	return r
+}
+
+// Has reports whether evt was archived by an earlier run. Only events read
+// from a file (with an offset) can match.
+func (r *Recorded) Has(evt pipeline.HighlightedEvent) bool {
+	<-r.ready
+	if evt.Offset <= 0 || len(r.keys) == 0 {
+		return false
+	}
+	_, ok := r.keys[recordKey{source: evt.Path, offset: evt.Offset, hash: LineHash(evt.Line)}]
+	return ok
+}
+
+// maxRecordedQueue bounds the events Filter holds while the set loads; past
+// it Filter stops reading, as a slow sink would.
+const maxRecordedQueue = 100000
+
+// Filter passes on the events of in that were not archived before, closing
+// the result when in closes. It keeps reading in while the set loads, so the
+// sink's buffer does not fill and drop live events in the meantime, and
+// checks what it queued once the set is ready.
+func (r *Recorded) Filter(ctx context.Context, in <-chan pipeline.HighlightedEvent) <-chan pipeline.HighlightedEvent {
+	out := make(chan pipeline.HighlightedEvent)
+	go func() {
+		defer close(out)
+		var queue []pipeline.HighlightedEvent
+		ready := r.ready
+		for in != nil || ready != nil || len(queue) > 0 {
+			var (
+				send chan<- pipeline.HighlightedEvent
+				next pipeline.HighlightedEvent
+			)
+			if ready == nil && len(queue) > 0 {
+				send, next = out, queue[0]
+			}
+			recv := in
+			if ready != nil && len(queue) >= maxRecordedQueue {
+				recv = nil
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ready:
+				ready = nil
+				queue = slices.DeleteFunc(queue, r.Has)
+			case evt, ok := <-recv:
+				if !ok {
+					in = nil
+					continue
+				}
+				if ready == nil && r.Has(evt) {
+					continue
+				}
+				queue = append(queue, evt)
+			case send <- next:
+				queue = queue[1:]
+			}
+		}
+	}()
+	return out
+}
+
+// parseRecorded reads `source \t file_offset \t line_hash` rows
+// (ClickHouse TabSeparated output).
+func parseRecorded(body []byte) (map[recordKey]struct{}, error) {
+	keys := make(map[recordKey]struct{})
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != 3 {
+			continue
+		}
+		offset, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		hash, err := strconv.ParseUint(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		keys[recordKey{source: unescapeTSV(fields[0]), offset: offset, hash: hash}] = struct{}{}
+	}
+	return keys, scanner.Err()
+}
+
+// unescapeTSV undoes ClickHouse's TabSeparated escaping of a string field.
+func unescapeTSV(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+	return strings.NewReplacer(`\\`, `\`, `\t`, "\t", `\n`, "\n", `\'`, "'", `\0`, "\x00").Replace(field)
+}
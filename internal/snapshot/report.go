@@ -0,0 +1,58 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// WriteText prints the comparison: sizes, rules and entities seen on one side
+// only, rules whose counts changed, then new and vanished message shapes.
+func WriteText(w io.Writer, r Report) error {
+	for _, s := range []Summary{r.A, r.B} {
+		fmt.Fprintf(w, "%s: %d lines in %d files, %d matched\n", s.Label, s.Lines, s.Files, s.Matched)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	section := func(title string, counts []Count, count func(Count) int) {
+		if len(counts) == 0 {
+			return
+		}
+		fmt.Fprintf(tw, "\n%s (%d):\n", title, len(counts))
+		for _, c := range counts {
+			fmt.Fprintf(tw, "  %s\t%d\n", c.Name, count(c))
+		}
+	}
+	section("rules only in "+r.A.Label, r.OnlyA.Rules, func(c Count) int { return c.A })
+	section("rules only in "+r.B.Label, r.OnlyB.Rules, func(c Count) int { return c.B })
+	if len(r.Changed) > 0 {
+		fmt.Fprintf(tw, "\nrule counts changed (%d):\n", len(r.Changed))
+		for _, c := range r.Changed {
+			fmt.Fprintf(tw, "  %s\t%d → %d\t%+d\n", c.Name, c.A, c.B, c.Delta())
+		}
+	}
+	section("entities only in "+r.A.Label, r.OnlyA.Entities, func(c Count) int { return c.A })
+	section("entities only in "+r.B.Label, r.OnlyB.Entities, func(c Count) int { return c.B })
+	shapes := func(title string, list []Shape) {
+		if len(list) == 0 {
+			return
+		}
+		fmt.Fprintf(tw, "\n%s (%d):\n", title, len(list))
+		for _, sh := range list {
+			fmt.Fprintf(tw, "  %d\t%s\n", sh.Count, sh.Shape)
+		}
+	}
+	shapes("new message shapes in "+r.B.Label, r.NewIn)
+	shapes("message shapes gone from "+r.B.Label, r.GoneFrom)
+	return tw.Flush()
+}
+
+// WriteJSON emits the report as one indented JSON document.
+func WriteJSON(w io.Writer, r Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		return fmt.Errorf("encode report: %w", err)
+	}
+	return nil
+}
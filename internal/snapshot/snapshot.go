@@ -0,0 +1,214 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"watcher/internal/rules"
+	"watcher/internal/search"
+)
+
+// maxShapes bounds the distinct message shapes kept per snapshot; lines of
+// further shapes still count in Lines but not in Shapes.
+const maxShapes = 50000
+
+// Snapshot summarizes one recording: which rules fired, which entities
+// appeared, and which message shapes were logged, with counts.
+type Snapshot struct {
+	Label   string
+	Files   int
+	Lines   int
+	Matched int
+	// Rules counts the lines each rule won (the rule the live view shows).
+	Rules map[string]int
+	// Entities counts `capture=value` pairs of the rule set's entity captures
+	// on matched lines.
+	Entities map[string]int
+	Shapes   map[string]*Shape
+	// Overflow counts lines whose shape was not kept (see maxShapes).
+	Overflow int
+}
+
+// Shape is one message shape (see rules.LineShape) with a line showing it.
+type Shape struct {
+	Shape  string `json:"shape"`
+	Count  int    `json:"count"`
+	Sample string `json:"sample"`
+}
+
+// Take runs rs over every line of files. Unreadable files are reported in the
+// joined error; the rest still count.
+func Take(ctx context.Context, label string, rs rules.RuleSet, files []string) (Snapshot, error) {
+	snap := Snapshot{
+		Label:    label,
+		Files:    len(files),
+		Rules:    make(map[string]int),
+		Entities: make(map[string]int),
+		Shapes:   make(map[string]*Shape),
+	}
+	entities := rs.Entities
+	if len(entities) == 0 {
+		entities = rules.DefaultEntities
+	}
+	var errs []error
+	for _, path := range files {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+		err := search.EachLine(path, func(_ int, line string) bool {
+			snap.Lines++
+			snap.observeShape(line)
+			m, ok := rs.Match(line)
+			if !ok {
+				return true
+			}
+			snap.Matched++
+			snap.Rules[m.Rule.Name]++
+			for _, name := range entities {
+				if value := m.Captures[name]; value != "" {
+					snap.Entities[name+"="+value]++
+				}
+			}
+			return true
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", label, path, err))
+		}
+	}
+	return snap, errors.Join(errs...)
+}
+
+func (s *Snapshot) observeShape(line string) {
+	shape := rules.LineShape(line)
+	if shape == "" {
+		return
+	}
+	if sh := s.Shapes[shape]; sh != nil {
+		sh.Count++
+		return
+	}
+	if len(s.Shapes) >= maxShapes {
+		s.Overflow++
+		return
+	}
+	s.Shapes[shape] = &Shape{Shape: shape, Count: 1, Sample: line}
+}
+
+// Count is one rule's or entity's count in both snapshots.
+type Count struct {
+	Name string `json:"name"`
+	A    int    `json:"a"`
+	B    int    `json:"b"`
+}
+
+// Delta is B minus A.
+func (c Count) Delta() int {
+	return c.B - c.A
+}
+
+// Report is what changed from snapshot A to snapshot B.
+type Report struct {
+	A        Summary `json:"a"`
+	B        Summary `json:"b"`
+	OnlyA    Side    `json:"only_a"`
+	OnlyB    Side    `json:"only_b"`
+	Changed  []Count `json:"changed"`
+	NewIn    []Shape `json:"new_shapes"`
+	GoneFrom []Shape `json:"gone_shapes"`
+}
+
+// Summary is a snapshot's size.
+type Summary struct {
+	Label   string `json:"label"`
+	Files   int    `json:"files"`
+	Lines   int    `json:"lines"`
+	Matched int    `json:"matched"`
+}
+
+// Side lists what one snapshot has and the other lacks.
+type Side struct {
+	Rules    []Count `json:"rules"`
+	Entities []Count `json:"entities"`
+}
+
+// Diff compares a (before) with b (after). Changed holds the rules present in
+// both whose counts differ, largest change first. Shape lists keep the top
+// most frequent entries (all of them when top is 0).
+func Diff(a, b Snapshot, top int) Report {
+	r := Report{A: a.summary(), B: b.summary()}
+	for name, n := range a.Rules {
+		switch m, ok := b.Rules[name]; {
+		case !ok:
+			r.OnlyA.Rules = append(r.OnlyA.Rules, Count{Name: name, A: n})
+		case m != n:
+			r.Changed = append(r.Changed, Count{Name: name, A: n, B: m})
+		}
+	}
+	for name, n := range b.Rules {
+		if _, ok := a.Rules[name]; !ok {
+			r.OnlyB.Rules = append(r.OnlyB.Rules, Count{Name: name, B: n})
+		}
+	}
+	for name, n := range a.Entities {
+		if _, ok := b.Entities[name]; !ok {
+			r.OnlyA.Entities = append(r.OnlyA.Entities, Count{Name: name, A: n})
+		}
+	}
+	for name, n := range b.Entities {
+		if _, ok := a.Entities[name]; !ok {
+			r.OnlyB.Entities = append(r.OnlyB.Entities, Count{Name: name, B: n})
+		}
+	}
+	r.NewIn = missingShapes(b, a, top)
+	r.GoneFrom = missingShapes(a, b, top)
+
+	for _, list := range [][]Count{r.OnlyA.Rules, r.OnlyB.Rules, r.OnlyA.Entities, r.OnlyB.Entities} {
+		sortCounts(list, func(c Count) int { return c.A + c.B })
+	}
+	sortCounts(r.Changed, func(c Count) int { return abs(c.Delta()) })
+	return r
+}
+
+func (s Snapshot) summary() Summary {
+	return Summary{Label: s.Label, Files: s.Files, Lines: s.Lines, Matched: s.Matched}
+}
+
+// missingShapes lists the shapes of from that other does not have, most
+// frequent first.
+func missingShapes(from, other Snapshot, top int) []Shape {
+	var out []Shape
+	for key, sh := range from.Shapes {
+		if _, ok := other.Shapes[key]; !ok {
+			out = append(out, *sh)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Shape < out[j].Shape
+	})
+	if top > 0 && len(out) > top {
+		out = out[:top]
+	}
+	return out
+}
+
+func sortCounts(list []Count, weight func(Count) int) {
+	sort.Slice(list, func(i, j int) bool {
+		if wi, wj := weight(list[i]), weight(list[j]); wi != wj {
+			return wi > wj
+		}
+		return list[i].Name < list[j].Name
+	})
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,108 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Baseline records which rule firings and capture values count as normal activity.
+type Baseline struct {
+	rules map[string]map[string]struct{}
+}
+
+type baselineFile struct {
+	Rules map[string][]string `json:"rules"`
+}
+
+// NewBaseline returns an empty baseline.
+func NewBaseline() *Baseline {
+	return &Baseline{rules: make(map[string]map[string]struct{})}
+}
+
+// LoadBaseline reads a baseline previously written with Save.
+func LoadBaseline(path string) (*Baseline, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var bf baselineFile
+	if err := json.Unmarshal(content, &bf); err != nil {
+		return nil, fmt.Errorf("parse baseline: %w", err)
+	}
+	b := NewBaseline()
+	for rule, values := range bf.Rules {
+		set := make(map[string]struct{}, len(values))
+		for _, v := range values {
+			set[v] = struct{}{}
+		}
+		b.rules[rule] = set
+	}
+	return b, nil
+}
+
+// Save writes the baseline as JSON so it can be imported by later sessions.
+func (b *Baseline) Save(path string) error {
+	bf := baselineFile{Rules: make(map[string][]string, len(b.rules))}
+	for rule, set := range b.rules {
+		values := make([]string, 0, len(set))
+		for v := range set {
+			values = append(values, v)
+		}
+		sort.Strings(values)
+		bf.Rules[rule] = values
+	}
+	content, err := json.MarshalIndent(bf, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode baseline: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("write baseline: %w", err)
+	}
+	return nil
+}
+
+// Learn marks the rule and each of its capture values as normal.
+func (b *Baseline) Learn(rule string, captures map[string]string) {
+	if b == nil || rule == "" {
+		return
+	}
+	set, ok := b.rules[rule]
+	if !ok {
+		set = make(map[string]struct{})
+		b.rules[rule] = set
+	}
+	for name, value := range captures {
+		set[captureKey(name, value)] = struct{}{}
+	}
+}
+
+// Novel reports whether the rule, or any of its capture values, was never seen in the baseline.
+func (b *Baseline) Novel(rule string, captures map[string]string) bool {
+	if b == nil {
+		return true
+	}
+	set, ok := b.rules[rule]
+	if !ok {
+		return true
+	}
+	for name, value := range captures {
+		if _, seen := set[captureKey(name, value)]; !seen {
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of rules present in the baseline.
+func (b *Baseline) Len() int {
+	if b == nil {
+		return 0
+	}
+	return len(b.rules)
+}
+
+func captureKey(name, value string) string {
+	return name + "=" + value
+}
@@ -0,0 +1,79 @@
+package stats
+
+import "time"
+
+// RateWindow counts events in consecutive fixed-size windows so the running
+// window can be compared with the one before it.
+type RateWindow struct {
+	size     time.Duration
+	start    time.Time
+	current  int
+	previous int
+	primed   bool
+	fired    bool
+}
+
+// NewRateWindow returns a counter comparing windows of the given size.
+func NewRateWindow(size time.Duration) *RateWindow {
+	if size <= 0 {
+		size = 10 * time.Minute
+	}
+	return &RateWindow{size: size}
+}
+
+// Add counts one event observed at the given time.
+func (w *RateWindow) Add(at time.Time) {
+	w.roll(at)
+	w.current++
+}
+
+// Counts returns the running window's count and the previous window's count.
+func (w *RateWindow) Counts() (int, int) {
+	return w.current, w.previous
+}
+
+// CountsAt is Counts as of now: windows that ended since the last event are
+// rolled first, so a quiet stream reads as a falling count.
+func (w *RateWindow) CountsAt(now time.Time) (int, int) {
+	if !w.start.IsZero() {
+		w.roll(now)
+	}
+	return w.current, w.previous
+}
+
+// Exceeded reports, at most once per window, that the running window reached
+// minCount and factor times the previous window. Nothing fires until one full
+// window has been observed, so startup backfill cannot trigger it.
+func (w *RateWindow) Exceeded(factor float64, minCount int) bool {
+	if !w.primed || w.fired || w.current < minCount {
+		return false
+	}
+	base := w.previous
+	if base < 1 {
+		base = 1
+	}
+	if float64(w.current) < factor*float64(base) {
+		return false
+	}
+	w.fired = true
+	return true
+}
+
+func (w *RateWindow) roll(at time.Time) {
+	if w.start.IsZero() {
+		w.start = at
+		return
+	}
+	elapsed := at.Sub(w.start)
+	if elapsed < w.size {
+		return
+	}
+	w.previous = w.current
+	if elapsed >= 2*w.size {
+		w.previous = 0
+	}
+	w.current = 0
+	w.fired = false
+	w.primed = true
+	w.start = w.start.Add(elapsed / w.size * w.size)
+}
@@ -0,0 +1,152 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sample is a numeric capture value observed at a point in time.
+type Sample struct {
+	At    time.Time
+	Value float64
+}
+
+// Collector tracks numeric capture values per capture name in bounded windows,
+// and how often each rule matched.
+type Collector struct {
+	window int
+	series map[string][]Sample
+	order  []string
+	rules  map[string]uint64
+}
+
+// NewCollector returns a collector retaining at most window samples per capture.
+func NewCollector(window int) *Collector {
+	if window <= 0 {
+		window = 120
+	}
+	return &Collector{window: window, series: make(map[string][]Sample), rules: make(map[string]uint64)}
+}
+
+// ObserveRule counts one match of rule; an empty name (unmatched line) is
+// ignored.
+func (c *Collector) ObserveRule(rule string) {
+	if c == nil || rule == "" {
+		return
+	}
+	c.rules[rule]++
+}
+
+// RuleCount reports how many matches of rule were observed.
+func (c *Collector) RuleCount(rule string) uint64 {
+	if c == nil {
+		return 0
+	}
+	return c.rules[rule]
+}
+
+// Observe records every capture in the map that parses as a number.
+func (c *Collector) Observe(at time.Time, captures map[string]string) {
+	if c == nil {
+		return
+	}
+	for name, raw := range captures {
+		value, ok := parseNumber(raw)
+		if !ok {
+			continue
+		}
+		samples, seen := c.series[name]
+		if !seen {
+			c.order = append(c.order, name)
+			sort.Strings(c.order)
+		}
+		samples = append(samples, Sample{At: at, Value: value})
+		if len(samples) > c.window {
+			samples = samples[len(samples)-c.window:]
+		}
+		c.series[name] = samples
+	}
+}
+
+// Names lists numeric captures seen so far in alphabetical order.
+func (c *Collector) Names() []string {
+	if c == nil {
+		return nil
+	}
+	return append([]string{}, c.order...)
+}
+
+// Series returns a copy of the samples retained for the named capture.
+func (c *Collector) Series(name string) []Sample {
+	if c == nil {
+		return nil
+	}
+	return append([]Sample{}, c.series[name]...)
+}
+
+// Bounds reports the min, max and last values of the samples.
+func Bounds(samples []Sample) (float64, float64, float64) {
+	if len(samples) == 0 {
+		return 0, 0, 0
+	}
+	min, max := samples[0].Value, samples[0].Value
+	for _, s := range samples[1:] {
+		if s.Value < min {
+			min = s.Value
+		}
+		if s.Value > max {
+			max = s.Value
+		}
+	}
+	return min, max, samples[len(samples)-1].Value
+}
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders the most recent samples as a block chart of at most width runes.
+func Sparkline(samples []Sample, width int) string {
+	if width <= 0 || len(samples) == 0 {
+		return ""
+	}
+	if len(samples) > width {
+		samples = samples[len(samples)-width:]
+	}
+	min, max, _ := Bounds(samples)
+	span := max - min
+	var b strings.Builder
+	for _, s := range samples {
+		idx := 0
+		if span > 0 {
+			idx = int((s.Value - min) / span * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[idx])
+	}
+	return b.String()
+}
+
+// ShortCount abbreviates a count for a badge: 999, 1.2k, 45k, 3.4M.
+func ShortCount(n uint64) string {
+	switch {
+	case n < 1000:
+		return strconv.FormatUint(n, 10)
+	case n < 10_000:
+		return strconv.FormatFloat(float64(n)/1e3, 'f', 1, 64) + "k"
+	case n < 1_000_000:
+		return strconv.FormatUint(n/1000, 10) + "k"
+	case n < 10_000_000:
+		return strconv.FormatFloat(float64(n)/1e6, 'f', 1, 64) + "M"
+	default:
+		return strconv.FormatUint(n/1_000_000, 10) + "M"
+	}
+}
+
+func parseNumber(raw string) (float64, bool) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, false
+	}
+	return value, true
+}
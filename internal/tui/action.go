@@ -0,0 +1,239 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// maxPendingActions bounds the confirmation queue; a burst of matches
+	// beyond it is dropped rather than piling up commands nobody reviewed.
+	maxPendingActions = 50
+	actionTimeout     = 30 * time.Second
+	// maxActionsPerRule caps how often one rule's command runs or is
+	// queued per actionWindowLen, so a flood of matches cannot turn into a
+	// flood of processes.
+	maxActionsPerRule = 5
+	actionWindowLen   = time.Minute
+)
+
+// pendingAction is a rendered rule command waiting for confirmation.
+type pendingAction struct {
+	rule string
+	argv []string
+	line string
+}
+
+// actionState is the confirmation queue and whether its modal is showing.
+// Only matches on lines that arrived after since (not the files' backlog or
+// replayed history) trigger actions, and runs counts them per rule.
+type actionState struct {
+	queue   []pendingAction
+	open    bool
+	dropped int
+	since   time.Time
+	runs    map[string]*actionWindow
+}
+
+// actionWindow counts one rule's actions in the window starting at start.
+type actionWindow struct {
+	start   time.Time
+	count   int
+	limited int
+}
+
+type actionResultMsg struct {
+	rule   string
+	argv   []string
+	output string
+	err    error
+}
+
+// queueAction renders the exec action of the event's rule, if it has one. It
+// runs at once when the rule is auto-approved; otherwise it joins the queue
+// (unless the same command is already waiting) and the confirmation modal
+// opens. Past maxActionsPerRule in a window the rule's actions are skipped. In monitor mode, or over another modal (where a typed y must not
+// approve anything), it stays closed and the status bar shows the count.
+func (m *Model) queueAction(rule, path, line string, captures map[string]string) tea.Cmd {
+	action, ok := m.cfg.Actions[rule]
+	if !ok {
+		return nil
+	}
+	argv, err := action.Render(path, captures)
+	if err != nil {
+		m.notification = err.Error()
+		m.notificationT = m.now()
+		return nil
+	}
+	for _, pending := range m.actions.queue {
+		if equalArgs(pending.argv, argv) {
+			return nil
+		}
+	}
+	if !m.allowAction(rule) {
+		return nil
+	}
+	if action.AutoApprove {
+		m.audit("action_run", "rule", rule, "command", strings.Join(argv, " "), "approved", "auto")
+		return runAction(rule, argv)
+	}
+	if len(m.actions.queue) >= maxPendingActions {
+		m.actions.dropped++
+		return nil
+	}
+	m.actions.queue = append(m.actions.queue, pendingAction{rule: rule, argv: argv, line: line})
+	if !m.monitoring() && !m.modalOpen() {
+		m.actions.open = true
+		m.pushModal(modalActions)
+	}
+	return nil
+}
+
+// allowAction counts one action of rule against its rate limit, reporting
+// false (and saying so once per window) when the limit is reached.
+func (m *Model) allowAction(rule string) bool {
+	now := m.now()
+	w := m.actions.runs[rule]
+	if w == nil || now.Sub(w.start) >= actionWindowLen {
+		if w != nil && w.limited > 0 {
+			m.audit("action_limited", "rule", rule, "skipped", fmt.Sprint(w.limited))
+		}
+		w = &actionWindow{start: now}
+		if m.actions.runs == nil {
+			m.actions.runs = make(map[string]*actionWindow)
+		}
+		m.actions.runs[rule] = w
+	}
+	if w.count >= maxActionsPerRule {
+		if w.limited++; w.limited == 1 {
+			m.notification = fmt.Sprintf("Actions for %s paused: %d per %s", rule, maxActionsPerRule, actionWindowLen)
+			m.notificationT = now
+		}
+		return false
+	}
+	w.count++
+	return true
+}
+
+// confirmAction runs the first queued command.
+func (m *Model) confirmAction() tea.Cmd {
+	if len(m.actions.queue) == 0 {
+		return nil
+	}
+	next := m.actions.queue[0]
+	m.actions.queue = m.actions.queue[1:]
+	m.closeActionsIfDone()
+	m.audit("action_run", "rule", next.rule, "command", strings.Join(next.argv, " "), "approved", "operator")
+	return runAction(next.rule, next.argv)
+}
+
+// rejectAction drops the first queued command without running it.
+func (m *Model) rejectAction() {
+	if len(m.actions.queue) == 0 {
+		return
+	}
+	next := m.actions.queue[0]
+	m.actions.queue = m.actions.queue[1:]
+	m.closeActionsIfDone()
+	m.audit("action_reject", "rule", next.rule, "command", strings.Join(next.argv, " "))
+}
+
+// openActions shows the confirmation modal again after esc.
+func (m *Model) openActions() {
+	if len(m.actions.queue) == 0 {
+		m.notification = "No actions waiting for confirmation"
+		m.notificationT = m.now()
+		return
+	}
+	m.actions.open = true
+	m.pushModal(modalActions)
+}
+
+func (m *Model) closeActionsIfDone() {
+	if len(m.actions.queue) == 0 {
+		m.actions.open = false
+		m.actions.dropped = 0
+	}
+}
+
+func (m *Model) handleActionResult(msg actionResultMsg) {
+	command := strings.Join(msg.argv, " ")
+	if msg.err != nil {
+		m.audit("action_failed", "rule", msg.rule, "command", command, "error", msg.err.Error())
+		m.notification = fmt.Sprintf("Action %s failed: %v", msg.argv[0], msg.err)
+	} else {
+		m.notification = fmt.Sprintf("Action %s done", msg.argv[0])
+	}
+	if msg.output != "" {
+		m.notification += " · " + msg.output
+	}
+	m.notificationT = m.now()
+}
+
+// runAction executes argv directly (no shell) with a timeout and reports the
+// first line of its output.
+func runAction(rule string, argv []string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), actionTimeout)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, argv[0], argv[1:]...).CombinedOutput()
+		first, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+		return actionResultMsg{rule: rule, argv: argv, output: first, err: err}
+	}
+}
+
+func equalArgs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Model) renderActionModal() string {
+	width, height := m.modalSize()
+	title := m.theme.Header.Render(fmt.Sprintf("confirm action (%d waiting)", len(m.actions.queue)))
+	instructions := m.theme.TagStyle.Render("y run · n skip · esc later (! reopens)")
+	innerWidth := width - (modalPaddingX * 2) - 2
+	if innerWidth < 20 {
+		innerWidth = 20
+	}
+	next := m.actions.queue[0]
+	quoted := make([]string, len(next.argv))
+	for i, arg := range next.argv {
+		quoted[i] = quoteArg(arg)
+	}
+	rows := []string{
+		"",
+		fmt.Sprintf("Rule: %s", next.rule),
+		"Command:",
+		m.theme.HighlightStyle.Copy().Bold(true).Render(wrapText(strings.Join(quoted, " "), innerWidth-2)),
+		"",
+		"Line:",
+		lipgloss.NewStyle().Faint(true).Render(wrapText(next.line, innerWidth-2)),
+	}
+	if m.actions.dropped > 0 {
+		rows = append(rows, "", fmt.Sprintf("%d more dropped: queue full", m.actions.dropped))
+	}
+	content := lipgloss.JoinVertical(lipgloss.Left, append([]string{title, instructions}, rows...)...)
+	return m.modalStyle(width, height).Render(content)
+}
+
+// quoteArg shows an argument the way a shell would need it, so spaces and
+// quotes inside capture values are visible before confirming.
+func quoteArg(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`;&|<>*?()[]{}!#~") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
@@ -0,0 +1,96 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"watcher/internal/rules"
+)
+
+// isSeparator reports whether the line is a synthetic quiet-period marker
+// inserted between two events that arrived far apart (QuietGap).
+func (l displayLine) isSeparator() bool {
+	return l.Quiet > 0
+}
+
+// withQuietSeparators inserts a marker before every line that follows the
+// previous one by at least QuietGap. A marker carries the Seq of the line
+// above it, so reselect still lands on real lines.
+func (m Model) withQuietSeparators(lines []displayLine) []displayLine {
+	gap := m.cfg.QuietGap
+	if gap <= 0 || len(lines) < 2 {
+		return lines
+	}
+	out := make([]displayLine, 0, len(lines)+4)
+	for i, line := range lines {
+		if i > 0 {
+			prev := lines[i-1]
+			if quiet := line.Timestamp.Sub(prev.Timestamp); quiet >= gap && !prev.isMarker() && !line.isMarker() {
+				out = append(out, displayLine{
+					Quiet:     quiet,
+					Severity:  rules.SeverityNormal,
+					Timestamp: line.Timestamp,
+					Index:     -1,
+					Seq:       prev.Seq,
+				})
+			}
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+// renderSeparator draws a quiet-period marker such as "── 14 minutes pass ──".
+func (m Model) renderSeparator(line displayLine, selected bool) string {
+	marker := fmt.Sprintf("── %s pass ──", humanQuiet(line.Quiet))
+	content := m.theme.TagStyle.Copy().Faint(true).Render(marker)
+	if selected {
+		indicator := m.theme.HighlightStyle.Copy().Bold(true).Render("➤")
+		return lipgloss.JoinHorizontal(lipgloss.Top, indicator, " ", content)
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, " ", " ", content)
+}
+
+func humanQuiet(d time.Duration) string {
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("%d days", int(d/(24*time.Hour)))
+	case d >= 2*time.Hour:
+		return fmt.Sprintf("%d hours", int(d/time.Hour))
+	case d >= 2*time.Minute:
+		return fmt.Sprintf("%d minutes", int(d/time.Minute))
+	}
+	return fmt.Sprintf("%d seconds", int(d/time.Second))
+}
+
+// ageLevel grades how old a line is against AgeAfter: 0 is current, 1 older
+// than AgeAfter (dimmed), 2 older than four times that (dimmed, without match
+// emphasis).
+func (m Model) ageLevel(line displayLine) int {
+	after := m.cfg.AgeAfter
+	if after <= 0 || line.Timestamp.IsZero() {
+		return 0
+	}
+	switch age := m.now().Sub(line.Timestamp); {
+	case age >= 4*after:
+		return 2
+	case age >= after:
+		return 1
+	}
+	return 0
+}
+
+// refreshAging re-renders the pane about once a minute while aging is on, so
+// lines dim in quiet logs too, where nothing else triggers a render.
+func (m *Model) refreshAging() {
+	if m.cfg.AgeAfter <= 0 || m.paused || m.now().Sub(m.agedAt) < time.Minute {
+		return
+	}
+	m.agedAt = m.now()
+	m.viewport.SetContent(m.renderLogContent())
+	if m.follow {
+		m.viewport.GotoBottom()
+	}
+}
@@ -0,0 +1,147 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"watcher/internal/rules"
+)
+
+// maxChips is how many filters get a number key (alt+1 … alt+9).
+const maxChips = 9
+
+// filterChip is one active filter in the bar above the pane.
+type filterChip struct {
+	label  string
+	remove func(*Model)
+}
+
+// filterChips lists the active filters in a stable order: severity floor,
+// delta mode, hidden lines, filtered then throttled rules by name, then
+// grouping.
+func (m Model) filterChips() []filterChip {
+	var chips []filterChip
+	if m.viewSeverity != "" && m.viewSeverity != rules.SeverityNormal {
+		chips = append(chips, filterChip{
+			label: "≥" + string(m.viewSeverity),
+			remove: func(m *Model) {
+				m.viewSeverity = ""
+			},
+		})
+	}
+	if m.deltaMode {
+		chips = append(chips, filterChip{
+			label: "delta",
+			remove: func(m *Model) {
+				m.deltaMode = false
+			},
+		})
+	}
+	if n := len(m.hiddenIndices); n > 0 {
+		chips = append(chips, filterChip{
+			label: fmt.Sprintf("%d hidden", n),
+			remove: func(m *Model) {
+				m.hiddenIndices = make(map[int]bool)
+			},
+		})
+	}
+	names := make([]string, 0, len(m.filteredRules))
+	for name, on := range m.filteredRules {
+		if on {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		name := name
+		chips = append(chips, filterChip{
+			label: "−" + name,
+			remove: func(m *Model) {
+				delete(m.filteredRules, name)
+				if m.persistOffer == name {
+					m.persistOffer = ""
+				}
+			},
+		})
+	}
+	throttled := make([]string, 0, len(m.throttledRules))
+	for name := range m.throttledRules {
+		throttled = append(throttled, name)
+	}
+	sort.Strings(throttled)
+	for _, name := range throttled {
+		name := name
+		chips = append(chips, filterChip{
+			label: "~" + name,
+			remove: func(m *Model) {
+				delete(m.throttledRules, name)
+			},
+		})
+	}
+	if label := m.groupingLabel(); label != "" {
+		chips = append(chips, filterChip{
+			label: "group " + label,
+			remove: func(m *Model) {
+				m.groupBy = ""
+				m.groupExpanded = make(map[string]bool)
+			},
+		})
+	}
+	return chips
+}
+
+// removeChip drops the filter behind chip key ("alt+3" removes chip 3).
+func (m *Model) removeChip(key string) {
+	var n int
+	if _, err := fmt.Sscanf(key, "alt+%d", &n); err != nil {
+		return
+	}
+	chips := m.filterChips()
+	if n < 1 || n > len(chips) || n > maxChips {
+		return
+	}
+	chip := chips[n-1]
+	chip.remove(m)
+	m.audit("remove_filter", "filter", chip.label)
+	m.notification = fmt.Sprintf("Removed filter: %s", chip.label)
+	m.notificationT = m.now()
+	m.refreshVisibleState()
+}
+
+// renderFilterBar draws the active filters as numbered chips; empty when none
+// is active.
+func (m Model) renderFilterBar() string {
+	chips := m.filterChips()
+	if len(chips) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(chips)+1)
+	parts = append(parts, m.theme.Header.Render("filters"))
+	for i, chip := range chips {
+		label := chip.label
+		if i < maxChips {
+			label = fmt.Sprintf("%d %s", i+1, label)
+		}
+		parts = append(parts, m.theme.PillStyle.Render(label))
+	}
+	width := m.viewport.Width
+	if width < 1 {
+		width = 1
+	}
+	return lipgloss.NewStyle().MaxWidth(width).Render(strings.Join(parts, " "))
+}
+
+// renderPaneTop stacks the auto-pause banner, the filter bar, and the pinned
+// strip above the viewport.
+func (m Model) renderPaneTop() string {
+	var parts []string
+	for _, part := range []string{m.renderFloodBanner(), m.renderFilterBar(), m.renderPinnedStrip()} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
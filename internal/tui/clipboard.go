@@ -0,0 +1,256 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	goruntime "runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"watcher/internal/rules"
+)
+
+// copyFormat is how the detail view's alert is put on the clipboard, chosen
+// by the key pressed: plain text for a terminal or email, JSON for tooling,
+// Markdown for a ticket, chat, or report.
+type copyFormat int
+
+const (
+	copyPlain copyFormat = iota
+	copyJSON
+	copyMarkdown
+)
+
+func (f copyFormat) String() string {
+	switch f {
+	case copyJSON:
+		return "json"
+	case copyMarkdown:
+		return "markdown"
+	}
+	return "plain"
+}
+
+// copyDetailToClipboard copies the open alert in format.
+func (m *Model) copyDetailToClipboard(format copyFormat) {
+	if !m.detailOpen {
+		m.notification = "No alert to copy"
+		m.notificationT = m.now()
+		return
+	}
+	content, err := m.clipboardContent(m.detailLine, format)
+	if err == nil {
+		err = writeClipboard(content)
+	}
+	if errors.Is(err, errNoClipboard) {
+		m.notification = "Clipboard not supported on this system"
+		m.notificationT = m.now()
+		return
+	}
+	if err != nil {
+		m.notification = fmt.Sprintf("Clipboard error: %v", err)
+		m.notificationT = m.now()
+		return
+	}
+	m.audit("export_clipboard", "rule", m.detailLine.RuleName, "severity", string(m.detailLine.Severity), "path", m.detailLine.Path, "format", format.String())
+	switch format {
+	case copyJSON:
+		m.notification = "Copied alert details as JSON"
+	case copyMarkdown:
+		m.notification = "Copied alert details as Markdown"
+	default:
+		m.notification = "Copied alert details to clipboard"
+	}
+	m.notificationT = m.now()
+}
+
+// clipboardContent renders line in format. Plain text is the detail view as
+// shown.
+func (m Model) clipboardContent(line displayLine, format copyFormat) (string, error) {
+	switch format {
+	case copyJSON:
+		return m.detailJSON(line)
+	case copyMarkdown:
+		return m.detailMarkdown(line), nil
+	}
+	return m.buildDetailContent(line), nil
+}
+
+// detailJSON is the alert as one indented object, with the field names of
+// `grep --json`.
+func (m Model) detailJSON(line displayLine) (string, error) {
+	event := struct {
+		Time     time.Time         `json:"time"`
+		Severity rules.Severity    `json:"severity"`
+		Rule     string            `json:"rule,omitempty"`
+		Pipeline string            `json:"pipeline,omitempty"`
+		Path     string            `json:"path"`
+		Sources  []string          `json:"sources,omitempty"`
+		LineNo   int64             `json:"line_no,omitempty"`
+		Offset   int64             `json:"offset,omitempty"`
+		Line     string            `json:"line"`
+		Tags     []string          `json:"tags,omitempty"`
+		Captures map[string]string `json:"captures,omitempty"`
+		Chain    []string          `json:"chain,omitempty"`
+		Derived  string            `json:"derived,omitempty"`
+		Template string            `json:"template,omitempty"`
+		Context  string            `json:"context,omitempty"`
+	}{
+		Time:     line.Timestamp,
+		Severity: line.Severity,
+		Rule:     line.RuleName,
+		Pipeline: line.Pipeline,
+		Path:     line.Path,
+		Sources:  line.Sources,
+		LineNo:   line.LineNo,
+		Offset:   line.Offset,
+		Line:     line.Text,
+		Tags:     line.Tags,
+		Captures: line.Captures,
+		Chain:    line.Chain,
+		Derived:  line.Derived,
+		Template: line.Template,
+		Context:  m.detailDisk,
+	}
+	content, err := json.MarshalIndent(event, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(content) + "\n", nil
+}
+
+// detailMarkdown is the alert as a snippet for a ticket or chat: a heading
+// line with a severity badge, a field list, and the line in a code block.
+func (m Model) detailMarkdown(line displayLine) string {
+	var b strings.Builder
+	rule := "unmatched"
+	if line.RuleName != "" {
+		rule = "`" + line.RuleName + "`"
+	}
+	fmt.Fprintf(&b, "%s **%s** · %s\n\n", severityBadge(line.Severity), strings.ToUpper(string(line.Severity)), rule)
+	source := "`" + line.Path + "`"
+	if len(line.Sources) > 1 {
+		source = "`" + strings.Join(line.Sources, "`, `") + "`"
+	}
+	if line.LineNo > 0 {
+		source += fmt.Sprintf(" line %d", line.LineNo)
+	}
+	fmt.Fprintf(&b, "- **Source:** %s\n", source)
+	fmt.Fprintf(&b, "- **Time:** %s\n", line.Timestamp.Format(m.cfg.Precision.RFC3339()))
+	if line.Pipeline != "" {
+		fmt.Fprintf(&b, "- **Pipeline:** %s\n", line.Pipeline)
+	}
+	if len(line.Chain) > 0 {
+		fmt.Fprintf(&b, "- **Via:** `%s` → %s\n", strings.Join(line.Chain, "` → `"), inlineCode(line.Derived))
+	}
+	if len(line.Tags) > 0 {
+		fmt.Fprintf(&b, "- **Tags:** %s\n", strings.Join(line.Tags, ", "))
+	}
+	if len(line.Captures) > 0 {
+		names := make([]string, 0, len(line.Captures))
+		for name := range line.Captures {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for i, name := range names {
+			names[i] = name + "=" + inlineCode(line.Captures[name])
+		}
+		fmt.Fprintf(&b, "- **Captures:** %s\n", strings.Join(names, ", "))
+	}
+	fmt.Fprintf(&b, "\n%s", codeBlock(line.Text))
+	if m.detailDisk != "" {
+		fmt.Fprintf(&b, "\nOn disk around offset %d:\n\n%s", line.Offset, codeBlock(m.detailDisk))
+	}
+	return b.String()
+}
+
+// severityBadge is a colored circle for the severity's built-in level, which
+// chat tools and ticket trackers render without any markup support.
+func severityBadge(sev rules.Severity) string {
+	switch rules.BuiltinFloor(sev) {
+	case rules.SeverityCritical:
+		return "🔴"
+	case rules.SeverityHigh:
+		return "🟠"
+	case rules.SeverityMedium:
+		return "🟡"
+	case rules.SeverityLow:
+		return "🔵"
+	}
+	return "⚪"
+}
+
+// codeBlock fences text, with a fence longer than any backtick run in it.
+func codeBlock(text string) string {
+	fence, run := "```", 0
+	for _, r := range text {
+		if r != '`' {
+			run = 0
+			continue
+		}
+		if run++; run >= len(fence) {
+			fence += "`"
+		}
+	}
+	return fence + "text\n" + strings.TrimRight(text, "\n") + "\n" + fence + "\n"
+}
+
+// inlineCode wraps text in a code span whose backtick fence is longer than
+// any backtick run inside, padded with spaces when text starts or ends with a
+// backtick, so a value like a`b or `id` cannot break out of the span.
+func inlineCode(text string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r != '`' {
+			run = 0
+			continue
+		}
+		run++
+		longest = max(longest, run)
+	}
+	fence := strings.Repeat("`", longest+1)
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		text = " " + text + " "
+	}
+	return fence + text + fence
+}
+
+// errNoClipboard is writeClipboard's error where no clipboard tool exists.
+var errNoClipboard = errors.New("clipboard not supported")
+
+// writeClipboard hands content to the platform's clipboard tool.
+func writeClipboard(content string) error {
+	var cmd *exec.Cmd
+	if goruntime.GOOS == "darwin" {
+		cmd = exec.Command("pbcopy")
+	} else if goruntime.GOOS == "linux" {
+		if _, err := exec.LookPath("xclip"); err == nil {
+			cmd = exec.Command("xclip", "-selection", "clipboard")
+		} else if _, err := exec.LookPath("xsel"); err == nil {
+			cmd = exec.Command("xsel", "--clipboard", "--input")
+		}
+	} else if goruntime.GOOS == "windows" {
+		cmd = exec.Command("clip.exe")
+	}
+	if cmd == nil {
+		return errNoClipboard
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(stdin, content); err != nil {
+		stdin.Close()
+		cmd.Wait()
+		return err
+	}
+	stdin.Close()
+	return cmd.Wait()
+}
@@ -0,0 +1,103 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// commandState is the `:` command line typed into the status bar.
+type commandState struct {
+	open  bool
+	input string
+}
+
+// commandNames are the commands the `:` line accepts.
+var commandNames = []string{"save-session", "load-session", "watch", "watch!", "unwatch", "goto", "pulse"}
+
+func (m *Model) openCommand() {
+	m.command = commandState{open: true}
+}
+
+func (m Model) handleCommandKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.command = commandState{}
+	case tea.KeyEnter:
+		input := m.command.input
+		m.command = commandState{}
+		m.runCommand(input)
+	case tea.KeyBackspace:
+		if input := []rune(m.command.input); len(input) > 0 {
+			m.command.input = string(input[:len(input)-1])
+		} else {
+			m.command = commandState{}
+		}
+	case tea.KeyTab:
+		m.completeCommand()
+	case tea.KeySpace:
+		m.command.input += " "
+	case tea.KeyRunes:
+		m.command.input += string(msg.Runes)
+	}
+	return m, nil
+}
+
+// completeCommand finishes a unique command name prefix.
+func (m *Model) completeCommand() {
+	if strings.Contains(m.command.input, " ") {
+		return
+	}
+	var match string
+	for _, name := range commandNames {
+		if strings.HasPrefix(name, m.command.input) {
+			if match != "" {
+				return
+			}
+			match = name
+		}
+	}
+	if match != "" {
+		m.command.input = match + " "
+	}
+}
+
+func (m *Model) runCommand(input string) {
+	fields := strings.Fields(input)
+	if len(fields) == 0 {
+		return
+	}
+	arg := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(input), fields[0]))
+	switch fields[0] {
+	case "save-session":
+		m.saveSessionAs(arg)
+	case "load-session":
+		m.loadSessionNamed(arg)
+	case "watch", "watch!":
+		m.addWatch(arg, fields[0] == "watch!")
+	case "unwatch":
+		m.removeWatch(arg)
+	case "goto":
+		m.gotoLine(arg)
+	case "pulse":
+		m.setPulse(arg)
+	default:
+		if isLineNumber(fields[0]) {
+			m.gotoLine(fields[0])
+			return
+		}
+		m.notification = fmt.Sprintf("unknown command %q (%s)", fields[0], strings.Join(commandNames, ", "))
+		m.notificationT = m.now()
+	}
+}
+
+// isLineNumber reports whether a command is a bare `:N` jump.
+func isLineNumber(s string) bool {
+	return strings.Trim(s, "0123456789") == "" && s != ""
+}
+
+// renderCommandLine replaces the status bar while a command is typed.
+func (m Model) renderCommandLine(width int) string {
+	return m.theme.StatusBar.Width(width).MaxHeight(1).Render(":" + m.command.input + "█")
+}
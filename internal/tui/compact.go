@@ -0,0 +1,70 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"watcher/internal/rules"
+)
+
+// compact reports whether the single-pane layout is in use: either forced with
+// `z`, or automatically when the window is narrower than CompactWidth.
+func (m Model) compact() bool {
+	if m.compactForced != nil {
+		return *m.compactForced
+	}
+	return m.cfg.CompactWidth > 0 && m.windowWidth > 0 && m.windowWidth < m.cfg.CompactWidth
+}
+
+// toggleCompact overrides the automatic choice with the opposite of the current layout.
+func (m *Model) toggleCompact() {
+	next := !m.compact()
+	m.compactForced = &next
+	m.resize(m.windowWidth, m.windowHeight)
+	m.notification = "Full layout"
+	if next {
+		m.notification = "Compact layout"
+	}
+	m.notificationT = m.now()
+}
+
+// timestampLayout shortens line timestamps to hours and minutes in compact
+// layout, and otherwise shows seconds at the configured precision.
+func (m Model) timestampLayout() string {
+	if m.compact() {
+		return "15:04"
+	}
+	return m.cfg.Precision.Layout("15:04:05")
+}
+
+// renderCompactBar folds the header and status bar into a single line: state,
+// severity counts, the most important header flags, and the key hints.
+func (m Model) renderCompactBar() string {
+	parts := []string{m.scrollState("live")}
+	var counts []string
+	for _, sev := range []rules.Severity{rules.SeverityCritical, rules.SeverityHigh, rules.SeverityMedium} {
+		if n := m.counts[sev]; n > 0 {
+			counts = append(counts, m.severityStyle(sev).Render(fmt.Sprintf("%s%d", strings.ToUpper(string(sev)[:1]), n)))
+		}
+	}
+	if len(counts) > 0 {
+		parts = append(parts, strings.Join(counts, " "))
+	}
+	if held := m.heldCount(); held > 0 {
+		parts = append(parts, fmt.Sprintf("held:%d", held))
+	}
+	if m.deltaMode {
+		parts = append(parts, "delta")
+	}
+	if m.monitoring() {
+		parts = append(parts, "MONITOR")
+	}
+	parts = append(parts, "? help  z full")
+	width := m.windowWidth
+	if width < 10 {
+		width = 10
+	}
+	return m.theme.StatusBar.Width(width).MaxHeight(1).Render(lipgloss.NewStyle().MaxWidth(width).Render(strings.Join(parts, " · ")))
+}
@@ -0,0 +1,28 @@
+package tui
+
+import "strings"
+
+// visibleControls replaces control characters other than tab and newline
+// with their Unicode control pictures (ESC becomes ␛), so escape sequences in
+// a log line are shown rather than interpreted by the terminal, where they
+// could move the cursor, recolor, or retitle the dashboard.
+func visibleControls(text string) string {
+	if !strings.ContainsFunc(text, isHiddenControl) {
+		return text
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == 0x7f:
+			return '␡'
+		case r < 0x20 && r != '\t' && r != '\n':
+			return 0x2400 + r
+		case r >= 0x80 && r < 0xa0:
+			return '�'
+		}
+		return r
+	}, text)
+}
+
+func isHiddenControl(r rune) bool {
+	return r < 0x20 && r != '\t' && r != '\n' || r >= 0x7f && r < 0xa0
+}
@@ -0,0 +1,83 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"watcher/internal/pipeline"
+)
+
+const (
+	// detailPageRows is how many rows of a huge alert the detail view wraps
+	// and shows at a time.
+	detailPageRows = 400
+	// detailPagedBytes is the content size beyond which the detail view pages
+	// even when the rows are few (one enormous line).
+	detailPagedBytes = 256 << 10
+	// detailRowRunes cuts longer rows of a paged alert into pieces so a page
+	// stays bounded.
+	detailRowRunes = 2000
+)
+
+// needsPaging reports whether content is too big to wrap in one piece: more
+// rows than a page (counting stitched continuation lines) or too many bytes.
+func needsPaging(content string) bool {
+	if len(content) > detailPagedBytes {
+		return true
+	}
+	return strings.Count(content, "\n")+strings.Count(content, pipeline.MultilineSeparator) >= detailPageRows
+}
+
+// detailRows splits content into rows for paging: one per line and per
+// stitched continuation line, with rows over detailRowRunes cut up.
+func detailRows(content string) []string {
+	var rows []string
+	for _, line := range strings.Split(content, "\n") {
+		for _, part := range strings.Split(line, pipeline.MultilineSeparator) {
+			runes := []rune(part)
+			for len(runes) > detailRowRunes {
+				rows = append(rows, string(runes[:detailRowRunes]))
+				runes = runes[detailRowRunes:]
+			}
+			rows = append(rows, string(runes))
+		}
+	}
+	return rows
+}
+
+func (m Model) detailPages() int {
+	return (len(m.detailRows) + detailPageRows - 1) / detailPageRows
+}
+
+// showDetailPage wraps just the rows of the current page into the viewport.
+func (m *Model) showDetailPage() {
+	width := m.detailViewport.Width
+	if width <= 0 {
+		width = 60
+	}
+	start := m.detailPage * detailPageRows
+	end := min(start+detailPageRows, len(m.detailRows))
+	m.detailViewport.SetContent(wrapText(visibleControls(strings.Join(m.detailRows[start:end], "\n")), width))
+}
+
+// turnDetailPage moves a paged alert delta pages on and shows that page from
+// its top.
+func (m *Model) turnDetailPage(delta int) {
+	if m.detailRows == nil {
+		return
+	}
+	page := clamp(m.detailPage+delta, 0, m.detailPages()-1)
+	if page == m.detailPage {
+		return
+	}
+	m.detailPage = page
+	m.showDetailPage()
+	m.detailViewport.GotoTop()
+}
+
+// detailPageStatus describes the page shown, for the modal's instructions.
+func (m Model) detailPageStatus() string {
+	start := m.detailPage * detailPageRows
+	end := min(start+detailPageRows, len(m.detailRows))
+	return fmt.Sprintf("[/] page %d/%d (rows %d–%d of %d)", m.detailPage+1, m.detailPages(), start+1, end, len(m.detailRows))
+}
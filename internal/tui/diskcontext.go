@@ -0,0 +1,55 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"watcher/internal/rules"
+	"watcher/internal/secrets"
+	"watcher/internal/watch"
+)
+
+// diskContextLines is how many lines `o` rereads on either side of an event.
+const diskContextLines = 5
+
+// loadDiskContext rereads the open alert's neighbourhood from its file, so the
+// surrounding lines are available even after the buffer dropped them. The
+// result is shown in the detail view and included when copying it, so with
+// secret redaction on, the lines go through the same scanner as live ones.
+func (m *Model) loadDiskContext() {
+	line := m.detailLine
+	if line.Offset <= 0 {
+		m.notification = "No file offset for this line (not read from a file)"
+		m.notificationT = m.now()
+		return
+	}
+	ctx, err := watch.ReadContext(line.Path, line.Offset, diskContextLines, diskContextLines)
+	if err != nil {
+		m.notification = err.Error()
+		m.notificationT = m.now()
+		return
+	}
+	scan := m.secretScan()
+	var b strings.Builder
+	for i, text := range ctx.Lines {
+		if scan.RedactsSecrets() {
+			text = secrets.Redact(text, scan.Scanner().Scan(text)).Line
+		}
+		marker := "  "
+		if i == ctx.Line {
+			marker = "➤ "
+		}
+		fmt.Fprintf(&b, "%s%s\n", marker, text)
+	}
+	m.detailDisk = b.String()
+	m.refreshDetailContent()
+}
+
+// secretScan is the active rule file's secret detector settings; without a
+// RuleSwap nothing is configured.
+func (m Model) secretScan() rules.SecretScan {
+	if m.cfg.RuleSwap == nil {
+		return rules.SecretScan{}
+	}
+	return m.cfg.RuleSwap.RuleSet().Secrets
+}
@@ -0,0 +1,168 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// entityState is the entity timeline modal: every buffered line whose
+// captures carry the same entity value, oldest first.
+type entityState struct {
+	open    bool
+	choices []entityRef
+	index   int
+}
+
+// entityRef is one entity on the line the timeline was opened from.
+type entityRef struct {
+	name  string
+	value string
+}
+
+// lineEntities lists the configured entity captures present on line, in the
+// order of ModelConfig.Entities.
+func (m Model) lineEntities(line displayLine) []entityRef {
+	var refs []entityRef
+	for _, name := range m.cfg.Entities {
+		if value := strings.TrimSpace(line.Captures[name]); value != "" {
+			refs = append(refs, entityRef{name: name, value: value})
+		}
+	}
+	return refs
+}
+
+// mentionsEntity reports whether line belongs on the open entity timeline.
+func (m Model) mentionsEntity(line displayLine) bool {
+	value := m.entity.choices[m.entity.index].value
+	for _, ref := range m.lineEntities(line) {
+		if ref.value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// entityTimeline returns the buffered lines mentioning value under any
+// entity capture, so a user seen as `invalid_user` on one line and `user` on
+// another shows up once. Lines are in timestamp order.
+func (m Model) entityTimeline(value string) []displayLine {
+	var out []displayLine
+	for _, line := range m.lines {
+		if line.isGroupHeader() || line.isSeparator() || line.isMarker() {
+			continue
+		}
+		for _, ref := range m.lineEntities(line) {
+			if ref.value == value {
+				out = append(out, line)
+				break
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].Timestamp.Equal(out[j].Timestamp) {
+			return out[i].Timestamp.Before(out[j].Timestamp)
+		}
+		return out[i].Seq < out[j].Seq
+	})
+	return out
+}
+
+// openEntityTimeline opens the timeline for the selected line, or for the
+// alert in the detail view when it is opened from there.
+func (m *Model) openEntityTimeline() {
+	line, ok := m.selectedLine()
+	if m.detailOpen {
+		line, ok = m.detailLine, true
+	}
+	if !ok || line.isGroupHeader() || line.isSeparator() {
+		return
+	}
+	refs := m.lineEntities(line)
+	if len(refs) == 0 {
+		m.notification = fmt.Sprintf("No entity captures on this line (%s)", strings.Join(m.cfg.Entities, ", "))
+		m.notificationT = m.now()
+		return
+	}
+	m.entity = entityState{open: true, choices: refs}
+	m.pushModal(modalEntity)
+	m.updateEntityViewportSize()
+	m.entityViewport.GotoTop()
+	m.refreshEntityContent()
+	m.audit("entity_timeline", "entity", refs[0].name, "value", refs[0].value)
+}
+
+// cycleEntity switches the timeline to the next entity of the original line.
+func (m *Model) cycleEntity() {
+	if len(m.entity.choices) < 2 {
+		return
+	}
+	m.entity.index = (m.entity.index + 1) % len(m.entity.choices)
+	m.entityViewport.GotoTop()
+	m.refreshEntityContent()
+}
+
+func (m *Model) closeEntityTimeline() {
+	m.entity = entityState{}
+}
+
+func (m *Model) updateEntityViewportSize() {
+	if !m.entity.open {
+		return
+	}
+	width, height := m.modalSize()
+	innerWidth := width - (modalPaddingX * 2) - 2
+	if innerWidth < 20 {
+		innerWidth = 20
+	}
+	innerHeight := height - (modalPaddingY * 2) - 2 - modalChromeLines
+	if innerHeight < 3 {
+		innerHeight = 3
+	}
+	m.entityViewport.Width = innerWidth
+	m.entityViewport.Height = innerHeight
+	m.refreshEntityContent()
+}
+
+func (m *Model) refreshEntityContent() {
+	if !m.entity.open {
+		return
+	}
+	ref := m.entity.choices[m.entity.index]
+	width := m.entityViewport.Width
+	if width <= 0 {
+		width = 60
+	}
+	timeline := m.entityTimeline(ref.value)
+	rows := make([]string, 0, len(timeline))
+	for _, line := range timeline {
+		sev := m.severityStyle(line.Severity).Render(fmt.Sprintf("%-8s", strings.ToUpper(string(line.Severity))))
+		rule := line.RuleName
+		if rule == "" {
+			rule = "(unmatched)"
+		}
+		head := fmt.Sprintf("%s %s %s", line.Timestamp.Format(m.cfg.Precision.Layout("15:04:05")), sev, rule)
+		rows = append(rows, head, lipgloss.NewStyle().Faint(true).Render(wrapText(visibleControls(line.Text), width-2)))
+	}
+	if len(rows) == 0 {
+		rows = append(rows, "nothing in the buffer")
+	}
+	m.entityViewport.SetContent(strings.Join(rows, "\n"))
+}
+
+func (m Model) renderEntityModal() string {
+	width, height := m.modalSize()
+	ref := m.entity.choices[m.entity.index]
+	count := len(m.entityTimeline(ref.value))
+	title := m.theme.Header.Render(fmt.Sprintf("entity timeline · %s=%s (%d)", ref.name, visibleControls(ref.value), count))
+	hint := "enter/esc close · arrows scroll"
+	if len(m.entity.choices) > 1 {
+		hint = fmt.Sprintf("e next entity (%d/%d) · %s", m.entity.index+1, len(m.entity.choices), hint)
+	}
+	instructions := m.theme.TagStyle.Render(hint)
+	body := m.entityViewport.View()
+	content := lipgloss.JoinVertical(lipgloss.Left, title, instructions, body)
+	return m.modalStyle(width, height).Render(content)
+}
@@ -0,0 +1,84 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"watcher/internal/rules"
+)
+
+// floodState measures the arrival rate, one-second buckets at a time, for
+// the auto-pause safeguard (--auto-pause-rate).
+type floodState struct {
+	second time.Time
+	count  int
+	// rate is the count of the last complete second.
+	rate int
+	// overSince is the first second of the current run above the limit.
+	overSince time.Time
+	// active marks a pause the safeguard made, shown with a banner until `p`.
+	active bool
+	// resumed holds off another auto-pause after `p` until the rate has
+	// dropped below the limit once, so resuming mid-flood sticks.
+	resumed bool
+}
+
+// observeFlood counts a line arriving at now. Once every second for
+// PauseAfter has seen more than PauseRate lines, rendering pauses
+// the way `p` does: lines are still buffered, counted, and acted on.
+func (m *Model) observeFlood(now time.Time) {
+	limit := m.cfg.PauseRate
+	if limit <= 0 {
+		return
+	}
+	f := &m.flood
+	sec := now.Truncate(time.Second)
+	if sec.Equal(f.second) {
+		f.count++
+		return
+	}
+	// A second closed. A skipped second had no lines, so it breaks a run.
+	f.rate = f.count
+	if f.count > limit && sec.Sub(f.second) == time.Second {
+		if f.overSince.IsZero() {
+			f.overSince = f.second
+		}
+	} else {
+		f.overSince = time.Time{}
+		f.resumed = false
+	}
+	f.second, f.count = sec, 1
+	if f.overSince.IsZero() || sec.Sub(f.overSince) < max(m.cfg.PauseAfter, time.Second) || m.paused || f.resumed {
+		return
+	}
+	m.paused = true
+	f.active = true
+	m.audit("auto_pause", "rate", fmt.Sprint(f.rate), "limit", fmt.Sprint(limit))
+	m.applyPaneHeight()
+}
+
+// endAutoPause clears the banner once the operator toggles pause.
+func (m *Model) endAutoPause() {
+	if !m.flood.active {
+		return
+	}
+	m.flood.active = false
+	m.flood.resumed = true
+	m.applyPaneHeight()
+}
+
+// renderFloodBanner explains an automatic pause; empty otherwise.
+func (m Model) renderFloodBanner() string {
+	if !m.flood.active {
+		return ""
+	}
+	text := fmt.Sprintf("⚠ rendering paused: over %d lines/s for %s (last %d/s) · lines are still collected · p resumes",
+		m.cfg.PauseRate, max(m.cfg.PauseAfter, time.Second), m.flood.rate)
+	width := m.viewport.Width
+	if width < 1 {
+		width = 1
+	}
+	return lipgloss.NewStyle().MaxWidth(width).Render(m.severityStyle(rules.SeverityHigh).Copy().Reverse(true).Render(text))
+}
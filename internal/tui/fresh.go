@@ -0,0 +1,42 @@
+package tui
+
+import "time"
+
+// freshBadge marks lines that arrived within FreshFor: a pulsing dot for the
+// first third, then a plain dot, then a faint one, then nothing.
+func (m Model) freshBadge(line displayLine) string {
+	ttl := m.cfg.FreshFor
+	if ttl <= 0 || line.Arrived.IsZero() {
+		return ""
+	}
+	age := m.now().Sub(line.Arrived)
+	accent := m.theme.HighlightStyle.Copy()
+	switch {
+	case age < ttl/3:
+		glyph := "●"
+		if m.shimmer {
+			glyph = "◉"
+		}
+		return accent.Bold(true).Render(glyph)
+	case age < 2*ttl/3:
+		return accent.Render("•")
+	case age < ttl:
+		return accent.Faint(true).Render("·")
+	}
+	return " "
+}
+
+// refreshFresh re-renders the pane on the pulse tick while any badge is still
+// fading, so badges age even when no new lines arrive.
+func (m *Model) refreshFresh() {
+	if m.cfg.FreshFor <= 0 || m.paused || m.lastArrival.IsZero() {
+		return
+	}
+	if m.now().Sub(m.lastArrival) > m.cfg.FreshFor+time.Second {
+		return
+	}
+	m.viewport.SetContent(m.renderLogContent())
+	if m.follow {
+		m.viewport.GotoBottom()
+	}
+}
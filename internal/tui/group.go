@@ -0,0 +1,205 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"watcher/internal/rules"
+)
+
+const (
+	groupByRule     = "rule"
+	groupByTemplate = "template"
+	groupByCapture  = "capture"
+	noGroupValue    = "(none)"
+)
+
+type lineGroup struct {
+	key string
+	// label is the header text: the key, or by template the template as of
+	// the group's latest line.
+	label  string
+	lines  []displayLine
+	worst  rules.Severity
+	latest time.Time
+}
+
+func (l displayLine) isGroupHeader() bool {
+	return l.GroupSize > 0
+}
+
+// cycleGrouping steps through: off → by rule → by message template (when the
+// Cluster stage runs) → by each capture name → off.
+func (m *Model) cycleGrouping() {
+	captures := m.captureNames()
+	switch m.groupBy {
+	case "":
+		m.groupBy = groupByRule
+	case groupByRule:
+		if m.hasTemplates() {
+			m.groupBy = groupByTemplate
+			break
+		}
+		fallthrough
+	case groupByTemplate:
+		if len(captures) == 0 {
+			m.groupBy = ""
+		} else {
+			m.groupBy = groupByCapture
+			m.groupCapture = captures[0]
+		}
+	case groupByCapture:
+		m.groupBy = ""
+		for i, name := range captures {
+			if name == m.groupCapture && i+1 < len(captures) {
+				m.groupBy = groupByCapture
+				m.groupCapture = captures[i+1]
+				break
+			}
+		}
+	}
+	m.groupExpanded = make(map[string]bool)
+	m.notification = "Grouping off"
+	if label := m.groupingLabel(); label != "" {
+		m.notification = fmt.Sprintf("Grouping by %s", label)
+	}
+	m.notificationT = m.now()
+	m.refreshVisibleState()
+}
+
+func (m Model) groupingLabel() string {
+	switch m.groupBy {
+	case groupByRule:
+		return "rule"
+	case groupByTemplate:
+		return "template"
+	case groupByCapture:
+		return "capture " + m.groupCapture
+	}
+	return ""
+}
+
+// hasTemplates reports whether any buffered line carries a message template.
+func (m Model) hasTemplates() bool {
+	for _, line := range m.lines {
+		if line.ClusterID > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// captureNames lists every capture name present in the buffer.
+func (m Model) captureNames() []string {
+	seen := make(map[string]struct{})
+	for _, line := range m.lines {
+		for name := range line.Captures {
+			seen[name] = struct{}{}
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m Model) groupKey(line displayLine) string {
+	var key string
+	switch m.groupBy {
+	case groupByRule:
+		key = line.RuleName
+	case groupByTemplate:
+		if line.ClusterID > 0 {
+			key = fmt.Sprintf("#%d", line.ClusterID)
+		}
+	case groupByCapture:
+		key = line.Captures[m.groupCapture]
+	}
+	if key == "" {
+		return noGroupValue
+	}
+	return key
+}
+
+// groupLines collapses lines into top-talker groups ordered by size, emitting a
+// header row per group followed by its members when the group is expanded.
+func (m Model) groupLines(lines []displayLine) []displayLine {
+	byKey := make(map[string]*lineGroup)
+	groups := make([]*lineGroup, 0)
+	for _, line := range lines {
+		if line.isMarker() {
+			continue
+		}
+		key := m.groupKey(line)
+		g, ok := byKey[key]
+		if !ok {
+			g = &lineGroup{key: key, label: key, worst: rules.SeverityNormal}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+		g.lines = append(g.lines, line)
+		if m.groupBy == groupByTemplate && line.Template != "" {
+			g.label = line.Template
+		}
+		if rules.SeverityRank(line.Severity) < rules.SeverityRank(g.worst) {
+			g.worst = line.Severity
+		}
+		if line.Timestamp.After(g.latest) {
+			g.latest = line.Timestamp
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if len(groups[i].lines) == len(groups[j].lines) {
+			return groups[i].latest.After(groups[j].latest)
+		}
+		return len(groups[i].lines) > len(groups[j].lines)
+	})
+
+	out := make([]displayLine, 0, len(groups))
+	for _, g := range groups {
+		header := displayLine{
+			Severity:  g.worst,
+			Timestamp: g.latest,
+			Text:      g.label,
+			Index:     -1,
+			GroupKey:  g.key,
+			GroupSize: len(g.lines),
+		}
+		if m.groupBy == groupByRule && g.key != noGroupValue {
+			header.RuleName = g.key
+		}
+		out = append(out, header)
+		if m.groupExpanded[g.key] {
+			out = append(out, g.lines...)
+		}
+	}
+	return out
+}
+
+func (m *Model) toggleGroup(line displayLine) {
+	m.groupExpanded[line.GroupKey] = !m.groupExpanded[line.GroupKey]
+	m.refreshVisibleState()
+}
+
+func (m Model) renderGroupHeader(line displayLine, selected bool) string {
+	style := m.severityStyle(line.Severity)
+	arrow := "▸"
+	if m.groupExpanded[line.GroupKey] {
+		arrow = "▾"
+	}
+	count := m.theme.PillStyle.Copy().Inherit(style).Render(fmt.Sprintf("×%d", line.GroupSize))
+	label := style.Copy().Bold(true).Render(fmt.Sprintf("%s %s", arrow, visibleControls(line.Text)))
+	meta := m.theme.TagStyle.Copy().Faint(true).Render("last " + line.Timestamp.Format(m.cfg.Precision.Layout("15:04:05")))
+	content := strings.Join([]string{label, count, meta}, " ")
+	if selected {
+		indicator := m.theme.HighlightStyle.Copy().Bold(true).Render("➤")
+		return lipgloss.JoinHorizontal(lipgloss.Top, indicator, " ", content)
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, " ", " ", content)
+}
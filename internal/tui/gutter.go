@@ -0,0 +1,108 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// gutterMode is what the column left of each line shows (`#` cycles it).
+type gutterMode int
+
+const (
+	gutterOff gutterMode = iota
+	// gutterLines shows the line's number within its source.
+	gutterLines
+	// gutterOffsets shows the file offset just past the line.
+	gutterOffsets
+)
+
+// gutterWidth fits numbers up to a million lines (or a 10 MB offset) without
+// the column shifting; larger ones widen their own row.
+const gutterWidth = 7
+
+func (g gutterMode) String() string {
+	switch g {
+	case gutterLines:
+		return "line numbers"
+	case gutterOffsets:
+		return "offsets"
+	}
+	return "off"
+}
+
+// cycleGutter steps the gutter through off, line numbers, and offsets.
+func (m *Model) cycleGutter() {
+	m.gutter = (m.gutter + 1) % (gutterOffsets + 1)
+	m.viewport.SetContent(m.renderLogContent())
+	m.notification = "Gutter: " + m.gutter.String()
+	m.notificationT = m.now()
+}
+
+// renderGutter is the gutter cell for line: blank for lines without a number
+// (markers, rate alerts, non-file offsets) so the text stays aligned.
+func (m Model) renderGutter(line displayLine) string {
+	if m.gutter == gutterOff {
+		return ""
+	}
+	value := line.LineNo
+	if m.gutter == gutterOffsets {
+		value = line.Offset
+	}
+	cell := strings.Repeat(" ", gutterWidth)
+	if value > 0 && !line.isMarker() && !line.isSeparator() && !line.isGroupHeader() {
+		cell = fmt.Sprintf("%*d", gutterWidth, value)
+	}
+	return m.theme.TagStyle.Copy().Faint(true).Render(cell) + " "
+}
+
+// gotoLine selects the visible line numbered n in source, or in the selected
+// line's source when source is empty (falling back to any source). It backs
+// `:N` and `:goto [source:]N`.
+func (m *Model) gotoLine(arg string) {
+	source, number := "", strings.TrimSpace(arg)
+	if i := strings.LastIndex(number, ":"); i >= 0 {
+		source, number = number[:i], number[i+1:]
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n <= 0 {
+		m.notification = fmt.Sprintf("goto: want a line number, got %q", arg)
+		m.notificationT = m.now()
+		return
+	}
+	visibleLines := m.getVisibleLines()
+	prefer := source
+	if prefer == "" {
+		if line, ok := m.selectedLine(); ok {
+			prefer = line.Path
+		}
+	}
+	found := -1
+	for i, line := range visibleLines {
+		if line.LineNo != n || line.isMarker() || line.isGroupHeader() {
+			continue
+		}
+		if line.Path == prefer {
+			found = i
+			break
+		}
+		if source == "" && found < 0 {
+			found = i
+		}
+	}
+	if found < 0 {
+		m.notification = fmt.Sprintf("Line %d is not in the visible buffer", n)
+		if source != "" {
+			m.notification = fmt.Sprintf("Line %d of %s is not in the visible buffer", n, source)
+		}
+		m.notificationT = m.now()
+		return
+	}
+	m.selectedIndex = found
+	m.follow = false
+	if m.gutter == gutterOff {
+		m.gutter = gutterLines
+	}
+	m.ensureSelectionVisible()
+	m.viewport.SetContent(m.renderLogContent())
+}
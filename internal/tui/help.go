@@ -0,0 +1,230 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// helpContext names what the keyboard is driving right now: the topmost
+// window under help, else the main view.
+type helpContext string
+
+const (
+	helpMain    helpContext = "main view"
+	helpMonitor helpContext = "monitor mode"
+	helpActions helpContext = "action confirmation"
+	helpConfig  helpContext = "configuration"
+	helpSuggest helpContext = "rule suggestions"
+	helpNoise   helpContext = "noise report"
+	helpRules   helpContext = "rule files"
+	helpEntity  helpContext = "entity timeline"
+	helpDetail  helpContext = "alert detail"
+)
+
+func (m Model) helpContext() helpContext {
+	stack := m.modalStack()
+	for i := len(stack) - 1; i >= 0; i-- {
+		if ctx, ok := modalHelpContext[stack[i]]; ok {
+			return ctx
+		}
+	}
+	if m.monitoring() {
+		return helpMonitor
+	}
+	return helpMain
+}
+
+// modalHelpContext maps each window but help itself to its context.
+var modalHelpContext = map[modalKind]helpContext{
+	modalActions: helpActions,
+	modalConfig:  helpConfig,
+	modalSuggest: helpSuggest,
+	modalNoise:   helpNoise,
+	modalRules:   helpRules,
+	modalEntity:  helpEntity,
+	modalDetail:  helpDetail,
+}
+
+// contextHelp lists only the bindings valid in each context.
+var contextHelp = map[helpContext]string{
+	helpMain: `
+NAVIGATION
+  ↑ / ↓         Move selection up/down; the sidebar's inspect section shows
+                the selected line's rule, tags, and captures
+  PgUp / PgDn   Page up/down
+  N / P         Jump to the next / previous critical or high event
+  End           Jump to the newest line and resume follow
+                (moving up pauses follow; reaching the bottom resumes it)
+  
+ACTIONS
+  Enter         Open alert details (expand/collapse when on a group)
+  h             Hide current line
+  x             Filter out all logs of this rule type
+  W             Persist the last x filter as enabled: false in the rule file
+  w             Watch bar: type a regex to flag matching lines as critical, in
+                reverse video, for this run only (:watch! also rings the bell)
+  r             Reset all filters (show everything)
+  alt+1 … 9     Remove just that filter from the filter bar above the pane
+  1 … 5         Show only critical (1), ≥high, ≥medium, ≥low, or everything (5)
+  E             Entity timeline: everything the selected line's user/IP/session
+                did in the buffer, oldest first (e cycles entities)
+  n             Noise report: the rules behind most visible lines lately, with
+                t throttle (1 line/min), d downgrade, x filter per rule
+  C             Switch rule files (*.rules.yaml) without a restart, with
+                rule counts per severity for each file
+  !             Review rule actions waiting for confirmation (y run, n skip)
+  m             Pin/unpin current line to the top of the pane
+  a / A         Acknowledge the selected / every held critical/high event
+  d             Toggle delta mode (only rules/values new vs baseline)
+  G             Group lines: off → by rule → by template → by capture (top talkers)
+  i             Mark unmatched line as interesting (queue a rule suggestion)
+  S             Review rule suggestions (enter accepts into the rule file)
+  
+PLAYBACK
+  p             Pause/unpause log streaming (also resumes an automatic pause)
+  I             Pause/resume ingestion (sources stop reading; resume catches up)
+  f             Toggle auto-follow (scroll to bottom)
+  
+APPEARANCE
+  t             Cycle themes (vapor → midnight → dusk → mono)
+  g             Cycle the charted numeric capture
+  M             Toggle the minimap (severity marks for the whole buffer; click to jump)
+  #             Cycle the gutter: off → line number in its source → file offset
+  z             Toggle compact layout (single pane, one-line header/status)
+  
+MODES
+  Tab           Switch monitor ↔ triage (saved to the profile)
+                monitor follows the stream and only accepts p/t/g/M/z/?/q
+
+OTHER
+  :             Command line (tab completes, esc cancels):
+                  save-session [name]  checkpoint layout, theme, filters,
+                                       grouping, pins and selection
+                  load-session name    restore a checkpoint
+                  watch[!] regex       flag lines matching regex (! rings
+                                       the terminal bell on each match)
+                  unwatch [regex]      drop one watch, or all of them
+                  N, goto [source:]N   select line N of the selected line's
+                                       source (or of source) in the buffer
+                  pulse tags [K]       sidebar pulse counts the top K tags
+                                       (default 5) with rate arrows
+                  pulse severity       back to severity counts (both saved
+                                       to the profile)
+  ?             Show this help (inside a window, the keys for that window)
+  q / Ctrl+C    Quit application
+  
+TIPS
+  • Pause (p) to stop scrolling while reviewing logs
+  • Filter (x) noisy rules to focus on important events
+  • Copy (y/c, J for JSON, M for Markdown) alert details to share with your team
+  • Fullscreen terminal shows severity counts in sidebar
+`,
+	helpMonitor: `
+MONITOR MODE
+  The view follows the stream; only keys that cannot change what is
+  shown work here.
+
+  Tab           Switch to triage (selection, filters, acknowledgment)
+  p             Pause/unpause log streaming (also resumes an automatic pause)
+  t             Cycle themes (vapor → midnight → dusk → mono)
+  g             Cycle the charted numeric capture
+  M             Toggle the minimap
+  z             Toggle compact layout
+  ?             Show this help
+  q / Ctrl+C    Quit application
+`,
+	helpActions: `
+CONFIRM ACTION
+  y             Run the command shown (no shell; 30s timeout)
+  n             Skip it without running
+  Esc           Decide later; ! in the main view reopens the queue
+`,
+	helpConfig: `
+CONFIGURATION
+  Tab / ← / →   Switch between log files and rule groups
+  ↑ / ↓         Move within the pane
+  Space         Toggle the entry
+  Enter         Apply (takes effect without a restart)
+  Esc           Close without applying
+`,
+	helpSuggest: `
+RULE SUGGESTIONS
+  ↑ / ↓ (k/j)   Move between suggestions
+  Enter / a     Append the suggestion to the rule file
+  s             Cycle its severity
+  x / Delete    Discard it
+  Esc / q / S   Close
+`,
+	helpNoise: `
+NOISE REPORT
+  ↑ / ↓ (k/j)   Move between rules
+  t             Throttle the rule to one line a minute (again to lift)
+  d             Downgrade the rule one severity level
+  x             Filter the rule out
+  Esc / q / n   Close
+`,
+	helpRules: `
+RULE FILES
+  ↑ / ↓ (k/j)   Move between files (*.rules.yaml, the active one first)
+  Enter         Switch to the file; new lines match its rules at once
+  Esc / q / C   Close
+`,
+	helpEntity: `
+ENTITY TIMELINE
+  e             Switch to the line's next entity
+  ↑ / ↓         Scroll the timeline
+  Enter / Esc   Close (back to the alert detail when opened from it)
+`,
+	helpDetail: `
+ALERT DETAIL
+  y / c         Copy alert details to clipboard as plain text
+  J             Copy as a JSON object (the fields of grep --json)
+  M             Copy as Markdown: severity badge, fields, code block
+  o             Reread the surrounding lines from the file on disk
+  E             Entity timeline of this alert over the detail (esc returns)
+  ↑ / ↓         Scroll detail content
+  [ / ]         Previous / next page of a huge alert (400 rows per page,
+                stitched continuation lines on rows of their own)
+  Enter / Esc   Close detail view
+`,
+}
+
+// helpText is the help modal body for the current context.
+func (m Model) helpText() string {
+	return strings.TrimSpace(contextHelp[m.helpContext()])
+}
+
+// contextHints is the one-line hint strip under a window.
+var contextHints = map[helpContext]string{
+	helpActions: "y run  ·  n skip  ·  esc later  ·  ? help",
+	helpConfig:  "tab pane  ·  ↑/↓ move  ·  space toggle  ·  enter apply  ·  esc close  ·  ? help",
+	helpSuggest: "↑/↓ move  ·  enter accept  ·  s severity  ·  x discard  ·  esc close  ·  ? help",
+	helpNoise:   "↑/↓ move  ·  t throttle  ·  d downgrade  ·  x filter  ·  esc close  ·  ? help",
+	helpRules:   "↑/↓ move  ·  enter switch  ·  esc close  ·  ? help",
+	helpEntity:  "e next entity  ·  ↑/↓ scroll  ·  esc close  ·  ? help",
+	helpDetail:  "y copy  ·  J json  ·  M markdown  ·  o reread from disk  ·  E entity  ·  ↑/↓ scroll  ·  [/] page  ·  esc close  ·  ? help",
+}
+
+// hintStrip is the key hint line for whatever is on screen; the main view
+// shows its keys in the status bar instead.
+func (m Model) hintStrip() string {
+	if m.helpOpen {
+		return "help · " + string(m.helpContext()) + "  ·  ↑/↓ scroll  ·  esc back"
+	}
+	return contextHints[m.helpContext()]
+}
+
+// placeModal centers a window over the screen with the hint strip below it.
+func (m Model) placeModal(modal string) string {
+	placed := lipgloss.Place(m.windowWidth, m.windowHeight-1, lipgloss.Center, lipgloss.Center, modal,
+		lipgloss.WithWhitespaceChars(" "),
+		lipgloss.WithWhitespaceBackground(m.theme.Backdrop))
+	strip := lipgloss.NewStyle().
+		Width(m.windowWidth).
+		MaxWidth(m.windowWidth).
+		Foreground(m.accentColor()).
+		Background(m.theme.Backdrop).
+		Render(" " + m.hintStrip())
+	return m.constrainToWindow(placed + "\n" + strip)
+}
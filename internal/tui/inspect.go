@@ -0,0 +1,47 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxInspectCaptures bounds the captures the sidebar lists for the selected
+// line; the detail view (enter) has the rest.
+const maxInspectCaptures = 8
+
+// renderInspect is the sidebar's view of the selected line: its rule and
+// severity, tags, and captures, so keyboard triage can read them without
+// opening the detail view. It follows the selection, and is empty for
+// markers and for unmatched lines with nothing to show.
+func (m Model) renderInspect() string {
+	line, ok := m.selectedLine()
+	if !ok || line.isMarker() || line.isSeparator() {
+		return ""
+	}
+	if line.RuleName == "" && len(line.Tags) == 0 && len(line.Captures) == 0 {
+		return ""
+	}
+	row := lipgloss.NewStyle().MaxWidth(m.sidebarContentWidth())
+	rows := []string{m.theme.Header.Render("inspect")}
+	rule := coalesce(line.RuleName, "unmatched")
+	rows = append(rows, row.Render(m.severityStyle(line.Severity).Render(rule)))
+	if len(line.Tags) > 0 {
+		rows = append(rows, row.Render(m.theme.TagStyle.Render("#"+strings.Join(line.Tags, " #"))))
+	}
+	names := make([]string, 0, len(line.Captures))
+	for name := range line.Captures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		if i == maxInspectCaptures {
+			rows = append(rows, m.theme.TagStyle.Render(fmt.Sprintf("+%d more · enter", len(names)-i)))
+			break
+		}
+		rows = append(rows, row.Render(name+"="+m.theme.HighlightStyle.Render(visibleControls(line.Captures[name]))))
+	}
+	return strings.Join(rows, "\n")
+}
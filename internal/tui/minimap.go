@@ -0,0 +1,133 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"watcher/internal/rules"
+)
+
+// minimapColumns is the width the minimap takes from the pane: a gutter, the
+// viewport marker, and the severity mark.
+const minimapColumns = 3
+
+// paneContentWidth is the pane's inner width, whether or not the minimap shares it.
+func (m Model) paneContentWidth() int {
+	if m.showMinimap {
+		return m.viewport.Width + minimapColumns
+	}
+	return m.viewport.Width
+}
+
+// applyPaneWidth gives the viewport whatever the minimap leaves of the pane width.
+func (m *Model) applyPaneWidth(width int) {
+	if m.showMinimap && width > minimapColumns+10 {
+		width -= minimapColumns
+	}
+	if width < 1 {
+		width = 1
+	}
+	m.viewport.Width = width
+}
+
+func (m *Model) toggleMinimap() {
+	width := m.paneContentWidth()
+	m.showMinimap = !m.showMinimap
+	m.applyPaneWidth(width)
+	m.notification = "Minimap off"
+	if m.showMinimap {
+		m.notification = "Minimap on · click a mark to jump"
+	}
+	m.notificationT = m.now()
+	m.viewport.SetContent(m.renderLogContent())
+}
+
+// minimapBuckets maps each minimap row to the [start,end) range of visible lines it summarizes.
+func (m Model) minimapBuckets(total int) [][2]int {
+	rows := m.viewport.Height
+	if rows < 1 || total == 0 {
+		return nil
+	}
+	if total < rows {
+		rows = total
+	}
+	buckets := make([][2]int, rows)
+	for r := range buckets {
+		buckets[r] = [2]int{r * total / rows, (r + 1) * total / rows}
+	}
+	return buckets
+}
+
+// renderMinimap draws one mark per row for the worst severity in that slice of
+// the buffer, with a bar beside the rows currently inside the viewport.
+func (m Model) renderMinimap() string {
+	visible := m.getVisibleLines()
+	buckets := m.minimapBuckets(len(visible))
+	rows := make([]string, m.viewport.Height)
+	viewTop := m.viewport.YOffset
+	viewBottom := viewTop + m.viewport.Height
+	marker := lipgloss.NewStyle().Foreground(m.accentColor())
+	quiet := lipgloss.NewStyle().Faint(true)
+	for r := range rows {
+		if r >= len(buckets) {
+			rows[r] = strings.Repeat(" ", minimapColumns)
+			continue
+		}
+		start, end := buckets[r][0], buckets[r][1]
+		position := " "
+		if start < viewBottom && end > viewTop {
+			position = marker.Render("▌")
+		}
+		worst := rules.SeverityNormal
+		for _, line := range visible[start:end] {
+			if rules.SeverityRank(line.Severity) < rules.SeverityRank(worst) {
+				worst = line.Severity
+			}
+		}
+		mark := quiet.Render("·")
+		if rules.MeetsThreshold(worst, rules.SeverityMedium) {
+			mark = m.severityStyle(worst).Render("■")
+		}
+		rows[r] = " " + position + mark
+	}
+	return strings.Join(rows, "\n")
+}
+
+// handleMinimapClick jumps to the region under a left click on the minimap,
+// preferring the most severe line in that row's slice. It reports whether the
+// click landed on the minimap.
+func (m *Model) handleMinimapClick(msg tea.MouseMsg) bool {
+	if !m.showMinimap || msg.Button != tea.MouseButtonLeft || msg.Action != tea.MouseActionPress {
+		return false
+	}
+	left := m.theme.Pane.GetBorderLeftSize() + m.theme.Pane.GetPaddingLeft() + m.viewport.Width
+	if msg.X < left || msg.X >= left+minimapColumns {
+		return false
+	}
+	top := m.theme.Pane.GetBorderTopSize() + m.theme.Pane.GetPaddingTop()
+	if m.showHeader {
+		top += lipgloss.Height(m.renderHeader())
+	}
+	if strip := m.renderPaneTop(); strip != "" {
+		top += lipgloss.Height(strip)
+	}
+	row := msg.Y - top
+	visible := m.getVisibleLines()
+	buckets := m.minimapBuckets(len(visible))
+	if row < 0 || row >= len(buckets) {
+		return false
+	}
+	target := buckets[row][0]
+	for i := buckets[row][0]; i < buckets[row][1]; i++ {
+		if rules.SeverityRank(visible[i].Severity) < rules.SeverityRank(visible[target].Severity) {
+			target = i
+		}
+	}
+	m.selectedIndex = target
+	m.follow = false
+	m.ensureSelectionVisible()
+	m.viewport.SetContent(m.renderLogContent())
+	return true
+}
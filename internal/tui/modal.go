@@ -0,0 +1,191 @@
+package tui
+
+import (
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// modalKind is one of the dashboard's windows. Windows stack: one opened over
+// another (help over the alert detail, the entity timeline over the detail)
+// takes the keyboard and the screen, and closing it returns to the window
+// below exactly as it was left.
+type modalKind int
+
+const (
+	modalDetail modalKind = iota
+	modalEntity
+	modalRules
+	modalNoise
+	modalSuggest
+	modalConfig
+	modalActions
+	modalHelp
+)
+
+// modalKinds is every kind in rank order, used to place a window that opened
+// without pushModal above those that did.
+var modalKinds = []modalKind{modalDetail, modalEntity, modalRules, modalNoise, modalSuggest, modalConfig, modalActions, modalHelp}
+
+func (m Model) isModalOpen(kind modalKind) bool {
+	switch kind {
+	case modalDetail:
+		return m.detailOpen
+	case modalEntity:
+		return m.entity.open
+	case modalRules:
+		return m.rulePicker.open
+	case modalNoise:
+		return m.noise.open
+	case modalSuggest:
+		return m.suggestOpen
+	case modalConfig:
+		return m.config.open
+	case modalActions:
+		return m.actions.open
+	case modalHelp:
+		return m.helpOpen
+	}
+	return false
+}
+
+// pushModal records kind as the topmost window; call it when opening one.
+// Windows closed since the last push are forgotten here.
+func (m *Model) pushModal(kind modalKind) {
+	m.modals = slices.DeleteFunc(m.modals, func(k modalKind) bool {
+		return k == kind || !m.isModalOpen(k)
+	})
+	m.modals = append(m.modals, kind)
+}
+
+// modalStack returns the open windows bottom to top.
+func (m Model) modalStack() []modalKind {
+	stack := make([]modalKind, 0, len(m.modals))
+	for _, kind := range m.modals {
+		if m.isModalOpen(kind) {
+			stack = append(stack, kind)
+		}
+	}
+	for _, kind := range modalKinds {
+		if m.isModalOpen(kind) && !slices.Contains(stack, kind) {
+			stack = append(stack, kind)
+		}
+	}
+	return stack
+}
+
+// topModal is the window that gets keys and is drawn, if any is open.
+func (m Model) topModal() (modalKind, bool) {
+	stack := m.modalStack()
+	if len(stack) == 0 {
+		return 0, false
+	}
+	return stack[len(stack)-1], true
+}
+
+// modalOpen reports whether a window other than the action confirmation is
+// up, which keeps queued actions from popping over it.
+func (m Model) modalOpen() bool {
+	for _, kind := range m.modalStack() {
+		if kind != modalActions {
+			return true
+		}
+	}
+	return false
+}
+
+// handleModalKey hands a key to the topmost window.
+func (m Model) handleModalKey(kind modalKind, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch kind {
+	case modalHelp:
+		switch msg.String() {
+		case "q", "esc", "enter", "?":
+			m.helpOpen = false
+		default:
+			var cmd tea.Cmd
+			m.helpViewport, cmd = m.helpViewport.Update(msg)
+			return m, cmd
+		}
+	case modalActions:
+		switch msg.String() {
+		case "y":
+			return m, m.confirmAction()
+		case "n":
+			m.rejectAction()
+		case "esc":
+			m.actions.open = false
+		}
+	case modalConfig:
+		return m.handleConfigKey(msg)
+	case modalSuggest:
+		return m.handleSuggestKey(msg)
+	case modalNoise:
+		return m.handleNoiseKey(msg)
+	case modalRules:
+		return m.handleRulePickerKey(msg)
+	case modalEntity:
+		switch msg.String() {
+		case "enter", "esc", "q":
+			m.closeEntityTimeline()
+		case "e":
+			m.cycleEntity()
+		default:
+			var cmd tea.Cmd
+			m.entityViewport, cmd = m.entityViewport.Update(msg)
+			return m, cmd
+		}
+	case modalDetail:
+		switch msg.String() {
+		case "enter", "esc", "q":
+			m.closeDetail()
+		case "y", "c":
+			m.copyDetailToClipboard(copyPlain)
+		case "J":
+			m.copyDetailToClipboard(copyJSON)
+		case "M":
+			m.copyDetailToClipboard(copyMarkdown)
+		case "o":
+			m.loadDiskContext()
+		case "E":
+			m.openEntityTimeline()
+		case "]":
+			m.turnDetailPage(1)
+		case "[":
+			m.turnDetailPage(-1)
+		default:
+			var cmd tea.Cmd
+			m.detailViewport, cmd = m.detailViewport.Update(msg)
+			return m, cmd
+		}
+	}
+	return m, nil
+}
+
+func (m Model) renderModal(kind modalKind) string {
+	switch kind {
+	case modalDetail:
+		return m.renderDetailModal()
+	case modalEntity:
+		return m.renderEntityModal()
+	case modalRules:
+		return m.renderRulePickerModal()
+	case modalNoise:
+		return m.renderNoiseModal()
+	case modalSuggest:
+		return m.renderSuggestModal()
+	case modalConfig:
+		return m.renderConfigModal()
+	case modalActions:
+		return m.renderActionModal()
+	default:
+		return m.renderHelpModal()
+	}
+}
+
+// resizeModals refits every open window, not just the visible one, so the
+// ones underneath are right when the top one closes.
+func (m *Model) resizeModals() {
+	m.updateDetailViewportSize()
+	m.updateEntityViewportSize()
+	m.updateHelpViewportSize()
+}
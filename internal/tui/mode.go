@@ -0,0 +1,113 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"watcher/internal/settings"
+)
+
+// Interaction modes. Monitor is for passive watching: the view follows the
+// stream and only keys that cannot change what is shown are accepted. Triage
+// enables selection, acknowledgment and filtering.
+const (
+	ModeTriage  = "triage"
+	ModeMonitor = "monitor"
+)
+
+// ParseMode validates a --mode value; empty means triage.
+func ParseMode(value string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", ModeTriage:
+		return ModeTriage, nil
+	case ModeMonitor:
+		return ModeMonitor, nil
+	default:
+		return "", fmt.Errorf("unknown mode %q (want triage or monitor)", value)
+	}
+}
+
+// monitorKeys are the only keys monitor mode accepts besides the mode toggle.
+var monitorKeys = map[string]bool{
+	"ctrl+c": true,
+	"q":      true,
+	"?":      true,
+	"p":      true,
+	"t":      true,
+	"g":      true,
+	"M":      true,
+	"z":      true,
+}
+
+func (m Model) monitoring() bool {
+	return m.mode == ModeMonitor
+}
+
+// allowedInMode reports whether key may run in the current mode and, if not,
+// tells the operator how to get to triage.
+func (m *Model) allowedInMode(key string) bool {
+	if !m.monitoring() || monitorKeys[key] {
+		return true
+	}
+	m.notification = fmt.Sprintf("%s is disabled in monitor mode · tab for triage", key)
+	m.notificationT = m.now()
+	return false
+}
+
+func (m *Model) toggleMode() {
+	next := ModeMonitor
+	if m.monitoring() {
+		next = ModeTriage
+	}
+	m.setMode(next)
+	m.audit("mode", "mode", next)
+	m.notification = fmt.Sprintf("%s mode", next)
+	if m.cfg.ProfilePath != "" {
+		if err := settings.UpdateProfile(m.cfg.ProfilePath, m.cfg.Profile, func(p *settings.Profile) { p.Mode = next }); err != nil {
+			m.notification = fmt.Sprintf("%s mode (not saved: %v)", next, err)
+		}
+	}
+	m.notificationT = m.now()
+}
+
+// setMode switches modes; entering monitor drops the selection and resumes
+// following so the view always shows the newest lines.
+func (m *Model) setMode(mode string) {
+	m.mode = mode
+	if !m.monitoring() {
+		return
+	}
+	m.selectedIndex = -1
+	m.follow = true
+	m.viewport.SetContent(m.renderLogContent())
+	m.viewport.GotoBottom()
+}
+
+// statusKeys lists the keys for the current mode at the three status bar widths.
+func (m Model) statusKeys(totalWidth int) string {
+	if n := len(m.actions.queue); n > 0 && !m.actions.open {
+		return fmt.Sprintf("%d action(s) waiting · ! review  ·  ", n) + m.modeKeys(totalWidth)
+	}
+	return m.modeKeys(totalWidth)
+}
+
+func (m Model) modeKeys(totalWidth int) string {
+	if m.monitoring() {
+		switch {
+		case totalWidth < 80:
+			return "MONITOR  ·  tab triage  ·  ? help  ·  p/t/g/M/z/q"
+		case totalWidth < 120:
+			return "MONITOR  ·  tab triage  ·  ? help  ·  p pause  ·  t/g/M/z/q"
+		default:
+			return "MONITOR  ·  tab triage  ·  ? help  ·  p pause  ·  t theme  ·  g chart  ·  M minimap  ·  z compact  ·  q quit"
+		}
+	}
+	switch {
+	case totalWidth < 80:
+		return "? help  ·  tab  ·  N/P  ·  1-5  ·  h/x/W/w/r/E/n/m/a/d/i/G  ·  p/I/f/t/g/M/z/q"
+	case totalWidth < 120:
+		return "? help  ·  tab monitor  ·  N/P severe  ·  1-5 min  ·  h hide  ·  x/W filter  ·  w watch  ·  r/alt+N reset  ·  E entity  ·  n noise  ·  C rules  ·  m pin  ·  a/A ack  ·  d delta  ·  i/S suggest  ·  G group  ·  : cmd  ·  p/I/f/t/g/M/z/q"
+	default:
+		return "? help  ·  tab monitor  ·  N/P severe  ·  1-5 min  ·  h hide  ·  x/W filter  ·  w watch  ·  r/alt+N reset  ·  E entity  ·  n noise  ·  C rules  ·  m pin  ·  a/A ack  ·  d delta  ·  i/S suggest  ·  G group  ·  : command  ·  p pause  ·  I ingest  ·  f follow  ·  t theme  ·  g chart  ·  M minimap  ·  # gutter  ·  z compact  ·  q quit"
+	}
+}
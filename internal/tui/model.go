@@ -2,9 +2,8 @@ package tui
 
 import (
 	"fmt"
-	"io"
-	"os/exec"
-	goruntime "runtime"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 	"unicode"
@@ -13,11 +12,16 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
+	"watcher/internal/audit"
+	"watcher/internal/clock"
 	"watcher/internal/config"
 	"watcher/internal/highlight"
+	"watcher/internal/notify"
 	"watcher/internal/pipeline"
 	"watcher/internal/rules"
 	"watcher/internal/runtime"
+	"watcher/internal/stats"
+	"watcher/internal/watch"
 )
 
 // ModelConfig wires the data stream into the UI.
@@ -28,19 +32,99 @@ type ModelConfig struct {
 	Files       []string
 	ShowAll     bool
 	MinSeverity rules.Severity
-	Controller  *runtime.Controller
-	Presets     []config.LogPreset
-	RuleGroups  []runtime.RuleGroup
+	// PinPipelines are the named pipelines (--pipelines) whose events fill
+	// the pinned strip instead of the stream.
+	PinPipelines []string
+	Controller   *runtime.Controller
+	Presets      []config.LogPreset
+	RuleGroups   []runtime.RuleGroup
+	ConfigPath   string
+	// RuleSwap, when set, is shared with the pipeline so `C` can switch rule
+	// files without a restart; RuleDirs are searched for *.rules.yaml.
+	RuleSwap *rules.Swap
+	RuleDirs []string
+	// Ingestion, when set, is the gate shared with the sources so `I` can stop
+	// reading entirely instead of only freezing the viewport.
+	Ingestion *watch.Gate
+	// Progress reports per-source read positions for the lag indicator.
+	Progress *watch.Progress
+	// Baseline seeds delta mode; BaselineLearn extends it from live traffic and
+	// BaselinePath receives the learned result once the period ends.
+	Baseline      *stats.Baseline
+	BaselinePath  string
+	BaselineLearn time.Duration
+	DeltaMode     bool
+	// Audit, when set, receives every operator action (--audit).
+	Audit *audit.Log
+	// Notifier hears acknowledgements so it can cancel pending escalations.
+	Notifier *notify.Dispatcher
+	// SinkFailures summarizes the forwarding sinks' undelivered events for
+	// the header ("clickhouse 12"); nil or empty shows nothing.
+	SinkFailures func() string
+	// UIDropped counts events the dashboard's own subscription lost because
+	// it fell behind the stream; shown in the header when nonzero.
+	UIDropped func() uint64
+	// Notice is shown as the first notification (e.g. an available update).
+	Notice string
+	// RetainCap bounds how many unacknowledged critical/high events are kept
+	// past Scrollback; zero lets trimming evict them like any other line.
+	RetainCap int
+	// FreshFor marks lines newer than this with a fading badge; zero disables it.
+	FreshFor time.Duration
+	// QuietGap inserts a "── N minutes pass ──" marker between consecutive
+	// lines at least this far apart; zero disables it.
+	QuietGap time.Duration
+	// AgeAfter dims lines older than this, and drops their match emphasis
+	// past four times that; zero disables it.
+	AgeAfter time.Duration
+	// PauseRate pauses rendering (not ingestion) once more than this many
+	// lines per second have arrived for PauseAfter; zero disables it.
+	PauseRate  int
+	PauseAfter time.Duration
+	// CompactWidth switches to the compact layout below this many columns;
+	// zero leaves it to the `z` key.
+	CompactWidth int
+	// Precision is how many fractional-second digits line timestamps show
+	// (--time-precision); compact layout keeps hours and minutes.
+	Precision pipeline.Precision
+	// TagStyles add styling to lines by tag (rule file `tag_styles`).
+	TagStyles map[string]rules.TagStyle
+	// Entities are the capture names `E` follows across lines (rule file
+	// `entities`).
+	Entities []string
+	// Actions are the rules' exec commands by rule name; they run after
+	// confirmation unless auto-approved.
+	Actions map[string]rules.Action
+	// NoiseWindow is how far back the noise report (`n`) counts lines; zero
+	// means ten minutes.
+	NoiseWindow time.Duration
+	// RateAlerts fire synthetic events when a severity count jumps between windows.
+	RateAlerts []rules.RateAlert
+	// Mode is the starting interaction mode (ModeTriage or ModeMonitor); a
+	// toggle is saved back to Profile in ProfilePath when that is set.
+	Mode        string
+	Profile     string
+	ProfilePath string
+	// PulseTags is the profile's tag pulse size (see settings.Profile).
+	PulseTags int
+	// SessionPath is the --session checkpoint: restored at startup when it
+	// exists, and where a bare `:save-session` writes.
+	SessionPath string
+	// Clock drives ages, windows, and notification timeouts; nil is the wall
+	// clock. Tests pass a testkit.Clock and send tickMsg themselves.
+	Clock clock.Clock
 }
 
 // Model renders a colorful monitoring dashboard.
 type Model struct {
 	cfg            ModelConfig
+	clock          clock.Clock
 	viewport       viewport.Model
 	theme          Theme
 	events         <-chan pipeline.HighlightedEvent
 	lines          []displayLine
 	scrollback     int
+	retainCap      int
 	paused         bool
 	follow         bool
 	shimmer        bool
@@ -57,26 +141,98 @@ type Model struct {
 	detailViewport viewport.Model
 	detailContent  string
 	detailLine     displayLine
+	detailDisk     string
+	// detailRows holds the rows of an alert too big to wrap at once (nil
+	// otherwise); only detailPage of them is wrapped into the viewport.
+	detailRows     []string
+	detailPage     int
 	helpOpen       bool
+	modals         []modalKind
 	helpViewport   viewport.Model
+	entity         entityState
+	actions        actionState
+	noise          noiseState
+	rulePicker     rulePickerState
+	entityViewport viewport.Model
 	config         configState
 	windowWidth    int
 	windowHeight   int
 	showHeader     bool
 	showStatus     bool
 	filteredRules  map[string]bool
+	throttledRules map[string]bool
+	downgrades     map[string]int
 	hiddenIndices  map[int]bool
+	captureStats   *stats.Collector
+	tagCounts      map[string]*tagCount
+	pulseTags      int
+	chartCapture   string
+	pinned         []displayLine
+	livePins       []displayLine
+	paneHeight     int
+	nextSeq        uint64
+	baseline       *stats.Baseline
+	learnUntil     time.Time
+	deltaMode      bool
+	suggestions    []rules.RuleDefinition
+	suggestOpen    bool
+	suggestIndex   int
+	groupBy        string
+	groupCapture   string
+	groupExpanded  map[string]bool
+	sourceStatus   map[string]watch.SourceStatus
+	showMinimap    bool
+	gutter         gutterMode
+	mode           string
+	rateTrackers   []rateTracker
+	compactForced  *bool
+	lastArrival    time.Time
+	newBelow       int
+	viewSeverity   rules.Severity
+	persistOffer   string
+	agedAt         time.Time
+	command        commandState
+	watch          watchState
+	flood          floodState
+	// pendingSelect is a restored session's selected line, selected once it
+	// is buffered.
+	pendingSelect *sessionLine
 }
 
 type displayLine struct {
 	Severity  rules.Severity
 	RuleName  string
 	Path      string
+	Sources   []string
+	Offset    int64
+	LineNo    int64
 	Timestamp time.Time
 	Fragments []highlight.Fragment
 	Tags      []string
+	Captures  map[string]string
+	Chain     []string
+	Derived   string
 	Text      string
 	Index     int
+	// Seq numbers the dashboard's lines in the order they were added,
+	// markers and restored pins included; it is not the pipeline's Seq.
+	Seq       uint64
+	Pipeline  string
+	ClusterID int
+	Template  string
+	Gap       time.Duration
+	Conn      *watch.ConnEvent
+	GroupKey  string
+	GroupSize int
+	Acked     bool
+	Arrived   time.Time
+	Quiet     time.Duration
+}
+
+// isMarker reports whether the line marks an ingestion event (paused, source
+// down or back) rather than holding a log line.
+func (l displayLine) isMarker() bool {
+	return l.Gap > 0 || l.Conn != nil
 }
 
 type logMsg pipeline.HighlightedEvent
@@ -84,6 +240,8 @@ type tickMsg time.Time
 type streamClosedMsg struct{}
 
 const (
+	maxPinned        = 3
+	maxLivePins      = 3
 	modalPaddingX    = 2
 	modalPaddingY    = 1
 	modalChromeLines = 2
@@ -98,14 +256,29 @@ func NewModel(cfg ModelConfig) Model {
 	theme := themeByName(cfg.ThemeName)
 	vp := viewport.New(80, 24)
 	vp.SetContent("booting logstream…")
+	baseline := cfg.Baseline
+	if baseline == nil {
+		baseline = stats.NewBaseline()
+	}
+	clk := clock.Or(cfg.Clock)
+	var learnUntil time.Time
+	if cfg.BaselineLearn > 0 {
+		learnUntil = clk.Now().Add(cfg.BaselineLearn)
+	}
+	mode, err := ParseMode(cfg.Mode)
+	if err != nil {
+		mode = ModeTriage
+	}
 	detailVP := viewport.New(60, 20)
 	helpVP := viewport.New(60, 20)
-	return Model{
+	m := Model{
 		cfg:            cfg,
+		clock:          clk,
 		viewport:       vp,
 		theme:          theme,
 		events:         cfg.Events,
 		scrollback:     scrollback,
+		retainCap:      cfg.RetainCap,
 		follow:         true,
 		sidebarWidth:   30,
 		activeFiles:    append([]string{}, cfg.Files...),
@@ -114,14 +287,95 @@ func NewModel(cfg ModelConfig) Model {
 		selectedIndex:  -1,
 		detailViewport: detailVP,
 		helpViewport:   helpVP,
+		entityViewport: viewport.New(60, 20),
 		config:         newConfigState(),
 		windowWidth:    80,
 		windowHeight:   24,
 		showHeader:     true,
 		showStatus:     true,
 		filteredRules:  make(map[string]bool),
+		throttledRules: make(map[string]bool),
+		downgrades:     make(map[string]int),
 		hiddenIndices:  make(map[int]bool),
+		captureStats:   stats.NewCollector(120),
+		tagCounts:      make(map[string]*tagCount),
+		pulseTags:      cfg.PulseTags,
+		baseline:       baseline,
+		learnUntil:     learnUntil,
+		actions:        actionState{since: clk.Now(), runs: make(map[string]*actionWindow)},
+		deltaMode:      cfg.DeltaMode,
+		groupExpanded:  make(map[string]bool),
+		notification:   cfg.Notice,
+		notificationT:  clk.Now(),
+		mode:           mode,
+		rateTrackers:   newRateTrackers(cfg.RateAlerts),
+	}
+	m.restoreSession()
+	return m
+}
+
+// resize lays out the pane, sidebar, header and status for the window size.
+// Compact layout drops the sidebar and folds header and status into one line.
+func (m *Model) resize(width, height int) {
+	m.windowWidth = width
+	m.windowHeight = height
+
+	if width < 10 {
+		width = 80
+	}
+	if height < 5 {
+		height = 24
+	}
+
+	if m.windowWidth < m.sidebarWidth+20 {
+		m.sidebarWidth = clamp(m.windowWidth/3, 18, 40)
+	}
+	paneFrameW, paneFrameH := m.theme.Pane.GetFrameSize()
+	sidebarFrameW, _ := m.theme.Sidebar.GetFrameSize()
+	sidebarTotal := m.sidebarWidth + sidebarFrameW
+	if m.compact() {
+		sidebarTotal = 0
+	}
+	totalWidth := width - sidebarTotal
+	if totalWidth < paneFrameW+1 {
+		totalWidth = paneFrameW + 1
+	}
+	contentWidth := totalWidth - paneFrameW
+	if contentWidth < 1 {
+		contentWidth = 1
+	}
+	m.applyPaneWidth(contentWidth)
+
+	m.showHeader = !m.compact()
+	m.showStatus = true
+	headerHeight := 0
+	if m.showHeader {
+		headerHeight = lipgloss.Height(m.renderHeader())
+	}
+	statusHeight := lipgloss.Height(m.renderStatus())
+	minBody := 3
+	availableHeight := height
+	if headerHeight+statusHeight+minBody > availableHeight {
+		m.showHeader = false
+		headerHeight = 0
+		if statusHeight+minBody > availableHeight {
+			m.showStatus = false
+			statusHeight = 0
+		}
+	}
+	totalHeight := availableHeight - headerHeight - statusHeight
+	if totalHeight < minBody {
+		totalHeight = minBody
 	}
+	contentHeight := totalHeight - paneFrameH
+	if contentHeight < 1 {
+		contentHeight = 1
+	}
+	m.paneHeight = contentHeight
+	m.applyPaneHeight()
+	m.viewport.SetContent(m.renderLogContent())
+	m.ensureSelectionVisible()
+	m.resizeModals()
 }
 
 func (m Model) Init() tea.Cmd {
@@ -141,6 +395,12 @@ func (m Model) listen() tea.Cmd {
 	}
 }
 
+// now is the model's clock reading; every age, window, and timeout in the
+// dashboard is measured with it.
+func (m Model) now() time.Time {
+	return m.clock.Now()
+}
+
 func pulse() tea.Cmd {
 	return tea.Tick(750*time.Millisecond, func(t time.Time) tea.Msg {
 		return tickMsg(t)
@@ -150,89 +410,24 @@ func pulse() tea.Cmd {
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		m.windowWidth = msg.Width
-		m.windowHeight = msg.Height
-
-		if msg.Width < 10 {
-			msg.Width = 80
-		}
-		if msg.Height < 5 {
-			msg.Height = 24
-		}
-
-		if m.windowWidth < m.sidebarWidth+20 {
-			m.sidebarWidth = clamp(m.windowWidth/3, 18, 40)
-		}
-		paneFrameW, paneFrameH := m.theme.Pane.GetFrameSize()
-		sidebarFrameW, _ := m.theme.Sidebar.GetFrameSize()
-		sidebarTotal := m.sidebarWidth + sidebarFrameW
-		totalWidth := msg.Width - sidebarTotal
-		if totalWidth < paneFrameW+1 {
-			totalWidth = paneFrameW + 1
-		}
-		contentWidth := totalWidth - paneFrameW
-		if contentWidth < 1 {
-			contentWidth = 1
-		}
-		m.viewport.Width = contentWidth
-
-		m.showHeader = true
-		m.showStatus = true
-		headerHeight := lipgloss.Height(m.renderHeader())
-		statusHeight := lipgloss.Height(m.renderStatus())
-		minBody := 3
-		availableHeight := msg.Height
-		if headerHeight+statusHeight+minBody > availableHeight {
-			m.showHeader = false
-			headerHeight = 0
-			if statusHeight+minBody > availableHeight {
-				m.showStatus = false
-				statusHeight = 0
-			}
-		}
-		totalHeight := availableHeight - headerHeight - statusHeight
-		if totalHeight < minBody {
-			totalHeight = minBody
-		}
-		contentHeight := totalHeight - paneFrameH
-		if contentHeight < 1 {
-			contentHeight = 1
-		}
-		m.viewport.Height = contentHeight
-		m.viewport.SetContent(m.renderLogContent())
-		m.ensureSelectionVisible()
-		if m.detailOpen {
-			m.updateDetailViewportSize()
-		}
-		if m.helpOpen {
-			m.updateHelpViewportSize()
-		}
+		m.resize(msg.Width, msg.Height)
 	case tea.KeyMsg:
-		if m.config.open {
-			return m.handleConfigKey(msg)
+		if m.command.open {
+			return m.handleCommandKey(msg)
 		}
-		if m.helpOpen {
-			switch msg.String() {
-			case "q", "esc", "enter", "?":
-				m.helpOpen = false
+		if top, ok := m.topModal(); ok {
+			// ? over a window lists that window's keys; closing help returns to it.
+			if msg.String() == "?" && top != modalHelp {
+				m.openHelp()
 				return m, nil
-			default:
-				var cmd tea.Cmd
-				m.helpViewport, cmd = m.helpViewport.Update(msg)
-				return m, cmd
 			}
+			return m.handleModalKey(top, msg)
 		}
-		if m.detailOpen {
-			switch msg.String() {
-			case "enter", "esc", "q":
-				m.closeDetail()
-			case "y", "c":
-				m.copyDetailToClipboard()
-			default:
-				var cmd tea.Cmd
-				m.detailViewport, cmd = m.detailViewport.Update(msg)
-				return m, cmd
-			}
+		if msg.String() == "tab" {
+			m.toggleMode()
+			return m, nil
+		}
+		if !m.allowedInMode(msg.String()) {
 			return m, nil
 		}
 		switch msg.String() {
@@ -241,24 +436,46 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "?":
 			m.openHelp()
 			return m, nil
+		case ":":
+			m.openCommand()
+			return m, nil
+		case "w":
+			m.openWatchBar()
+			return m, nil
 		case "up":
 			m.moveSelection(-1)
 		case "down":
 			m.moveSelection(1)
+			m.resumeFollowAtBottom()
 		case "pgup", "pageup":
 			m.pageSelection(-1)
 		case "pgdown", "pagedown":
 			m.pageSelection(1)
+			m.resumeFollowAtBottom()
+		case "end":
+			m.snapToBottom()
 		case "enter":
 			m.openDetail()
 		case "h":
 			m.hideCurrentLine()
 		case "x":
 			m.filterCurrentRule()
+		case "W":
+			m.persistRuleFilter()
+		case "E":
+			m.openEntityTimeline()
+		case "n":
+			m.openNoiseReport()
+		case "C":
+			m.openRulePicker()
+		case "!":
+			m.openActions()
 		case "r":
 			m.resetFilters()
 		case "p":
 			m.paused = !m.paused
+			m.audit("view_pause", "paused", fmt.Sprint(m.paused))
+			m.endAutoPause()
 			if !m.paused {
 				m.viewport.SetContent(m.renderLogContent())
 				if m.follow {
@@ -267,10 +484,52 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case "f":
 			m.follow = !m.follow
+			m.newBelow = 0
 		case "t":
 			m.theme = themeByName(nextTheme(m.theme.Name))
 		case "c":
 			m.openConfig()
+		case "g":
+			m.cycleChartCapture()
+		case "m":
+			m.togglePinCurrentLine()
+		case "d":
+			m.toggleDeltaMode()
+		case "i":
+			m.markInteresting()
+		case "S":
+			m.openSuggestions()
+		case "I":
+			m.toggleIngestion()
+		case "G":
+			m.cycleGrouping()
+		case "a":
+			m.acknowledgeCurrentLine()
+		case "A":
+			m.acknowledgeAll()
+		case "M":
+			m.toggleMinimap()
+		case "#":
+			m.cycleGutter()
+		case "z":
+			m.toggleCompact()
+		case "N":
+			m.jumpSevere(1)
+		case "P":
+			m.jumpSevere(-1)
+		case "1", "2", "3", "4", "5":
+			m.setViewSeverity(msg.String())
+		case "alt+1", "alt+2", "alt+3", "alt+4", "alt+5", "alt+6", "alt+7", "alt+8", "alt+9":
+			m.removeChip(msg.String())
+		}
+	case tea.MouseMsg:
+		if !m.monitoring() && m.handleMinimapClick(msg) {
+			return m, nil
+		}
+		// Scrolling back with the wheel stops following, as the keys do, so
+		// the next line does not snap the pane back to the bottom.
+		if msg.Button == tea.MouseButtonWheelUp && msg.Action == tea.MouseActionPress && !m.monitoring() {
+			m.follow = false
 		}
 	case logMsg:
 		return m.consumeLog(msg)
@@ -279,12 +538,22 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if len(eyeFrames) > 0 {
 			m.eyeFrame = (m.eyeFrame + 1) % len(eyeFrames)
 		}
-		if time.Since(m.notificationT) > 5*time.Second {
+		if m.now().Sub(m.notificationT) > 5*time.Second {
 			m.notification = ""
 		}
+		if m.learningBaseline() && m.now().After(m.learnUntil) {
+			m.finishBaseline()
+		}
+		if m.cfg.Progress != nil {
+			m.sourceStatus = m.cfg.Progress.Snapshot()
+		}
+		m.refreshFresh()
+		m.refreshAging()
 		return m, pulse()
 	case streamClosedMsg:
 		m.notification = "stream closed"
+	case actionResultMsg:
+		m.handleActionResult(msg)
 	case configResultMsg:
 		m.config.applying = false
 		if msg.err != nil {
@@ -295,8 +564,9 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.config.open = false
 		m.activeFiles = append([]string{}, msg.files...)
 		m.activeTags = append([]string{}, msg.tags...)
+		m.audit("config_apply", "files", strings.Join(msg.files, ","), "rule_groups", strings.Join(msg.tags, ","))
 		m.notification = fmt.Sprintf("watching %d files", len(msg.files))
-		m.notificationT = time.Now()
+		m.notificationT = m.now()
 	}
 
 	var cmd tea.Cmd
@@ -309,53 +579,81 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m Model) consumeLog(evt logMsg) (tea.Model, tea.Cmd) {
 	if evt.Err != nil {
 		m.notification = evt.Err.Error()
-		m.notificationT = time.Now()
+		m.notificationT = m.now()
 		return m, m.listen()
 	}
 
+	if evt.Gap > 0 || evt.Conn != nil {
+		return m.consumeGap(evt)
+	}
+
 	dl := displayLine{
-		Severity:  evt.Severity,
+		Severity:  m.downgraded(evt.RuleName, evt.Severity),
 		RuleName:  evt.RuleName,
 		Path:      evt.Path,
+		Sources:   evt.Sources,
+		Offset:    evt.Offset,
+		LineNo:    evt.LineNo,
 		Timestamp: evt.Timestamp,
 		Fragments: evt.Fragments,
 		Tags:      append([]string{}, evt.Tags...),
+		Captures:  evt.Captures,
+		Chain:     evt.Chain,
+		Derived:   evt.Derived,
 		Text:      evt.Line,
 		Index:     len(m.lines),
+		Seq:       m.nextSeq,
+		Pipeline:  evt.Pipeline,
+		ClusterID: evt.ClusterID,
+		Template:  evt.Template,
+		Arrived:   m.now(),
+	}
+	m.nextSeq++
+	m.overlayWatches(&dl)
+	if evt.Pipeline != "" && slices.Contains(m.cfg.PinPipelines, evt.Pipeline) {
+		m.pinLive(dl)
+		return m, m.listen()
+	}
+	m.lastArrival = dl.Arrived
+	if !m.follow {
+		m.newBelow++
 	}
 	m.lines = append(m.lines, dl)
-	if len(m.lines) > m.scrollback {
-		trim := len(m.lines) - m.scrollback
-		m.lines = m.lines[trim:]
-		newHidden := make(map[int]bool)
-		for idx := range m.hiddenIndices {
-			if idx >= trim {
-				newHidden[idx-trim] = true
-			}
-		}
-		m.hiddenIndices = newHidden
-		for i := range m.lines {
-			m.lines[i].Index = i
-		}
-		if m.selectedIndex >= 0 {
-			m.selectedIndex -= trim
-			if m.selectedIndex < 0 {
-				m.selectedIndex = 0
-			}
+	m.captureStats.Observe(evt.Timestamp, evt.Captures)
+	m.captureStats.ObserveRule(evt.RuleName)
+	m.observeTags(dl.Tags)
+	if m.learningBaseline() {
+		m.baseline.Learn(evt.RuleName, evt.Captures)
+	}
+	if m.chartCapture == "" {
+		if names := m.captureStats.Names(); len(names) > 0 {
+			m.chartCapture = names[0]
 		}
 	}
+	m.trimScrollback()
 	visibleLines := m.getVisibleLines()
 	if len(visibleLines) == 0 {
 		m.selectedIndex = -1
 	} else if m.follow || m.selectedIndex == -1 {
 		m.selectedIndex = len(visibleLines) - 1
 	}
-	m.counts[evt.Severity]++
-	if evt.RuleName != "" {
-		m.lastRule = evt.RuleName
-		m.notification = fmt.Sprintf("%s · %s", evt.Severity, evt.RuleName)
-		m.notificationT = time.Now()
+	m.selectPending()
+	if m.entity.open && m.mentionsEntity(dl) {
+		m.refreshEntityContent()
+	}
+	m.counts[dl.Severity]++
+	if dl.RuleName != "" {
+		m.lastRule = dl.RuleName
+		m.notification = fmt.Sprintf("%s · %s", dl.Severity, dl.RuleName)
+		m.notificationT = m.now()
+		m.observeRates(dl.Severity, evt.Timestamp)
 	}
+	var action tea.Cmd
+	if !evt.Backlog && !evt.Timestamp.Before(m.actions.since) {
+		action = m.queueAction(evt.RuleName, evt.Path, evt.Line, evt.Captures)
+	}
+	bell := m.watchBell(dl.RuleName)
+	m.observeFlood(dl.Arrived)
 	if !m.paused {
 		m.viewport.SetContent(m.renderLogContent())
 		if m.follow {
@@ -364,9 +662,66 @@ func (m Model) consumeLog(evt logMsg) (tea.Model, tea.Cmd) {
 			m.ensureSelectionVisible()
 		}
 	}
+	return m, tea.Batch(m.listen(), action, bell)
+}
+
+// consumeGap appends the marker sources emit when ingestion resumes after a
+// pause, or when a source goes down, comes back, or is given up.
+func (m Model) consumeGap(evt logMsg) (tea.Model, tea.Cmd) {
+	m.lines = append(m.lines, displayLine{
+		Severity:  rules.SeverityNormal,
+		Path:      evt.Path,
+		Timestamp: evt.Timestamp,
+		Index:     len(m.lines),
+		Seq:       m.nextSeq,
+		Gap:       evt.Gap,
+		Conn:      evt.Conn,
+	})
+	m.nextSeq++
+	if !m.paused {
+		m.viewport.SetContent(m.renderLogContent())
+		if m.follow {
+			m.viewport.GotoBottom()
+		}
+	}
 	return m, m.listen()
 }
 
+func (m *Model) toggleIngestion() {
+	gate := m.cfg.Ingestion
+	if gate == nil {
+		m.notification = "Ingestion control unavailable"
+		m.notificationT = m.now()
+		return
+	}
+	if paused, since := gate.Paused(); paused {
+		gate.Resume()
+		m.audit("ingest_resume", "paused_for", m.now().Sub(since).Round(time.Second).String())
+		m.notification = fmt.Sprintf("Ingestion resumed after %s · catching up", m.now().Sub(since).Round(time.Second))
+	} else {
+		gate.Pause()
+		m.audit("ingest_pause")
+		m.notification = "Ingestion paused · sources stopped reading"
+	}
+	m.notificationT = m.now()
+}
+
+// audit records an operator action when --audit is enabled; a failed write is surfaced as the notification.
+func (m *Model) audit(action string, kv ...string) {
+	if err := m.cfg.Audit.Record(action, kv...); err != nil {
+		m.notification = err.Error()
+		m.notificationT = m.now()
+	}
+}
+
+func (m Model) ingestionPaused() bool {
+	if m.cfg.Ingestion == nil {
+		return false
+	}
+	paused, _ := m.cfg.Ingestion.Paused()
+	return paused
+}
+
 func (m *Model) moveSelection(delta int) {
 	visibleLines := m.getVisibleLines()
 	if len(visibleLines) == 0 {
@@ -439,17 +794,20 @@ func (m *Model) refreshVisibleState() {
 		m.selectedIndex = len(visibleLines) - 1
 	}
 	m.viewport.SetContent(m.renderLogContent())
+	// The filter bar comes and goes with the filters.
+	m.applyPaneHeight()
 	m.ensureSelectionVisible()
 }
 
 func (m *Model) hideCurrentLine() {
 	line, ok := m.selectedLine()
-	if !ok {
+	if !ok || line.isGroupHeader() || line.isSeparator() {
 		return
 	}
 	m.hiddenIndices[line.Index] = true
+	m.audit("hide_line", "rule", line.RuleName, "path", line.Path, "line", line.Text)
 	m.notification = "Hidden 1 line"
-	m.notificationT = time.Now()
+	m.notificationT = m.now()
 	m.refreshVisibleState()
 }
 
@@ -458,30 +816,72 @@ func (m *Model) filterCurrentRule() {
 	if !ok || line.RuleName == "" {
 		return
 	}
-	m.filteredRules[line.RuleName] = true
+	m.filterRule(line.RuleName)
+}
+
+// filterRule hides every line of rule for the rest of the session.
+func (m *Model) filterRule(rule string) {
+	m.filteredRules[rule] = true
 	count := 0
 	for _, l := range m.lines {
-		if l.RuleName == line.RuleName {
+		if l.RuleName == rule {
 			count++
 		}
 	}
-	m.notification = fmt.Sprintf("Filtered rule: %s (%d lines)", line.RuleName, count)
-	m.notificationT = time.Now()
+	m.audit("filter_rule", "rule", rule, "lines", fmt.Sprint(count))
+	m.notification = fmt.Sprintf("Filtered rule: %s (%d lines)", rule, count)
+	if m.cfg.ConfigPath != "" {
+		m.persistOffer = rule
+		m.notification += " · W disables it in the rule file"
+	}
+	m.notificationT = m.now()
 	m.refreshVisibleState()
 }
 
+// persistRuleFilter writes the most recently filtered rule back to the rule
+// file as `enabled: false`, so the filter survives a restart.
+func (m *Model) persistRuleFilter() {
+	name := m.persistOffer
+	if name == "" {
+		m.notification = "Filter a rule with x first"
+		m.notificationT = m.now()
+		return
+	}
+	m.persistOffer = ""
+	if err := rules.SetEnabled(m.cfg.ConfigPath, name, false); err != nil {
+		m.notification = fmt.Sprintf("Disable rule: %v", err)
+		m.notificationT = m.now()
+		return
+	}
+	m.audit("rule_disabled", "rule", name, "file", m.cfg.ConfigPath)
+	m.notification = fmt.Sprintf("Disabled %q in %s", name, m.cfg.ConfigPath)
+	m.notificationT = m.now()
+}
+
 func (m *Model) resetFilters() {
 	hiddenCount := len(m.hiddenIndices)
-	ruleCount := len(m.filteredRules)
+	ruleCount := len(m.filteredRules) + len(m.throttledRules)
 	m.filteredRules = make(map[string]bool)
+	m.throttledRules = make(map[string]bool)
 	m.hiddenIndices = make(map[int]bool)
+	m.persistOffer = ""
+	m.audit("reset_filters", "lines", fmt.Sprint(hiddenCount), "rules", fmt.Sprint(ruleCount))
 	m.notification = fmt.Sprintf("Reset filters (%d lines, %d rules restored)", hiddenCount, ruleCount)
-	m.notificationT = time.Now()
+	m.notificationT = m.now()
 	m.refreshVisibleState()
 }
 
 func (m Model) getVisibleLines() []displayLine {
+	visible := m.filteredLines()
+	if m.groupBy != "" {
+		return m.groupLines(visible)
+	}
+	return m.withQuietSeparators(visible)
+}
+
+func (m Model) filteredLines() []displayLine {
 	visible := make([]displayLine, 0, len(m.lines))
+	lastShown := make(map[string]time.Time, len(m.throttledRules))
 	for _, line := range m.lines {
 		if line.RuleName != "" && m.filteredRules[line.RuleName] {
 			continue
@@ -489,21 +889,223 @@ func (m Model) getVisibleLines() []displayLine {
 		if m.hiddenIndices[line.Index] {
 			continue
 		}
+		if m.isBaselineNoise(line) {
+			continue
+		}
+		if m.belowViewSeverity(line) {
+			continue
+		}
+		if m.throttled(line, lastShown) {
+			continue
+		}
 		visible = append(visible, line)
 	}
 	return visible
 }
 
+func (m *Model) togglePinCurrentLine() {
+	line, ok := m.selectedLine()
+	if !ok || line.isGroupHeader() || line.isSeparator() {
+		return
+	}
+	for i, pin := range m.pinned {
+		if pin.Seq == line.Seq {
+			m.pinned = append(m.pinned[:i:i], m.pinned[i+1:]...)
+			m.audit("unpin_line", "rule", line.RuleName, "line", line.Text)
+			m.notification = "Unpinned line"
+			m.notificationT = m.now()
+			m.applyPaneHeight()
+			return
+		}
+	}
+	m.pinned = append(m.pinned, line)
+	if len(m.pinned) > maxPinned {
+		m.pinned = m.pinned[len(m.pinned)-maxPinned:]
+	}
+	m.audit("pin_line", "rule", line.RuleName, "line", line.Text)
+	m.notification = fmt.Sprintf("Pinned line (%d/%d)", len(m.pinned), maxPinned)
+	m.notificationT = m.now()
+	m.applyPaneHeight()
+}
+
+// pinLive shows a line from a pinned pipeline in the pinned strip, which
+// keeps the latest maxLivePins of them below, and apart from, the lines
+// pinned by hand.
+func (m *Model) pinLive(line displayLine) {
+	m.livePins = append(m.livePins, line)
+	if len(m.livePins) > maxLivePins {
+		m.livePins = m.livePins[len(m.livePins)-maxLivePins:]
+	}
+	m.applyPaneHeight()
+}
+
+// applyPaneHeight splits the pane content height between the pinned strip and the viewport.
+func (m *Model) applyPaneHeight() {
+	if m.paneHeight <= 0 {
+		return
+	}
+	height := m.paneHeight
+	if strip := m.renderPaneTop(); strip != "" {
+		height -= lipgloss.Height(strip)
+	}
+	if height < 1 {
+		height = 1
+	}
+	m.viewport.Height = height
+	m.ensureSelectionVisible()
+}
+
+func (m *Model) markInteresting() {
+	line, ok := m.selectedLine()
+	if !ok || line.isGroupHeader() || line.isSeparator() {
+		return
+	}
+	if line.RuleName != "" {
+		m.notification = fmt.Sprintf("Already matched by %s", line.RuleName)
+		m.notificationT = m.now()
+		return
+	}
+	m.suggestions = append(m.suggestions, rules.SuggestRule(line.Text))
+	m.notification = fmt.Sprintf("Rule suggestion queued (%d) · S to review", len(m.suggestions))
+	m.notificationT = m.now()
+}
+
+func (m *Model) openSuggestions() {
+	if len(m.suggestions) == 0 {
+		m.notification = "No rule suggestions (mark unmatched lines with i)"
+		m.notificationT = m.now()
+		return
+	}
+	m.suggestOpen = true
+	m.pushModal(modalSuggest)
+	m.suggestIndex = clamp(m.suggestIndex, 0, len(m.suggestions)-1)
+}
+
+func (m Model) handleSuggestKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "S":
+		m.suggestOpen = false
+	case "up", "k":
+		m.suggestIndex = clamp(m.suggestIndex-1, 0, len(m.suggestions)-1)
+	case "down", "j":
+		m.suggestIndex = clamp(m.suggestIndex+1, 0, len(m.suggestions)-1)
+	case "s":
+		def := &m.suggestions[m.suggestIndex]
+		def.Severity = nextSeverity(def.Severity)
+	case "enter", "a":
+		m.acceptSuggestion()
+	case "x", "delete", "backspace":
+		m.dropSuggestion()
+		m.notification = "Suggestion discarded"
+		m.notificationT = m.now()
+	}
+	return m, nil
+}
+
+func (m *Model) acceptSuggestion() {
+	def := m.suggestions[m.suggestIndex]
+	if m.cfg.ConfigPath == "" {
+		m.notification = "No rule file to write suggestions into"
+		m.notificationT = m.now()
+		return
+	}
+	if err := rules.AppendToFile(m.cfg.ConfigPath, def); err != nil {
+		m.notification = fmt.Sprintf("Add rule: %v", err)
+		m.notificationT = m.now()
+		return
+	}
+	m.dropSuggestion()
+	m.audit("rule_added", "rule", def.Name, "pattern", def.Pattern, "severity", string(def.Severity), "file", m.cfg.ConfigPath)
+	m.notification = fmt.Sprintf("Added %q to %s (applies on reload)", def.Name, m.cfg.ConfigPath)
+	m.notificationT = m.now()
+}
+
+func (m *Model) dropSuggestion() {
+	m.suggestions = append(m.suggestions[:m.suggestIndex:m.suggestIndex], m.suggestions[m.suggestIndex+1:]...)
+	if len(m.suggestions) == 0 {
+		m.suggestOpen = false
+		m.suggestIndex = 0
+		return
+	}
+	m.suggestIndex = clamp(m.suggestIndex, 0, len(m.suggestions)-1)
+}
+
+func (m Model) learningBaseline() bool {
+	return !m.learnUntil.IsZero()
+}
+
+func (m *Model) finishBaseline() {
+	m.learnUntil = time.Time{}
+	m.notification = fmt.Sprintf("Baseline learned (%d rules)", m.baseline.Len())
+	if m.cfg.BaselinePath != "" {
+		if err := m.baseline.Save(m.cfg.BaselinePath); err != nil {
+			m.notification = err.Error()
+		} else {
+			m.audit("baseline_saved", "file", m.cfg.BaselinePath, "rules", fmt.Sprint(m.baseline.Len()))
+		}
+	}
+	m.notificationT = m.now()
+	m.refreshVisibleState()
+}
+
+func (m *Model) toggleDeltaMode() {
+	m.deltaMode = !m.deltaMode
+	m.audit("delta_mode", "enabled", fmt.Sprint(m.deltaMode))
+	if m.deltaMode {
+		m.notification = fmt.Sprintf("Delta mode: novel activity vs baseline (%d rules)", m.baseline.Len())
+	} else {
+		m.notification = "Delta mode off"
+	}
+	m.notificationT = m.now()
+	m.refreshVisibleState()
+}
+
+// isBaselineNoise hides lines whose rule and capture values were all seen in the baseline.
+func (m Model) isBaselineNoise(line displayLine) bool {
+	if !m.deltaMode || m.learningBaseline() {
+		return false
+	}
+	if line.RuleName == "" {
+		return true
+	}
+	return !m.baseline.Novel(line.RuleName, line.Captures)
+}
+
+func (m *Model) cycleChartCapture() {
+	names := m.captureStats.Names()
+	if len(names) == 0 {
+		m.notification = "No numeric captures to chart"
+		m.notificationT = m.now()
+		return
+	}
+	next := names[0]
+	for i, name := range names {
+		if name == m.chartCapture {
+			next = names[(i+1)%len(names)]
+			break
+		}
+	}
+	m.chartCapture = next
+	m.notification = fmt.Sprintf("Charting capture: %s", next)
+	m.notificationT = m.now()
+}
+
 func (m *Model) openDetail() {
 	if m.detailOpen {
 		return
 	}
 	line, ok := m.selectedLine()
-	if !ok {
+	if !ok || line.isSeparator() {
+		return
+	}
+	if line.isGroupHeader() {
+		m.toggleGroup(line)
 		return
 	}
 	m.detailLine = line
 	m.detailOpen = true
+	m.pushModal(modalDetail)
+	m.detailPage = 0
 	m.updateDetailViewportSize()
 	m.detailViewport.GotoTop()
 	m.refreshDetailContent()
@@ -512,6 +1114,8 @@ func (m *Model) openDetail() {
 func (m *Model) closeDetail() {
 	m.detailOpen = false
 	m.detailLine = displayLine{}
+	m.detailDisk = ""
+	m.detailRows = nil
 }
 
 func (m *Model) openHelp() {
@@ -519,6 +1123,7 @@ func (m *Model) openHelp() {
 		return
 	}
 	m.helpOpen = true
+	m.pushModal(modalHelp)
 	m.updateHelpViewportSize()
 	m.helpViewport.GotoTop()
 }
@@ -533,36 +1138,64 @@ func (m *Model) refreshDetailContent() {
 	if width <= 0 {
 		width = 60
 	}
-	wrapped := wrapText(m.detailContent, width)
-	m.detailViewport.SetContent(wrapped)
+	if !needsPaging(m.detailContent) {
+		m.detailRows = nil
+		m.detailViewport.SetContent(wrapText(m.detailContent, width))
+		return
+	}
+	m.detailRows = detailRows(m.detailContent)
+	m.detailPage = clamp(m.detailPage, 0, m.detailPages()-1)
+	m.showDetailPage()
 }
 
 func (m Model) buildDetailContent(line displayLine) string {
 	var b strings.Builder
 	fmt.Fprintf(&b, "Severity: %s\n", strings.ToUpper(string(line.Severity)))
 	if line.RuleName != "" {
-		fmt.Fprintf(&b, "Rule: %s\n", line.RuleName)
+		fmt.Fprintf(&b, "Rule: %s (%d matches this session)\n", line.RuleName, m.captureStats.RuleCount(line.RuleName))
 	} else {
 		fmt.Fprintf(&b, "Rule: (unmatched)\n")
 	}
-	fmt.Fprintf(&b, "File: %s\n", line.Path)
-	fmt.Fprintf(&b, "Timestamp: %s\n", line.Timestamp.Format(time.RFC3339))
+	if len(line.Chain) > 0 {
+		fmt.Fprintf(&b, "Via: %s\n", strings.Join(line.Chain, " -> "))
+		fmt.Fprintf(&b, "Derived: %s\n", line.Derived)
+	}
+	if len(line.Sources) > 1 {
+		fmt.Fprintf(&b, "Files: %s\n", strings.Join(line.Sources, ", "))
+	} else {
+		fmt.Fprintf(&b, "File: %s\n", line.Path)
+	}
+	fmt.Fprintf(&b, "Timestamp: %s\n", line.Timestamp.Format(m.cfg.Precision.RFC3339()))
 	if len(line.Tags) > 0 {
 		fmt.Fprintf(&b, "Tags: %s\n", strings.Join(line.Tags, ", "))
 	}
+	if len(line.Captures) > 0 {
+		names := make([]string, 0, len(line.Captures))
+		for name := range line.Captures {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(&b, "Captures:\n")
+		for _, name := range names {
+			fmt.Fprintf(&b, "  %s = %s\n", name, line.Captures[name])
+		}
+	}
 	if text := strings.TrimSpace(line.Text); text != "" {
 		fmt.Fprintf(&b, "\nLog Entry:\n%s\n", line.Text)
 	}
 	if combined := strings.TrimSpace(highlight.String(line.Fragments)); combined != "" && combined != strings.TrimSpace(line.Text) {
 		fmt.Fprintf(&b, "\nHighlighted:\n%s\n", combined)
 	}
+	if m.detailDisk != "" {
+		fmt.Fprintf(&b, "\nOn disk (%s, offset %d):\n%s", line.Path, line.Offset, m.detailDisk)
+	}
 	return b.String()
 }
 
 func (m Model) modalSize() (int, int) {
 	width := m.windowWidth
 	if width <= 0 {
-		width = m.viewport.Width + m.sidebarWidth + 6
+		width = m.paneContentWidth() + m.sidebarWidth + 6
 	}
 	height := m.windowHeight
 	if height <= 0 {
@@ -591,6 +1224,14 @@ func (m Model) modalSize() (int, int) {
 	if modalHeight < 10 {
 		modalHeight = 10
 	}
+	// On a tiny terminal the window shrinks rather than push the hint strip
+	// off screen: the border adds two columns and rows, the strip a row.
+	if m.windowWidth > 2 {
+		modalWidth = min(modalWidth, m.windowWidth-2)
+	}
+	if m.windowHeight > 3 {
+		modalHeight = min(modalHeight, m.windowHeight-3)
+	}
 	return modalWidth, modalHeight
 }
 
@@ -622,131 +1263,60 @@ func (m *Model) updateHelpViewportSize() {
 		innerWidth = 40
 	}
 	innerHeight := height - (modalPaddingY * 2) - 4
-	if innerHeight < 10 {
-		innerHeight = 10
+	if innerHeight < 3 {
+		innerHeight = 3
 	}
 	m.helpViewport.Width = innerWidth
 	m.helpViewport.Height = innerHeight
-	helpText := `
-NAVIGATION
-  ↑ / ↓         Move selection up/down
-  PgUp / PgDn   Page up/down
-  
-ACTIONS
-  Enter         Open alert details
-  h             Hide current line
-  x             Filter out all logs of this rule type
-  r             Reset all filters (show everything)
-  
-DETAIL VIEW (when alert open)
-  y / c         Copy alert details to clipboard
-  ↑ / ↓         Scroll detail content
-  Enter / Esc   Close detail view
-  
-PLAYBACK
-  p             Pause/unpause log streaming
-  f             Toggle auto-follow (scroll to bottom)
-  
-APPEARANCE
-  t             Cycle themes (vapor → midnight → dusk)
-  
-OTHER
-  ?             Show this help
-  q / Ctrl+C    Quit application
-  
-TIPS
-  • Pause (p) to stop scrolling while reviewing logs
-  • Filter (x) noisy rules to focus on important events
-  • Copy (y/c) alert details to share with your team
-  • Fullscreen terminal shows severity counts in sidebar
-`
-	m.helpViewport.SetContent(strings.TrimSpace(helpText))
-}
-
-func (m *Model) copyDetailToClipboard() {
-	if !m.detailOpen {
-		m.notification = "No alert to copy"
-		m.notificationT = time.Now()
-		return
-	}
-	content := m.buildDetailContent(m.detailLine)
-	var cmd *exec.Cmd
-	if goruntime.GOOS == "darwin" {
-		cmd = exec.Command("pbcopy")
-	} else if goruntime.GOOS == "linux" {
-		if _, err := exec.LookPath("xclip"); err == nil {
-			cmd = exec.Command("xclip", "-selection", "clipboard")
-		} else if _, err := exec.LookPath("xsel"); err == nil {
-			cmd = exec.Command("xsel", "--clipboard", "--input")
-		}
-	}
-	if cmd == nil {
-		m.notification = "Clipboard not supported on this system"
-		m.notificationT = time.Now()
-		return
-	}
-	stdin, err := cmd.StdinPipe()
-	if err != nil {
-		m.notification = fmt.Sprintf("Clipboard error: %v", err)
-		m.notificationT = time.Now()
-		return
-	}
-	if err := cmd.Start(); err != nil {
-		m.notification = fmt.Sprintf("Clipboard error: %v", err)
-		m.notificationT = time.Now()
-		return
-	}
-	if _, err := io.WriteString(stdin, content); err != nil {
-		stdin.Close()
-		m.notification = fmt.Sprintf("Clipboard error: %v", err)
-		m.notificationT = time.Now()
-		return
-	}
-	stdin.Close()
-	if err := cmd.Wait(); err != nil {
-		m.notification = fmt.Sprintf("Clipboard error: %v", err)
-		m.notificationT = time.Now()
-		return
-	}
-	m.notification = "Copied alert details to clipboard"
-	m.notificationT = time.Now()
+	m.helpViewport.SetContent(m.helpText())
 }
 
 func (m Model) renderDetailModal() string {
 	width, height := m.modalSize()
 	title := m.theme.Header.Render("alert details")
-	instructions := m.theme.TagStyle.Render("y/c copy · enter/esc close · arrows scroll")
+	hint := "y/c copy · J json · M markdown · o file context · enter/esc close · arrows scroll"
+	if m.detailRows != nil {
+		hint += " · " + m.detailPageStatus()
+	}
+	instructions := m.theme.TagStyle.Render(hint)
 	body := m.detailViewport.View()
-	modalStyle := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(m.accentColor()).
-		Width(width).
-		Height(height).
-		Padding(modalPaddingY, modalPaddingX).
-		Background(lipgloss.Color("#1A0F1F")).
-		Align(lipgloss.Left)
 	content := lipgloss.JoinVertical(lipgloss.Left, title, instructions, body)
-	return modalStyle.Render(content)
+	return m.modalStyle(width, height).Render(content)
 }
 
 func (m Model) renderHelpModal() string {
 	width, height := m.modalSize()
-	title := m.theme.Header.Render("keyboard shortcuts")
+	title := m.theme.Header.Render("keyboard shortcuts · " + string(m.helpContext()))
 	instructions := lipgloss.NewStyle().
 		Foreground(m.accentColor()).
 		Italic(true).
 		Render("↑/↓ scroll · q/esc/enter/? close")
 	body := m.helpViewport.View()
-	modalStyle := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
-		BorderForeground(m.accentColor()).
-		Width(width).
-		Height(height).
-		Padding(modalPaddingY, modalPaddingX).
-		Background(lipgloss.Color("#1A0F1F")).
-		Align(lipgloss.Left)
 	content := lipgloss.JoinVertical(lipgloss.Left, title, instructions, body)
-	return modalStyle.Render(content)
+	return m.modalStyle(width, height).Render(content)
+}
+
+func (m Model) renderSuggestModal() string {
+	width, height := m.modalSize()
+	title := m.theme.Header.Render(fmt.Sprintf("rule suggestions (%d)", len(m.suggestions)))
+	instructions := m.theme.TagStyle.Render("enter accept · s severity · x discard · esc close")
+	innerWidth := width - (modalPaddingX * 2) - 2
+	if innerWidth < 20 {
+		innerWidth = 20
+	}
+	rows := make([]string, 0, len(m.suggestions)*3)
+	for i, def := range m.suggestions {
+		marker := "  "
+		if i == m.suggestIndex {
+			marker = m.theme.HighlightStyle.Copy().Bold(true).Render("➤ ")
+		}
+		sev := m.severityStyle(def.Severity).Render(strings.ToUpper(string(def.Severity)))
+		rows = append(rows, fmt.Sprintf("%s%s %s", marker, sev, def.Name))
+		rows = append(rows, lipgloss.NewStyle().Faint(true).Render(wrapText(def.Pattern, innerWidth-2)))
+	}
+	body := strings.Join(rows, "\n")
+	content := lipgloss.JoinVertical(lipgloss.Left, title, instructions, body)
+	return m.modalStyle(width, height).Render(content)
 }
 
 func (m Model) View() string {
@@ -779,9 +1349,11 @@ func (m Model) View() string {
 		availableBodyHeight = 3
 	}
 
-	paneView := m.theme.Pane.Render(m.viewport.View())
-	sidebarContent := m.renderSidebar(availableBodyHeight)
-	sidebarView := m.theme.Sidebar.Render(sidebarContent)
+	paneView := m.theme.Pane.Render(m.renderPaneBody(m.viewport.View()))
+	sidebarView := ""
+	if !m.compact() {
+		sidebarView = m.theme.Sidebar.Render(m.renderSidebar(availableBodyHeight))
+	}
 
 	paneHeight := lipgloss.Height(paneView)
 	sidebarHeight := lipgloss.Height(sidebarView)
@@ -793,6 +1365,9 @@ func (m Model) View() string {
 	if maxHeight > availableBodyHeight {
 		_, paneFrameH := m.theme.Pane.GetFrameSize()
 		desiredViewportHeight := availableBodyHeight - paneFrameH
+		if strip := m.renderPaneTop(); strip != "" {
+			desiredViewportHeight -= lipgloss.Height(strip)
+		}
 		if desiredViewportHeight < 1 {
 			desiredViewportHeight = 1
 		}
@@ -804,15 +1379,16 @@ func (m Model) View() string {
 			viewportContent = strings.Join(lines, "\n")
 		}
 
-		paneView = m.theme.Pane.Render(viewportContent)
+		paneView = m.theme.Pane.Render(m.renderPaneBody(viewportContent))
 
 		_, sidebarFrameH := m.theme.Sidebar.GetFrameSize()
 		desiredSidebarHeight := availableBodyHeight - sidebarFrameH
 		if desiredSidebarHeight < 1 {
 			desiredSidebarHeight = 1
 		}
-		sidebarContent = m.renderSidebar(desiredSidebarHeight)
-		sidebarView = m.theme.Sidebar.Render(sidebarContent)
+		if !m.compact() {
+			sidebarView = m.theme.Sidebar.Render(m.renderSidebar(desiredSidebarHeight))
+		}
 
 		paneHeight = lipgloss.Height(paneView)
 		sidebarHeight = lipgloss.Height(sidebarView)
@@ -848,23 +1424,8 @@ func (m Model) View() string {
 		result = strings.Join(lines, "\n")
 	}
 
-	if m.helpOpen {
-		modal := m.renderHelpModal()
-		return lipgloss.Place(m.windowWidth, m.windowHeight, lipgloss.Center, lipgloss.Center, modal,
-			lipgloss.WithWhitespaceChars(" "),
-			lipgloss.WithWhitespaceBackground(lipgloss.Color("#05010A")))
-	}
-	if m.config.open {
-		modal := m.renderConfigModal()
-		return lipgloss.Place(m.windowWidth, m.windowHeight, lipgloss.Center, lipgloss.Center, modal,
-			lipgloss.WithWhitespaceChars(" "),
-			lipgloss.WithWhitespaceBackground(lipgloss.Color("#05010A")))
-	}
-	if m.detailOpen {
-		modal := m.renderDetailModal()
-		return lipgloss.Place(m.windowWidth, m.windowHeight, lipgloss.Center, lipgloss.Center, modal,
-			lipgloss.WithWhitespaceChars(" "),
-			lipgloss.WithWhitespaceBackground(lipgloss.Color("#05010A")))
+	if top, ok := m.topModal(); ok {
+		return m.placeModal(m.renderModal(top))
 	}
 
 	return result
@@ -927,33 +1488,27 @@ func (m Model) renderSidebar(maxHeight int) string {
 	} else {
 		for _, file := range m.activeFiles {
 			files.WriteString("\n" + m.theme.PillStyle.Render(file))
+			if lag := m.renderSourceLag(watch.SourceName(file)); lag != "" {
+				files.WriteString("\n" + lag)
+			}
 		}
 	}
 	appendSection(files.String(), true)
+	appendSection(m.renderInspect(), false)
 
 	if wideTerminal {
-		var pulse strings.Builder
-		pulse.WriteString(m.theme.Header.Render("pulse"))
-		order := []rules.Severity{
-			rules.SeverityCritical,
-			rules.SeverityHigh,
-			rules.SeverityMedium,
-			rules.SeverityLow,
-			rules.SeverityNormal,
-		}
-		for _, sev := range order {
-			count := m.counts[sev]
-			pill := m.theme.PillStyle.Copy().Inherit(m.severityStyle(sev)).Render(fmt.Sprintf("%s %d", strings.ToUpper(string(sev)), count))
-			pulse.WriteString("\n" + pill)
-		}
-		appendSection(pulse.String(), false)
+		appendSection(m.renderPulse(), false)
+	}
+
+	if mediumTerminal {
+		appendSection(m.renderCaptureChart(), false)
 	}
 
 	lastSection := fmt.Sprintf("%s\n%s", m.theme.Header.Render("last"), m.theme.TagStyle.Render(coalesce(m.lastRule, "—")))
 	appendSection(lastSection, true)
 
 	if m.notification != "" {
-		alertStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF61D8")).Padding(0, 1)
+		alertStyle := lipgloss.NewStyle().Foreground(m.accentColor()).Padding(0, 1)
 		note := fmt.Sprintf("%s\n%s", m.theme.Header.Render("signal"), alertStyle.Render(m.notification))
 		appendSection(note, true)
 	}
@@ -969,40 +1524,126 @@ func (m Model) renderSidebar(maxHeight int) string {
 	return content
 }
 
+// renderSourceLag tells whether a source is live, idle, still backfilling, being reopened, or given up.
+func (m Model) renderSourceLag(path string) string {
+	if m.cfg.Progress == nil {
+		return ""
+	}
+	lagStyle := lipgloss.NewStyle().Faint(true).PaddingLeft(1)
+	st, ok := m.sourceStatus[path]
+	if !ok {
+		return lagStyle.Render("waiting for lines")
+	}
+	if st.GaveUp {
+		return lagStyle.Copy().Faint(false).Foreground(m.severityStyle(rules.SeverityCritical).GetForeground()).Render("gave up")
+	}
+	if st.Retries > 0 {
+		wait := st.NextRetry.Sub(m.now()).Round(time.Second)
+		if wait < 0 {
+			wait = 0
+		}
+		retryStyle := lagStyle.Copy().Faint(false).Foreground(m.severityStyle(rules.SeverityHigh).GetForeground())
+		return retryStyle.Render(fmt.Sprintf("retry #%d in %s", st.Retries, wait))
+	}
+	if behind := st.Behind(); behind > 0 {
+		return lagStyle.Render(fmt.Sprintf("backfill · %s behind", formatBytes(behind)))
+	}
+	age := m.now().Sub(st.LastLine)
+	if age < 5*time.Second {
+		return lagStyle.Render("live")
+	}
+	return lagStyle.Render(fmt.Sprintf("idle %s", age.Round(time.Second)))
+}
+
+func (m Model) renderCaptureChart() string {
+	if m.chartCapture == "" {
+		return ""
+	}
+	samples := m.captureStats.Series(m.chartCapture)
+	if len(samples) == 0 {
+		return ""
+	}
+	min, max, last := stats.Bounds(samples)
+	spark := stats.Sparkline(samples, m.sidebarContentWidth())
+	summary := fmt.Sprintf("%s %g (%g–%g)", m.chartCapture, last, min, max)
+	return fmt.Sprintf("%s\n%s\n%s", m.theme.Header.Render("chart"), m.theme.HighlightStyle.Render(spark), m.theme.TagStyle.Render(summary))
+}
+
 func (m Model) renderStatus() string {
 	if !m.showStatus {
 		return ""
 	}
-	state := "streaming"
-	if m.paused {
-		state = "paused"
+	if m.command.open && m.compact() {
+		return m.renderCommandLine(max(m.windowWidth, 10))
+	}
+	if m.compact() {
+		return m.renderCompactBar()
 	}
+	state := m.scrollState("streaming")
 	glow := "✧"
 	if m.shimmer {
 		glow = "✦"
 	}
 	paneFrameW, _ := m.theme.Pane.GetFrameSize()
 	sidebarFrameW, _ := m.theme.Sidebar.GetFrameSize()
-	totalWidth := m.viewport.Width + paneFrameW + m.sidebarWidth + sidebarFrameW
-	var content string
-	if totalWidth < 80 {
-		content = fmt.Sprintf("%s %s  ·  ? help  ·  h/x/r  ·  p/f/t/q", glow, state)
-	} else if totalWidth < 120 {
-		content = fmt.Sprintf("%s %s  ·  ? help  ·  h hide  ·  x filter  ·  r reset  ·  p/f/t/q", glow, state)
-	} else {
-		content = fmt.Sprintf("%s %s  ·  ? help  ·  h hide  ·  x filter  ·  r reset  ·  p pause  ·  f follow  ·  t theme  ·  q quit", glow, state)
-	}
+	totalWidth := m.paneContentWidth() + paneFrameW + m.sidebarWidth + sidebarFrameW
+	content := fmt.Sprintf("%s %s  ·  %s", glow, state, m.statusKeys(totalWidth))
 	if totalWidth < 10 {
 		totalWidth = 10
 	}
+	if m.command.open {
+		return m.renderCommandLine(totalWidth)
+	}
 	return m.theme.StatusBar.Width(totalWidth).Render(content)
 }
 
+func (m Model) renderPaneBody(viewportContent string) string {
+	if m.showMinimap {
+		minimap := strings.Split(m.renderMinimap(), "\n")
+		if height := lipgloss.Height(viewportContent); len(minimap) > height {
+			minimap = minimap[:height]
+		}
+		viewportContent = lipgloss.JoinHorizontal(lipgloss.Top, lipgloss.NewStyle().MaxWidth(m.viewport.Width).Render(viewportContent), strings.Join(minimap, "\n"))
+	}
+	strip := m.renderPaneTop()
+	if strip == "" {
+		return viewportContent
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, strip, viewportContent)
+}
+
+// renderPinnedStrip draws pinned events above the viewport, hand pins first
+// and then pinned pipelines' latest lines; pins hold copies so trimming never
+// drops them.
+func (m Model) renderPinnedStrip() string {
+	if len(m.pinned) == 0 && len(m.livePins) == 0 {
+		return ""
+	}
+	width := m.viewport.Width
+	if width < 1 {
+		width = 1
+	}
+	rowStyle := lipgloss.NewStyle().MaxWidth(width)
+	rows := make([]string, 0, len(m.pinned)+len(m.livePins)+1)
+	header := fmt.Sprintf("pinned %d/%d", len(m.pinned), maxPinned)
+	if len(m.livePins) > 0 {
+		header += " · live"
+	}
+	rows = append(rows, m.theme.Header.Render(header))
+	for _, line := range append(append([]displayLine(nil), m.pinned...), m.livePins...) {
+		rows = append(rows, rowStyle.Render(m.renderLine(line, false)))
+	}
+	return strings.Join(rows, "\n")
+}
+
 func (m Model) renderLogContent() string {
 	visibleLines := m.getVisibleLines()
 	if len(visibleLines) == 0 {
-		if len(m.filteredRules) > 0 || len(m.hiddenIndices) > 0 {
-			return "all lines filtered (press 'r' to reset)"
+		if len(m.filteredRules) > 0 || len(m.throttledRules) > 0 || len(m.hiddenIndices) > 0 {
+			return "all lines filtered (press 'r' to reset, alt+N to drop one filter)"
+		}
+		if m.deltaMode && !m.learningBaseline() && len(m.lines) > 0 {
+			return "no novel activity vs baseline (press 'd' to show all)"
 		}
 		return "awaiting signals…"
 	}
@@ -1014,20 +1655,77 @@ func (m Model) renderLogContent() string {
 }
 
 func (m Model) renderLine(line displayLine, selected bool) string {
-	style := m.severityStyle(line.Severity)
-	timestamp := m.theme.TagStyle.Copy().Render(line.Timestamp.Format("15:04:05"))
-	fragments := renderFragments(line.Fragments, style, m.theme.HighlightStyle)
-	meta := style.Copy().Faint(true).Render(line.Path)
+	if line.isGroupHeader() {
+		return m.renderGroupHeader(line, selected)
+	}
+	if line.isSeparator() {
+		return m.renderSeparator(line, selected)
+	}
+	if line.isMarker() {
+		marker := fmt.Sprintf("── ingestion paused %s · replaying backlog from %s ──", line.Gap.Round(time.Second), line.Path)
+		markerStyle := m.theme.TagStyle.Copy().Faint(true)
+		if line.Conn != nil {
+			marker = fmt.Sprintf("── %s · %s ──", line.Path, line.Conn)
+			if line.Conn.State != watch.ConnUp {
+				markerStyle = m.severityStyle(rules.SeverityHigh)
+			}
+		}
+		content := markerStyle.Render(marker)
+		if selected {
+			indicator := m.theme.HighlightStyle.Copy().Bold(true).Render("➤")
+			return lipgloss.JoinHorizontal(lipgloss.Top, indicator, " ", content)
+		}
+		return lipgloss.JoinHorizontal(lipgloss.Top, " ", " ", content)
+	}
+	style, strip := m.applyTagStyles(m.severityStyle(line.Severity), line.Tags)
+	if isWatchRule(line.RuleName) {
+		style = style.Copy().Reverse(true).Bold(true)
+	}
+	timestampStyle, emphasis := m.theme.TagStyle.Copy(), m.theme.HighlightStyle
+	switch m.ageLevel(line) {
+	case 2:
+		emphasis = style.Copy().Faint(true)
+		fallthrough
+	case 1:
+		style = style.Copy().Faint(true)
+		timestampStyle = timestampStyle.Faint(true)
+	}
+	timestamp := timestampStyle.Render(line.Timestamp.Format(m.timestampLayout()))
+	fragments := renderFragments(line.Fragments, style, emphasis)
+	path := line.Path
+	if len(line.Sources) > 1 {
+		path = fmt.Sprintf("%s +%d", path, len(line.Sources)-1)
+	}
+	if line.Pipeline != "" {
+		path += " · " + line.Pipeline
+	}
+	meta := style.Copy().Faint(true).Render(path)
 	rule := ""
 	if line.RuleName != "" {
-		rule = m.theme.PillStyle.Copy().Inherit(style).Render(line.RuleName)
+		rule = m.theme.PillStyle.Copy().Inherit(style).Render(m.rulePillLabel(line.RuleName))
 	}
 	content := fmt.Sprintf("%s %s %s %s", timestamp, fragments, meta, rule)
+	if glyph := m.severityGlyph(line.Severity); glyph != "" {
+		content = style.Render(glyph) + " " + content
+	}
+	if badge := m.freshBadge(line); badge != "" {
+		content = badge + " " + content
+	}
+	content = m.renderGutter(line) + content
 	if selected {
 		indicator := m.theme.HighlightStyle.Copy().Bold(true).Render("➤")
-		return lipgloss.JoinHorizontal(lipgloss.Top, indicator, " ", content)
+		return lipgloss.JoinHorizontal(lipgloss.Top, indicator, strip, content)
 	}
-	return lipgloss.JoinHorizontal(lipgloss.Top, " ", " ", content)
+	return lipgloss.JoinHorizontal(lipgloss.Top, " ", strip, content)
+}
+
+// rulePillLabel is the rule name with its live match count, so a reader
+// sees at once whether the rule fires constantly or rarely.
+func (m Model) rulePillLabel(rule string) string {
+	if n := m.captureStats.RuleCount(rule); n > 0 {
+		return rule + " · " + stats.ShortCount(n)
+	}
+	return rule
 }
 
 func renderFragments(frags []highlight.Fragment, base, emphasis lipgloss.Style) string {
@@ -1040,7 +1738,7 @@ func renderFragments(frags []highlight.Fragment, base, emphasis lipgloss.Style)
 		if frag.Emphasized {
 			sty = emphasis.Inherit(base)
 		}
-		b.WriteString(sty.Render(frag.Text))
+		b.WriteString(sty.Render(visibleControls(frag.Text)))
 	}
 	return b.String()
 }
@@ -1049,6 +1747,17 @@ func (m Model) severityStyle(sev rules.Severity) lipgloss.Style {
 	if style, ok := m.theme.LevelStyles[sev]; ok {
 		return style
 	}
+	// Custom levels: their own color if set, else the theme's style for the
+	// built-in level below them.
+	if color := rules.SeverityColor(sev); color != "" && !m.theme.Colorless {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(color)).Bold(rules.MeetsThreshold(sev, rules.SeverityHigh))
+	}
+	if style, ok := m.theme.LevelStyles[rules.BuiltinFloor(sev)]; ok {
+		return style
+	}
+	if m.theme.Colorless {
+		return lipgloss.NewStyle()
+	}
 	return lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
 }
 
@@ -1092,13 +1801,40 @@ func (m Model) renderHeaderInfo() string {
 	parts := []string{
 		"Spectra Watch",
 		fmt.Sprintf("theme:%s", strings.ToUpper(m.theme.Name)),
-		fmt.Sprintf("min:%s", strings.ToUpper(string(m.cfg.MinSeverity))),
+		fmt.Sprintf("min:%s", strings.ToUpper(string(m.shownMinSeverity()))),
 		fmt.Sprintf("show:%v", m.cfg.ShowAll),
 	}
+	if label := m.groupingLabel(); label != "" {
+		parts = append(parts, "group:"+strings.ToUpper(label))
+	}
+	if held := m.heldCount(); held > 0 {
+		parts = append(parts, fmt.Sprintf("held:%d", held))
+	}
+	if n := len(m.watch.entries); n > 0 {
+		parts = append(parts, fmt.Sprintf("watch:%d", n))
+	}
+	if m.cfg.SinkFailures != nil {
+		if failed := m.cfg.SinkFailures(); failed != "" {
+			parts = append(parts, "undelivered:"+failed)
+		}
+	}
+	if m.cfg.UIDropped != nil {
+		if n := m.cfg.UIDropped(); n > 0 {
+			parts = append(parts, fmt.Sprintf("dropped:%d", n))
+		}
+	}
+	if m.learningBaseline() {
+		parts = append(parts, "delta:LEARNING")
+	} else if m.deltaMode {
+		parts = append(parts, "delta:ON")
+	}
 	return strings.Join(parts, "  ·  ")
 }
 
 func (m Model) accentColor() lipgloss.TerminalColor {
+	if m.theme.Colorless {
+		return lipgloss.NoColor{}
+	}
 	if fg := m.theme.Header.GetForeground(); fg != nil {
 		return fg
 	}
@@ -1212,6 +1948,19 @@ var eyeFrames = []string{
 ╰──────╯`,
 }
 
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
 func coalesce(val, fallback string) string {
 	if val == "" {
 		return fallback
@@ -1219,8 +1968,29 @@ func coalesce(val, fallback string) string {
 	return val
 }
 
+// severityOrder lists every known severity, custom levels included, most
+// urgent first.
+func severityOrder() []rules.Severity {
+	levels := rules.Severities()
+	out := make([]rules.Severity, len(levels))
+	for i, level := range levels {
+		out[i] = level.Name
+	}
+	return out
+}
+
+func nextSeverity(current rules.Severity) rules.Severity {
+	order := severityOrder()
+	for i, sev := range order {
+		if sev == current {
+			return order[(i+1)%len(order)]
+		}
+	}
+	return order[0]
+}
+
 func nextTheme(current string) string {
-	order := []string{"vapor", "midnight", "dusk"}
+	order := []string{"vapor", "midnight", "dusk", "mono"}
 	for i, theme := range order {
 		if theme == strings.ToLower(current) {
 			return order[(i+1)%len(order)]
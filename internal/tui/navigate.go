@@ -0,0 +1,32 @@
+package tui
+
+import "watcher/internal/rules"
+
+// jumpSevere moves the selection to the next (dir > 0) or previous (dir < 0)
+// visible critical or high line, skipping everything less severe.
+func (m *Model) jumpSevere(dir int) {
+	visibleLines := m.getVisibleLines()
+	if len(visibleLines) == 0 {
+		return
+	}
+	start := m.selectedIndex
+	if start < 0 {
+		start = len(visibleLines)
+	}
+	for i := start + dir; i >= 0 && i < len(visibleLines); i += dir {
+		line := visibleLines[i]
+		if line.isGroupHeader() || line.isMarker() || !rules.MeetsThreshold(line.Severity, rules.SeverityHigh) {
+			continue
+		}
+		m.selectedIndex = i
+		m.follow = false
+		m.ensureSelectionVisible()
+		m.viewport.SetContent(m.renderLogContent())
+		return
+	}
+	m.notification = "No earlier critical/high event"
+	if dir > 0 {
+		m.notification = "No later critical/high event"
+	}
+	m.notificationT = m.now()
+}
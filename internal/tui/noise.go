@@ -0,0 +1,251 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"watcher/internal/rules"
+)
+
+const (
+	// defaultNoiseWindow is how far back the noise report counts when
+	// --noise-window is unset.
+	defaultNoiseWindow = 10 * time.Minute
+	// throttleEvery is how often a throttled rule may show a line.
+	throttleEvery = time.Minute
+	// maxNoiseRows caps the report at the loudest rules.
+	maxNoiseRows = 15
+)
+
+// noiseState is the noise report modal: rules ranked by how many visible
+// lines they produced in the report window.
+type noiseState struct {
+	open  bool
+	index int
+	rows  []noiseRow
+	total int
+}
+
+// noiseRow is one rule in the report.
+type noiseRow struct {
+	rule     string
+	count    int
+	severity rules.Severity
+}
+
+func (m Model) noiseWindow() time.Duration {
+	if m.cfg.NoiseWindow > 0 {
+		return m.cfg.NoiseWindow
+	}
+	return defaultNoiseWindow
+}
+
+// noiseReport counts the visible matched lines that arrived within the report
+// window per rule, loudest first (ties by name).
+func (m Model) noiseReport() ([]noiseRow, int) {
+	since := m.now().Add(-m.noiseWindow())
+	byRule := make(map[string]*noiseRow)
+	total := 0
+	for _, line := range m.filteredLines() {
+		if line.RuleName == "" || line.isMarker() || line.Arrived.Before(since) {
+			continue
+		}
+		row, ok := byRule[line.RuleName]
+		if !ok {
+			row = &noiseRow{rule: line.RuleName}
+			byRule[line.RuleName] = row
+		}
+		row.count++
+		row.severity = line.Severity
+		total++
+	}
+	rows := make([]noiseRow, 0, len(byRule))
+	for _, row := range byRule {
+		rows = append(rows, *row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].count != rows[j].count {
+			return rows[i].count > rows[j].count
+		}
+		return rows[i].rule < rows[j].rule
+	})
+	if len(rows) > maxNoiseRows {
+		rows = rows[:maxNoiseRows]
+	}
+	return rows, total
+}
+
+func (m *Model) openNoiseReport() {
+	m.noise = noiseState{open: true}
+	m.pushModal(modalNoise)
+	m.refreshNoiseReport()
+	m.audit("noise_report", "window", m.noiseWindow().String(), "rules", fmt.Sprint(len(m.noise.rows)))
+}
+
+// refreshNoiseReport recounts after an option changed what is visible,
+// keeping the cursor in range.
+func (m *Model) refreshNoiseReport() {
+	m.noise.rows, m.noise.total = m.noiseReport()
+	m.noise.index = clamp(m.noise.index, 0, len(m.noise.rows)-1)
+	if m.noise.index < 0 {
+		m.noise.index = 0
+	}
+}
+
+func (m Model) handleNoiseKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "n":
+		m.noise = noiseState{}
+		return m, nil
+	case "up", "k":
+		m.noise.index = clamp(m.noise.index-1, 0, len(m.noise.rows)-1)
+		return m, nil
+	case "down", "j":
+		m.noise.index = clamp(m.noise.index+1, 0, len(m.noise.rows)-1)
+		return m, nil
+	}
+	if len(m.noise.rows) == 0 {
+		return m, nil
+	}
+	rule := m.noise.rows[m.noise.index].rule
+	switch msg.String() {
+	case "t":
+		m.toggleThrottle(rule)
+	case "d":
+		m.downgradeRule(rule)
+	case "x":
+		m.filterRule(rule)
+	default:
+		return m, nil
+	}
+	m.refreshNoiseReport()
+	return m, nil
+}
+
+// toggleThrottle limits rule to one visible line per throttleEvery, or lifts
+// the limit again. Throttled lines are only hidden, like other filters.
+func (m *Model) toggleThrottle(rule string) {
+	if m.throttledRules[rule] {
+		delete(m.throttledRules, rule)
+		m.audit("unthrottle_rule", "rule", rule)
+		m.notification = fmt.Sprintf("Unthrottled rule: %s", rule)
+	} else {
+		m.throttledRules[rule] = true
+		m.audit("throttle_rule", "rule", rule, "every", throttleEvery.String())
+		m.notification = fmt.Sprintf("Throttled rule: %s (1 line per %s)", rule, throttleEvery)
+	}
+	m.notificationT = m.now()
+	m.refreshVisibleState()
+}
+
+// throttled reports whether a throttled rule already showed a line less than
+// throttleEvery before line; last tracks the most recent shown line per rule.
+func (m Model) throttled(line displayLine, last map[string]time.Time) bool {
+	if line.RuleName == "" || !m.throttledRules[line.RuleName] {
+		return false
+	}
+	if prev, ok := last[line.RuleName]; ok && line.Arrived.Sub(prev) < throttleEvery {
+		return true
+	}
+	last[line.RuleName] = line.Arrived
+	return false
+}
+
+// downgradeRule lowers rule's severity one level for the rest of the session,
+// on buffered lines and on every later match. It changes only what the
+// dashboard shows; sinks still receive the rule's own severity.
+func (m *Model) downgradeRule(rule string) {
+	from := m.ruleSeverity(rule)
+	to, ok := lowerSeverity(from)
+	if !ok {
+		m.notification = fmt.Sprintf("%s is already %s", rule, strings.ToUpper(string(from)))
+		m.notificationT = m.now()
+		return
+	}
+	m.downgrades[rule]++
+	for i := range m.lines {
+		line := &m.lines[i]
+		if line.RuleName != rule || line.isMarker() {
+			continue
+		}
+		if lowered, ok := lowerSeverity(line.Severity); ok {
+			m.counts[line.Severity]--
+			m.counts[lowered]++
+			line.Severity = lowered
+		}
+	}
+	m.audit("downgrade_rule", "rule", rule, "from", string(from), "to", string(to))
+	m.notification = fmt.Sprintf("Downgraded %s: %s → %s", rule, strings.ToUpper(string(from)), strings.ToUpper(string(to)))
+	m.notificationT = m.now()
+	m.refreshVisibleState()
+}
+
+// ruleSeverity is the shown severity of rule's newest buffered line.
+func (m Model) ruleSeverity(rule string) rules.Severity {
+	for i := len(m.lines) - 1; i >= 0; i-- {
+		if m.lines[i].RuleName == rule {
+			return m.lines[i].Severity
+		}
+	}
+	return rules.SeverityNormal
+}
+
+// downgraded applies the session downgrades of rule to an arriving severity.
+func (m Model) downgraded(rule string, sev rules.Severity) rules.Severity {
+	for steps := m.downgrades[rule]; steps > 0; steps-- {
+		lowered, ok := lowerSeverity(sev)
+		if !ok {
+			break
+		}
+		sev = lowered
+	}
+	return sev
+}
+
+// lowerSeverity returns the level just below sev, if there is one.
+func lowerSeverity(sev rules.Severity) (rules.Severity, bool) {
+	order := severityOrder()
+	for i, s := range order {
+		if s == sev && i+1 < len(order) {
+			return order[i+1], true
+		}
+	}
+	return sev, false
+}
+
+func (m Model) renderNoiseModal() string {
+	width, height := m.modalSize()
+	title := m.theme.Header.Render(fmt.Sprintf("noise report · last %s · %d lines", m.noiseWindow(), m.noise.total))
+	instructions := m.theme.TagStyle.Render("t throttle · d downgrade · x filter · esc close")
+	rows := make([]string, 0, len(m.noise.rows)+1)
+	for i, row := range m.noise.rows {
+		marker := "  "
+		if i == m.noise.index {
+			marker = m.theme.HighlightStyle.Copy().Bold(true).Render("➤ ")
+		}
+		sev := m.severityStyle(row.severity).Render(fmt.Sprintf("%-8s", strings.ToUpper(string(row.severity))))
+		share := 100 * row.count / m.noise.total
+		var flags []string
+		if m.throttledRules[row.rule] {
+			flags = append(flags, "throttled")
+		}
+		if n := m.downgrades[row.rule]; n > 0 {
+			flags = append(flags, fmt.Sprintf("downgraded ×%d", n))
+		}
+		text := fmt.Sprintf("%s%5d %3d%% %s %s", marker, row.count, share, sev, row.rule)
+		if len(flags) > 0 {
+			text += lipgloss.NewStyle().Faint(true).Render(" · " + strings.Join(flags, ", "))
+		}
+		rows = append(rows, text)
+	}
+	if len(rows) == 0 {
+		rows = append(rows, "", fmt.Sprintf("no matched lines in the last %s", m.noiseWindow()))
+	}
+	content := lipgloss.JoinVertical(lipgloss.Left, title, instructions, strings.Join(rows, "\n"))
+	return m.modalStyle(width, height).Render(content)
+}
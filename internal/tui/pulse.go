@@ -0,0 +1,128 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"watcher/internal/settings"
+	"watcher/internal/stats"
+)
+
+const (
+	// tagPulseWindow is the window the tag pulse compares with the one
+	// before it for its rate arrows.
+	tagPulseWindow = time.Minute
+	// defaultPulseTags is how many tags `:pulse tags` shows without a count.
+	defaultPulseTags = 5
+)
+
+// tagCount is one tag's line total and its per-window rate.
+type tagCount struct {
+	total  int
+	window *stats.RateWindow
+}
+
+// observeTags counts a line toward each of its tags.
+func (m *Model) observeTags(tags []string) {
+	now := m.now()
+	for _, tag := range tags {
+		c, ok := m.tagCounts[tag]
+		if !ok {
+			c = &tagCount{window: stats.NewRateWindow(tagPulseWindow)}
+			m.tagCounts[tag] = c
+		}
+		c.total++
+		c.window.Add(now)
+	}
+}
+
+// renderPulse is the sidebar pulse: a count per severity, or with pulse tags
+// set, the busiest tags with an arrow comparing this minute to the last.
+func (m Model) renderPulse() string {
+	var b strings.Builder
+	if m.pulseTags <= 0 {
+		b.WriteString(m.theme.Header.Render("pulse"))
+		for _, sev := range severityOrder() {
+			count := m.counts[sev]
+			pill := m.theme.PillStyle.Copy().Inherit(m.severityStyle(sev)).Render(fmt.Sprintf("%s %d", strings.ToUpper(string(sev)), count))
+			b.WriteString("\n" + pill)
+		}
+		return b.String()
+	}
+	b.WriteString(m.theme.Header.Render("pulse · tags"))
+	tags := make([]string, 0, len(m.tagCounts))
+	for tag := range m.tagCounts {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		a, b := m.tagCounts[tags[i]].total, m.tagCounts[tags[j]].total
+		if a != b {
+			return a > b
+		}
+		return tags[i] < tags[j]
+	})
+	if len(tags) == 0 {
+		b.WriteString("\n" + m.theme.TagStyle.Render("no tagged lines yet"))
+	}
+	for _, tag := range tags[:min(len(tags), m.pulseTags)] {
+		c := m.tagCounts[tag]
+		current, previous := c.window.CountsAt(m.now())
+		arrow := "→"
+		switch {
+		case current > previous:
+			arrow = "↑"
+		case current < previous:
+			arrow = "↓"
+		}
+		style, _ := m.applyTagStyles(m.theme.PillStyle.Copy(), []string{tag})
+		b.WriteString("\n" + style.Render(fmt.Sprintf("%s %d %s", tag, c.total, arrow)))
+	}
+	return b.String()
+}
+
+// setPulse handles `:pulse tags [K]` and `:pulse severity`, saving the choice
+// to the profile.
+func (m *Model) setPulse(arg string) {
+	fields := strings.Fields(arg)
+	next := m.pulseTags
+	switch {
+	case len(fields) == 0:
+		m.notification = "usage: pulse tags [K] | pulse severity"
+		m.notificationT = m.now()
+		return
+	case fields[0] == "severity":
+		next = 0
+	case fields[0] == "tags":
+		next = defaultPulseTags
+		if len(fields) > 1 {
+			k, err := strconv.Atoi(fields[1])
+			if err != nil || k <= 0 {
+				m.notification = fmt.Sprintf("pulse: bad tag count %q", fields[1])
+				m.notificationT = m.now()
+				return
+			}
+			next = k
+		}
+	default:
+		m.notification = fmt.Sprintf("pulse: unknown view %q (tags, severity)", fields[0])
+		m.notificationT = m.now()
+		return
+	}
+	m.pulseTags = next
+	label := "severity"
+	if next > 0 {
+		label = fmt.Sprintf("top %d tags", next)
+	}
+	m.audit("pulse", "view", label)
+	m.notification = "pulse: " + label
+	if m.cfg.ProfilePath != "" {
+		err := settings.UpdateProfile(m.cfg.ProfilePath, m.cfg.Profile, func(p *settings.Profile) { p.PulseTags = next })
+		if err != nil {
+			m.notification = fmt.Sprintf("pulse: %s (not saved: %v)", label, err)
+		}
+	}
+	m.notificationT = m.now()
+}
@@ -0,0 +1,51 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"watcher/internal/rules"
+)
+
+// quickSeverities maps the number keys to the lowest severity the pane shows:
+// 1 is critical only, 5 everything the stream delivers.
+var quickSeverities = map[string]rules.Severity{
+	"1": rules.SeverityCritical,
+	"2": rules.SeverityHigh,
+	"3": rules.SeverityMedium,
+	"4": rules.SeverityLow,
+	"5": rules.SeverityNormal,
+}
+
+// setViewSeverity applies a number-key threshold to the displayed lines. It
+// only hides what already arrived; the stream's --min-severity still decides
+// what reaches the dashboard.
+func (m *Model) setViewSeverity(key string) {
+	sev := quickSeverities[key]
+	m.viewSeverity = sev
+	count := 0
+	for _, line := range m.lines {
+		if m.belowViewSeverity(line) {
+			count++
+		}
+	}
+	m.audit("min_severity", "severity", string(sev))
+	m.notification = fmt.Sprintf("Showing %s and above (%d lines hidden)", strings.ToUpper(string(sev)), count)
+	m.notificationT = m.now()
+	m.refreshVisibleState()
+}
+
+// belowViewSeverity reports whether the number-key threshold hides line.
+// Ingestion markers are always shown.
+func (m Model) belowViewSeverity(line displayLine) bool {
+	return m.viewSeverity != "" && !line.isMarker() && !rules.MeetsThreshold(line.Severity, m.viewSeverity)
+}
+
+// shownMinSeverity is the threshold named in the header: the number-key
+// choice once made, else the configured minimum.
+func (m Model) shownMinSeverity() rules.Severity {
+	if m.viewSeverity != "" {
+		return m.viewSeverity
+	}
+	return m.cfg.MinSeverity
+}
@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	"watcher/internal/highlight"
+	"watcher/internal/rules"
+	"watcher/internal/stats"
+)
+
+// rateTracker pairs a configured rate alert with its window counter.
+type rateTracker struct {
+	alert  rules.RateAlert
+	window *stats.RateWindow
+}
+
+func newRateTrackers(alerts []rules.RateAlert) []rateTracker {
+	trackers := make([]rateTracker, 0, len(alerts))
+	for _, alert := range alerts {
+		trackers = append(trackers, rateTracker{alert: alert, window: stats.NewRateWindow(alert.Window)})
+	}
+	return trackers
+}
+
+// observeRates feeds a matched event to every rate alert it counts toward and
+// appends a synthetic line for each alert that fires.
+func (m *Model) observeRates(severity rules.Severity, at time.Time) {
+	for _, tracker := range m.rateTrackers {
+		alert := tracker.alert
+		if !rules.MeetsThreshold(severity, alert.Severity) {
+			continue
+		}
+		tracker.window.Add(at)
+		if !tracker.window.Exceeded(alert.Factor, alert.MinCount) {
+			continue
+		}
+		current, previous := tracker.window.Counts()
+		text := fmt.Sprintf("%d %s+ events in the current %s window vs %d in the previous one (%s)", current, alert.Severity, alert.Window, previous, alert.Describe())
+		m.lines = append(m.lines, displayLine{
+			Severity:  alert.Alert,
+			RuleName:  "rate: " + alert.Name,
+			Path:      "spectra",
+			Timestamp: at,
+			Fragments: []highlight.Fragment{{Text: text, Emphasized: true}},
+			Tags:      []string{"rate"},
+			Captures:  map[string]string{"current": fmt.Sprint(current), "previous": fmt.Sprint(previous)},
+			Text:      text,
+			Index:     len(m.lines),
+			Seq:       m.nextSeq,
+			Arrived:   m.now(),
+		})
+		m.nextSeq++
+		m.counts[alert.Alert]++
+		m.notification = fmt.Sprintf("rate alert · %s · %d vs %d", alert.Name, current, previous)
+		m.notificationT = m.now()
+	}
+}
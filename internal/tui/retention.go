@@ -0,0 +1,144 @@
+package tui
+
+import (
+	"fmt"
+
+	"watcher/internal/rules"
+)
+
+// evictionOrder lists severities in the order scrollback trimming gives them
+// up: least urgent first, custom levels by rank.
+func evictionOrder() []rules.Severity {
+	order := severityOrder()
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+	return order
+}
+
+// held reports whether trimming must keep the line: critical/high events stay
+// in memory until acknowledged with `a`/`A`.
+func (l displayLine) held() bool {
+	return !l.Acked && !l.isMarker() && !l.isGroupHeader() && rules.MeetsThreshold(l.Severity, rules.SeverityHigh)
+}
+
+// trimScrollback evicts lines beyond the scrollback limit, least severe first and
+// oldest first within a severity. Held lines survive unless more than retainCap
+// of them are waiting, in which case the oldest beyond the cap become evictable.
+func (m *Model) trimScrollback() {
+	excess := len(m.lines) - m.scrollback
+	if excess <= 0 {
+		return
+	}
+	selected, hadSelection := m.selectedLine()
+
+	heldCount := 0
+	for _, line := range m.lines {
+		if line.held() {
+			heldCount++
+		}
+	}
+	overCap := heldCount - m.retainCap
+	evict := make([]bool, len(m.lines))
+	for _, sev := range evictionOrder() {
+		for i, line := range m.lines {
+			if excess == 0 {
+				break
+			}
+			if evict[i] || line.Severity != sev {
+				continue
+			}
+			if line.held() {
+				if overCap <= 0 {
+					continue
+				}
+				overCap--
+			}
+			evict[i] = true
+			excess--
+		}
+	}
+
+	kept := m.lines[:0]
+	newHidden := make(map[int]bool)
+	for i, line := range m.lines {
+		if evict[i] {
+			continue
+		}
+		if m.hiddenIndices[line.Index] {
+			newHidden[len(kept)] = true
+		}
+		line.Index = len(kept)
+		kept = append(kept, line)
+	}
+	m.lines = kept
+	m.hiddenIndices = newHidden
+	if hadSelection {
+		m.reselect(selected)
+	}
+}
+
+// reselect points the selection back at line after the buffer was compacted,
+// falling back to the nearest surviving line.
+func (m *Model) reselect(line displayLine) {
+	visible := m.getVisibleLines()
+	m.selectedIndex = -1
+	for i, candidate := range visible {
+		if candidate.isGroupHeader() {
+			if line.isGroupHeader() && candidate.GroupKey == line.GroupKey {
+				m.selectedIndex = i
+				return
+			}
+			continue
+		}
+		if candidate.Seq >= line.Seq && !line.isGroupHeader() {
+			m.selectedIndex = i
+			return
+		}
+	}
+	if len(visible) > 0 {
+		m.selectedIndex = len(visible) - 1
+	}
+}
+
+// acknowledgeCurrentLine releases the selected critical/high event so trimming may evict it.
+func (m *Model) acknowledgeCurrentLine() {
+	line, ok := m.selectedLine()
+	if !ok || !line.held() {
+		return
+	}
+	m.lines[line.Index].Acked = true
+	m.cfg.Notifier.Ack(line.Path, line.Text)
+	m.audit("ack", "rule", line.RuleName, "severity", string(line.Severity), "line", line.Text)
+	m.notification = fmt.Sprintf("Acknowledged %s · %d held", line.Severity, m.heldCount())
+	m.notificationT = m.now()
+	m.trimScrollback()
+	m.refreshVisibleState()
+}
+
+// acknowledgeAll releases every held event at once.
+func (m *Model) acknowledgeAll() {
+	count := 0
+	for i := range m.lines {
+		if m.lines[i].held() {
+			m.lines[i].Acked = true
+			count++
+		}
+	}
+	m.cfg.Notifier.AckAll()
+	m.audit("ack_all", "count", fmt.Sprint(count))
+	m.notification = fmt.Sprintf("Acknowledged %d critical/high events", count)
+	m.notificationT = m.now()
+	m.trimScrollback()
+	m.refreshVisibleState()
+}
+
+func (m Model) heldCount() int {
+	count := 0
+	for _, line := range m.lines {
+		if line.held() {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,182 @@
+package tui
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"watcher/internal/rules"
+	"watcher/internal/runtime"
+)
+
+// ruleFileSuffix marks the files the rule picker offers.
+const ruleFileSuffix = ".rules.yaml"
+
+// rulePickerState is the `C` modal: rule files found in ModelConfig.RuleDirs
+// with their rule counts per severity.
+type rulePickerState struct {
+	open     bool
+	files    []ruleFileEntry
+	index    int
+	errorMsg string
+}
+
+type ruleFileEntry struct {
+	path   string
+	counts map[rules.Severity]int
+	err    error
+}
+
+// findRuleFiles lists *.rules.yaml under dirs (recursively), each once and in
+// path order, after the active file.
+func findRuleFiles(dirs []string, active string) []string {
+	seen := make(map[string]bool)
+	if abs, err := filepath.Abs(active); active != "" && err == nil {
+		seen[abs] = true
+	}
+	var found []string
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(d.Name(), ruleFileSuffix) {
+				return nil
+			}
+			if abs, err := filepath.Abs(path); err == nil && !seen[abs] {
+				seen[abs] = true
+				found = append(found, path)
+			}
+			return nil
+		})
+	}
+	sort.Strings(found)
+	if active != "" {
+		found = append([]string{active}, found...)
+	}
+	return found
+}
+
+func (m *Model) openRulePicker() {
+	if m.cfg.RuleSwap == nil {
+		m.notification = "Switching rule files is not available here"
+		m.notificationT = m.now()
+		return
+	}
+	paths := findRuleFiles(m.cfg.RuleDirs, m.cfg.ConfigPath)
+	files := make([]ruleFileEntry, 0, len(paths))
+	for _, path := range paths {
+		counts, err := rules.CountSeverities(path)
+		files = append(files, ruleFileEntry{path: path, counts: counts, err: err})
+	}
+	m.rulePicker = rulePickerState{open: true, files: files}
+	m.pushModal(modalRules)
+}
+
+func (m Model) handleRulePickerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "C":
+		m.rulePicker = rulePickerState{}
+	case "up", "k":
+		m.rulePicker.index = clamp(m.rulePicker.index-1, 0, len(m.rulePicker.files)-1)
+	case "down", "j":
+		m.rulePicker.index = clamp(m.rulePicker.index+1, 0, len(m.rulePicker.files)-1)
+	case "enter":
+		if len(m.rulePicker.files) > 0 {
+			m.switchRules(m.rulePicker.files[m.rulePicker.index].path)
+		}
+	}
+	return m, nil
+}
+
+// switchRules loads path and makes it the active rule set for the running
+// pipeline and the dashboard. A file that fails to load leaves everything as
+// it was.
+func (m *Model) switchRules(path string) {
+	rs, err := rules.Load(path)
+	if err != nil {
+		m.rulePicker.errorMsg = err.Error()
+		return
+	}
+	m.storeRules(rs)
+	m.cfg.ConfigPath = path
+	m.cfg.RateAlerts = rs.RateAlerts
+	m.rateTrackers = newRateTrackers(rs.RateAlerts)
+	m.cfg.TagStyles = rs.TagStyles
+	m.cfg.Entities = rs.Entities
+	m.cfg.Actions = rs.Actions()
+	m.cfg.RuleGroups = runtime.BuildRuleGroups(rs)
+	m.persistOffer = ""
+	m.rulePicker = rulePickerState{}
+	m.audit("rules_switch", "file", path, "rules", fmt.Sprint(len(rs.Rules)))
+	m.notification = fmt.Sprintf("Rules from %s (%d rules) · applies to new lines", path, len(rs.Rules))
+	m.notificationT = m.now()
+	m.viewport.SetContent(m.renderLogContent())
+}
+
+func (m Model) renderRulePickerModal() string {
+	width, height := m.modalSize()
+	title := m.theme.Header.Render(fmt.Sprintf("rule files (%d)", len(m.rulePicker.files)))
+	instructions := m.theme.TagStyle.Render("enter load · esc close")
+	activeAbs, _ := filepath.Abs(m.cfg.ConfigPath)
+	rows := make([]string, 0, len(m.rulePicker.files)*2+2)
+	for i, file := range m.rulePicker.files {
+		marker := "  "
+		if i == m.rulePicker.index {
+			marker = m.theme.HighlightStyle.Copy().Bold(true).Render("➤ ")
+		}
+		name := file.path
+		if abs, _ := filepath.Abs(file.path); abs == activeAbs {
+			name += " (active)"
+		}
+		rows = append(rows, marker+name)
+		rows = append(rows, "    "+m.renderSeverityCounts(file))
+	}
+	if len(rows) == 0 {
+		rows = append(rows, "", fmt.Sprintf("no *%s files in %s", ruleFileSuffix, strings.Join(m.cfg.RuleDirs, ", ")))
+	}
+	if m.rulePicker.errorMsg != "" {
+		rows = append(rows, "", m.severityStyle(rules.SeverityCritical).Render(wrapText(m.rulePicker.errorMsg, width-(modalPaddingX*2)-4)))
+	}
+	content := lipgloss.JoinVertical(lipgloss.Left, title, instructions, strings.Join(rows, "\n"))
+	return m.modalStyle(width, height).Render(content)
+}
+
+// renderSeverityCounts shows a file's rule counts, most urgent level first;
+// levels only that file defines follow the known ones.
+func (m Model) renderSeverityCounts(file ruleFileEntry) string {
+	if file.err != nil {
+		return lipgloss.NewStyle().Faint(true).Render("unreadable: " + file.err.Error())
+	}
+	var parts []string
+	shown := make(map[rules.Severity]bool)
+	total := 0
+	for _, sev := range severityOrder() {
+		shown[sev] = true
+		if n := file.counts[sev]; n > 0 {
+			parts = append(parts, m.severityStyle(sev).Render(fmt.Sprintf("%d %s", n, sev)))
+			total += n
+		}
+	}
+	var extra []string
+	for sev := range file.counts {
+		if !shown[sev] {
+			extra = append(extra, string(sev))
+		}
+	}
+	sort.Strings(extra)
+	for _, sev := range extra {
+		n := file.counts[rules.Severity(sev)]
+		parts = append(parts, fmt.Sprintf("%d %s", n, sev))
+		total += n
+	}
+	if total == 0 {
+		return lipgloss.NewStyle().Faint(true).Render("no enabled rules")
+	}
+	return fmt.Sprintf("%d rules: %s", total, strings.Join(parts, " · "))
+}
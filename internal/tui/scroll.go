@@ -0,0 +1,48 @@
+package tui
+
+import "fmt"
+
+// Smart scroll lock: moving the selection away from the newest line suspends
+// follow (see moveSelection); lines that arrive meanwhile are counted, and
+// follow resumes on its own once the selection is back on the last line.
+
+// resumeFollowAtBottom re-enables follow when the selection reached the end.
+func (m *Model) resumeFollowAtBottom() {
+	if m.follow {
+		return
+	}
+	visibleLines := m.getVisibleLines()
+	if len(visibleLines) > 0 && m.selectedIndex == len(visibleLines)-1 {
+		m.follow = true
+		m.newBelow = 0
+		m.viewport.GotoBottom()
+	}
+}
+
+// snapToBottom jumps to the newest line and resumes follow (End key).
+func (m *Model) snapToBottom() {
+	visibleLines := m.getVisibleLines()
+	m.selectedIndex = len(visibleLines) - 1
+	m.follow = true
+	m.newBelow = 0
+	m.viewport.SetContent(m.renderLogContent())
+	m.viewport.GotoBottom()
+}
+
+// scrollState is the stream state shown in the status bar: paused states win,
+// then the scroll lock with the count of lines waiting below.
+func (m Model) scrollState(live string) string {
+	switch {
+	case m.ingestionPaused():
+		return "ingest paused"
+	case m.flood.active:
+		return "auto-paused · p resume"
+	case m.paused:
+		return "paused"
+	case !m.follow && m.newBelow > 0:
+		return fmt.Sprintf("scrolled: %d new below · End", m.newBelow)
+	case !m.follow:
+		return "scrolled · End"
+	}
+	return live
+}
@@ -0,0 +1,345 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"watcher/internal/highlight"
+	"watcher/internal/rules"
+	"watcher/internal/runtime"
+	"watcher/internal/settings"
+)
+
+// sessionFile is a checkpoint of the UI (`:save-session`, `--session`, and
+// on quit): layout, theme, view filters, grouping, the watched files and rule
+// groups, pinned lines, the selected line, and an open entity timeline.
+// Lines themselves are not saved; hidden lines refer to this run's buffer, so
+// they are not kept either.
+type sessionFile struct {
+	SavedAt        time.Time      `json:"saved_at"`
+	Mode           string         `json:"mode"`
+	Theme          string         `json:"theme"`
+	Compact        *bool          `json:"compact,omitempty"`
+	Minimap        bool           `json:"minimap,omitempty"`
+	Gutter         gutterMode     `json:"gutter,omitempty"`
+	Follow         bool           `json:"follow"`
+	Delta          bool           `json:"delta,omitempty"`
+	Chart          string         `json:"chart,omitempty"`
+	MinSeverity    rules.Severity `json:"min_severity,omitempty"`
+	FilteredRules  []string       `json:"filtered_rules,omitempty"`
+	ThrottledRules []string       `json:"throttled_rules,omitempty"`
+	Downgrades     map[string]int `json:"downgrades,omitempty"`
+	GroupBy        string         `json:"group_by,omitempty"`
+	GroupCapture   string         `json:"group_capture,omitempty"`
+	Expanded       []string       `json:"expanded,omitempty"`
+	Pinned         []sessionLine  `json:"pinned,omitempty"`
+	// Files and Tags are the configuration modal's selection; restoring them
+	// re-applies it when a Controller is available.
+	Files []string `json:"files,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+	// Entity is the entity timeline that was open, reopened on restore.
+	Entity *sessionEntity `json:"entity,omitempty"`
+	// Selected is the line the selection was on when follow was off; it is
+	// selected again once a line with the same source and text is buffered.
+	Selected *sessionLine `json:"selected,omitempty"`
+}
+
+type sessionLine struct {
+	Path     string            `json:"path"`
+	Text     string            `json:"text"`
+	Rule     string            `json:"rule,omitempty"`
+	Severity rules.Severity    `json:"severity,omitempty"`
+	Time     time.Time         `json:"time"`
+	Tags     []string          `json:"tags,omitempty"`
+	Captures map[string]string `json:"captures,omitempty"`
+}
+
+type sessionEntity struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func toSessionLine(line displayLine) sessionLine {
+	return sessionLine{
+		Path:     line.Path,
+		Text:     line.Text,
+		Rule:     line.RuleName,
+		Severity: line.Severity,
+		Time:     line.Timestamp,
+		Tags:     line.Tags,
+		Captures: line.Captures,
+	}
+}
+
+// displayLine rebuilds a pinned line; its match emphasis is not saved.
+func (l sessionLine) displayLine() displayLine {
+	return displayLine{
+		Severity:  l.Severity,
+		RuleName:  l.Rule,
+		Path:      l.Path,
+		Timestamp: l.Time,
+		Fragments: highlight.Plain(l.Text),
+		Tags:      l.Tags,
+		Captures:  l.Captures,
+		Text:      l.Text,
+		Index:     -1,
+	}
+}
+
+func (m Model) snapshotSession() sessionFile {
+	s := sessionFile{
+		SavedAt:        m.now(),
+		Mode:           m.mode,
+		Theme:          m.theme.Name,
+		Compact:        m.compactForced,
+		Minimap:        m.showMinimap,
+		Gutter:         m.gutter,
+		Follow:         m.follow,
+		Delta:          m.deltaMode,
+		Chart:          m.chartCapture,
+		MinSeverity:    m.viewSeverity,
+		FilteredRules:  setKeys(m.filteredRules),
+		ThrottledRules: setKeys(m.throttledRules),
+		Downgrades:     m.downgrades,
+		GroupBy:        m.groupBy,
+		GroupCapture:   m.groupCapture,
+		Expanded:       setKeys(m.groupExpanded),
+		Files:          m.activeFiles,
+		Tags:           m.activeTags,
+	}
+	if m.entity.open {
+		ref := m.entity.choices[m.entity.index]
+		s.Entity = &sessionEntity{Name: ref.name, Value: ref.value}
+	}
+	for _, pin := range m.pinned {
+		s.Pinned = append(s.Pinned, toSessionLine(pin))
+	}
+	if line, ok := m.selectedLine(); ok && !m.follow && !line.isGroupHeader() && !line.isSeparator() && !line.isMarker() {
+		sel := toSessionLine(line)
+		s.Selected = &sel
+	}
+	return s
+}
+
+// setKeys lists the true entries of a set, sorted.
+func setKeys(set map[string]bool) []string {
+	var keys []string
+	for key, on := range set {
+		if on {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func keySet(keys []string) map[string]bool {
+	set := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		set[key] = true
+	}
+	return set
+}
+
+func saveSession(path string, s sessionFile) error {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode session: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("save session: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("save session: %w", err)
+	}
+	return nil
+}
+
+func loadSession(path string) (sessionFile, error) {
+	var s sessionFile
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return s, fmt.Errorf("load session: %w", err)
+	}
+	if err := json.Unmarshal(content, &s); err != nil {
+		return s, fmt.Errorf("parse session %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// applySession restores a checkpoint over the current state. Unknown themes,
+// modes, and severities (e.g. from another rule file) fall back silently.
+func (m *Model) applySession(s sessionFile) {
+	if mode, err := ParseMode(s.Mode); err == nil {
+		m.setMode(mode)
+	}
+	if s.Theme != "" {
+		m.theme = themeByName(s.Theme)
+	}
+	m.compactForced = s.Compact
+	m.showMinimap = s.Minimap
+	if s.Gutter >= gutterOff && s.Gutter <= gutterOffsets {
+		m.gutter = s.Gutter
+	}
+	m.follow = s.Follow || m.monitoring()
+	m.deltaMode = s.Delta
+	if s.Chart != "" {
+		m.chartCapture = s.Chart
+	}
+	m.viewSeverity = ""
+	if s.MinSeverity != "" {
+		if sev, err := rules.ParseSeverity(string(s.MinSeverity)); err == nil {
+			m.viewSeverity = sev
+		}
+	}
+	m.filteredRules = keySet(s.FilteredRules)
+	m.throttledRules = keySet(s.ThrottledRules)
+	m.downgrades = make(map[string]int, len(s.Downgrades))
+	for rule, steps := range s.Downgrades {
+		m.downgrades[rule] = steps
+	}
+	m.groupBy, m.groupCapture = "", ""
+	if s.GroupBy == groupByRule || (s.GroupBy == groupByCapture && s.GroupCapture != "") {
+		m.groupBy, m.groupCapture = s.GroupBy, s.GroupCapture
+	}
+	m.groupExpanded = keySet(s.Expanded)
+	m.pinned = nil
+	for _, pin := range s.Pinned {
+		line := pin.displayLine()
+		line.Seq = m.nextSeq
+		m.nextSeq++
+		m.pinned = append(m.pinned, line)
+	}
+	if len(m.pinned) > maxPinned {
+		m.pinned = m.pinned[len(m.pinned)-maxPinned:]
+	}
+	m.restoreSelection(s.Files, s.Tags)
+	m.pendingSelect = s.Selected
+	m.resize(m.windowWidth, m.windowHeight)
+	m.refreshVisibleState()
+	m.selectPending()
+	if m.follow {
+		m.viewport.GotoBottom()
+	}
+	if s.Entity != nil && s.Entity.Value != "" {
+		if !m.entity.open {
+			m.pushModal(modalEntity)
+		}
+		m.entity = entityState{open: true, choices: []entityRef{{name: s.Entity.Name, value: s.Entity.Value}}}
+		m.updateEntityViewportSize()
+	}
+}
+
+// restoreSelection re-applies a session's files and rule groups through the
+// Controller when they differ from the current ones; without one (e.g. with
+// --pipelines) the saved selection is left alone.
+func (m *Model) restoreSelection(files, tags []string) {
+	if len(files) == 0 || m.cfg.Controller == nil {
+		return
+	}
+	if slices.Equal(files, m.activeFiles) && slices.Equal(tags, m.activeTags) {
+		return
+	}
+	if err := m.cfg.Controller.Apply(runtime.Selection{Files: files, Tags: tags}); err != nil {
+		m.notification = fmt.Sprintf("restore files: %v", err)
+		m.notificationT = m.now()
+		return
+	}
+	m.activeFiles = append([]string{}, files...)
+	m.activeTags = append([]string{}, tags...)
+}
+
+// selectPending moves the selection to the restored session's selected line
+// once it is in the buffer, and stops following so it stays put.
+func (m *Model) selectPending() {
+	if m.pendingSelect == nil || m.monitoring() {
+		return
+	}
+	for i, line := range m.getVisibleLines() {
+		if line.Path == m.pendingSelect.Path && line.Text == m.pendingSelect.Text && !line.isMarker() && !line.isGroupHeader() {
+			m.selectedIndex = i
+			m.follow = false
+			m.pendingSelect = nil
+			m.viewport.SetContent(m.renderLogContent())
+			m.ensureSelectionVisible()
+			return
+		}
+	}
+}
+
+// restoreSession loads the --session file at startup; a session that does
+// not exist yet is created by the first `:save-session`.
+func (m *Model) restoreSession() {
+	path := m.cfg.SessionPath
+	if path == "" {
+		return
+	}
+	s, err := loadSession(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		m.notification = err.Error()
+		m.notificationT = m.now()
+		return
+	}
+	m.applySession(s)
+	m.notification = fmt.Sprintf("Restored session %s (saved %s)", filepath.Base(path), s.SavedAt.Format("Jan 2 15:04"))
+	m.notificationT = m.now()
+}
+
+// SaveSession writes the UI to the --session file, if there is one; it is
+// the dashboard's shutdown task, so quitting keeps the session current.
+func (m Model) SaveSession() error {
+	if m.cfg.SessionPath == "" {
+		return nil
+	}
+	return saveSession(m.cfg.SessionPath, m.snapshotSession())
+}
+
+// saveSessionAs checkpoints the UI to the named session, or to the --session
+// one when name is empty.
+func (m *Model) saveSessionAs(name string) {
+	path := m.cfg.SessionPath
+	if name != "" {
+		path = settings.SessionPath(name)
+	}
+	if path == "" {
+		m.notification = "save-session needs a name (or start with --session)"
+		m.notificationT = m.now()
+		return
+	}
+	if err := saveSession(path, m.snapshotSession()); err != nil {
+		m.notification = err.Error()
+		m.notificationT = m.now()
+		return
+	}
+	m.audit("session_save", "file", path)
+	m.notification = fmt.Sprintf("Saved session %s", filepath.Base(path))
+	m.notificationT = m.now()
+}
+
+func (m *Model) loadSessionNamed(name string) {
+	if name == "" {
+		m.notification = "load-session needs a name"
+		m.notificationT = m.now()
+		return
+	}
+	path := settings.SessionPath(name)
+	s, err := loadSession(path)
+	if err != nil {
+		m.notification = err.Error()
+		m.notificationT = m.now()
+		return
+	}
+	m.applySession(s)
+	m.audit("session_load", "file", path)
+	m.notification = fmt.Sprintf("Loaded session %s", filepath.Base(path))
+	m.notificationT = m.now()
+}
@@ -0,0 +1,55 @@
+package tui
+
+import (
+	"sort"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"watcher/internal/rules"
+)
+
+// applyTagStyles layers the configured tag styles (in tag name order) onto a
+// line's severity style. It also returns the gutter strip drawn in the
+// background color, or a blank when no matching style sets one.
+func (m Model) applyTagStyles(base lipgloss.Style, tags []string) (lipgloss.Style, string) {
+	if len(m.cfg.TagStyles) == 0 || len(tags) == 0 {
+		return base, " "
+	}
+	sorted := append([]string{}, tags...)
+	sort.Strings(sorted)
+	style := base.Copy()
+	strip := " "
+	for _, tag := range sorted {
+		ts, ok := rules.TagStyleFor(m.cfg.TagStyles, tag)
+		if !ok {
+			continue
+		}
+		if m.theme.Colorless {
+			ts.Foreground, ts.Background = "", ""
+		}
+		style = withTagStyle(style, ts)
+		if ts.Background != "" {
+			strip = lipgloss.NewStyle().Foreground(lipgloss.Color(ts.Background)).Render("▌")
+		}
+	}
+	return style, strip
+}
+
+func withTagStyle(style lipgloss.Style, ts rules.TagStyle) lipgloss.Style {
+	if ts.Foreground != "" {
+		style = style.Foreground(lipgloss.Color(ts.Foreground))
+	}
+	if ts.Background != "" {
+		style = style.Background(lipgloss.Color(ts.Background))
+	}
+	if ts.Bold {
+		style = style.Bold(true)
+	}
+	if ts.Italic {
+		style = style.Italic(true)
+	}
+	if ts.Underline {
+		style = style.Underline(true)
+	}
+	return style
+}
@@ -20,14 +20,30 @@ type Theme struct {
 	HighlightStyle lipgloss.Style
 	TagStyle       lipgloss.Style
 	PillStyle      lipgloss.Style
+	// Modal frames pop-up windows; Backdrop fills the screen around them.
+	Modal    lipgloss.Style
+	Backdrop lipgloss.TerminalColor
+	// Glyphs, when set, prefix each log line with a symbol for its severity
+	// so the level reads without color.
+	Glyphs map[rules.Severity]string
+	// Colorless drops every color, including accents, custom severity
+	// colors, and tag styles, leaving only text attributes.
+	Colorless bool
 }
 
+var (
+	defaultModal    = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Background(lipgloss.Color("#1A0F1F"))
+	defaultBackdrop = lipgloss.Color("#05010A")
+)
+
 func themeByName(name string) Theme {
 	switch strings.ToLower(name) {
 	case "midnight":
 		return midnightTheme()
 	case "dusk":
 		return duskTheme()
+	case "mono":
+		return monoTheme()
 	default:
 		return vaporTheme()
 	}
@@ -62,6 +78,8 @@ func vaporTheme() Theme {
 		HighlightStyle: highlight,
 		TagStyle:       tag,
 		PillStyle:      pill,
+		Modal:          defaultModal,
+		Backdrop:       defaultBackdrop,
 	}
 }
 
@@ -93,6 +111,8 @@ func midnightTheme() Theme {
 		HighlightStyle: highlight,
 		TagStyle:       tag,
 		PillStyle:      pill,
+		Modal:          defaultModal,
+		Backdrop:       defaultBackdrop,
 	}
 }
 
@@ -124,5 +144,75 @@ func duskTheme() Theme {
 		HighlightStyle: highlight,
 		TagStyle:       tag,
 		PillStyle:      pill,
+		Modal:          defaultModal,
+		Backdrop:       defaultBackdrop,
+	}
+}
+
+// monoTheme draws no borders, backgrounds, or colors and shows severity as a
+// glyph plus bold, underline, and faint text, for terminals with few colors
+// and for screen recordings.
+func monoTheme() Theme {
+	plain := lipgloss.NewStyle()
+	pane := plain.Copy().Padding(0, 1)
+	sidebar := pane.Copy().Width(25)
+	status := plain.Copy().Bold(true)
+	header := plain.Copy().Bold(true)
+	highlight := plain.Copy().Underline(true)
+	tag := plain.Copy().Faint(true)
+	pill := plain.Copy().Faint(true)
+
+	levelStyles := map[rules.Severity]lipgloss.Style{
+		rules.SeverityCritical: plain.Copy().Bold(true).Underline(true),
+		rules.SeverityHigh:     plain.Copy().Bold(true),
+		rules.SeverityMedium:   plain.Copy().Bold(true),
+		rules.SeverityLow:      plain.Copy(),
+		rules.SeverityNormal:   plain.Copy().Faint(true),
+	}
+	glyphs := map[rules.Severity]string{
+		rules.SeverityCritical: "!!",
+		rules.SeverityHigh:     "! ",
+		rules.SeverityMedium:   "* ",
+		rules.SeverityLow:      "- ",
+		rules.SeverityNormal:   "  ",
+	}
+
+	return Theme{
+		Name:           "mono",
+		Background:     plain,
+		Pane:           pane,
+		Sidebar:        sidebar,
+		StatusBar:      status,
+		Header:         header,
+		LevelStyles:    levelStyles,
+		HighlightStyle: highlight,
+		TagStyle:       tag,
+		PillStyle:      pill,
+		Modal:          plain.Copy().Border(lipgloss.HiddenBorder()),
+		Backdrop:       lipgloss.NoColor{},
+		Glyphs:         glyphs,
+		Colorless:      true,
 	}
 }
+
+// severityGlyph is the theme's symbol for sev, custom levels taking the one
+// of the built-in level below them; empty when the theme has no glyphs.
+func (m Model) severityGlyph(sev rules.Severity) string {
+	if m.theme.Glyphs == nil {
+		return ""
+	}
+	if glyph, ok := m.theme.Glyphs[sev]; ok {
+		return glyph
+	}
+	return m.theme.Glyphs[rules.BuiltinFloor(sev)]
+}
+
+// modalStyle frames a width×height pop-up in the theme's modal style.
+func (m Model) modalStyle(width, height int) lipgloss.Style {
+	return m.theme.Modal.Copy().
+		BorderForeground(m.accentColor()).
+		Width(width).
+		Height(height).
+		Padding(modalPaddingY, modalPaddingX).
+		Align(lipgloss.Left)
+}
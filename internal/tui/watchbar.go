@@ -0,0 +1,152 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"watcher/internal/highlight"
+	"watcher/internal/rules"
+)
+
+// watchRulePrefix names the rules made by `:watch`; lines they match are
+// drawn in reverse video so they stand out from rule-file matches.
+const watchRulePrefix = "watch: "
+
+// watchTag is the tag on lines a watch matched, for the dashboard's tag
+// filters.
+const watchTag = "watch"
+
+// watchEntry is one ad-hoc pattern typed into the watch bar. It lives for the
+// run only: nothing is written to the rule file or the session.
+type watchEntry struct {
+	pattern string
+	bell    bool
+	rule    rules.Rule
+}
+
+// watchState holds the active watches. They are a display overlay: the
+// dashboard matches them against the lines it receives, while the pipeline,
+// the sinks, the ban list, and actions keep seeing only the rule file's
+// matches.
+type watchState struct {
+	entries []watchEntry
+	// set holds the entries' rules, in entry order, for matching.
+	set rules.RuleSet
+}
+
+// openWatchBar starts the command line with `watch ` typed.
+func (m *Model) openWatchBar() {
+	m.command = commandState{open: true, input: "watch "}
+}
+
+// addWatch compiles pattern as a critical rule the dashboard checks every
+// new line against (see overlayWatches).
+func (m *Model) addWatch(pattern string, bell bool) {
+	if pattern == "" {
+		m.notify("watch needs a pattern, e.g. :watch timeout|refused")
+		return
+	}
+	rs, err := rules.Compile([]rules.RuleDefinition{{
+		Name:     watchRulePrefix + pattern,
+		Pattern:  pattern,
+		Match:    "regex",
+		Severity: rules.SeverityCritical,
+		Tags:     []string{watchTag},
+	}})
+	if err != nil {
+		m.notify(fmt.Sprintf("watch: %v", err))
+		return
+	}
+	m.watch.entries = slices.DeleteFunc(m.watch.entries, func(w watchEntry) bool { return w.pattern == pattern })
+	m.watch.entries = append(m.watch.entries, watchEntry{pattern: pattern, bell: bell, rule: rs.Rules[0]})
+	m.applyWatches()
+	m.audit("watch_add", "pattern", pattern, "bell", fmt.Sprint(bell))
+	suffix := ""
+	if bell {
+		suffix = " · bell on match"
+	}
+	m.notify(fmt.Sprintf("Watching %q (%d active)%s · applies to new lines", pattern, len(m.watch.entries), suffix))
+}
+
+// removeWatch drops the watch for pattern, or every watch when it is empty.
+func (m *Model) removeWatch(pattern string) {
+	if len(m.watch.entries) == 0 {
+		m.notify("No watches")
+		return
+	}
+	before := len(m.watch.entries)
+	m.watch.entries = slices.DeleteFunc(m.watch.entries, func(w watchEntry) bool {
+		return pattern == "" || w.pattern == pattern
+	})
+	removed := before - len(m.watch.entries)
+	if removed == 0 {
+		m.notify(fmt.Sprintf("not watching %q", pattern))
+		return
+	}
+	m.applyWatches()
+	m.audit("watch_remove", "pattern", pattern, "removed", fmt.Sprint(removed))
+	m.notify(fmt.Sprintf("Removed %d watch(es), %d active", removed, len(m.watch.entries)))
+}
+
+// storeRules makes rs the pipeline's rule set.
+func (m *Model) storeRules(rs rules.RuleSet) {
+	m.cfg.RuleSwap.Store(rs)
+}
+
+func (m *Model) applyWatches() {
+	extra := make([]rules.Rule, len(m.watch.entries))
+	for i, w := range m.watch.entries {
+		extra[i] = w.rule
+	}
+	m.watch.set = rules.RuleSet{}.Prepend(extra)
+}
+
+// overlayWatches shows line as the first watch matching it, if any: critical,
+// under the watch's rule name and tag, with the watch's match highlighted.
+// Only the dashboard's copy changes.
+func (m Model) overlayWatches(line *displayLine) {
+	if len(m.watch.entries) == 0 || line.isGroupHeader() || line.isSeparator() {
+		return
+	}
+	match, ok := m.watch.set.Match(line.Text)
+	if !ok {
+		return
+	}
+	line.RuleName = match.Rule.Name
+	line.Severity = rules.SeverityCritical
+	line.Fragments = highlight.BuildFragments(line.Text, match.HighlightSpans)
+	if !slices.Contains(line.Tags, watchTag) {
+		line.Tags = append(line.Tags, watchTag)
+	}
+}
+
+func (m *Model) notify(text string) {
+	m.notification = text
+	m.notificationT = m.now()
+}
+
+func isWatchRule(name string) bool {
+	return strings.HasPrefix(name, watchRulePrefix)
+}
+
+// watchBell rings the terminal bell when rule is a watch that asked for it.
+// It writes to stderr so the bell does not interleave with a frame.
+func (m Model) watchBell(rule string) tea.Cmd {
+	if !isWatchRule(rule) {
+		return nil
+	}
+	pattern := strings.TrimPrefix(rule, watchRulePrefix)
+	for _, w := range m.watch.entries {
+		if w.pattern == pattern && w.bell {
+			return func() tea.Msg {
+				os.Stderr.WriteString("\a")
+				return nil
+			}
+		}
+	}
+	return nil
+}
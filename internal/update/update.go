@@ -0,0 +1,251 @@
+package update
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// DefaultURL is the signed release manifest; `<url>.sig` holds its signature.
+const DefaultURL = "https://github.com/dcbz/spectra/releases/latest/download/release.json"
+
+// PublicKey is the base64 Ed25519 key release manifests are signed with. It is
+// injected at build time (-ldflags "-X watcher/internal/update.PublicKey=...");
+// builds without it can check for updates but refuse to install them.
+var PublicKey = ""
+
+// maxManifestBytes and maxBinaryBytes bound downloads from the release endpoint.
+const (
+	maxManifestBytes = 1 << 20
+	maxBinaryBytes   = 256 << 20
+)
+
+// Manifest describes one release.
+type Manifest struct {
+	Version   string  `json:"version"`
+	RulePacks string  `json:"rule_packs"`
+	Notes     string  `json:"notes"`
+	Assets    []Asset `json:"assets"`
+	verified  bool
+}
+
+// Asset is the binary for one platform; SHA256 is hex encoded.
+type Asset struct {
+	OS     string `json:"os"`
+	Arch   string `json:"arch"`
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+}
+
+// Client talks to the release endpoint.
+type Client struct {
+	URL  string
+	HTTP *http.Client
+}
+
+// Fetch downloads the manifest and, when a PublicKey is built in, verifies its
+// signature. Unverified manifests may be used to report updates, never to install.
+func (c Client) Fetch(ctx context.Context) (Manifest, error) {
+	body, err := c.get(ctx, c.URL, maxManifestBytes)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("fetch manifest: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		return Manifest{}, fmt.Errorf("parse manifest: %w", err)
+	}
+	if PublicKey == "" {
+		return m, nil
+	}
+	sig, err := c.get(ctx, c.URL+".sig", maxManifestBytes)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("fetch signature: %w", err)
+	}
+	if err := verify(body, sig); err != nil {
+		return Manifest{}, err
+	}
+	m.verified = true
+	return m, nil
+}
+
+// Install downloads the asset for this platform, checks it against the signed
+// manifest, and atomically replaces the running executable.
+func (c Client) Install(ctx context.Context, m Manifest) (string, error) {
+	if !m.verified {
+		return "", errors.New("manifest signature not verified (binary built without a release signing key)")
+	}
+	asset, ok := m.asset(runtime.GOOS, runtime.GOARCH)
+	if !ok {
+		return "", fmt.Errorf("release %s has no build for %s/%s", m.Version, runtime.GOOS, runtime.GOARCH)
+	}
+	binary, err := c.get(ctx, asset.URL, maxBinaryBytes)
+	if err != nil {
+		return "", fmt.Errorf("download %s: %w", asset.URL, err)
+	}
+	sum := sha256.Sum256(binary)
+	if !strings.EqualFold(hex.EncodeToString(sum[:]), asset.SHA256) {
+		return "", fmt.Errorf("checksum mismatch for %s", asset.URL)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("locate executable: %w", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return "", fmt.Errorf("locate executable: %w", err)
+	}
+	if err := replaceFile(exe, binary); err != nil {
+		return "", err
+	}
+	return exe, nil
+}
+
+func (m Manifest) asset(goos, goarch string) (Asset, bool) {
+	for _, a := range m.Assets {
+		if a.OS == goos && a.Arch == goarch {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+func verify(body, sig []byte) error {
+	key, err := base64.StdEncoding.DecodeString(PublicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return errors.New("invalid built-in release key")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return fmt.Errorf("decode signature: %w", err)
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), body, raw) {
+		return errors.New("manifest signature does not match the release key")
+	}
+	return nil
+}
+
+// replaceFile writes the new binary beside path and renames it into place so
+// the swap is atomic. Windows cannot overwrite a running executable, so the
+// old one is first moved aside to path.old.
+func replaceFile(path string, content []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("stat executable: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".new-*")
+	if err != nil {
+		return fmt.Errorf("stage update: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("stage update: %w", err)
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("stage update: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("stage update: %w", err)
+	}
+	if runtime.GOOS == "windows" {
+		old := path + ".old"
+		os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return fmt.Errorf("move old executable aside: %w", err)
+		}
+		if err := os.Rename(tmp.Name(), path); err != nil {
+			os.Rename(old, path)
+			return fmt.Errorf("install update: %w", err)
+		}
+		return nil
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("install update: %w", err)
+	}
+	return nil
+}
+
+func (c Client) get(ctx context.Context, url string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := c.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("%s: response larger than %d bytes", url, limit)
+	}
+	return body, nil
+}
+
+// Newer reports whether version a is newer than b. Versions are dotted
+// numbers with an optional `v` prefix; a pre-release (`1.2.0-rc1`) sorts
+// before its release, and `dev` builds never count as newer than anything.
+func Newer(a, b string) bool {
+	if a == "" || a == "dev" {
+		return false
+	}
+	if b == "" || b == "dev" {
+		return true
+	}
+	aNum, aPre := splitVersion(a)
+	bNum, bPre := splitVersion(b)
+	for i := 0; i < max(len(aNum), len(bNum)); i++ {
+		var x, y int
+		if i < len(aNum) {
+			x = aNum[i]
+		}
+		if i < len(bNum) {
+			y = bNum[i]
+		}
+		if x != y {
+			return x > y
+		}
+	}
+	switch {
+	case aPre == bPre:
+		return false
+	case aPre == "":
+		return true
+	case bPre == "":
+		return false
+	}
+	return aPre > bPre
+}
+
+func splitVersion(v string) ([]int, string) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	v, pre, _ := strings.Cut(v, "-")
+	var nums []int
+	for _, part := range strings.Split(v, ".") {
+		n, _ := strconv.Atoi(part)
+		nums = append(nums, n)
+	}
+	return nums, pre
+}
@@ -0,0 +1,84 @@
+package watch
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"watcher/internal/relay"
+)
+
+// AgentsPrefix marks a listener for spectra agents forwarding over TLS:
+// `agents::7443` (see `spectra-watch agent --forward`).
+const AgentsPrefix = "agents:"
+
+type agentTLSKey struct{}
+
+// WithAgentTLS returns a context whose `agents:` listeners serve cfg (see
+// relay.ServerConfig). Without one they refuse to start.
+func WithAgentTLS(ctx context.Context, cfg *tls.Config) context.Context {
+	return context.WithValue(ctx, agentTLSKey{}, cfg)
+}
+
+func agentTLSFrom(ctx context.Context) *tls.Config {
+	cfg, _ := ctx.Value(agentTLSKey{}).(*tls.Config)
+	return cfg
+}
+
+// listenAgents accepts agent connections. Each record becomes one line
+// whose Path is host:path (relay.Record.Source) and whose time is the
+// agent's, so the aggregator's rules and dashboard see the whole fleet.
+func listenAgents(ctx context.Context, spec, addr string) (sourceFunc, error) {
+	cfg := agentTLSFrom(ctx)
+	if cfg == nil {
+		return nil, fmt.Errorf("%s: needs --agents-cert and --agents-key", spec)
+	}
+	ln, err := tls.Listen("tcp", addr, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("listen %s: %w", spec, err)
+	}
+	return func(ctx context.Context, out chan<- LogEvent) error {
+		stop := closeOnDone(ctx, ln)
+		defer stop()
+		conns := &sync.WaitGroup{}
+		defer conns.Wait()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				if ctx.Err() == nil {
+					return fmt.Errorf("accept %s: %w", spec, err)
+				}
+				return nil
+			}
+			conns.Add(1)
+			go func(conn net.Conn) {
+				defer conns.Done()
+				stopConn := closeOnDone(ctx, conn)
+				defer stopConn()
+				readAgent(ctx, spec, conn, out)
+			}(conn)
+		}
+	}, nil
+}
+
+// readAgent reads one agent connection until it closes. A failed handshake
+// or a malformed record is reported and ends the connection.
+func readAgent(ctx context.Context, spec string, conn net.Conn, out chan<- LogEvent) {
+	r := relay.NewReader(conn)
+	for {
+		rec, err := r.Read()
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) && ctx.Err() == nil {
+				emit(ctx, out, LogEvent{Path: spec, Err: fmt.Errorf("%s: agent %s: %w", spec, conn.RemoteAddr(), err)})
+			}
+			return
+		}
+		if !emit(ctx, out, LogEvent{Path: rec.Source(), Line: rec.Line, Time: rec.Time, Rule: rec.Rule, Severity: rec.Severity}) {
+			return
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"watcher/internal/archive"
+	"watcher/internal/clock"
+)
+
+const (
+	journalExportPrefix = "journal-export:"
+	evtxPrefix          = "evtx:"
+)
+
+// errSourceFinished ends a source that has nothing more to read (an imported
+// archive); superviseSource stops instead of reopening it.
+var errSourceFinished = errors.New("source finished")
+
+// archiveSpec resolves `journal-export:path` and `evtx:path`, or a plain path
+// whose content is one of those formats (see archive.Detect).
+func archiveSpec(spec string) (string, archive.Format, bool) {
+	switch {
+	case strings.HasPrefix(spec, journalExportPrefix):
+		return strings.TrimPrefix(spec, journalExportPrefix), archive.FormatJournalExport, true
+	case strings.HasPrefix(spec, evtxPrefix):
+		return strings.TrimPrefix(spec, evtxPrefix), archive.FormatEvtx, true
+	}
+	if format := archive.Detect(spec); format != archive.FormatNone {
+		return spec, format, true
+	}
+	return "", archive.FormatNone, false
+}
+
+type replaySpeedKey struct{}
+
+// WithReplaySpeed makes archives imported under ctx emit their records with
+// their original spacing, divided by speed (see Paced). Zero or less, the
+// default, imports them as fast as the pipeline takes them.
+func WithReplaySpeed(ctx context.Context, speed float64) context.Context {
+	return context.WithValue(ctx, replaySpeedKey{}, speed)
+}
+
+func replaySpeedFrom(ctx context.Context) float64 {
+	speed, _ := ctx.Value(replaySpeedKey{}).(float64)
+	return speed
+}
+
+// readArchive imports an archive once, from the start or after a restart from
+// the last delivered record, then finishes, paced by the context's replay
+// speed. Events carry the archive's path and each record's time, but no
+// Offset: the record positions are binary, and a nonzero Offset would send
+// the detail view's disk context and the sinks' file_offset into them.
+func readArchive(spec, path string, format archive.Format, state *sourceState) sourceFunc {
+	return func(ctx context.Context, out chan<- LogEvent) error {
+		pace := pacer{speed: replaySpeedFrom(ctx), clk: clock.System}
+		delivered := true
+		err := archive.Each(path, format, func(rec archive.Record) bool {
+			if rec.Offset <= state.offset {
+				return true
+			}
+			if delivered = pace.wait(ctx, rec.Time) && emit(ctx, out, LogEvent{Path: path, Line: rec.Line, Time: rec.Time}); !delivered {
+				return false
+			}
+			state.offset = rec.Offset
+			return true
+		})
+		if err != nil {
+			return fmt.Errorf("%s: %w", spec, err)
+		}
+		if !delivered {
+			return nil
+		}
+		return errSourceFinished
+	}
+}
@@ -0,0 +1,114 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"watcher/internal/auditd"
+)
+
+// AuditdPrefix marks a Linux audit source: `auditd:` (or `auditd:netlink`)
+// listens on the kernel's audit netlink socket, `auditd:/path` connects to
+// an audisp af_unix plugin socket such as /var/run/audispd_events.
+const AuditdPrefix = "auditd:"
+
+// recordReader is the netlink socket or an audisp stream.
+type recordReader interface {
+	Read() (auditd.Record, error)
+	Close() error
+}
+
+type audispStream struct {
+	*auditd.Reader
+	net.Conn
+}
+
+func (s audispStream) Read() (auditd.Record, error) { return s.Reader.Read() }
+
+// openAuditd delivers one line per audit event, its records joined (see
+// auditd.Event.Line) and stamped with the event's own time. Like
+// readerSource, it opens now so a bad spec fails at startup; the stream
+// ending is an error, so the supervisor reopens it.
+func openAuditd(spec string) (sourceFunc, error) {
+	open := func() (recordReader, error) {
+		target := strings.TrimPrefix(spec, AuditdPrefix)
+		if target == "" || target == "netlink" {
+			return auditd.Dial()
+		}
+		conn, err := net.Dial("unix", target)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", spec, err)
+		}
+		return audispStream{Reader: auditd.NewReader(conn), Conn: conn}, nil
+	}
+	r, err := open()
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, out chan<- LogEvent) error {
+		stop := closeOnDone(ctx, r)
+		defer stop()
+		return pumpAudit(ctx, spec, r, out)
+	}, nil
+}
+
+// pumpAudit assembles records into events, sending events whose EOE never
+// came once auditd.EventTimeout passes.
+func pumpAudit(ctx context.Context, spec string, r recordReader, out chan<- LogEvent) error {
+	records := make(chan auditd.Record)
+	failed := make(chan error, 1)
+	go func() {
+		for {
+			rec, err := r.Read()
+			if err != nil {
+				failed <- err
+				return
+			}
+			select {
+			case records <- rec:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	assembler := auditd.NewAssembler()
+	ticker := time.NewTicker(auditd.EventTimeout / 2)
+	defer ticker.Stop()
+	send := func(events []auditd.Event) bool {
+		for _, e := range events {
+			if !emit(ctx, out, LogEvent{Path: spec, Line: e.Line(), Time: e.Time}) {
+				return false
+			}
+		}
+		return true
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case rec := <-records:
+			if !send(assembler.Add(rec, time.Now())) {
+				return nil
+			}
+		case now := <-ticker.C:
+			if !send(assembler.Expire(now)) {
+				return nil
+			}
+		case err := <-failed:
+			send(assembler.Flush())
+			if ctx.Err() != nil {
+				return nil
+			}
+			if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) || errors.Is(err, os.ErrClosed) {
+				return fmt.Errorf("%s closed", spec)
+			}
+			return fmt.Errorf("read %s: %w", spec, err)
+		}
+	}
+}
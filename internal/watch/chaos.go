@@ -0,0 +1,119 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Chaos injects faults into source events for robustness testing: random
+// delays, duplicated lines, and synthetic read errors. It is a developer aid
+// (the hidden --chaos flag); the same seed replays the same decisions for the
+// same sequence of events.
+type Chaos struct {
+	DelayRate float64
+	MaxDelay  time.Duration
+	DupRate   float64
+	ErrorRate float64
+	Seed      int64
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+type chaosKey struct{}
+
+// ErrChaos marks errors injected by Chaos.
+var ErrChaos = errors.New("injected fault")
+
+// ParseChaos reads a spec such as "delay=0.1,max-delay=2s,dup=0.05,error=0.01".
+// "on" selects those defaults; omitted keys in a custom spec are zero.
+func ParseChaos(spec string, seed int64) (*Chaos, error) {
+	c := &Chaos{MaxDelay: 2 * time.Second, Seed: seed}
+	if strings.TrimSpace(spec) == "on" {
+		c.DelayRate, c.DupRate, c.ErrorRate = 0.1, 0.05, 0.01
+	} else {
+		for _, part := range strings.Split(spec, ",") {
+			key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+			if !ok {
+				return nil, fmt.Errorf("chaos: want key=value, got %q", part)
+			}
+			var err error
+			switch key {
+			case "delay":
+				c.DelayRate, err = parseRate(value)
+			case "max-delay":
+				c.MaxDelay, err = time.ParseDuration(value)
+			case "dup":
+				c.DupRate, err = parseRate(value)
+			case "error":
+				c.ErrorRate, err = parseRate(value)
+			default:
+				err = fmt.Errorf("unknown key")
+			}
+			if err != nil {
+				return nil, fmt.Errorf("chaos %s: %w", key, err)
+			}
+		}
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	c.rng = rand.New(rand.NewSource(c.Seed))
+	return c, nil
+}
+
+func parseRate(value string) (float64, error) {
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	if rate < 0 || rate > 1 {
+		return 0, fmt.Errorf("rate %g outside 0..1", rate)
+	}
+	return rate, nil
+}
+
+// String summarizes the configuration, including the seed needed to replay it.
+func (c *Chaos) String() string {
+	return fmt.Sprintf("delay=%g max-delay=%s dup=%g error=%g seed=%d", c.DelayRate, c.MaxDelay, c.DupRate, c.ErrorRate, c.Seed)
+}
+
+// WithChaos attaches c to ctx so every source started with it is disrupted.
+func WithChaos(ctx context.Context, c *Chaos) context.Context {
+	return context.WithValue(ctx, chaosKey{}, c)
+}
+
+func chaosFrom(ctx context.Context) *Chaos {
+	c, _ := ctx.Value(chaosKey{}).(*Chaos)
+	return c
+}
+
+// fault is what Chaos decided to do to one event.
+type fault struct {
+	delay     time.Duration
+	duplicate bool
+	err       error
+}
+
+func (c *Chaos) roll(path string) fault {
+	if c == nil {
+		return fault{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var f fault
+	if c.rng.Float64() < c.DelayRate && c.MaxDelay > 0 {
+		f.delay = time.Duration(c.rng.Int63n(int64(c.MaxDelay)))
+	}
+	f.duplicate = c.rng.Float64() < c.DupRate
+	if c.rng.Float64() < c.ErrorRate {
+		f.err = fmt.Errorf("read %s: %w", path, ErrChaos)
+	}
+	return f
+}
@@ -0,0 +1,51 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"watcher/internal/cloudwatch"
+)
+
+// CloudWatchPrefix marks a CloudWatch Logs source: `cloudwatch:group[:stream]`.
+const CloudWatchPrefix = "cloudwatch:"
+
+// tailCloudWatch polls a log group (or one stream, or streams by `prefix*`)
+// from the time it is first opened; after a restart it resumes at the
+// latest delivered event's millisecond, skipping the events it already
+// delivered there. Lines are cloudwatch.Event.Line.
+func tailCloudWatch(spec string, state *sourceState) (sourceFunc, error) {
+	target, err := cloudwatch.ParseTarget(strings.TrimPrefix(spec, CloudWatchPrefix))
+	if err != nil {
+		return nil, err
+	}
+	client, err := cloudwatch.NewClient()
+	if err != nil {
+		return nil, err
+	}
+	if state.since.IsZero() {
+		state.since = time.Now()
+	}
+	return func(ctx context.Context, out chan<- LogEvent) error {
+		err := client.Tail(ctx, target, state.since, state.sinceIDs, func(evt cloudwatch.Event) bool {
+			if !emit(ctx, out, LogEvent{Path: spec, Line: evt.Line()}) {
+				return false
+			}
+			// Late events re-queried from before the cursor must not
+			// move it back, or a restart would repeat what followed.
+			switch t := evt.Time(); {
+			case t.After(state.since):
+				state.since, state.sinceIDs = t, []string{evt.ID}
+			case t.Equal(state.since):
+				state.sinceIDs = append(state.sinceIDs, evt.ID)
+			}
+			return true
+		})
+		if err != nil && ctx.Err() == nil {
+			return fmt.Errorf("%s: %w", spec, err)
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,97 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ExecPrefix marks a command whose stdout is read as a source:
+// `exec:dmesg -w`. The command runs through the shell (sh -c, or cmd /C on
+// Windows), so pipes and quoting work as typed. A leading `label:` word
+// names the source: `exec:api: kubectl logs -f deploy/api`.
+const ExecPrefix = "exec:"
+
+// commandLabel is a source name heading an exec: spec.
+var commandLabel = regexp.MustCompile(`^([A-Za-z0-9_.-]+):\s+(\S.*)$`)
+
+// ParseCommand splits an exec: spec into its label and command. Without an
+// explicit label the label is the command string itself.
+func ParseCommand(spec string) (label, command string) {
+	command = strings.TrimSpace(strings.TrimPrefix(spec, ExecPrefix))
+	if m := commandLabel.FindStringSubmatch(command); m != nil {
+		return m[1], m[2]
+	}
+	return command, command
+}
+
+// runCommand starts the command each time the source runs; its lines carry
+// the label as their path. The command exiting, successfully or not, is an
+// error naming the exit status and the last line it wrote to stderr, so the
+// supervisor restarts it with backoff. Stopping kills the whole process
+// group, pipelines included.
+func runCommand(spec string) (sourceFunc, error) {
+	label, command := ParseCommand(spec)
+	if command == "" {
+		return nil, fmt.Errorf("%s: want exec:command", spec)
+	}
+	return func(ctx context.Context, out chan<- LogEvent) error {
+		cmd := shellCommand(ctx, command)
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return fmt.Errorf("%s: %w", label, err)
+		}
+		var stderr tailWriter
+		cmd.Stderr = &stderr
+		if err := cmd.Start(); err != nil {
+			return fmt.Errorf("start %s: %w", label, err)
+		}
+		scanErr := scanLines(ctx, label, stdout, out)
+		waitErr := cmd.Wait()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if scanErr != nil {
+			return scanErr
+		}
+		status := "exited"
+		if waitErr != nil {
+			status = waitErr.Error()
+		}
+		if last := stderr.lastLine(); last != "" {
+			return fmt.Errorf("%s: %s: %s", label, status, last)
+		}
+		return fmt.Errorf("%s: %s", label, status)
+	}, nil
+}
+
+// tailWriter keeps the end of a command's stderr for its exit error.
+type tailWriter struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+const tailWriterBytes = 4 << 10
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	if len(w.buf) > tailWriterBytes {
+		w.buf = w.buf[len(w.buf)-tailWriterBytes:]
+	}
+	return len(p), nil
+}
+
+func (w *tailWriter) lastLine() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	text := bytes.TrimRight(w.buf, "\r\n")
+	if i := bytes.LastIndexByte(text, '\n'); i >= 0 {
+		text = text[i+1:]
+	}
+	return strings.TrimSpace(string(text))
+}
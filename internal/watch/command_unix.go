@@ -0,0 +1,24 @@
+//go:build !windows
+
+package watch
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// shellCommand runs command under sh in its own process group, which is
+// sent SIGTERM when ctx ends and SIGKILL if it has not exited 2s later.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", command)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		err := syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+		time.AfterFunc(2*time.Second, func() { syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) })
+		return err
+	}
+	cmd.WaitDelay = 3 * time.Second
+	return cmd
+}
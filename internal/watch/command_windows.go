@@ -0,0 +1,19 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// shellCommand runs command under cmd.exe, which is killed when ctx ends.
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	shell := os.Getenv("ComSpec")
+	if shell == "" {
+		shell = "cmd.exe"
+	}
+	cmd := exec.CommandContext(ctx, shell, "/C", command)
+	cmd.WaitDelay = 3 * time.Second
+	return cmd
+}
@@ -0,0 +1,83 @@
+package watch
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// contextWindow caps how far ReadContext reads on either side of a line.
+const contextWindow = 64 << 10
+
+// FileContext is a stretch of a file around one line. Lines[Line] is the
+// line itself.
+type FileContext struct {
+	Lines []string
+	Line  int
+}
+
+// ReadContext rereads up to before and after lines around the line ending at
+// offset (a LogEvent Offset) from disk. It fails when the file has since
+// shrunk below offset, i.e. was truncated or rotated away.
+func ReadContext(path string, offset int64, before, after int) (FileContext, error) {
+	if offset <= 0 {
+		return FileContext{}, fmt.Errorf("read context %s: no file offset recorded", path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return FileContext{}, fmt.Errorf("read context: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return FileContext{}, fmt.Errorf("read context: %w", err)
+	}
+	if info.Size() < offset {
+		return FileContext{}, fmt.Errorf("read context %s: file is shorter than offset %d (rotated or truncated)", path, offset)
+	}
+
+	start := offset - contextWindow
+	if start < 0 {
+		start = 0
+	}
+	head := make([]byte, offset-start)
+	if _, err := f.ReadAt(head, start); err != nil && err != io.EOF {
+		return FileContext{}, fmt.Errorf("read context: %w", err)
+	}
+	lines := splitLines(head)
+	// Unless the window reached the start of the file its first line is partial.
+	if start > 0 && len(lines) > 0 {
+		lines = lines[1:]
+	}
+	if len(lines) == 0 {
+		return FileContext{}, fmt.Errorf("read context %s: no line ends at offset %d", path, offset)
+	}
+	if len(lines) > before+1 {
+		lines = lines[len(lines)-before-1:]
+	}
+	ctx := FileContext{Lines: lines, Line: len(lines) - 1}
+
+	scanner := bufio.NewScanner(io.NewSectionReader(f, offset, contextWindow))
+	scanner.Buffer(make([]byte, 0, 4096), contextWindow)
+	for n := 0; n < after && scanner.Scan(); n++ {
+		ctx.Lines = append(ctx.Lines, string(bytes.TrimSuffix(scanner.Bytes(), []byte{'\r'})))
+	}
+	return ctx, nil
+}
+
+// splitLines splits data on newlines, dropping the terminator after the last
+// line and any trailing carriage returns.
+func splitLines(data []byte) []string {
+	data = bytes.TrimSuffix(data, []byte{'\n'})
+	if len(data) == 0 {
+		return nil
+	}
+	parts := bytes.Split(data, []byte{'\n'})
+	lines := make([]string, len(parts))
+	for i, part := range parts {
+		lines[i] = string(bytes.TrimSuffix(part, []byte{'\r'}))
+	}
+	return lines
+}
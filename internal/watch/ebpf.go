@@ -0,0 +1,43 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"watcher/internal/procexec"
+)
+
+// EBPFPrefix marks a Linux eBPF probe; `ebpf:exec` reports every process
+// execution (see procexec.Event.Line for the line format).
+const EBPFPrefix = "ebpf:"
+
+// openEBPF loads the probe now, so missing privileges fail at startup, and
+// again when the supervisor restarts it.
+func openEBPF(spec string) (sourceFunc, error) {
+	if probe := strings.TrimPrefix(spec, EBPFPrefix); probe != "exec" {
+		return nil, fmt.Errorf("%s: unknown probe %q (want exec)", spec, probe)
+	}
+	m, err := procexec.Open()
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, out chan<- LogEvent) error {
+		stop := closeOnDone(ctx, m)
+		defer stop()
+		for {
+			e, err := m.Read()
+			if err != nil {
+				if ctx.Err() != nil || errors.Is(err, os.ErrClosed) {
+					return nil
+				}
+				return fmt.Errorf("read %s: %w", spec, err)
+			}
+			if !emit(ctx, out, LogEvent{Path: spec, Line: e.Line()}) {
+				return nil
+			}
+		}
+	}, nil
+}
@@ -0,0 +1,73 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"watcher/internal/fluent"
+)
+
+const fluentPrefix = "fluent:"
+
+// listenFluent accepts Fluentd forward protocol connections (fluentd,
+// td-agent, fluent-bit `forward` outputs). Each record becomes one line (see
+// fluent.Entry.Line) whose Path is its tag and whose time is the record's.
+// Chunks sent with `require_ack_response` are acknowledged once their lines
+// are handed on.
+func listenFluent(spec, addr string) (sourceFunc, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen %s: %w", spec, err)
+	}
+	return func(ctx context.Context, out chan<- LogEvent) error {
+		stop := closeOnDone(ctx, ln)
+		defer stop()
+		conns := &sync.WaitGroup{}
+		defer conns.Wait()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				if ctx.Err() == nil {
+					return fmt.Errorf("accept %s: %w", spec, err)
+				}
+				return nil
+			}
+			conns.Add(1)
+			go func(conn net.Conn) {
+				defer conns.Done()
+				stopConn := closeOnDone(ctx, conn)
+				defer stopConn()
+				readFluent(ctx, spec, conn, out)
+			}(conn)
+		}
+	}, nil
+}
+
+// readFluent reads one connection until it closes. A malformed message is
+// reported and ends the connection, since the stream cannot be resynchronized.
+func readFluent(ctx context.Context, spec string, conn net.Conn, out chan<- LogEvent) {
+	r := fluent.NewReader(conn)
+	for {
+		entries, chunk, err := r.Read()
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) && ctx.Err() == nil {
+				emit(ctx, out, LogEvent{Path: spec, Err: fmt.Errorf("%s: %w", spec, err)})
+			}
+			return
+		}
+		for _, entry := range entries {
+			if !emit(ctx, out, LogEvent{Path: entry.Tag, Line: entry.Line(), Time: entry.Time}) {
+				return
+			}
+		}
+		if chunk != "" {
+			if _, err := conn.Write(fluent.Ack(chunk)); err != nil {
+				return
+			}
+		}
+	}
+}
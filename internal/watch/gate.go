@@ -0,0 +1,85 @@
+package watch
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Gate suspends reading from sources without tearing them down. While paused,
+// sources stop pulling data (file offsets and socket buffers hold the backlog);
+// on resume each source first emits a gap marker, then catches up by replaying
+// everything written in the meantime.
+type Gate struct {
+	mu     sync.Mutex
+	paused bool
+	since  time.Time
+	resume chan struct{}
+}
+
+type gateKey struct{}
+
+// NewGate returns an open gate.
+func NewGate() *Gate {
+	return &Gate{resume: make(chan struct{})}
+}
+
+// WithGate attaches the gate to ctx so every source started with it honors pauses.
+func WithGate(ctx context.Context, g *Gate) context.Context {
+	return context.WithValue(ctx, gateKey{}, g)
+}
+
+func gateFrom(ctx context.Context) *Gate {
+	g, _ := ctx.Value(gateKey{}).(*Gate)
+	return g
+}
+
+// Pause stops sources from reading further lines.
+func (g *Gate) Pause() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.paused {
+		return
+	}
+	g.paused = true
+	g.since = time.Now()
+}
+
+// Resume lets sources continue reading.
+func (g *Gate) Resume() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.paused {
+		return
+	}
+	g.paused = false
+	close(g.resume)
+	g.resume = make(chan struct{})
+}
+
+// Paused reports whether ingestion is currently suspended and since when.
+func (g *Gate) Paused() (bool, time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.paused, g.since
+}
+
+// wait blocks while the gate is paused and returns how long ingestion was held.
+func (g *Gate) wait(ctx context.Context) (time.Duration, bool) {
+	if g == nil {
+		return 0, true
+	}
+	g.mu.Lock()
+	if !g.paused {
+		g.mu.Unlock()
+		return 0, true
+	}
+	since, resume := g.since, g.resume
+	g.mu.Unlock()
+	select {
+	case <-ctx.Done():
+		return 0, false
+	case <-resume:
+		return time.Since(since), true
+	}
+}
@@ -0,0 +1,111 @@
+package watch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"watcher/internal/gelf"
+)
+
+const (
+	gelfUDPPrefix = "gelf-udp:"
+	gelfTCPPrefix = "gelf-tcp:"
+)
+
+// listenGELFUDP receives GELF datagrams (plain, gzip or zlib, chunked or not)
+// and emits each message as one line; see gelf.Message.Line.
+func listenGELFUDP(spec, addr string) (sourceFunc, error) {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen %s: %w", spec, err)
+	}
+	return func(ctx context.Context, out chan<- LogEvent) error {
+		stop := closeOnDone(ctx, conn)
+		defer stop()
+		assembler := gelf.NewAssembler()
+		buf := make([]byte, maxDatagramBytes)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				if ctx.Err() == nil {
+					return fmt.Errorf("read %s: %w", spec, err)
+				}
+				return nil
+			}
+			payload, complete, err := assembler.Add(buf[:n], time.Now())
+			if err == nil && complete {
+				err = emitGELF(ctx, spec, payload, out)
+			}
+			if err != nil && !emit(ctx, out, LogEvent{Path: spec, Err: err}) {
+				return nil
+			}
+		}
+	}, nil
+}
+
+// listenGELFTCP accepts GELF over TCP: uncompressed messages, each terminated
+// by a NUL byte.
+func listenGELFTCP(spec, addr string) (sourceFunc, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen %s: %w", spec, err)
+	}
+	return func(ctx context.Context, out chan<- LogEvent) error {
+		stop := closeOnDone(ctx, ln)
+		defer stop()
+		conns := &sync.WaitGroup{}
+		defer conns.Wait()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				if ctx.Err() == nil {
+					return fmt.Errorf("accept %s: %w", spec, err)
+				}
+				return nil
+			}
+			conns.Add(1)
+			go func(conn net.Conn) {
+				defer conns.Done()
+				stopConn := closeOnDone(ctx, conn)
+				defer stopConn()
+				scanner := bufio.NewScanner(conn)
+				scanner.Buffer(make([]byte, 0, 64<<10), maxLineBytes)
+				scanner.Split(splitNUL)
+				for scanner.Scan() {
+					if err := emitGELF(ctx, spec, scanner.Bytes(), out); err != nil && !emit(ctx, out, LogEvent{Path: spec, Err: err}) {
+						return
+					}
+				}
+			}(conn)
+		}
+	}, nil
+}
+
+func emitGELF(ctx context.Context, spec string, payload []byte, out chan<- LogEvent) error {
+	if len(bytes.TrimSpace(payload)) == 0 {
+		return nil
+	}
+	msg, err := gelf.Parse(payload)
+	if err != nil {
+		return fmt.Errorf("%s: %w", spec, err)
+	}
+	emit(ctx, out, LogEvent{Path: spec, Line: msg.Line()})
+	return nil
+}
+
+// splitNUL is a bufio.SplitFunc for NUL-terminated frames (a trailing frame
+// without a terminator is accepted at EOF).
+func splitNUL(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,179 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// sourcePattern is a --files entry that names a set of files rather than one:
+// a glob such as `/var/log/nginx/*.log`, or a directory. Files matching it
+// are tailed as they are found, at startup and whenever one is created later.
+type sourcePattern struct {
+	spec    string
+	pattern string
+	// dir marks a directory entry: every regular file in it except rotated
+	// copies (`access.log.1`, `error.log.2.gz`) and hidden files.
+	dir bool
+}
+
+// sourcePrefixes are the spec prefixes of sources that are not file paths.
+var sourcePrefixes = []string{unixStreamPrefix, unixDatagramPrefix, gelfUDPPrefix, gelfTCPPrefix, fluentPrefix, AgentsPrefix, wsPrefix, wssPrefix, CloudWatchPrefix, LokiPrefix, NATSPrefix, SerialPrefix, AuditdPrefix, EBPFPrefix, ExecPrefix, journalExportPrefix, evtxPrefix}
+
+// parsePattern reports whether spec is a glob or a directory. A path that
+// exists as a file is always taken literally, even with `[` in its name, and
+// `-` is standard input.
+func parsePattern(spec string) (sourcePattern, bool) {
+	if spec == StdinSource {
+		return sourcePattern{}, false
+	}
+	for _, prefix := range sourcePrefixes {
+		if strings.HasPrefix(spec, prefix) {
+			return sourcePattern{}, false
+		}
+	}
+	info, err := os.Stat(spec)
+	switch {
+	case err == nil && info.IsDir():
+		return sourcePattern{spec: spec, pattern: filepath.Join(spec, "*"), dir: true}, true
+	case err == nil:
+		return sourcePattern{}, false
+	case strings.ContainsAny(spec, "*?["):
+		if _, err := filepath.Match(spec, ""); err != nil {
+			return sourcePattern{}, false
+		}
+		return sourcePattern{spec: spec, pattern: spec}, true
+	}
+	return sourcePattern{}, false
+}
+
+// matches reports whether path is one of the pattern's files.
+func (p sourcePattern) matches(path string) bool {
+	if ok, _ := filepath.Match(p.pattern, path); !ok {
+		return false
+	}
+	if p.dir && (strings.HasPrefix(filepath.Base(path), ".") || isRotatedName(filepath.Base(path))) {
+		return false
+	}
+	info, err := os.Stat(path)
+	return err == nil && (info.Mode().IsRegular() || info.Mode()&os.ModeNamedPipe != 0)
+}
+
+// isRotatedName recognises logrotate copies: a numeric last extension, with
+// or without a trailing .gz, or any .gz file.
+func isRotatedName(name string) bool {
+	if strings.HasSuffix(name, ".gz") {
+		return true
+	}
+	ext := strings.TrimPrefix(filepath.Ext(name), ".")
+	if ext == "" {
+		return false
+	}
+	for _, r := range ext {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// expand lists the pattern's files that exist now, sorted.
+func (p sourcePattern) expand() []string {
+	paths, _ := filepath.Glob(p.pattern)
+	var files []string
+	for _, path := range paths {
+		if p.matches(path) {
+			files = append(files, path)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// dirs lists the directories to watch for new files. Wildcards in the
+// directory part are resolved once, so a directory created later is not
+// watched.
+func (p sourcePattern) dirs() []string {
+	dir := filepath.Dir(p.pattern)
+	if !strings.ContainsAny(dir, "*?[") {
+		return []string{dir}
+	}
+	paths, _ := filepath.Glob(dir)
+	var dirs []string
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			dirs = append(dirs, path)
+		}
+	}
+	return dirs
+}
+
+// watchPatterns watches the directories of patterns for new files.
+func watchPatterns(patterns []sourcePattern) (*fsnotify.Watcher, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("watch files: %w", err)
+	}
+	for _, p := range patterns {
+		dirs := p.dirs()
+		if len(dirs) == 0 {
+			w.Close()
+			return nil, fmt.Errorf("watch %s: no such directory", p.spec)
+		}
+		for _, dir := range dirs {
+			if err := w.Add(dir); err != nil {
+				w.Close()
+				return nil, fmt.Errorf("watch %s: %w", p.spec, err)
+			}
+		}
+	}
+	return w, nil
+}
+
+// followPatterns calls start for every file that appears in w's directories
+// and matches one of patterns, until ctx is done. start ignores files already
+// tailed; one that fails to open is reported and tried again on its next
+// event.
+func followPatterns(ctx context.Context, w *fsnotify.Watcher, patterns []sourcePattern, start func(string) error, out chan<- LogEvent) {
+	defer w.Close()
+	// tailed skips the stat for writes to files already being tailed.
+	tailed := make(map[string]bool)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case evt, ok := <-w.Events:
+			if !ok {
+				return
+			}
+			if tailed[evt.Name] || !evt.Has(fsnotify.Create) && !evt.Has(fsnotify.Write) {
+				continue
+			}
+			for _, p := range patterns {
+				if !p.matches(evt.Name) {
+					continue
+				}
+				if err := start(evt.Name); err != nil {
+					if !emit(ctx, out, LogEvent{Path: p.spec, Err: err}) {
+						return
+					}
+				} else {
+					tailed[evt.Name] = true
+				}
+				break
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return
+			}
+			if !emit(ctx, out, LogEvent{Path: patterns[0].spec, Err: fmt.Errorf("watch files: %w", err)}) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"watcher/internal/loki"
+)
+
+// LokiPrefix marks a Grafana Loki source: `loki:{app="nginx"} |= "error"`.
+const LokiPrefix = "loki:"
+
+// tailLoki live-tails a LogQL query from the time it is first opened; after a
+// restart it resumes just after the last delivered entry. Lines keep the time
+// Loki recorded for them.
+func tailLoki(spec string, state *sourceState) (sourceFunc, error) {
+	query := strings.TrimSpace(strings.TrimPrefix(spec, LokiPrefix))
+	if query == "" {
+		return nil, fmt.Errorf("%s: want loki:<LogQL query>", spec)
+	}
+	client, err := loki.NewClient()
+	if err != nil {
+		return nil, err
+	}
+	if state.since.IsZero() {
+		state.since = time.Now()
+	}
+	return func(ctx context.Context, out chan<- LogEvent) error {
+		err := client.Tail(ctx, query, state.since, func(entry loki.Entry) bool {
+			if entry.Dropped > 0 {
+				return emit(ctx, out, LogEvent{Path: spec, Err: fmt.Errorf("%s: Loki dropped %d entries, tail too slow", spec, entry.Dropped)})
+			}
+			if !emit(ctx, out, LogEvent{Path: spec, Line: entry.Line, Time: entry.Time}) {
+				return false
+			}
+			state.since = entry.Time.Add(time.Nanosecond)
+			return true
+		})
+		if err != nil && ctx.Err() == nil {
+			return fmt.Errorf("%s: %w", spec, err)
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,78 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"watcher/internal/nats"
+)
+
+// NATSPrefix marks a NATS source: `nats:subject` subscribes to a subject
+// (wildcards allowed), `nats:subject@durable` pulls from a JetStream durable
+// consumer of the stream that stores it.
+const NATSPrefix = "nats:"
+
+// subscribeNATS delivers message payloads as lines, a payload with several
+// lines becoming several. A core subscription sees only what is published
+// while it is connected; a durable consumer acknowledges each message once
+// its lines are handed on, so after a restart or reconnect it resumes after
+// the last one delivered.
+func subscribeNATS(spec string) (sourceFunc, error) {
+	subject, durable, _ := strings.Cut(strings.TrimPrefix(spec, NATSPrefix), "@")
+	if subject == "" || strings.ContainsAny(subject, " \t") {
+		return nil, fmt.Errorf("%s: want nats:subject[@durable]", spec)
+	}
+	return func(ctx context.Context, out chan<- LogEvent) error {
+		conn, err := nats.Dial(ctx)
+		if err != nil {
+			return fmt.Errorf("%s: %w", spec, err)
+		}
+		stop := closeOnDone(ctx, conn)
+		defer stop()
+		defer conn.Close()
+		deliver := func(msg nats.Msg) bool {
+			for _, line := range strings.Split(strings.TrimRight(string(msg.Data), "\r\n"), "\n") {
+				if !emit(ctx, out, LogEvent{Path: spec, Line: strings.TrimRight(line, "\r")}) {
+					return false
+				}
+			}
+			return true
+		}
+		if durable != "" {
+			var consumer *nats.Consumer
+			if consumer, err = conn.Durable(subject, durable); err == nil {
+				err = consumer.Each(deliver)
+			}
+		} else {
+			err = subscribeCore(conn, subject, deliver)
+		}
+		if ctx.Err() != nil || err == nil {
+			return nil
+		}
+		if errors.Is(err, net.ErrClosed) {
+			err = errors.New("connection closed")
+		}
+		return fmt.Errorf("%s: %w", spec, err)
+	}, nil
+}
+
+// subscribeCore delivers a plain subscription until deliver returns false or
+// the connection fails.
+func subscribeCore(conn *nats.Conn, subject string, deliver func(nats.Msg) bool) error {
+	sid, err := conn.Subscribe(subject)
+	if err != nil {
+		return err
+	}
+	for {
+		msg, err := conn.Next()
+		if err != nil {
+			return err
+		}
+		if msg.Sid == sid && !deliver(msg) {
+			return nil
+		}
+	}
+}
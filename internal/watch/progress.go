@@ -0,0 +1,131 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+)
+
+// SourceStatus describes how far a source has read. Retries is non-zero while
+// the source is down and being reopened; RetryErr and NextRetry describe why and when.
+// GaveUp is set once the retry limit was reached and the source stays closed.
+type SourceStatus struct {
+	Path      string
+	LastLine  time.Time
+	Offset    int64
+	Size      int64
+	Retries   int
+	RetryErr  string
+	NextRetry time.Time
+	GaveUp    bool
+	statAt    time.Time
+}
+
+// Behind returns the bytes still unread at the last size check (0 when live or unknown).
+func (s SourceStatus) Behind() int64 {
+	if s.Size <= s.Offset {
+		return 0
+	}
+	return s.Size - s.Offset
+}
+
+// Progress records per-source read positions so the UI can show ingest lag
+// even for lines the pipeline filters out.
+type Progress struct {
+	mu      sync.Mutex
+	sources map[string]*SourceStatus
+}
+
+type progressKey struct{}
+
+// sizeCheckInterval throttles how often file sizes are re-read for lag reporting.
+const sizeCheckInterval = time.Second
+
+// NewProgress returns an empty tracker.
+func NewProgress() *Progress {
+	return &Progress{sources: make(map[string]*SourceStatus)}
+}
+
+// WithProgress attaches the tracker to ctx so sources started with it report progress.
+func WithProgress(ctx context.Context, p *Progress) context.Context {
+	return context.WithValue(ctx, progressKey{}, p)
+}
+
+func progressFrom(ctx context.Context) *Progress {
+	p, _ := ctx.Value(progressKey{}).(*Progress)
+	return p
+}
+
+// Snapshot copies the current status of every source that has produced a line or failed.
+func (p *Progress) Snapshot() map[string]SourceStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make(map[string]SourceStatus, len(p.sources))
+	for path, st := range p.sources {
+		out[path] = *st
+	}
+	return out
+}
+
+func (p *Progress) observe(evt LogEvent) {
+	if p == nil || evt.Err != nil || evt.Gap > 0 || evt.Conn != nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	st := p.source(evt.Path)
+	st.Retries, st.RetryErr = 0, ""
+	now := time.Now()
+	st.LastLine = now
+	st.Offset = evt.Offset
+	if evt.Offset > 0 && now.Sub(st.statAt) >= sizeCheckInterval {
+		st.statAt = now
+		if info, err := os.Stat(evt.Path); err == nil && info.Mode().IsRegular() {
+			st.Size = info.Size()
+		}
+	}
+}
+
+func (p *Progress) retrying(path string, attempt int, err error, next time.Time) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	st := p.source(path)
+	st.Retries = attempt
+	st.RetryErr = err.Error()
+	st.NextRetry = next
+}
+
+func (p *Progress) gaveUp(path string, err error) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	st := p.source(path)
+	st.GaveUp = true
+	st.RetryErr = err.Error()
+}
+
+func (p *Progress) recovered(path string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	st := p.source(path)
+	st.Retries, st.RetryErr = 0, ""
+}
+
+// source returns the status entry for path, creating it; callers hold p.mu.
+func (p *Progress) source(path string) *SourceStatus {
+	st, ok := p.sources[path]
+	if !ok {
+		st = &SourceStatus{Path: path}
+		p.sources[path] = st
+	}
+	return st
+}
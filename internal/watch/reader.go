@@ -0,0 +1,201 @@
+package watch
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"watcher/internal/clock"
+)
+
+// StreamReader emits each line of r as an event from the source named name,
+// for output that is produced rather than written to a file (a command's
+// stdout, such as `log stream` on macOS). It has no restart: the stream ends
+// with r, after an event carrying the read error if there was one. Pausing
+// ingestion stops reading, so a pipe backs up into its writer.
+func StreamReader(ctx context.Context, name string, r io.Reader) <-chan LogEvent {
+	out := make(chan LogEvent)
+	go func() {
+		defer close(out)
+		if err := scanLines(ctx, name, r, out); err != nil {
+			emit(ctx, out, LogEvent{Path: name, Err: err})
+		}
+	}()
+	return out
+}
+
+// replayHold caps how many live lines ReplayThenStream holds while history is
+// still replaying; beyond it the oldest are dropped and reported.
+const replayHold = 100000
+
+// ReplayThenStream is StreamReader over history and then live: history's
+// lines carry the time they were written (see ParseTimestamp; a line without
+// a stamp takes the one before it), live's are stamped on arrival. live is
+// read while history replays, into a buffer of up to replayHold lines, so
+// its writer never blocks on a long replay (held lines are stamped when they
+// are passed on); after that, pausing ingestion backs live up as with
+// StreamReader. Live lines stamped no later than the last replayed one are
+// skipped, so live output that began before history was fully read is not
+// shown twice.
+func ReplayThenStream(ctx context.Context, name string, history, live io.Reader) <-chan LogEvent {
+	out := make(chan LogEvent)
+	lines := make(chan string)
+	var liveErr error
+	go func() {
+		defer close(lines)
+		liveErr = eachLine(live, func(line string) bool {
+			select {
+			case lines <- line:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	replayed := make(chan struct{})
+	type holdResult struct {
+		lines   []string
+		dropped int
+	}
+	held := make(chan holdResult, 1)
+	go func() {
+		var hold holdResult
+		defer func() { held <- hold }()
+		for {
+			select {
+			case l, ok := <-lines:
+				if !ok {
+					return
+				}
+				if len(hold.lines) == replayHold {
+					hold.lines = hold.lines[1:]
+					hold.dropped++
+				}
+				hold.lines = append(hold.lines, l)
+			case <-replayed:
+				return
+			}
+		}
+	}()
+	go func() {
+		defer close(out)
+		var last time.Time
+		err := eachLine(history, func(line string) bool {
+			if ts, ok := ParseTimestamp(line, time.Now()); ok {
+				last = ts
+			}
+			return emit(ctx, out, LogEvent{Path: name, Line: line, Time: last})
+		})
+		if err != nil {
+			emit(ctx, out, LogEvent{Path: name, Err: fmt.Errorf("replay %s: %w", name, err)})
+		}
+		close(replayed)
+		hold := <-held
+		if ctx.Err() != nil {
+			return
+		}
+		if hold.dropped > 0 {
+			emit(ctx, out, LogEvent{Path: name, Err: fmt.Errorf("read %s: dropped %d live lines during the replay", name, hold.dropped)})
+		}
+		caughtUp := last.IsZero()
+		send := func(line string) bool {
+			if !caughtUp {
+				ts, ok := ParseTimestamp(line, time.Now())
+				if !ok || !ts.After(last) {
+					return true
+				}
+				caughtUp = true
+			}
+			return emit(ctx, out, LogEvent{Path: name, Line: line})
+		}
+		for _, l := range hold.lines {
+			if !send(l) {
+				return
+			}
+		}
+		for l := range lines {
+			if !send(l) {
+				return
+			}
+		}
+		if liveErr != nil && ctx.Err() == nil {
+			emit(ctx, out, LogEvent{Path: name, Err: fmt.Errorf("read %s: %w", name, liveErr)})
+		}
+	}()
+	return out
+}
+
+// eachLine calls fn for each line of r until fn returns false.
+func eachLine(r io.Reader, fn func(string) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxLineBytes)
+	for scanner.Scan() {
+		if !fn(strings.TrimRight(scanner.Text(), "\r")) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// Paced passes events on spaced by the gaps between their Time stamps,
+// divided by speed, so a replay unfolds with its original timing (2 plays it
+// twice as fast). Events without a Time (live lines, errors, markers) and
+// stamps that step back pass at once. A speed of zero or less returns in
+// unchanged; a nil clk is the wall clock.
+func Paced(ctx context.Context, in <-chan LogEvent, speed float64, clk clock.Clock) <-chan LogEvent {
+	if speed <= 0 {
+		return in
+	}
+	pace := pacer{speed: speed, clk: clock.Or(clk)}
+	out := make(chan LogEvent)
+	go func() {
+		defer close(out)
+		for {
+			var evt LogEvent
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-in:
+				if !ok {
+					return
+				}
+				evt = e
+			}
+			if !pace.wait(ctx, evt.Time) || !emit(ctx, out, evt) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// pacer spaces stamped events by the gaps between their times divided by
+// speed; see Paced.
+type pacer struct {
+	speed float64
+	clk   clock.Clock
+	last  time.Time
+}
+
+// wait blocks for the gap between at and the previous stamp, if at is set,
+// later, and speed positive. It reports false when ctx ends first.
+func (p *pacer) wait(ctx context.Context, at time.Time) bool {
+	if at.IsZero() || p.speed <= 0 {
+		return true
+	}
+	gap := at.Sub(p.last)
+	first := p.last.IsZero()
+	p.last = at
+	if first || gap <= 0 {
+		return true
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-p.clk.After(time.Duration(float64(gap) / p.speed)):
+		return true
+	}
+}
@@ -0,0 +1,157 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	retryBase   = 500 * time.Millisecond
+	retryMax    = 30 * time.Second
+	retryJitter = 0.2
+	// healthyRun is how long a source must run before a failure counts as
+	// new trouble rather than continuing the previous backoff sequence.
+	healthyRun = 30 * time.Second
+)
+
+// backoff yields exponentially growing delays with ±20% jitter so sources
+// sharing a failing mount do not retry in lockstep.
+type backoff struct {
+	attempt int
+}
+
+func (b *backoff) next() time.Duration {
+	b.attempt++
+	delay := retryBase << min(b.attempt-1, 16)
+	if delay > retryMax || delay <= 0 {
+		delay = retryMax
+	}
+	jitter := 1 + retryJitter*(2*rand.Float64()-1)
+	return time.Duration(float64(delay) * jitter)
+}
+
+func (b *backoff) reset() {
+	b.attempt = 0
+}
+
+// ConnState is a change in a source's connection.
+type ConnState string
+
+const (
+	// ConnDown: the source stopped and is being reopened.
+	ConnDown ConnState = "down"
+	// ConnUp: the source was reopened after an outage.
+	ConnUp ConnState = "up"
+	// ConnGaveUp: the retry limit was reached; the source stays closed.
+	ConnGaveUp ConnState = "gave-up"
+)
+
+// ConnEvent marks where ingest from a source was degraded. Reason is the
+// error that took the source down; Retries and Outage say how many reopen
+// attempts were made and how long it was down (for up and gave-up).
+type ConnEvent struct {
+	State   ConnState
+	Reason  string
+	Retries int
+	Outage  time.Duration
+}
+
+// String describes the event for a marker or log line.
+func (c ConnEvent) String() string {
+	switch c.State {
+	case ConnDown:
+		return "source down: " + c.Reason
+	case ConnUp:
+		return fmt.Sprintf("source back after %s (%d %s)", c.Outage.Round(time.Second), c.Retries, plural(c.Retries, "retry", "retries"))
+	default:
+		return fmt.Sprintf("gave up after %d %s over %s: %s", c.Retries, plural(c.Retries, "retry", "retries"), c.Outage.Round(time.Second), c.Reason)
+	}
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+type retryLimitKey struct{}
+
+// WithRetryLimit makes sources started with ctx give up after limit
+// consecutive failed reopen attempts; 0 retries forever.
+func WithRetryLimit(ctx context.Context, limit int) context.Context {
+	return context.WithValue(ctx, retryLimitKey{}, limit)
+}
+
+func retryLimitFrom(ctx context.Context) int {
+	limit, _ := ctx.Value(retryLimitKey{}).(int)
+	return limit
+}
+
+// superviseSource runs a source and, whenever it stops while ctx is still live
+// (permission lost, NFS hiccup, socket error), reopens it with jittered
+// exponential backoff until the context's retry limit (see WithRetryLimit).
+// A source that finished (an imported archive) is not reopened. Each outage
+// is bracketed by ConnEvents in the stream; retry state is reported through
+// the context's Progress. Both name the source as its events do (SourceName).
+func superviseSource(ctx context.Context, spec string, run sourceFunc, state *sourceState, out chan<- LogEvent) {
+	name := SourceName(spec)
+	var b backoff
+	limit := retryLimitFrom(ctx)
+	for {
+		started := time.Now()
+		err := run(ctx, out)
+		if ctx.Err() != nil || errors.Is(err, errSourceFinished) {
+			return
+		}
+		if err == nil {
+			err = fmt.Errorf("%s: source ended", name)
+		}
+		if time.Since(started) >= healthyRun {
+			b.reset()
+		}
+		down := time.Now()
+		if !emit(ctx, out, LogEvent{Path: name, Conn: &ConnEvent{State: ConnDown, Reason: err.Error()}}) {
+			return
+		}
+		retries := 0
+		for {
+			if limit > 0 && b.attempt >= limit {
+				progressFrom(ctx).gaveUp(name, err)
+				emit(ctx, out, LogEvent{Path: name, Conn: &ConnEvent{State: ConnGaveUp, Reason: err.Error(), Retries: retries, Outage: time.Since(down)}})
+				return
+			}
+			delay := b.next()
+			retries++
+			progressFrom(ctx).retrying(name, b.attempt, err, time.Now().Add(delay))
+			if !emit(ctx, out, LogEvent{Path: name, Err: fmt.Errorf("%w (retry %d in %s)", err, b.attempt, delay.Round(100*time.Millisecond))}) {
+				return
+			}
+			if !sleepContext(ctx, delay) {
+				return
+			}
+			run, err = openSource(ctx, spec, state)
+			if err == nil {
+				break
+			}
+		}
+		progressFrom(ctx).recovered(name)
+		if !emit(ctx, out, LogEvent{Path: name, Conn: &ConnEvent{State: ConnUp, Retries: retries, Outage: time.Since(down)}}) {
+			return
+		}
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,127 @@
+package watch
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type backfillKey struct{}
+
+// WithBackfillRotated makes regular files started with ctx replay their
+// rotated siblings (see rotatedSiblings) before tailing the live file, so a
+// session starts with recent history.
+func WithBackfillRotated(ctx context.Context, on bool) context.Context {
+	return context.WithValue(ctx, backfillKey{}, on)
+}
+
+func backfillFrom(ctx context.Context) bool {
+	on, _ := ctx.Value(backfillKey{}).(bool)
+	return on
+}
+
+// rotatedSiblings lists the copies logrotate left of file — `auth.log.1`,
+// `auth.log.2.gz`, … — oldest (highest index) first. When an index exists
+// both plain and compressed, the plain copy is used.
+func rotatedSiblings(file string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Dir(file))
+	if err != nil {
+		return nil, err
+	}
+	prefix := filepath.Base(file) + "."
+	byIndex := make(map[int]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		suffix := strings.TrimPrefix(name, prefix)
+		gz := strings.HasSuffix(suffix, ".gz")
+		index, err := strconv.Atoi(strings.TrimSuffix(suffix, ".gz"))
+		if err != nil || index < 1 {
+			continue
+		}
+		if _, seen := byIndex[index]; seen && gz {
+			continue
+		}
+		byIndex[index] = filepath.Join(filepath.Dir(file), name)
+	}
+	indexes := make([]int, 0, len(byIndex))
+	for index := range byIndex {
+		indexes = append(indexes, index)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(indexes)))
+	paths := make([]string, len(indexes))
+	for i, index := range indexes {
+		paths[i] = byIndex[index]
+	}
+	return paths, nil
+}
+
+// backfillRotated emits the lines of file's rotated siblings, oldest first,
+// as if read from file (with no offset, since they are not in it). A sibling
+// that cannot be read is reported as an error event and skipped.
+func backfillRotated(ctx context.Context, file string, out chan<- LogEvent) {
+	paths, err := rotatedSiblings(file)
+	if err != nil {
+		emit(ctx, out, LogEvent{Path: file, Err: fmt.Errorf("backfill %s: %w", file, err)})
+		return
+	}
+	for _, path := range paths {
+		if err := readRotated(ctx, file, path, out); err != nil {
+			if !emit(ctx, out, LogEvent{Path: file, Err: err}) {
+				return
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// asBacklog runs replay with a channel whose events reach out marked as
+// Backlog.
+func asBacklog(ctx context.Context, out chan<- LogEvent, replay func(chan<- LogEvent)) {
+	marked := make(chan LogEvent)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for evt := range marked {
+			evt.Backlog = evt.Err == nil
+			select {
+			case <-ctx.Done():
+			case out <- evt:
+			}
+		}
+	}()
+	replay(marked)
+	close(marked)
+	<-done
+}
+
+func readRotated(ctx context.Context, file, path string, out chan<- LogEvent) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("backfill %s: %w", file, err)
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("backfill %s: %s: %w", file, path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	if err := scanLines(ctx, file, r, out); err != nil {
+		return fmt.Errorf("backfill %s: %s: %w", file, path, err)
+	}
+	return nil
+}
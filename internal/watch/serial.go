@@ -0,0 +1,24 @@
+package watch
+
+import (
+	"io"
+	"strings"
+
+	"watcher/internal/serial"
+)
+
+// SerialPrefix marks a serial console: `serial:/dev/ttyUSB0@115200` (the
+// rate defaults to 115200).
+const SerialPrefix = "serial:"
+
+// openSerial reads console output line by line. Unplugging the adapter ends
+// the read; the supervisor reopens the device when it reappears.
+func openSerial(spec string) (sourceFunc, error) {
+	device, baud, err := serial.ParseSpec(strings.TrimPrefix(spec, SerialPrefix))
+	if err != nil {
+		return nil, err
+	}
+	return readerSource(spec, func() (io.ReadCloser, error) {
+		return serial.Open(device, baud)
+	})
+}
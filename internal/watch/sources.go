@@ -0,0 +1,295 @@
+package watch
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sourceFunc pumps events from one source until ctx is cancelled or the source
+// ends; the returned error says why a source stopped on its own.
+type sourceFunc func(ctx context.Context, out chan<- LogEvent) error
+
+// sourceState survives restarts of one source so it can resume where it stopped.
+type sourceState struct {
+	offset int64
+	// backfilled is set once rotated siblings were replayed, so a reopened
+	// file does not replay them again.
+	backfilled bool
+	// since is where a remote source (CloudWatch, Loki) resumes. sinceIDs
+	// holds the CloudWatch events already delivered at that millisecond, so
+	// resuming there neither repeats nor skips any.
+	since    time.Time
+	sinceIDs []string
+	// backlog is the file's size when it was first opened (opened): lines
+	// ending at or before it were there before spectra started.
+	backlog int64
+	opened  bool
+}
+
+const (
+	unixStreamPrefix   = "unix:"
+	unixDatagramPrefix = "unixgram:"
+	maxLineBytes       = 1 << 20
+	maxDatagramBytes   = 64 << 10
+)
+
+// openSource resolves a --files entry into a source:
+//   - `-` reads standard input until it closes (see stdin.go);
+//   - `unix:/path` listens on a stream socket and reads lines from every client;
+//   - `unixgram:/path` binds a datagram socket and treats each datagram as lines;
+//   - `gelf-udp:host:port` / `gelf-tcp:host:port` receive GELF messages (see gelf.go);
+//   - `fluent:host:port` accepts the Fluentd forward protocol (see fluent.go);
+//   - `agents:host:port` accepts spectra agents over TLS (see agents.go);
+//   - `ws://` / `wss://` URLs read a WebSocket's text messages, reconnecting
+//     when it closes (see websocket.go);
+//   - a path to a FIFO is read continuously across writer reconnects;
+//   - `cloudwatch:group[:stream]` polls a CloudWatch Logs group (see cloudwatch.go);
+//   - `loki:<LogQL query>` live-tails Grafana Loki (see loki.go);
+//   - `nats:subject[@durable]` subscribes to NATS or JetStream (see nats.go);
+//   - `serial:/dev/ttyUSB0@115200` reads a serial console (see serial.go);
+//   - `auditd:` / `auditd:/path` reads Linux audit events (see auditd.go);
+//   - `ebpf:exec` reports process executions from eBPF (see ebpf.go);
+//   - `exec:command` reads a command's stdout, restarting it when it exits
+//     (see command.go);
+//   - `journal-export:path` / `evtx:path`, or a file that is one of those
+//     archives, is imported once and then finishes (see archive.go);
+//   - anything else is tailed as a regular file.
+func openSource(ctx context.Context, spec string, state *sourceState) (sourceFunc, error) {
+	switch {
+	case spec == StdinSource:
+		return readStdin(), nil
+	case strings.HasPrefix(spec, wsPrefix), strings.HasPrefix(spec, wssPrefix):
+		return openWebSocket(spec)
+	case strings.HasPrefix(spec, AgentsPrefix):
+		return listenAgents(ctx, spec, strings.TrimPrefix(spec, AgentsPrefix))
+	case strings.HasPrefix(spec, gelfUDPPrefix):
+		return listenGELFUDP(spec, strings.TrimPrefix(spec, gelfUDPPrefix))
+	case strings.HasPrefix(spec, gelfTCPPrefix):
+		return listenGELFTCP(spec, strings.TrimPrefix(spec, gelfTCPPrefix))
+	case strings.HasPrefix(spec, fluentPrefix):
+		return listenFluent(spec, strings.TrimPrefix(spec, fluentPrefix))
+	case strings.HasPrefix(spec, unixDatagramPrefix):
+		return listenUnixgram(spec, strings.TrimPrefix(spec, unixDatagramPrefix))
+	case strings.HasPrefix(spec, unixStreamPrefix):
+		return listenUnix(spec, strings.TrimPrefix(spec, unixStreamPrefix))
+	case strings.HasPrefix(spec, CloudWatchPrefix):
+		return tailCloudWatch(spec, state)
+	case strings.HasPrefix(spec, LokiPrefix):
+		return tailLoki(spec, state)
+	case strings.HasPrefix(spec, NATSPrefix):
+		return subscribeNATS(spec)
+	case strings.HasPrefix(spec, SerialPrefix):
+		return openSerial(spec)
+	case strings.HasPrefix(spec, AuditdPrefix):
+		return openAuditd(spec)
+	case strings.HasPrefix(spec, EBPFPrefix):
+		return openEBPF(spec)
+	case strings.HasPrefix(spec, ExecPrefix):
+		return runCommand(spec)
+	}
+	if info, err := os.Stat(spec); err == nil && info.Mode()&os.ModeNamedPipe != 0 {
+		return readNamedPipe(spec)
+	}
+	if path, format, ok := archiveSpec(spec); ok {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("open %s: %w", spec, err)
+		}
+		return readArchive(spec, path, format, state), nil
+	}
+	return tailRegularFile(spec, state)
+}
+
+// SourceName is the name a source's events carry as their Path, and the one
+// the supervisor's ConnEvents and errors and Progress report it under: an
+// exec: command's label (see ParseCommand), `stdin` for `-`, else the spec
+// itself.
+func SourceName(spec string) string {
+	switch {
+	case spec == StdinSource:
+		return stdinPath
+	case strings.HasPrefix(spec, ExecPrefix):
+		label, _ := ParseCommand(spec)
+		return label
+	}
+	return spec
+}
+
+func readNamedPipe(path string) (sourceFunc, error) {
+	return readerSource(path, func() (io.ReadCloser, error) {
+		// O_RDWR keeps a writer reference open so the pipe never reports EOF
+		// when the logging daemon restarts, and the open itself does not block.
+		f, err := os.OpenFile(path, os.O_RDWR, 0)
+		if err != nil {
+			return nil, fmt.Errorf("open pipe %s: %w", path, err)
+		}
+		return f, nil
+	})
+}
+
+// readerSource is a source over a byte stream that is neither a file to tail
+// nor a listener: a pipe or a device. open runs now, so a bad spec fails at
+// startup, and again on each restart. Every line read is an event; the
+// stream ending is an error, so the supervisor reopens it.
+func readerSource(spec string, open func() (io.ReadCloser, error)) (sourceFunc, error) {
+	r, err := open()
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, out chan<- LogEvent) error {
+		stop := closeOnDone(ctx, r)
+		defer stop()
+		if err := scanLines(ctx, spec, r, out); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		return fmt.Errorf("%s closed", spec)
+	}, nil
+}
+
+func listenUnix(spec, path string) (sourceFunc, error) {
+	removeStaleSocket(path)
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("listen %s: %w", spec, err)
+	}
+	return func(ctx context.Context, out chan<- LogEvent) error {
+		stop := closeOnDone(ctx, ln)
+		defer stop()
+		conns := &sync.WaitGroup{}
+		defer conns.Wait()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				if ctx.Err() == nil {
+					return fmt.Errorf("accept %s: %w", spec, err)
+				}
+				return nil
+			}
+			conns.Add(1)
+			go func(conn net.Conn) {
+				defer conns.Done()
+				stopConn := closeOnDone(ctx, conn)
+				defer stopConn()
+				// A broken client connection only ends that client.
+				if err := scanLines(ctx, spec, conn, out); err != nil {
+					emit(ctx, out, LogEvent{Path: spec, Err: err})
+				}
+			}(conn)
+		}
+	}, nil
+}
+
+func listenUnixgram(spec, path string) (sourceFunc, error) {
+	removeStaleSocket(path)
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("listen %s: %w", spec, err)
+	}
+	return func(ctx context.Context, out chan<- LogEvent) error {
+		stop := closeOnDone(ctx, conn)
+		defer stop()
+		defer os.Remove(path)
+		buf := make([]byte, maxDatagramBytes)
+		for {
+			n, _, err := conn.ReadFromUnix(buf)
+			if err != nil {
+				if ctx.Err() == nil {
+					return fmt.Errorf("read %s: %w", spec, err)
+				}
+				return nil
+			}
+			for _, line := range strings.Split(strings.TrimRight(string(buf[:n]), "\r\n"), "\n") {
+				if !emit(ctx, out, LogEvent{Path: spec, Line: strings.TrimRight(line, "\r")}) {
+					return nil
+				}
+			}
+		}
+	}, nil
+}
+
+// scanLines emits each line of r, returning the read error (if any) that ended it.
+func scanLines(ctx context.Context, path string, r io.Reader, out chan<- LogEvent) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxLineBytes)
+	for scanner.Scan() {
+		if !emit(ctx, out, LogEvent{Path: path, Line: strings.TrimRight(scanner.Text(), "\r")}) {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil && !errors.Is(err, net.ErrClosed) && !errors.Is(err, os.ErrClosed) {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	return nil
+}
+
+// emit delivers evt unless ctx is cancelled first. It is also where sources
+// park while the context's Gate is paused, announcing the gap on resume, and
+// where the context's Chaos (if any) delays, duplicates, or fails events.
+func emit(ctx context.Context, out chan<- LogEvent, evt LogEvent) bool {
+	held, ok := gateFrom(ctx).wait(ctx)
+	if !ok {
+		return false
+	}
+	if held > 0 {
+		select {
+		case <-ctx.Done():
+			return false
+		case out <- LogEvent{Path: evt.Path, Gap: held}:
+		}
+	}
+	f := chaosFrom(ctx).roll(evt.Path)
+	if f.delay > 0 && !sleepContext(ctx, f.delay) {
+		return false
+	}
+	if f.err != nil {
+		select {
+		case <-ctx.Done():
+			return false
+		case out <- LogEvent{Path: evt.Path, Err: f.err}:
+		}
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case out <- evt:
+		progressFrom(ctx).observe(evt)
+	}
+	if f.duplicate {
+		select {
+		case <-ctx.Done():
+			return false
+		case out <- evt:
+		}
+	}
+	return true
+}
+
+// closeOnDone closes c when ctx is cancelled, unblocking pending reads; the returned func closes it early.
+func closeOnDone(ctx context.Context, c io.Closer) func() {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		c.Close()
+	}()
+	return func() { close(done) }
+}
+
+// removeStaleSocket clears a socket file left behind by a previous run.
+func removeStaleSocket(path string) {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+}
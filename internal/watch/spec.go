@@ -0,0 +1,82 @@
+package watch
+
+import (
+	"os"
+	"strings"
+)
+
+// SourceKind is what a --files entry names, for tools that describe sources
+// instead of opening them (`export-config`).
+type SourceKind string
+
+const (
+	SourceFile       SourceKind = "file"
+	SourceGlob       SourceKind = "glob"
+	SourceDir        SourceKind = "dir"
+	SourcePipe       SourceKind = "fifo"
+	SourceUnix       SourceKind = "unix"
+	SourceUnixgram   SourceKind = "unixgram"
+	SourceGELFUDP    SourceKind = "gelf-udp"
+	SourceGELFTCP    SourceKind = "gelf-tcp"
+	SourceFluent     SourceKind = "fluent"
+	SourceCloudWatch SourceKind = "cloudwatch"
+	SourceLoki       SourceKind = "loki"
+	SourceNATS       SourceKind = "nats"
+	SourceSerial     SourceKind = "serial"
+	SourceAuditd     SourceKind = "auditd"
+	SourceEBPF       SourceKind = "ebpf"
+	SourceExec       SourceKind = "exec"
+	SourceStdin      SourceKind = "stdin"
+	SourceAgents     SourceKind = "agents"
+	SourceWebSocket  SourceKind = "websocket"
+	SourceArchive    SourceKind = "archive"
+)
+
+// ClassifySource reports the kind of spec and its target: the path, address,
+// or log group with any prefix removed. It resolves specs as openSource does,
+// but a path that does not exist here is taken as a file.
+func ClassifySource(spec string) (SourceKind, string) {
+	if spec == StdinSource {
+		return SourceStdin, spec
+	}
+	if strings.HasPrefix(spec, wsPrefix) || strings.HasPrefix(spec, wssPrefix) {
+		return SourceWebSocket, spec
+	}
+	for _, p := range []struct {
+		prefix string
+		kind   SourceKind
+	}{
+		{gelfUDPPrefix, SourceGELFUDP},
+		{gelfTCPPrefix, SourceGELFTCP},
+		{fluentPrefix, SourceFluent},
+		{AgentsPrefix, SourceAgents},
+		{unixDatagramPrefix, SourceUnixgram},
+		{unixStreamPrefix, SourceUnix},
+		{CloudWatchPrefix, SourceCloudWatch},
+		{LokiPrefix, SourceLoki},
+		{NATSPrefix, SourceNATS},
+		{SerialPrefix, SourceSerial},
+		{AuditdPrefix, SourceAuditd},
+		{EBPFPrefix, SourceEBPF},
+		{ExecPrefix, SourceExec},
+		{journalExportPrefix, SourceArchive},
+		{evtxPrefix, SourceArchive},
+	} {
+		if strings.HasPrefix(spec, p.prefix) {
+			return p.kind, strings.TrimPrefix(spec, p.prefix)
+		}
+	}
+	if p, ok := parsePattern(spec); ok {
+		if p.dir {
+			return SourceDir, spec
+		}
+		return SourceGlob, spec
+	}
+	if info, err := os.Stat(spec); err == nil && info.Mode()&os.ModeNamedPipe != 0 {
+		return SourcePipe, spec
+	}
+	if _, _, ok := archiveSpec(spec); ok {
+		return SourceArchive, spec
+	}
+	return SourceFile, spec
+}
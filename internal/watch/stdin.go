@@ -0,0 +1,40 @@
+package watch
+
+import (
+	"context"
+	"os"
+)
+
+// StdinSource is the spec that reads spectra's own standard input, so a
+// stream can be piped in: `journalctl -f | spectra-watch -`.
+const StdinSource = "-"
+
+// stdinPath is the name of the standard input source (see SourceName): the
+// path its events carry and what its status is reported under.
+const stdinPath = "stdin"
+
+// readStdin reads standard input to its end, then finishes: a pipe that has
+// closed cannot be reopened, so there is nothing for the supervisor to
+// restart. A read error is still retried.
+func readStdin() sourceFunc {
+	return func(ctx context.Context, out chan<- LogEvent) error {
+		// Stdin is closed only on shutdown, to unblock the read; after a
+		// read error the retry reads on from the same stream.
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-ctx.Done():
+				os.Stdin.Close()
+			case <-done:
+			}
+		}()
+		if err := scanLines(ctx, stdinPath, os.Stdin, out); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		return errSourceFinished
+	}
+}
@@ -3,19 +3,50 @@ package watch
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"sync"
+	"time"
 
 	"github.com/nxadm/tail"
 )
 
-// LogEvent represents a single line read from a log file.
+// LogEvent represents a single line read from a log file. A non-zero Gap marks
+// where ingestion was paused for that long before the following lines; a
+// non-nil Conn marks a source going down or coming back (see superviseSource).
+// Offset is the file position just past the line (0 for non-file sources).
 type LogEvent struct {
-	Path string
-	Line string
-	Err  error
+	Path   string
+	Line   string
+	Err    error
+	Gap    time.Duration
+	Conn   *ConnEvent
+	Offset int64
+	// LineNo numbers the line among those its source delivered this run,
+	// from 1 (for a file read from its start, its line number). It restarts
+	// at 1 when the file is truncated or replaced by rotation. The pipeline
+	// sets it; a continuation stitched onto a line keeps the first number.
+	LineNo int64
+	// Time, when set, is when a replayed line was written; live lines are
+	// stamped with their arrival by the pipeline.
+	Time time.Time
+	// Backlog marks a line that was already in its file when spectra first
+	// opened it (or in a rotated copy replayed before it), as opposed to one
+	// written while watching.
+	Backlog bool
+	// Rule and Severity are how a forwarding agent matched the line (see
+	// relay.Record); the pipeline falls back to them when its own rules do
+	// not match. Empty for every other source.
+	Rule     string
+	Severity string
 }
 
-// TailFiles streams log lines from multiple files.
+// TailFiles streams log lines from multiple files. Besides regular files it accepts
+// named pipes and `unix:`/`unixgram:` socket specs (see openSource). Every source
+// must open once up front; after that a failing source is reopened with backoff
+// (see superviseSource) rather than dropped. Globs (`/var/log/nginx/*.log`) and
+// directories are expanded at startup, and files matching them that appear
+// later are tailed from their first line as they are created.
 func TailFiles(ctx context.Context, files []string) (<-chan LogEvent, error) {
 	if len(files) == 0 {
 		return nil, fmt.Errorf("no files provided")
@@ -23,34 +54,55 @@ func TailFiles(ctx context.Context, files []string) (<-chan LogEvent, error) {
 
 	out := make(chan LogEvent)
 	wg := &sync.WaitGroup{}
-	wg.Add(len(files))
+	started := make(map[string]bool)
+	start := func(file string) error {
+		if started[file] {
+			return nil
+		}
+		state := &sourceState{}
+		run, err := openSource(ctx, file, state)
+		if err != nil {
+			return err
+		}
+		started[file] = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			superviseSource(ctx, file, run, state, out)
+		}()
+		return nil
+	}
 
+	var patterns []sourcePattern
 	for _, file := range files {
-		cfg := tail.Config{Follow: true, ReOpen: true, Logger: tail.DiscardingLogger, MustExist: true}
-		t, err := tail.TailFile(file, cfg)
+		if p, ok := parsePattern(file); ok {
+			patterns = append(patterns, p)
+			continue
+		}
+		if err := start(file); err != nil {
+			return nil, err
+		}
+	}
+	if len(patterns) > 0 {
+		w, err := watchPatterns(patterns)
 		if err != nil {
-			return nil, fmt.Errorf("tail %s: %w", file, err)
+			return nil, err
 		}
-
-		go func(p string, tails *tail.Tail) {
-			defer wg.Done()
-			defer tails.Cleanup()
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case line, ok := <-tails.Lines:
-					if !ok {
-						return
-					}
-					if line.Err != nil {
-						out <- LogEvent{Path: p, Err: line.Err}
-						continue
-					}
-					out <- LogEvent{Path: p, Line: line.Text}
+		for _, p := range patterns {
+			for _, file := range p.expand() {
+				if err := start(file); err != nil {
+					w.Close()
+					return nil, err
 				}
 			}
-		}(file, t)
+		}
+		// The watcher counts as a source, so the stream stays open for
+		// files that have yet to appear.
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			followPatterns(ctx, w, patterns, start, out)
+		}()
 	}
 
 	go func() {
@@ -60,3 +112,64 @@ func TailFiles(ctx context.Context, files []string) (<-chan LogEvent, error) {
 
 	return out, nil
 }
+
+// tailRegularFile tails file from the start, or after a restart from the last
+// delivered offset unless the file has since shrunk below it. With
+// WithBackfillRotated the file's rotated siblings are replayed first.
+func tailRegularFile(file string, state *sourceState) (sourceFunc, error) {
+	cfg := tail.Config{Follow: true, ReOpen: true, Logger: tail.DiscardingLogger, MustExist: true}
+	if state.offset > 0 {
+		if info, err := os.Stat(file); err == nil && info.Size() >= state.offset {
+			cfg.Location = &tail.SeekInfo{Offset: state.offset, Whence: io.SeekStart}
+		}
+	}
+	if !state.opened {
+		if info, err := os.Stat(file); err == nil {
+			state.backlog = info.Size()
+		}
+		state.opened = true
+	}
+	t, err := tail.TailFile(file, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("tail %s: %w", file, err)
+	}
+	return func(ctx context.Context, out chan<- LogEvent) error {
+		defer t.Cleanup()
+		if !state.backfilled && backfillFrom(ctx) {
+			asBacklog(ctx, out, func(out chan<- LogEvent) { backfillRotated(ctx, file, out) })
+			if ctx.Err() != nil {
+				return nil
+			}
+			state.backfilled = true
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case line, ok := <-t.Lines:
+				if !ok {
+					if err := t.Err(); err != nil {
+						return fmt.Errorf("tail %s: %w", file, err)
+					}
+					return fmt.Errorf("tail %s: stopped", file)
+				}
+				if line.Err != nil {
+					if !emit(ctx, out, LogEvent{Path: file, Err: line.Err}) {
+						return nil
+					}
+					continue
+				}
+				// Past the backlog, or reopened after rotation or
+				// truncation (offsets going back), every line is new.
+				if line.SeekInfo.Offset > state.backlog || line.SeekInfo.Offset <= state.offset {
+					state.backlog = 0
+				}
+				backlog := state.backlog > 0
+				if !emit(ctx, out, LogEvent{Path: file, Line: line.Text, Offset: line.SeekInfo.Offset, Backlog: backlog}) {
+					return nil
+				}
+				state.offset = line.SeekInfo.Offset
+			}
+		}
+	}, nil
+}
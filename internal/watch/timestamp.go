@@ -0,0 +1,62 @@
+package watch
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	isoStamp    = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?`)
+	syslogStamp = regexp.MustCompile(`^[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}`)
+	clfStamp    = regexp.MustCompile(`\[(\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4})\]`)
+)
+
+// stampWindow limits how far into a line a timestamp is looked for.
+const stampWindow = 96
+
+// ParseTimestamp extracts when a log line was written. It understands ISO 8601
+// / RFC 3339 stamps, classic syslog (`Jan  2 15:04:05`, whose year is taken
+// from ref and rolled back when that would put it in ref's future), and
+// Apache/nginx common log format. Zone-less stamps are read as local time.
+func ParseTimestamp(line string, ref time.Time) (time.Time, bool) {
+	head := line
+	if len(head) > stampWindow {
+		head = head[:stampWindow]
+	}
+	if m := syslogStamp.FindString(head); m != "" {
+		ts, err := time.ParseInLocation("Jan _2 15:04:05", m, time.Local)
+		if err == nil {
+			ts = ts.AddDate(ref.Year(), 0, 0)
+			if ts.After(ref.Add(24 * time.Hour)) {
+				ts = ts.AddDate(-1, 0, 0)
+			}
+			return ts, true
+		}
+	}
+	if m := isoStamp.FindString(head); m != "" {
+		if ts, ok := parseISO(m); ok {
+			return ts, true
+		}
+	}
+	if m := clfStamp.FindStringSubmatch(head); m != nil {
+		if ts, err := time.Parse("02/Jan/2006:15:04:05 -0700", m[1]); err == nil {
+			return ts, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func parseISO(value string) (time.Time, bool) {
+	value = strings.Replace(value, ",", ".", 1)
+	value = strings.Replace(value, " ", "T", 1)
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999Z0700"} {
+		if ts, err := time.Parse(layout, value); err == nil {
+			return ts, true
+		}
+	}
+	if ts, err := time.ParseInLocation("2006-01-02T15:04:05.999999999", value, time.Local); err == nil {
+		return ts, true
+	}
+	return time.Time{}, false
+}
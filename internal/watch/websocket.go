@@ -0,0 +1,86 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+
+	"watcher/internal/websocket"
+)
+
+// WebSocket URL prefixes: `wss://logs.internal/api/stream` reads a live log
+// stream over a WebSocket, each text message one or more lines.
+const (
+	wsPrefix  = "ws://"
+	wssPrefix = "wss://"
+)
+
+// openWebSocket connects to the URL each time the source runs; lines carry
+// the URL as their path. The connection ending, by a close frame or
+// otherwise, is an error, so the supervisor reconnects with backoff. Binary
+// messages are skipped, and the first one on a connection is reported.
+// Credentials come from WEBSOCKET_USERNAME and WEBSOCKET_PASSWORD or
+// WEBSOCKET_BEARER_TOKEN, never the URL, which is shown wherever sources are.
+func openWebSocket(spec string) (sourceFunc, error) {
+	u, err := url.Parse(spec)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("%s: want ws://host/path or wss://host/path", spec)
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("%s: put credentials in WEBSOCKET_USERNAME/WEBSOCKET_PASSWORD or WEBSOCKET_BEARER_TOKEN, not the URL", u.Redacted())
+	}
+	header := http.Header{}
+	switch user, token := os.Getenv("WEBSOCKET_USERNAME"), os.Getenv("WEBSOCKET_BEARER_TOKEN"); {
+	case user != "":
+		req := &http.Request{Header: header}
+		req.SetBasicAuth(user, os.Getenv("WEBSOCKET_PASSWORD"))
+	case token != "":
+		header.Set("Authorization", "Bearer "+token)
+	}
+	return func(ctx context.Context, out chan<- LogEvent) error {
+		conn, err := websocket.Dial(ctx, u, header)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("%s: %w", spec, err)
+		}
+		defer conn.Close()
+		stop := context.AfterFunc(ctx, func() { conn.Close() })
+		defer stop()
+		reported := false
+		for {
+			text, msg, err := conn.ReadMessage()
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+					return fmt.Errorf("%s: connection closed", spec)
+				}
+				return fmt.Errorf("%s: %w", spec, err)
+			}
+			if !text {
+				if !reported {
+					reported = true
+					if !emit(ctx, out, LogEvent{Path: spec, Err: fmt.Errorf("%s: skipping binary messages", spec)}) {
+						return nil
+					}
+				}
+				continue
+			}
+			if err := scanLines(ctx, spec, bytes.NewReader(msg), out); err != nil {
+				return err
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+		}
+	}, nil
+}
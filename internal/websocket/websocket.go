@@ -0,0 +1,231 @@
+// Package websocket is a minimal WebSocket client (RFC 6455): the upgrade
+// handshake over plain TCP or TLS, reading data messages, and answering pings
+// and close frames. It never sends data, which is all a log tail needs.
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// WebSocket opcodes (RFC 6455 §5.2).
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+	// maxMessage bounds one reassembled message; Loki batches a tail's
+	// entries, so this is well above a busy second.
+	maxMessage = 16 << 20
+	wsGUID     = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+)
+
+// Conn is the client side of a WebSocket connection, just enough to read a
+// stream: data frames, ping/pong, and close.
+type Conn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// Dial connects to u (ws, wss, http, or https) and performs the upgrade
+// handshake with the extra header. A refused upgrade returns the server's
+// status and the start of its body, which is where servers such as Loki
+// explain a bad request.
+func Dial(ctx context.Context, u *url.URL, header http.Header) (*Conn, error) {
+	secure := u.Scheme == "https" || u.Scheme == "wss"
+	host := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if secure {
+			port = "443"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if secure {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: u.Hostname()}}
+		conn, err = dialer.DialContext(ctx, "tcp", host)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", host)
+	}
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+	target := *u
+	switch target.Scheme {
+	case "ws":
+		target.Scheme = "http"
+	case "wss":
+		target.Scheme = "https"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	r := bufio.NewReaderSize(conn, 64<<10)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("upgrade: %w", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		conn.Close()
+		if msg := strings.TrimSpace(string(body)); msg != "" {
+			return nil, fmt.Errorf("upgrade: %s: %s", resp.Status, msg)
+		}
+		return nil, fmt.Errorf("upgrade: %s", resp.Status)
+	}
+	sum := sha1.Sum([]byte(key + wsGUID))
+	if resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		conn.Close()
+		return nil, fmt.Errorf("upgrade: bad Sec-WebSocket-Accept")
+	}
+	return &Conn{conn: conn, r: r}, nil
+}
+
+// ReadMessage returns the next data message and whether it is text (else
+// binary), answering pings on the way. A close frame ends the stream: io.EOF
+// for a normal closure, else an error carrying the server's code and reason.
+func (c *Conn) ReadMessage() (bool, []byte, error) {
+	var (
+		msg  []byte
+		text bool
+	)
+	for {
+		fin, op, payload, err := c.frame()
+		if err != nil {
+			return false, nil, err
+		}
+		switch op {
+		case opPing:
+			if err := c.writeControl(opPong, payload); err != nil {
+				return false, nil, err
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			code := 1005
+			if len(payload) >= 2 {
+				code = int(binary.BigEndian.Uint16(payload))
+				c.writeControl(opClose, payload[:2])
+			}
+			if code == 1000 || code == 1005 {
+				return false, nil, io.EOF
+			}
+			return false, nil, fmt.Errorf("closed by server: %d %s", code, payload[min(2, len(payload)):])
+		case opText, opBinary:
+			text = op == opText
+		case opContinuation:
+		default:
+			return false, nil, fmt.Errorf("websocket: unknown opcode %#x", op)
+		}
+		if len(msg)+len(payload) > maxMessage {
+			return false, nil, fmt.Errorf("websocket: message exceeds %d bytes", maxMessage)
+		}
+		msg = append(msg, payload...)
+		if fin {
+			return text, msg, nil
+		}
+	}
+}
+
+// frame reads one frame, unmasking it if the server (wrongly) masked it.
+func (c *Conn) frame() (bool, byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.r, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin, op := head[0]&0x80 != 0, head[0]&0x0f
+	masked := head[1]&0x80 != 0
+	n := uint64(head[1] & 0x7f)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if n > maxMessage {
+		return false, 0, nil, fmt.Errorf("websocket: %d byte frame exceeds %d", n, maxMessage)
+	}
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.r, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, op, payload, nil
+}
+
+// writeControl sends a masked control frame, as clients must; control
+// payloads are at most 125 bytes.
+func (c *Conn) writeControl(op byte, payload []byte) error {
+	payload = payload[:min(len(payload), 125)]
+	frame := []byte{0x80 | op, 0x80 | byte(len(payload)), 0, 0, 0, 0}
+	if _, err := rand.Read(frame[2:6]); err != nil {
+		return err
+	}
+	for i, b := range payload {
+		frame = append(frame, b^frame[2+i%4])
+	}
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
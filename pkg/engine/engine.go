@@ -0,0 +1,256 @@
+// Package engine is the stable, importable entry point to spectra's matching
+// engine for Go programs that want rule matching without the TUI:
+//
+//	rs, err := engine.LoadRules("rules.yaml")
+//	eng := engine.New(ctx, rs, engine.Options{MinSeverity: engine.SeverityHigh})
+//	sub := eng.Subscribe(256)
+//	if err := eng.AddSource("/var/log/auth.log"); err != nil { ... }
+//	for evt := range sub.Events() { ... }
+//
+// The types below alias the implementation packages, so values move freely
+// between this API and code inside the module.
+package engine
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"watcher/internal/clock"
+	"watcher/internal/highlight"
+	"watcher/internal/pipeline"
+	"watcher/internal/rules"
+	"watcher/internal/watch"
+)
+
+type (
+	// Severity is a rule's importance level.
+	Severity = rules.Severity
+	// RuleSet is a compiled set of rules.
+	RuleSet = rules.RuleSet
+	// RuleDefinition is one rule as written in YAML.
+	RuleDefinition = rules.RuleDefinition
+	// Event is a matched (or, with ShowAll, unmatched) line.
+	Event = pipeline.HighlightedEvent
+	// Fragment is a span of an event's line, emphasized where a rule matched.
+	Fragment = highlight.Fragment
+	// Subscription receives events; see Engine.Subscribe.
+	Subscription = pipeline.Subscription
+	// SourceStatus describes how far a source has read.
+	SourceStatus = watch.SourceStatus
+	// ConnEvent marks a source going down, coming back, or being given up.
+	ConnEvent = watch.ConnEvent
+	// Clock supplies time to the pipeline; testkit.Clock is a manual one.
+	Clock = clock.Clock
+)
+
+const (
+	SeverityCritical = rules.SeverityCritical
+	SeverityHigh     = rules.SeverityHigh
+	SeverityMedium   = rules.SeverityMedium
+	SeverityLow      = rules.SeverityLow
+	SeverityNormal   = rules.SeverityNormal
+)
+
+// LoadRules reads and compiles a YAML rule file (includes and templates included).
+func LoadRules(path string) (RuleSet, error) {
+	return rules.LoadFromFile(path)
+}
+
+// CompileRules compiles rule definitions built in code.
+func CompileRules(defs []RuleDefinition) (RuleSet, error) {
+	return rules.Compile(defs)
+}
+
+// ParseSeverity converts user input such as "high" into a Severity.
+func ParseSeverity(value string) (Severity, error) {
+	return rules.ParseSeverity(value)
+}
+
+// Options tune which lines an Engine publishes.
+type Options struct {
+	// ShowAll publishes unmatched lines too (with SeverityNormal).
+	ShowAll bool
+	// MinSeverity drops matches below it; empty means SeverityMedium.
+	MinSeverity Severity
+	// Multiline stitches continuation lines (indented, "Caused by:") onto the
+	// line before them, waiting up to this long for more; zero disables it.
+	Multiline time.Duration
+	// Dedupe folds identical lines from different sources within this window
+	// into one event listing every source (Event.Sources); zero disables it.
+	Dedupe time.Duration
+	// SourceRetries gives up on a source after this many consecutive failed
+	// reopen attempts; zero retries forever. Outages show up in the stream
+	// as events with Conn set.
+	SourceRetries int
+	// BackfillRotated replays a file's rotated copies (name.1, name.2.gz, …),
+	// oldest first, before tailing it.
+	BackfillRotated bool
+	// Clock stamps live lines and times the Multiline and Dedupe windows;
+	// nil is the wall clock.
+	Clock Clock
+}
+
+// Stats is a snapshot of an Engine's counters.
+type Stats struct {
+	// Lines counts every line read from any source.
+	Lines uint64
+	// Events counts what was published after matching and thresholds.
+	Events uint64
+	// Errors counts source read errors.
+	Errors uint64
+	// BySeverity counts published events per severity.
+	BySeverity map[Severity]uint64
+	// Sources reports per-source read positions and retry state.
+	Sources map[string]SourceStatus
+}
+
+// Engine matches lines from any number of sources against a rule set and
+// fans the results out to subscribers. Sources are reopened with backoff when
+// they fail, exactly as in the dashboard.
+type Engine struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	in       chan watch.LogEvent
+	bus      *pipeline.Broadcaster
+	progress *watch.Progress
+	sources  sync.WaitGroup
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// New starts an engine that runs until ctx is done or Close is called.
+func New(ctx context.Context, rs RuleSet, opts Options) *Engine {
+	min := opts.MinSeverity
+	if min == "" {
+		min = SeverityMedium
+	}
+	progress := watch.NewProgress()
+	ctx = watch.WithBackfillRotated(watch.WithRetryLimit(watch.WithProgress(ctx, progress), opts.SourceRetries), opts.BackfillRotated)
+	ctx, cancel := context.WithCancel(ctx)
+	e := &Engine{
+		ctx:      ctx,
+		cancel:   cancel,
+		in:       make(chan watch.LogEvent),
+		progress: progress,
+		stats:    Stats{BySeverity: make(map[Severity]uint64)},
+	}
+	matched := pipeline.New(rs, opts.ShowAll, min).WithMultiline(opts.Multiline).WithClock(opts.Clock).Connect(ctx, e.in)
+	e.bus = pipeline.NewBroadcaster(e.count(pipeline.Dedupe(ctx, matched, opts.Dedupe, opts.Clock)))
+	e.bus.Start(ctx)
+	return e
+}
+
+// Subscribe registers a consumer. Events published before a subscription
+// exists are not replayed, so subscribe before adding sources. A subscriber
+// whose buffer is full loses events (see Subscription.Dropped) rather than
+// stalling the engine.
+func (e *Engine) Subscribe(buffer int) Subscription {
+	return e.bus.Subscribe(buffer)
+}
+
+// AddSource starts reading a file, named pipe, or `unix:`/`unixgram:` socket
+// spec. The source must open now; later failures are retried.
+func (e *Engine) AddSource(spec string) error {
+	events, err := watch.TailFiles(e.ctx, []string{spec})
+	if err != nil {
+		return fmt.Errorf("add source %s: %w", spec, err)
+	}
+	e.sources.Add(1)
+	go func() {
+		defer e.sources.Done()
+		for evt := range events {
+			if !e.forward(evt) {
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// AddReader reads lines from r under the given name until EOF or Close.
+// Close does not wait for it, since a Read (on os.Stdin, say) may block
+// indefinitely; if r is an io.Closer, Close closes it to end that Read.
+func (e *Engine) AddReader(name string, r io.Reader) {
+	if c, ok := r.(io.Closer); ok {
+		go func() {
+			<-e.ctx.Done()
+			c.Close()
+		}()
+	}
+	go func() {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+		for scanner.Scan() {
+			if !e.forward(watch.LogEvent{Path: name, Line: strings.TrimRight(scanner.Text(), "\r")}) {
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			e.forward(watch.LogEvent{Path: name, Err: fmt.Errorf("read %s: %w", name, err)})
+		}
+	}()
+}
+
+// Stats returns a snapshot of the counters.
+func (e *Engine) Stats() Stats {
+	e.mu.Lock()
+	out := e.stats
+	out.BySeverity = make(map[Severity]uint64, len(e.stats.BySeverity))
+	for sev, n := range e.stats.BySeverity {
+		out.BySeverity[sev] = n
+	}
+	e.mu.Unlock()
+	out.Sources = e.progress.Snapshot()
+	return out
+}
+
+// Close stops every source and closes all subscriptions. It waits for the
+// sources added with AddSource, not for readers (see AddReader).
+func (e *Engine) Close() {
+	e.cancel()
+	e.sources.Wait()
+}
+
+func (e *Engine) forward(evt watch.LogEvent) bool {
+	e.mu.Lock()
+	if evt.Err != nil {
+		e.stats.Errors++
+	} else if evt.Gap == 0 && evt.Conn == nil {
+		e.stats.Lines++
+	}
+	e.mu.Unlock()
+	select {
+	case <-e.ctx.Done():
+		return false
+	case e.in <- evt:
+		return true
+	}
+}
+
+// count tallies published events on their way to the broadcaster.
+func (e *Engine) count(in <-chan Event) <-chan Event {
+	out := make(chan Event)
+	go func() {
+		defer close(out)
+		for evt := range in {
+			if evt.Err == nil && evt.Gap == 0 && evt.Conn == nil {
+				e.mu.Lock()
+				e.stats.Events++
+				e.stats.BySeverity[evt.Severity]++
+				e.mu.Unlock()
+			}
+			select {
+			case <-e.ctx.Done():
+				return
+			case out <- evt:
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,270 @@
+package testkit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"runtime/debug"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"watcher/internal/auditd"
+	"watcher/internal/fluent"
+	"watcher/internal/gelf"
+	"watcher/internal/highlight"
+	"watcher/internal/parsers"
+	"watcher/internal/pipeline"
+	"watcher/internal/rules"
+	"watcher/internal/watch"
+)
+
+// FuzzTarget is one place hostile log content enters: Fn feeds it arbitrary
+// bytes and returns an error when an invariant (a size limit, round-tripping
+// the text) breaks. A panic is a failure too; Fuzz recovers it.
+//
+// Each target also fits the native fuzzer from a _test.go file:
+//
+//	f.Fuzz(func(t *testing.T, data []byte) {
+//		if err := target.Fn(data); err != nil {
+//			t.Fatal(err)
+//		}
+//	})
+type FuzzTarget struct {
+	Name  string
+	Fn    func(data []byte) error
+	Seeds [][]byte
+}
+
+// FuzzTargets returns the decoding, matching, highlighting, stitching, and
+// wire-format targets, matching lines against rs.
+func FuzzTargets(rs rules.RuleSet) []FuzzTarget {
+	lines := [][]byte{
+		[]byte("Jan  2 15:04:05 host sshd[42]: Failed password for invalid user admin from 203.0.113.9 port 2222 ssh2"),
+		[]byte("Jan  2 15:04:05 mx postfix/smtp[7]: 4BC1D2E3F4: to=<a@example.com>, relay=mx.example.com[203.0.113.5]:25, delay=1.2, dsn=2.0.0, status=sent (250 ok)"),
+		[]byte("2024-01-02T15:04:05.123Z ERROR java.lang.IllegalStateException: boom\n\tat com.example.Main.run(Main.java:42)\n... 3 more"),
+		[]byte("\xff\xfeh\x00i\x00"),
+		[]byte("\x1b[2J\x1b]0;pwned\x07 colored \x1b[31mred\x1b[0m"),
+	}
+	return []FuzzTarget{
+		{Name: "decode", Fn: fuzzDecode, Seeds: lines},
+		{Name: "match", Fn: func(data []byte) error { return fuzzMatch(rs, data) }, Seeds: lines},
+		{Name: "highlight", Fn: fuzzHighlight, Seeds: [][]byte{[]byte("\x00\x05\x03\x09héllo wörld"), []byte("\xff\x01\x80\x7fabc")}},
+		{Name: "stitch", Fn: fuzzStitch, Seeds: lines},
+		{Name: "parsers", Fn: fuzzParsers, Seeds: lines},
+		{Name: "gelf", Fn: fuzzGELF, Seeds: [][]byte{[]byte(`{"version":"1.1","host":"h","short_message":"m","_user":"u","level":3}`)}},
+		{Name: "auditd", Fn: fuzzAuditd, Seeds: [][]byte{[]byte("type=EXECVE msg=audit(1700000000.123:456): argc=2 a0=\"curl\" a1=2D73\ntype=PROCTITLE msg=audit(1700000000.123:456): proctitle=6375726C002D73\ntype=EOE msg=audit(1700000000.123:456): ")}},
+		{Name: "fluent", Fn: fuzzFluent, Seeds: [][]byte{{0x93, 0xa3, 't', 'a', 'g', 0x01, 0x81, 0xa3, 'l', 'o', 'g', 0xa2, 'h', 'i'}}},
+	}
+}
+
+// maxDecoded is the longest line Decode may return: the cap plus its note.
+var maxDecoded = pipeline.MaxLineBytes + len(fmt.Sprintf(" … [%d bytes truncated]", 1<<30))
+
+func fuzzDecode(data []byte) error {
+	var d pipeline.Decoder
+	for _, raw := range strings.Split(string(data), "\n") {
+		line := d.Decode(raw)
+		if len(line) > maxDecoded {
+			return fmt.Errorf("decoded %d bytes, over the %d byte cap", len(line), maxDecoded)
+		}
+		if !utf8.ValidString(line) {
+			return fmt.Errorf("decoded line is not UTF-8: %q", line)
+		}
+	}
+	return nil
+}
+
+func fuzzMatch(rs rules.RuleSet, data []byte) error {
+	var d pipeline.Decoder
+	stream := pipeline.New(rs, true, rules.SeverityNormal)
+	for _, raw := range strings.Split(string(data), "\n") {
+		line := d.Decode(raw)
+		evt, _ := stream.Process(watch.LogEvent{Path: "fuzz", Line: line})
+		if got := highlight.String(evt.Fragments); got != evt.Line {
+			return fmt.Errorf("fragments %q do not spell the line %q", got, evt.Line)
+		}
+	}
+	return nil
+}
+
+// fuzzHighlight reads spans from the first bytes (a count, then start/end
+// pairs, signed so they can point outside the line) and the line from the rest.
+func fuzzHighlight(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	n := int(data[0])
+	data = data[1:]
+	var spans [][2]int
+	for i := 0; i < n && len(data) >= 2; i++ {
+		spans = append(spans, [2]int{int(int8(data[0])) * 3, int(int8(data[1])) * 3})
+		data = data[2:]
+	}
+	line := string(data)
+	frags := highlight.BuildFragments(line, spans)
+	if got := highlight.String(frags); got != line {
+		return fmt.Errorf("fragments %q do not spell the line %q", got, line)
+	}
+	if len(frags) > 2*highlight.MaxSpans+1 {
+		return fmt.Errorf("%d fragments for %d spans", len(frags), len(spans))
+	}
+	if utf8.ValidString(line) {
+		for _, f := range frags {
+			if !utf8.ValidString(f.Text) {
+				return fmt.Errorf("fragment %q splits a character", f.Text)
+			}
+		}
+	}
+	return nil
+}
+
+// fuzzStitch runs the lines through Connect with multiline stitching on.
+func fuzzStitch(data []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	lines := strings.Split(string(data), "\n")
+	out := pipeline.New(rules.RuleSet{}, true, rules.SeverityNormal).
+		WithMultiline(time.Hour).
+		Connect(ctx, Lines("fuzz", lines...))
+	for evt := range out {
+		if len(evt.Line) > pipeline.MaxStitchedBytes {
+			return fmt.Errorf("stitched event of %d bytes, over the %d byte cap", len(evt.Line), pipeline.MaxStitchedBytes)
+		}
+	}
+	return ctx.Err()
+}
+
+// maxParsed allows for the fixed fields a parser adds around the attributes.
+const maxParsed = parsers.MaxFields + 16
+
+func fuzzParsers(data []byte) error {
+	for _, name := range parsers.Names() {
+		p, err := parsers.Lookup(name)
+		if err != nil {
+			return err
+		}
+		if fields, _ := p.Parse(string(data)); len(fields) > maxParsed {
+			return fmt.Errorf("%s: %d fields, over %d", name, len(fields), maxParsed)
+		}
+	}
+	return nil
+}
+
+func fuzzGELF(data []byte) error {
+	if msg, err := gelf.Parse(data); err == nil {
+		msg.Line()
+	}
+	a := gelf.NewAssembler()
+	a.Add(data, time.Now())
+	return nil
+}
+
+func fuzzAuditd(data []byte) error {
+	a := auditd.NewAssembler()
+	r := auditd.NewReader(bytes.NewReader(data))
+	now := time.Now()
+	for {
+		rec, err := r.Read()
+		if err != nil {
+			break
+		}
+		for _, e := range a.Add(rec, now) {
+			e.Line()
+		}
+	}
+	for _, e := range a.Flush() {
+		e.Line()
+	}
+	return nil
+}
+
+func fuzzFluent(data []byte) error {
+	r := fluent.NewReader(bytes.NewReader(data))
+	for {
+		entries, _, err := r.Read()
+		if err != nil {
+			return nil
+		}
+		for _, e := range entries {
+			e.Line()
+		}
+	}
+}
+
+// Fuzz runs target on its seeds and then on n random mutations of them,
+// returning the first input that panics or breaks an invariant.
+func Fuzz(target FuzzTarget, n int, rnd *rand.Rand) ([]byte, error) {
+	seeds := target.Seeds
+	if len(seeds) == 0 {
+		seeds = [][]byte{{}}
+	}
+	for _, seed := range seeds {
+		if err := runFuzz(target, seed); err != nil {
+			return seed, err
+		}
+	}
+	for i := 0; i < n; i++ {
+		input := Mutate(seeds[rnd.Intn(len(seeds))], rnd)
+		if err := runFuzz(target, input); err != nil {
+			return input, err
+		}
+	}
+	return nil, nil
+}
+
+func runFuzz(target FuzzTarget, data []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s: panic: %v\n%s", target.Name, r, debug.Stack())
+		}
+	}()
+	if err := target.Fn(data); err != nil {
+		return fmt.Errorf("%s: %w", target.Name, err)
+	}
+	return nil
+}
+
+// hostile are byte strings that tend to find edge cases in log handling:
+// terminal escapes, NULs, byte-order marks, broken UTF-8, separators.
+var hostile = [][]byte{
+	[]byte("\x1b["), []byte("\x1b]0;"), {0x00}, {0xff, 0xfe}, {0xfe, 0xff}, {0xef, 0xbb, 0xbf},
+	{0xc3}, {0xe2, 0x82}, {0x9b}, []byte("\r\n"), []byte("\n\t"), []byte("Caused by: "),
+	[]byte(" ⏎ "), []byte("="), []byte(", "), []byte("[]: "), {0x7f},
+}
+
+// Mutate returns a copy of seed with a few random edits: flipped bits,
+// inserted or deleted bytes, hostile tokens spliced in, or a chunk repeated
+// (which grows lines toward the size limits).
+func Mutate(seed []byte, rnd *rand.Rand) []byte {
+	out := append([]byte(nil), seed...)
+	for edits := 1 + rnd.Intn(4); edits > 0; edits-- {
+		at := 0
+		if len(out) > 0 {
+			at = rnd.Intn(len(out) + 1)
+		}
+		switch rnd.Intn(5) {
+		case 0:
+			if at < len(out) {
+				out[at] ^= 1 << rnd.Intn(8)
+			}
+		case 1:
+			out = append(out[:at], append([]byte{byte(rnd.Intn(256))}, out[at:]...)...)
+		case 2:
+			if at < len(out) {
+				out = append(out[:at], out[at+1:]...)
+			}
+		case 3:
+			token := hostile[rnd.Intn(len(hostile))]
+			out = append(out[:at], append(append([]byte(nil), token...), out[at:]...)...)
+		case 4:
+			if at < len(out) {
+				end := at + 1 + rnd.Intn(min(len(out)-at, 64))
+				chunk := bytes.Repeat(out[at:end], 1+rnd.Intn(1<<12))
+				out = append(out[:end], append(chunk, out[end:]...)...)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,274 @@
+// Package testkit helps code that embeds pkg/engine (or, inside this module,
+// the rules and pipeline packages) test rulesets and sinks end to end without
+// real files: Source stands in for the
+// tailer, Clock makes timestamps deterministic, and Recorder collects the
+// highlighted output.
+package testkit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"watcher/internal/clock"
+	"watcher/internal/pipeline"
+	"watcher/internal/rules"
+	"watcher/internal/watch"
+)
+
+// Clock is a manually advanced clock for deterministic timestamps. It
+// implements clock.Clock, so it can drive the pipeline's hold windows
+// (Stream.WithClock, Dedupe, Reorder), the dashboard's ticks, and
+// watch.Paced: tickers and After channels fire only when Advance passes
+// their deadline.
+type Clock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*waiter
+}
+
+// waiter is a pending After (every zero) or ticker.
+type waiter struct {
+	at      time.Time
+	every   time.Duration
+	c       chan time.Time
+	stopped bool
+}
+
+// NewClock returns a clock stopped at start.
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now returns the current fake time.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward, fires the tickers and After channels that
+// came due (each ticker at most once, as a real ticker drops ticks nobody
+// read), and returns the new time.
+func (c *Clock) Advance(d time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	kept := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.stopped {
+			continue
+		}
+		if !w.at.After(c.now) {
+			select {
+			case w.c <- c.now:
+			default:
+			}
+			if w.every == 0 {
+				continue
+			}
+			for !w.at.After(c.now) {
+				w.at = w.at.Add(w.every)
+			}
+		}
+		kept = append(kept, w)
+	}
+	c.waiters = kept
+	return c.now
+}
+
+// After delivers the fake time once Advance reaches d from now.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w := &waiter{at: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		w.c <- c.now
+		return w.c
+	}
+	c.waiters = append(c.waiters, w)
+	return w.c
+}
+
+// NewTicker ticks every d of fake time.
+func (c *Clock) NewTicker(d time.Duration) clock.Ticker {
+	if d <= 0 {
+		panic("testkit: non-positive ticker interval")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w := &waiter{at: c.now.Add(d), every: d, c: make(chan time.Time, 1)}
+	c.waiters = append(c.waiters, w)
+	return fakeTicker{clock: c, w: w}
+}
+
+type fakeTicker struct {
+	clock *Clock
+	w     *waiter
+}
+
+func (t fakeTicker) C() <-chan time.Time { return t.w.c }
+
+func (t fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.w.stopped = true
+}
+
+// Source is a synthetic log source producing the same events as the tailer.
+type Source struct {
+	path   string
+	events chan watch.LogEvent
+	once   sync.Once
+}
+
+// NewSource returns a source reporting lines from path; buffer bounds how many
+// events may be emitted before a reader must catch up.
+func NewSource(path string, buffer int) *Source {
+	return &Source{path: path, events: make(chan watch.LogEvent, buffer)}
+}
+
+// Events is the channel to hand to pipeline.Stream.Connect.
+func (s *Source) Events() <-chan watch.LogEvent {
+	return s.events
+}
+
+// Emit sends each line as a separate event.
+func (s *Source) Emit(lines ...string) {
+	for _, line := range lines {
+		s.events <- watch.LogEvent{Path: s.path, Line: line}
+	}
+}
+
+// EmitAt sends a line written at t, as replayed sources do; watch.Paced
+// spaces such lines by their original gaps.
+func (s *Source) EmitAt(t time.Time, line string) {
+	s.events <- watch.LogEvent{Path: s.path, Line: line, Time: t}
+}
+
+// Gap sends the marker a source emits after ingestion was paused for d.
+func (s *Source) Gap(d time.Duration) {
+	s.events <- watch.LogEvent{Path: s.path, Gap: d}
+}
+
+// Fail sends a read error, as the tailer does for a failing file.
+func (s *Source) Fail(err error) {
+	s.events <- watch.LogEvent{Path: s.path, Err: err}
+}
+
+// Close ends the stream; it is safe to call more than once.
+func (s *Source) Close() {
+	s.once.Do(func() { close(s.events) })
+}
+
+// Lines returns a closed, pre-filled channel of events for path.
+func Lines(path string, lines ...string) <-chan watch.LogEvent {
+	src := NewSource(path, len(lines))
+	src.Emit(lines...)
+	src.Close()
+	return src.Events()
+}
+
+// Run matches lines synchronously through the same stage the live pipeline
+// uses (severity threshold, secret redaction) and stamps kept events with the
+// clock, advancing it by step after each line. A nil clock leaves timestamps zero.
+func Run(rs rules.RuleSet, showAll bool, min rules.Severity, clock *Clock, step time.Duration, path string, lines ...string) []pipeline.HighlightedEvent {
+	stream := pipeline.New(rs, showAll, min)
+	var out []pipeline.HighlightedEvent
+	for _, line := range lines {
+		evt, keep := stream.Process(watch.LogEvent{Path: path, Line: line})
+		if clock != nil {
+			evt.Timestamp = clock.Now()
+			clock.Advance(step)
+		}
+		if keep {
+			out = append(out, evt)
+		}
+	}
+	return out
+}
+
+// Recorder collects highlighted events from a channel, such as a pipeline
+// output or a Broadcaster subscription, for later assertions.
+type Recorder struct {
+	mu     sync.Mutex
+	events []pipeline.HighlightedEvent
+	notify chan struct{}
+	done   chan struct{}
+}
+
+// Record starts collecting from in until it closes or ctx is done.
+func Record(ctx context.Context, in <-chan pipeline.HighlightedEvent) *Recorder {
+	r := &Recorder{notify: make(chan struct{}, 1), done: make(chan struct{})}
+	go func() {
+		defer close(r.done)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case evt, ok := <-in:
+				if !ok {
+					return
+				}
+				r.mu.Lock()
+				r.events = append(r.events, evt)
+				r.mu.Unlock()
+				select {
+				case r.notify <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return r
+}
+
+// Events returns a copy of everything recorded so far.
+func (r *Recorder) Events() []pipeline.HighlightedEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]pipeline.HighlightedEvent{}, r.events...)
+}
+
+// Wait blocks until at least n events were recorded, the input closed, or
+// timeout elapsed, and returns what was recorded. ok is false unless n arrived.
+func (r *Recorder) Wait(n int, timeout time.Duration) ([]pipeline.HighlightedEvent, bool) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		if events := r.Events(); len(events) >= n {
+			return events, true
+		}
+		select {
+		case <-r.notify:
+		case <-r.done:
+			events := r.Events()
+			return events, len(events) >= n
+		case <-deadline.C:
+			events := r.Events()
+			return events, len(events) >= n
+		}
+	}
+}
+
+// Rules lists the rule name of each recorded event in arrival order ("" for
+// unmatched lines recorded with show-all).
+func (r *Recorder) Rules() []string {
+	events := r.Events()
+	names := make([]string, len(events))
+	for i, evt := range events {
+		names[i] = evt.RuleName
+	}
+	return names
+}
+
+// Count reports how many recorded events carry the given severity.
+func (r *Recorder) Count(severity rules.Severity) int {
+	n := 0
+	for _, evt := range r.Events() {
+		if evt.Severity == severity {
+			n++
+		}
+	}
+	return n
+}